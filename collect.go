@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/push"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/relabel"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/sirupsen/logrus"
+)
+
+// runCollect implements the one-shot mode (`collect` or `--once`): collect the configured
+// targets (POLL_STEAM_IDS and POLL_OSRS_PLAYERS) once and write them in the Prometheus text
+// format, to stdout or a .prom file for node_exporter's textfile collector.
+// Returns the process exit code: 1 if any target failed (its metrics are still written, with
+// exporter_collection_success 0).
+func runCollect(args []string) int {
+	flags := flag.NewFlagSet("collect", flag.ExitOnError)
+	output := flags.String("output", "-", "File to write, e.g. /var/lib/node_exporter/textfile/games.prom (- for stdout)")
+	worlds := flags.Bool("worlds", false, "Also collect OSRS world player counts")
+	prices := flags.Bool("prices", false, "Also collect the store prices of STEAM_PRICE_APP_IDS")
+	addConfigFlags(flags)
+	flags.Parse(args)
+	applyLogFlags()
+
+	// Keep stdout for the metrics
+	if *output == "-" {
+		logger.Log.SetOutput(os.Stderr)
+	}
+
+	config := loadConfig()
+
+	cacheStore, err := newCache(config)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to initialize cache")
+		return 1
+	}
+	defer cacheStore.Close()
+
+	var steamCollector *steam.Collector
+	if len(config.SteamKeys) > 0 {
+		steamCollector = steam.NewCollector(steamConfig(config), cacheStore)
+	} else if len(config.PollSteamIDs) > 0 {
+		logger.Log.Warn("STEAM_KEY not set - skipping Steam targets")
+	}
+	osrsCollector := newOSRSCollector(config, cacheStore)
+
+	ctx := context.Background()
+	collected := newTextfile(relabelRules(config))
+
+	if steamCollector != nil {
+		for _, steamId := range config.PollSteamIDs {
+			err := steamCollector.Collect(ctx, steamId)
+			if steam.IsPartial(err) {
+				// The games that failed are left out, the rest are written
+				logger.Log.WithFields(logrus.Fields{
+					"steam_id": steamId,
+					"error":    err.Error(),
+				}).Warn("Steam target collected partially")
+				err = nil
+			}
+			collected.add(push.KindSteam, steamId, err)
+		}
+	}
+	for _, rsn := range config.PollOSRSPlayers {
+		// Background polling collects vanilla hiscores, so one-shot does too
+		collected.add(push.KindOSRS, osrsCollector.PlayerLabel(rsn), osrsCollector.CollectPlayerStats(ctx, rsn, "vanilla"))
+	}
+	if *worlds {
+		collected.add(push.KindWorlds, "worlds", osrsCollector.CollectWorldData(ctx))
+	}
+	if *prices {
+		if priceCollector := priceCollector(config, cacheStore); priceCollector != nil {
+			collected.add(push.KindPrices, "prices", priceCollector.CollectPrices(ctx))
+		} else {
+			logger.Log.Warn("STEAM_PRICE_APP_IDS not set - skipping prices")
+		}
+	}
+
+	if err := collected.write(*output); err != nil {
+		logger.Log.WithError(err).Error("Failed to write metrics")
+		return 1
+	}
+
+	logger.Log.WithFields(logrus.Fields{
+		"targets": collected.targets,
+		"failed":  collected.failed,
+		"output":  *output,
+	}).Info("One-shot collection completed")
+
+	if collected.failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// textfile accumulates the metrics of each target. Collections reset the shared metrics,
+// so each target's series are gathered straight after it is collected.
+type textfile struct {
+	families map[string]*dto.MetricFamily
+	success  *prometheus.GaugeVec
+	registry *prometheus.Registry
+	targets  int
+	failed   int
+	rules    relabel.Rules // Applied when writing
+}
+
+func newTextfile(rules relabel.Rules) *textfile {
+	success := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "exporter_collection_success",
+		Help: "Whether collecting the target succeeded (1) or failed (0)",
+	}, []string{"collector", "target"})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(success)
+
+	return &textfile{
+		families: make(map[string]*dto.MetricFamily),
+		success:  success,
+		registry: registry,
+		rules:    rules,
+	}
+}
+
+func (t *textfile) add(kind string, id string, err error) {
+	t.targets++
+	if err != nil {
+		t.failed++
+		t.success.WithLabelValues(kind, id).Set(0)
+		logger.Log.WithFields(logrus.Fields{
+			"kind":   kind,
+			"target": id,
+			"error":  err.Error(),
+		}).Error("Failed to collect target")
+		return
+	}
+	t.success.WithLabelValues(kind, id).Set(1)
+
+	all, gatherErr := prometheus.DefaultGatherer.Gather()
+	if gatherErr != nil {
+		logger.Log.WithError(gatherErr).Error("Failed to gather metrics")
+		return
+	}
+	for _, mf := range push.TargetFamilies(all, kind, id) {
+		if existing, ok := t.families[mf.GetName()]; ok {
+			existing.Metric = append(existing.Metric, mf.Metric...)
+			continue
+		}
+		t.families[mf.GetName()] = mf
+	}
+}
+
+// Gather returns the collected families and the success gauge, sorted by name
+func (t *textfile) Gather() ([]*dto.MetricFamily, error) {
+	families, err := t.registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	for _, mf := range t.families {
+		families = append(families, mf)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+	return families, nil
+}
+
+// write writes the metrics to stdout, or atomically replaces a file so the textfile
+// collector never reads a partial one
+func (t *textfile) write(output string) error {
+	gatherer := relabel.Gatherer(t, t.rules)
+	if output != "-" {
+		return prometheus.WriteToTextfile(output, gatherer)
+	}
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(os.Stdout, mf); err != nil {
+			return fmt.Errorf("failed to write %s: %w", mf.GetName(), err)
+		}
+	}
+	return nil
+}
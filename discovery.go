@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// runDiscovery calls discover right away and then every interval until ctx is done, e.g. to
+// sync a Wise Old Man group's members into the polled targets. A failed run is logged and
+// leaves the targets of the last successful one in place.
+func runDiscovery(ctx context.Context, source string, interval time.Duration, discover func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := discover(ctx); err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"source": source,
+				"error":  err.Error(),
+			}).Warn("Target discovery failed, keeping the previous targets")
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+)
+
+// configVars lists every environment variable. Each is mirrored by a flag named after it
+// (REDIS_ADDR -> --redis-addr); a flag takes precedence over the environment.
+var configVars = []string{
+	"STEAM_KEY", "STEAM_KEY_ROTATION", "STEAM_BACKOFF_INITIAL", "STEAM_BACKOFF_MULTIPLIER", "STEAM_BACKOFF_MAX",
+	"STEAM_GAME_INFO", "STEAM_GAME_COMPAT", "STEAM_FRIENDS", "STEAM_WORKSHOP", "STEAM_PROFILE_CONTENT", "STEAM_INFO_METRICS", "STEAM_PRICE_APP_IDS", "STEAM_PRICE_REGIONS",
+	"STEAM_MAX_ACHIEVEMENTS_PER_GAME", "STEAM_MAX_ACHIEVEMENT_SERIES", "STEAM_MAX_ACHIEVEMENT_REFRESHES",
+	"STEAM_DISCOVER_FRIENDS_OF", "STEAM_DISCOVER_ALLOW", "STEAM_DISCOVER_DENY", "STEAM_DISCOVER_INTERVAL",
+	"OSRS_RATE_LIMIT", "OSRS_GE_ITEMS", "OSRS_WOM_GROUP_ID", "OSRS_WOM_SYNC_INTERVAL",
+	"EPIC_ACCOUNTS",
+	"NINTENDO_SESSION_TOKEN",
+	"BNET_CLIENT_ID", "BNET_CLIENT_SECRET", "BNET_REGION",
+	"CLASH_OF_CLANS_TOKEN", "CLASH_ROYALE_TOKEN",
+	"BATTLEMETRICS_SERVERS", "BATTLEMETRICS_TOKEN",
+	"RCON_SERVERS",
+	"DISCORD_BOT_TOKEN",
+	"NOTIFY_WEBHOOK_URL",
+	"DAY_TIMEZONE",
+	"METRIC_NAMESPACE_STEAM", "METRIC_NAMESPACE_OSRS", "METRIC_DROP_LABELS", "METRIC_STATIC_LABELS",
+	"METRIC_TIMESTAMPS",
+	"CACHE_BACKEND", "CACHE_FILE_PATH",
+	"REDIS_ADDR", "REDIS_USERNAME", "REDIS_PASSWORD", "REDIS_DB",
+	"REDIS_TLS", "REDIS_TLS_SKIP_VERIFY", "REDIS_TLS_CA_FILE", "REDIS_OP_TIMEOUT", "REDIS_COMPRESS",
+	"POLL_INTERVAL_NORMAL", "POLL_INTERVAL_ACTIVE", "POLL_WORKERS", "POLL_JITTER", "POLL_MAX_BACKOFF",
+	"POLL_PAUSED", "POLL_COORDINATION", "POLL_INSTANCE_ID", "POLL_SCHEDULES",
+	"POLL_STEAM_IDS", "POLL_OSRS_PLAYERS",
+	"AUTH_BEARER_TOKEN", "AUTH_USERNAME", "AUTH_PASSWORD",
+	"RATE_LIMIT_PER_IP", "RATE_LIMIT_BURST", "MAX_CONCURRENT_COLLECTIONS", "SCRAPE_TIMEOUT",
+	"SHUTDOWN_TIMEOUT",
+	"TRACING_ENABLED", "OTEL_SERVICE_NAME", "TRACING_SAMPLE_RATIO",
+	"PUSH_REMOTE_WRITE_URL", "PUSH_REMOTE_WRITE_USERNAME", "PUSH_REMOTE_WRITE_PASSWORD",
+	"PUSH_REMOTE_WRITE_BEARER_TOKEN", "PUSH_OTLP",
+	"PUSHGATEWAY_URL", "PUSHGATEWAY_USERNAME", "PUSHGATEWAY_PASSWORD",
+	"GRAPHITE_ADDR", "GRAPHITE_PREFIX", "PUSH_JOB",
+	"HISTORY_DRIVER", "HISTORY_DSN",
+	"STARTUP_CHECK", "CONFIG_FILE", "CONFIG_STRICT", "SECRETS_FILE", "UPSTREAM_FIXTURES", "UPSTREAM_FIXTURES_MODE",
+	"UPSTREAM_MAX_IDLE_CONNS_PER_HOST", "UPSTREAM_IDLE_CONN_TIMEOUT", "UPSTREAM_HTTP2",
+	"UPSTREAM_FORCE_IPV4", "UPSTREAM_DNS_SERVER", "UPSTREAM_HOSTS", "PORT",
+	"LOG_LEVEL", "LOG_FORMAT", "LOG_CALLER",
+}
+
+// boolVars can be passed as bare flags (--redis-tls)
+var boolVars = map[string]bool{
+	"STEAM_GAME_INFO": true, "STEAM_GAME_COMPAT": true, "STEAM_FRIENDS": true, "STEAM_WORKSHOP": true, "STEAM_PROFILE_CONTENT": true, "STEAM_INFO_METRICS": true, "METRIC_TIMESTAMPS": true,
+	"REDIS_TLS": true, "REDIS_TLS_SKIP_VERIFY": true, "REDIS_COMPRESS": true,
+	"POLL_PAUSED": true, "POLL_COORDINATION": true,
+	"TRACING_ENABLED": true, "PUSH_OTLP": true,
+	"STARTUP_CHECK": true, "CONFIG_STRICT": true, "LOG_CALLER": true, "UPSTREAM_HTTP2": true,
+}
+
+// secretVars can also be read from a file (STEAM_KEY_FILE, --steam-key-file), for
+// Docker and Kubernetes secret mounts
+var secretVars = []string{
+	"STEAM_KEY",
+	"REDIS_PASSWORD",
+	"AUTH_BEARER_TOKEN", "AUTH_PASSWORD",
+	"PUSH_REMOTE_WRITE_PASSWORD", "PUSH_REMOTE_WRITE_BEARER_TOKEN",
+	"PUSHGATEWAY_PASSWORD",
+	"HISTORY_DSN",
+	"EPIC_ACCOUNTS",
+	"NINTENDO_SESSION_TOKEN",
+	"BNET_CLIENT_SECRET",
+	"CLASH_OF_CLANS_TOKEN", "CLASH_ROYALE_TOKEN",
+	"BATTLEMETRICS_TOKEN",
+	"RCON_SERVERS",
+	"DISCORD_BOT_TOKEN",
+	"NOTIFY_WEBHOOK_URL",
+}
+
+// flagValues holds the config flags given on the command line, by environment variable name
+var flagValues = make(map[string]string)
+
+// configFlag is a flag mirroring an environment variable
+type configFlag struct {
+	env    string
+	isBool bool
+}
+
+func (f *configFlag) String() string {
+	return ""
+}
+
+func (f *configFlag) Set(value string) error {
+	if f.isBool {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return err
+		}
+	}
+	flagValues[f.env] = value
+	return nil
+}
+
+func (f *configFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// flagName maps an environment variable to its flag name: REDIS_ADDR -> redis-addr
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// addConfigFlags registers a flag for every environment variable (and secret file) on flags
+func addConfigFlags(flags *flag.FlagSet) {
+	for _, env := range configVars {
+		flags.Var(&configFlag{env: env, isBool: boolVars[env]}, flagName(env), "Overrides $"+env)
+	}
+	for _, env := range secretVars {
+		flags.Var(&configFlag{env: env + "_FILE"}, flagName(env+"_FILE"), "Read "+env+" from this file (overrides $"+env+"_FILE)")
+	}
+}
+
+// parseConfigFlags parses the command line for the server and the check subcommand
+func parseConfigFlags(name string, args []string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	addConfigFlags(flags)
+	flags.Parse(args)
+	applyLogFlags()
+}
+
+// applyLogFlags reconfigures the logger, which reads LOG_* from the environment at init,
+// when any of them was given as a flag
+func applyLogFlags() {
+	_, level := flagValues["LOG_LEVEL"]
+	_, format := flagValues["LOG_FORMAT"]
+	_, caller := flagValues["LOG_CALLER"]
+	if !level && !format && !caller {
+		return
+	}
+	if err := logger.Configure(logger.Options{
+		Level:  configValue("LOG_LEVEL"),
+		Format: configValue("LOG_FORMAT"),
+		Caller: getEnvBool(nil, "LOG_CALLER", false),
+	}); err != nil {
+		logger.Log.WithError(err).Fatal("Invalid logging flags")
+	}
+}
+
+// configValue returns a setting from its flag, its environment variable, the file named by
+// its _FILE variant (trailing newlines are trimmed) or SECRETS_FILE, in that order
+func configValue(key string) string {
+	if value, ok := flagValues[key]; ok {
+		return value
+	}
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	path, ok := flagValues[key+"_FILE"]
+	if !ok {
+		path = os.Getenv(key + "_FILE")
+	}
+	if path == "" {
+		value, _ := secretsFileValue(key)
+		return value
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// A missing secret mount shouldn't silently start without the secret
+		logger.Log.WithError(fmt.Errorf("failed to read %s_FILE: %w", key, err)).Fatal("Invalid configuration")
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/check"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/polling"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminHandlers serves the operator endpoints under /admin
+type AdminHandlers struct {
+	cache    CacheAdmin
+	polling  PollingAdmin
+	checker  Checker
+	reloader Reloader
+	steam    RateLimitAdmin // nil when Steam isn't configured
+}
+
+type CacheAdmin interface {
+	FlushPrefix(ctx context.Context, prefix string) (int64, error)
+	Stats(ctx context.Context) (cache.Stats, error)
+	Ping(ctx context.Context) error
+}
+
+type PollingAdmin interface {
+	Pause()
+	Resume()
+	Paused() bool
+	TargetCount() int
+	Targets(kind string) []string
+	Status() []polling.TargetStatus
+}
+
+type Checker interface {
+	Run(ctx context.Context) []check.Result
+}
+
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+type RateLimitAdmin interface {
+	ResetRateLimit()
+	RateLimited() bool
+}
+
+func NewAdminHandlers(cache CacheAdmin, polling PollingAdmin, checker Checker, reloader Reloader, steam RateLimitAdmin) *AdminHandlers {
+	return &AdminHandlers{
+		cache:    cache,
+		polling:  polling,
+		checker:  checker,
+		reloader: reloader,
+		steam:    steam,
+	}
+}
+
+// HandleCacheFlush handles POST /admin/cache/flush?prefix=steam:
+func (h *AdminHandlers) HandleCacheFlush(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	logger.Log.WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"prefix": prefix,
+		"ip":     r.RemoteAddr,
+	}).Info("Cache flush request received")
+
+	if prefix == "" {
+		http.Error(w, "prefix query parameter is required (e.g. ?prefix=steam:owned_games:)", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.cache.FlushPrefix(r.Context(), prefix)
+	if err != nil {
+		logger.Log.WithFields(logrus.Fields{
+			"prefix":  prefix,
+			"deleted": deleted,
+			"error":   err.Error(),
+		}).Error("Failed to flush cache prefix")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Log.WithFields(logrus.Fields{
+		"prefix":  prefix,
+		"deleted": deleted,
+	}).Warn("Flushed cache keys by prefix")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"prefix":  prefix,
+		"deleted": deleted,
+	})
+}
+
+// HandleCacheStats handles GET /admin/cache/stats
+func (h *AdminHandlers) HandleCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.cache.Stats(r.Context())
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to collect cache stats")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// HandlePollingPause handles POST /admin/polling/pause
+func (h *AdminHandlers) HandlePollingPause(w http.ResponseWriter, r *http.Request) {
+	logger.Log.WithFields(logrus.Fields{
+		"path": r.URL.Path,
+		"ip":   r.RemoteAddr,
+	}).Info("Polling pause request received")
+
+	h.polling.Pause()
+	h.writePollingStatus(w)
+}
+
+// HandlePollingResume handles POST /admin/polling/resume
+func (h *AdminHandlers) HandlePollingResume(w http.ResponseWriter, r *http.Request) {
+	logger.Log.WithFields(logrus.Fields{
+		"path": r.URL.Path,
+		"ip":   r.RemoteAddr,
+	}).Info("Polling resume request received")
+
+	h.polling.Resume()
+	h.writePollingStatus(w)
+}
+
+// HandlePollingStatus handles GET /admin/polling
+func (h *AdminHandlers) HandlePollingStatus(w http.ResponseWriter, r *http.Request) {
+	h.writePollingStatus(w)
+}
+
+func (h *AdminHandlers) writePollingStatus(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"paused":  h.polling.Paused(),
+		"targets": h.polling.TargetCount(),
+	})
+}
+
+// HandleRateLimitReset handles POST /admin/ratelimit/reset, clearing the Steam API backoff
+// of every key when the operator knows the 403s were spurious
+func (h *AdminHandlers) HandleRateLimitReset(w http.ResponseWriter, r *http.Request) {
+	logger.Log.WithFields(logrus.Fields{
+		"path": r.URL.Path,
+		"ip":   r.RemoteAddr,
+	}).Info("Rate limit reset request received")
+
+	if h.steam == nil {
+		http.Error(w, "Steam collector not initialized - STEAM_KEY not set", http.StatusNotFound)
+		return
+	}
+
+	h.steam.ResetRateLimit()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"rate_limited": h.steam.RateLimited(),
+	})
+}
+
+// HandleCheck handles GET /admin/check, checking every dependency (503 if any check fails)
+func (h *AdminHandlers) HandleCheck(w http.ResponseWriter, r *http.Request) {
+	results := h.checker.Run(r.Context())
+	passed := check.Passed(results)
+
+	if !passed {
+		logger.FromContext(r.Context()).WithField("results", results).Warn("Dependency check failed")
+	}
+
+	status := http.StatusOK
+	if !passed {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"ok":     passed,
+		"checks": results,
+	})
+}
+
+// HandleReload handles POST /admin/reload, re-reading the config file (as SIGHUP does)
+func (h *AdminHandlers) HandleReload(w http.ResponseWriter, r *http.Request) {
+	logger.Log.WithFields(logrus.Fields{
+		"path": r.URL.Path,
+		"ip":   r.RemoteAddr,
+	}).Info("Config reload request received")
+
+	if err := h.reloader.Reload(r.Context()); err != nil {
+		logger.Log.WithError(err).Error("Failed to reload configuration")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"reloaded": true,
+		"targets":  h.polling.TargetCount(),
+	})
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		logger.Log.WithError(err).Error("Failed to write JSON response")
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	both := AuthConfig{BearerToken: "token", Username: "admin", Password: "hunter2"}
+
+	tests := []struct {
+		name      string
+		auth      AuthConfig
+		header    string // Authorization header, none when empty
+		basic     []string
+		want      int
+		challenge string // WWW-Authenticate of a 401
+	}{
+		{name: "no credentials configured", auth: AuthConfig{}, want: http.StatusOK},
+		{name: "bearer token", auth: AuthConfig{BearerToken: "token"}, header: "Bearer token", want: http.StatusOK},
+		{name: "wrong token", auth: AuthConfig{BearerToken: "token"}, header: "Bearer tokem", want: http.StatusUnauthorized, challenge: `Bearer realm="game-stats-exporter"`},
+		{name: "token prefix", auth: AuthConfig{BearerToken: "token"}, header: "Bearer tok", want: http.StatusUnauthorized, challenge: `Bearer realm="game-stats-exporter"`},
+		{name: "token without scheme", auth: AuthConfig{BearerToken: "token"}, header: "token", want: http.StatusUnauthorized, challenge: `Bearer realm="game-stats-exporter"`},
+		{name: "missing header", auth: AuthConfig{BearerToken: "token"}, want: http.StatusUnauthorized, challenge: `Bearer realm="game-stats-exporter"`},
+		{name: "basic auth", auth: AuthConfig{Username: "admin", Password: "hunter2"}, basic: []string{"admin", "hunter2"}, want: http.StatusOK},
+		{name: "wrong password", auth: AuthConfig{Username: "admin", Password: "hunter2"}, basic: []string{"admin", "hunter3"}, want: http.StatusUnauthorized, challenge: `Basic realm="game-stats-exporter", charset="UTF-8"`},
+		{name: "wrong username", auth: AuthConfig{Username: "admin", Password: "hunter2"}, basic: []string{"root", "hunter2"}, want: http.StatusUnauthorized, challenge: `Basic realm="game-stats-exporter", charset="UTF-8"`},
+		{name: "missing basic auth", auth: AuthConfig{Username: "admin", Password: "hunter2"}, want: http.StatusUnauthorized, challenge: `Basic realm="game-stats-exporter", charset="UTF-8"`},
+		{name: "password as bearer token", auth: AuthConfig{Username: "admin", Password: "hunter2"}, header: "Bearer hunter2", want: http.StatusUnauthorized, challenge: `Basic realm="game-stats-exporter", charset="UTF-8"`},
+		{name: "either: token", auth: both, header: "Bearer token", want: http.StatusOK},
+		{name: "either: basic auth", auth: both, basic: []string{"admin", "hunter2"}, want: http.StatusOK},
+		{name: "either: wrong password", auth: both, basic: []string{"admin", "token"}, want: http.StatusUnauthorized, challenge: `Basic realm="game-stats-exporter", charset="UTF-8"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics/steam/76561197960287930", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.basic != nil {
+				req.SetBasicAuth(tt.basic[0], tt.basic[1])
+			}
+			w := httptest.NewRecorder()
+			RequireAuth(tt.auth)(ok).ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.challenge)
+			}
+		})
+	}
+}
+
+func TestOpenAPIUnauthenticated(t *testing.T) {
+	router := newTestRouter(t, RouterConfig{Auth: AuthConfig{BearerToken: "token", Tenants: tenants}})
+
+	// The OpenAPI document stays readable, so clients can discover how to authenticate
+	if got := get(router, "/api/openapi.json", "").Code; got != http.StatusOK {
+		t.Errorf("/api/openapi.json without credentials = %d, want 200", got)
+	}
+	if got := get(router, "/api/openapi.json", "Bearer wrong").Code; got != http.StatusOK {
+		t.Errorf("/api/openapi.json with a wrong token = %d, want 200", got)
+	}
+	// Unlike everything else
+	if got := get(router, "/metrics", "").Code; got != http.StatusUnauthorized {
+		t.Errorf("/metrics without credentials = %d, want 401", got)
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// dashboardPanel is one panel of a generated dashboard
+type dashboardPanel struct {
+	title  string
+	kind   string // Grafana panel type: stat, timeseries, bargauge, table
+	unit   string
+	expr   string
+	legend string
+	width  int
+	height int
+}
+
+// dashboardSpec describes a generated dashboard. The target variable is a custom variable
+// holding the polled targets, falling back to a label_values query when none are configured.
+type dashboardSpec struct {
+	title    string
+	uid      string
+	variable string // Label the dashboard is filtered by
+	query    string // label_values query used when no targets are configured
+	panels   []dashboardPanel
+}
+
+var dashboards = map[string]dashboardSpec{
+	"steam": {
+		title:    "Steam",
+		uid:      "game-stats-steam",
+		variable: "steam_id",
+		query:    "label_values(steam_owned_games_playtime_seconds, steam_id)",
+		panels: []dashboardPanel{
+			{title: "Total playtime", kind: "stat", unit: "s", width: 8, height: 5,
+				expr: `sum by (steam_id) (steam_owned_games_playtime_seconds{steam_id=~"$steam_id"})`, legend: "{{steam_id}}"},
+			{title: "Achievements unlocked", kind: "stat", unit: "short", width: 8, height: 5,
+				expr: `sum by (steam_id) (steam_achievements_achieved{steam_id=~"$steam_id"})`, legend: "{{steam_id}}"},
+			{title: "Games played", kind: "stat", unit: "short", width: 8, height: 5,
+				expr: `count by (steam_id) (steam_owned_games_playtime_seconds{steam_id=~"$steam_id"} > 0)`, legend: "{{steam_id}}"},
+			{title: "Playtime over time", kind: "timeseries", unit: "s", width: 24, height: 9,
+				expr: `sum by (steam_id) (steam_owned_games_playtime_seconds{steam_id=~"$steam_id"})`, legend: "{{steam_id}}"},
+			{title: "Most played games", kind: "bargauge", unit: "s", width: 12, height: 10,
+				expr: `topk(10, steam_owned_games_playtime_seconds{steam_id=~"$steam_id"})`, legend: "{{game_name}}"},
+			{title: "Played in the last 7 days", kind: "bargauge", unit: "s", width: 12, height: 10,
+				expr: `topk(10, delta(steam_owned_games_playtime_seconds{steam_id=~"$steam_id"}[7d]) > 0)`, legend: "{{game_name}}"},
+			{title: "Achievement completion", kind: "bargauge", unit: "percentunit", width: 24, height: 10,
+				expr: `topk(15, steam_game_completion_ratio{steam_id=~"$steam_id"})`, legend: "{{game_name}}"},
+		},
+	},
+	"osrs": {
+		title:    "Old School RuneScape",
+		uid:      "game-stats-osrs",
+		variable: "player",
+		query:    "label_values(osrs_player_xp, player)",
+		panels: []dashboardPanel{
+			{title: "Total level", kind: "stat", unit: "short", width: 8, height: 5,
+				expr: `osrs_player_level{player=~"$player", skill="Overall"}`, legend: "{{player}} ({{mode}})"},
+			{title: "Total XP", kind: "stat", unit: "short", width: 8, height: 5,
+				expr: `osrs_player_xp{player=~"$player", skill="Overall"}`, legend: "{{player}} ({{mode}})"},
+			{title: "XP gained in the last 7 days", kind: "stat", unit: "short", width: 8, height: 5,
+				expr: `delta(osrs_player_xp{player=~"$player", skill="Overall"}[7d])`, legend: "{{player}} ({{mode}})"},
+			{title: "XP over time", kind: "timeseries", unit: "short", width: 24, height: 9,
+				expr: `osrs_player_xp{player=~"$player", skill="Overall"}`, legend: "{{player}} ({{mode}})"},
+			{title: "XP gained per skill (7 days)", kind: "bargauge", unit: "short", width: 12, height: 12,
+				expr: `topk(10, delta(osrs_player_xp{player=~"$player", skill!="Overall"}[7d]) > 0)`, legend: "{{skill}}"},
+			{title: "Skill levels", kind: "bargauge", unit: "short", width: 12, height: 12,
+				expr: `osrs_player_level{player=~"$player", skill!="Overall"}`, legend: "{{skill}}"},
+			{title: "Minigame and boss scores", kind: "table", unit: "short", width: 24, height: 10,
+				expr: `osrs_minigame_score{player=~"$player"}`, legend: "{{minigame}}"},
+		},
+	},
+}
+
+// HandleDashboard handles GET /admin/dashboards/{steam|osrs}, emitting Grafana dashboard JSON
+// ready to import, templated with the targets being polled
+func (h *AdminHandlers) HandleDashboard(w http.ResponseWriter, r *http.Request) {
+	kind := chi.URLParam(r, "kind")
+
+	spec, ok := dashboards[kind]
+	if !ok {
+		http.Error(w, "Unknown dashboard. Supported dashboards: 'steam', 'osrs'", http.StatusNotFound)
+		return
+	}
+
+	targets := h.polling.Targets(kind)
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"dashboard": kind,
+		"targets":   len(targets),
+	}).Info("Generated Grafana dashboard")
+
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+spec.uid+".json\"")
+	writeJSON(w, http.StatusOK, spec.dashboard(targets))
+}
+
+// dashboard builds the Grafana dashboard model
+func (spec dashboardSpec) dashboard(targets []string) map[string]interface{} {
+	datasource := map[string]interface{}{"type": "prometheus", "uid": "${datasource}"}
+
+	var panels []interface{}
+	x, y, rowHeight := 0, 0, 0
+	for i, panel := range spec.panels {
+		if x+panel.width > 24 {
+			x, y, rowHeight = 0, y+rowHeight, 0
+		}
+		panels = append(panels, map[string]interface{}{
+			"id":         i + 1,
+			"type":       panel.kind,
+			"title":      panel.title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"x": x, "y": y, "w": panel.width, "h": panel.height},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]interface{}{"unit": panel.unit},
+				"overrides": []interface{}{},
+			},
+			"targets": []interface{}{map[string]interface{}{
+				"refId":        "A",
+				"datasource":   datasource,
+				"expr":         panel.expr,
+				"legendFormat": panel.legend,
+				"instant":      panel.kind != "timeseries",
+			}},
+		})
+		x += panel.width
+		if panel.height > rowHeight {
+			rowHeight = panel.height
+		}
+	}
+
+	return map[string]interface{}{
+		"title":         spec.title,
+		"uid":           spec.uid,
+		"tags":          []string{"game-stats-exporter"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"editable":      true,
+		"refresh":       "5m",
+		"time":          map[string]string{"from": "now-7d", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				spec.targetVariable(targets, datasource),
+			},
+		},
+		"panels": panels,
+	}
+}
+
+func (spec dashboardSpec) targetVariable(targets []string, datasource map[string]interface{}) map[string]interface{} {
+	variable := map[string]interface{}{
+		"name":       spec.variable,
+		"label":      spec.variable,
+		"multi":      true,
+		"includeAll": true,
+		"current":    map[string]interface{}{"text": "All", "value": "$__all"},
+	}
+	if len(targets) == 0 {
+		variable["type"] = "query"
+		variable["datasource"] = datasource
+		variable["query"] = spec.query
+		variable["refresh"] = 2
+		return variable
+	}
+
+	options := make([]interface{}, 0, len(targets))
+	for _, target := range targets {
+		options = append(options, map[string]interface{}{"text": target, "value": target, "selected": false})
+	}
+	variable["type"] = "custom"
+	variable["query"] = strings.Join(targets, ",")
+	variable["options"] = options
+	return variable
+}
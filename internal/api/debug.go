@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/debugraw"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/sirupsen/logrus"
+)
+
+// The /debug/raw endpoint fetches a target straight from upstream, bypassing the cache, and
+// answers with the raw responses next to the structs they were parsed into, for when the
+// hiscores format changes or the world list decoder misbehaves. Nothing is cached or reported.
+
+// Collectors served by /debug/raw/{collector}/{target}
+const (
+	debugSteam      = "steam"       // Target is a Steam ID; fetches the owned games
+	debugOSRS       = "osrs"        // Target is an RSN; fetches the hiscores of ?mode= (default vanilla)
+	debugOSRSWorlds = "osrs_worlds" // Target is a world number, or "all"; fetches the world list
+)
+
+// debugRawResponse is the body of /debug/raw/{collector}/{target}
+type debugRawResponse struct {
+	Collector string             `json:"collector"`
+	Target    string             `json:"target"`
+	Mode      string             `json:"mode,omitempty"`
+	FetchedAt time.Time          `json:"fetched_at"`
+	Responses []debugRawUpstream `json:"responses"`
+	Parsed    any                `json:"parsed,omitempty"` // Omitted when parsing failed
+	Error     string             `json:"error,omitempty"`
+}
+
+// debugRawUpstream is one upstream response. Bodies that aren't UTF-8 (the binary world list)
+// are base64 encoded.
+type debugRawUpstream struct {
+	URL          string `json:"url"`
+	Status       int    `json:"status"`
+	Size         int    `json:"size"`
+	BodyEncoding string `json:"body_encoding"` // "text" or "base64"
+	Body         string `json:"body"`
+}
+
+// HandleDebugRaw handles /debug/raw/{collector}/{target}
+func (h *Handlers) HandleDebugRaw(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	collector := chi.URLParam(r, "collector")
+	target := chi.URLParam(r, "target")
+	response := debugRawResponse{Collector: collector, Target: target, FetchedAt: start}
+
+	ctx, recorder := debugraw.WithRecorder(r.Context())
+	var parsed any
+	var err error
+	status := http.StatusBadGateway
+	switch collector {
+	case debugSteam:
+		if h.steamCollector == nil {
+			writeJSONError(w, http.StatusInternalServerError, "Steam collector not initialized - STEAM_KEY environment variable is required")
+			return
+		}
+		parsed, err = h.steamCollector.DebugOwnedGames(ctx, target)
+	case debugOSRS:
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = "vanilla"
+		}
+		if modes := h.osrsCollector.Modes(); !isSupportedMode(modes, mode) {
+			writeJSONError(w, http.StatusBadRequest, "Unknown mode. Supported modes: "+quotedModes(modes))
+			return
+		}
+		response.Mode = mode
+
+		var skills []osrs.SkillInfo
+		var minigames []osrs.MinigameInfo
+		skills, minigames, err = h.osrsCollector.DebugPlayerStats(ctx, target, mode)
+		parsed = osrsPlayerResponse{RSN: target, Mode: mode, Skills: skills, Minigames: minigames}
+		if err != nil {
+			status = osrsErrorStatus(err)
+		}
+	case debugOSRSWorlds:
+		var world uint64
+		if target != "all" {
+			if world, err = strconv.ParseUint(target, 10, 16); err != nil {
+				writeJSONError(w, http.StatusBadRequest, "target must be a world number or 'all'")
+				return
+			}
+		}
+
+		var worlds []osrs.World
+		worlds, err = h.osrsCollector.DebugWorlds(ctx)
+		parsed = osrsWorldsResponse{Worlds: worldsNumbered(worlds, uint16(world))}
+	default:
+		writeJSONError(w, http.StatusNotFound, "Unknown collector. Supported collectors: 'steam', 'osrs', 'osrs_worlds'")
+		return
+	}
+
+	response.Responses = debugUpstreams(recorder.Responses())
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"collector": collector,
+			"target":    target,
+			"responses": len(response.Responses),
+			"error":     err.Error(),
+			"duration":  time.Since(start),
+		}).Warn("Debug fetch failed")
+		response.Error = err.Error()
+		writeJSON(w, status, response)
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"collector": collector,
+		"target":    target,
+		"responses": len(response.Responses),
+		"duration":  time.Since(start),
+	}).Info("Served raw upstream responses")
+
+	response.Parsed = parsed
+	writeJSON(w, http.StatusOK, response)
+}
+
+// worldsNumbered returns the world numbered id, or every world when id is 0
+func worldsNumbered(worlds []osrs.World, id uint16) []osrs.World {
+	if id == 0 {
+		return worlds
+	}
+	for _, world := range worlds {
+		if world.ID == id {
+			return []osrs.World{world}
+		}
+	}
+	return []osrs.World{}
+}
+
+func debugUpstreams(responses []debugraw.Response) []debugRawUpstream {
+	upstreams := make([]debugRawUpstream, len(responses))
+	for i, response := range responses {
+		upstreams[i] = debugRawUpstream{
+			URL:          response.URL,
+			Status:       response.Status,
+			Size:         len(response.Body),
+			BodyEncoding: "text",
+			Body:         string(response.Body),
+		}
+		if !utf8.Valid(response.Body) {
+			upstreams[i].BodyEncoding = "base64"
+			upstreams[i].Body = base64.StdEncoding.EncodeToString(response.Body)
+		}
+	}
+	return upstreams
+}
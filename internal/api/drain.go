@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// errDraining is returned for collections requested once shutdown has begun
+var errDraining = errors.New("exporter is shutting down")
+
+// drain tracks the on-demand collections, which outlive their request after a scrape timeout,
+// so shutdown can cancel them and wait until they stop writing to the cache and gauges
+type drain struct {
+	ctx    context.Context // Cancelled when shutdown begins
+	cancel context.CancelFunc
+
+	mu       sync.Mutex // Orders starting collections against Wait
+	draining bool
+	running  sync.WaitGroup
+}
+
+func newDrain() *drain {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &drain{ctx: ctx, cancel: cancel}
+}
+
+// start registers a collection, returning its context (detached from the request's
+// cancellation, cancelled on shutdown) and the function to call once it's done
+func (d *drain) start(parent context.Context) (context.Context, func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return nil, nil, errDraining
+	}
+	d.running.Add(1)
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	stop := context.AfterFunc(d.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		d.running.Done()
+	}, nil
+}
+
+// stopping reports whether shutdown has begun
+func (d *drain) stopping() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Drain refuses new collections, cancels the running ones and waits for them to return, or for
+// ctx to be done
+func (h *Handlers) Drain(ctx context.Context) error {
+	h.drain.mu.Lock()
+	h.drain.draining = true
+	h.drain.mu.Unlock()
+	h.drain.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		h.drain.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RejectWhileDraining answers collection requests with a 503 once shutdown has begun, so a
+// scrape arriving on a kept-alive connection doesn't start a collection that's cancelled at once
+func (h *Handlers) RejectWhileDraining(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.drain.stopping() {
+			logger.FromContext(r.Context()).WithFields(logrus.Fields{
+				"path": r.URL.Path,
+			}).Info("Rejected collection request during shutdown")
+			w.Header().Set("Connection", "close")
+			http.Error(w, errDraining.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
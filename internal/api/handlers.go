@@ -1,48 +1,178 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/battlenet"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/clash"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/epic"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/family"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/history"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/relabel"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
 	"github.com/sirupsen/logrus"
 )
 
 type Handlers struct {
 	steamCollector SteamCollector
 	osrsCollector  OSRSCollector
+	options        HandlerOptions
+	snapshots      *snapshotStore
+	drain          *drain
+}
+
+// HandlerOptions configures how the metrics handlers collect
+type HandlerOptions struct {
+	// ScrapeTimeout bounds how long a scrape waits for collection before serving
+	// whatever is available. Shortened further by Prometheus's scrape timeout header.
+	// 0 waits for collection to finish.
+	ScrapeTimeout time.Duration
+
+	// History serves the /history endpoints; nil when no history store is configured
+	History HistoryReader
+
+	// Prices serves /metrics/steam/prices; nil when no apps are tracked (STEAM_PRICE_APP_IDS)
+	Prices PriceCollector
+
+	// GE serves /metrics/osrs/ge; nil when no Grand Exchange items are watched (OSRS_GE_ITEMS)
+	GE PriceCollector
+
+	// Families serves /metrics/family/{family}, for the families defined in CONFIG_FILE
+	Families FamilyCollector
+
+	// Epic serves /metrics/epic/{account_id}; nil when no Epic account is configured (EPIC_ACCOUNTS)
+	Epic EpicCollector
+
+	// Nintendo serves /metrics/nintendo; nil when no Parental Controls account is configured (NINTENDO_SESSION_TOKEN)
+	Nintendo NintendoCollector
+
+	// BattleNet serves /metrics/bnet/{game}/{profile}; nil without Blizzard API client credentials (BNET_CLIENT_ID)
+	BattleNet BattleNetCollector
+
+	// Clash serves /metrics/clash/{tag}; nil without a Supercell API token (CLASH_OF_CLANS_TOKEN, CLASH_ROYALE_TOKEN)
+	Clash ClashCollector
+
+	// BattleMetrics serves /metrics/battlemetrics; nil when no server is configured (BATTLEMETRICS_SERVERS)
+	BattleMetrics BattleMetricsCollector
+
+	// RCON serves /metrics/rcon; nil when no dedicated server is configured (RCON_SERVERS)
+	RCON RCONCollector
+
+	// Discord serves /metrics/discord; nil without a Discord bot (DISCORD_BOT_TOKEN)
+	Discord DiscordPresence
+
+	// Users maps the names served at /metrics/user/{name} to their accounts (CONFIG_FILE users)
+	Users UserDirectory
+
+	// Targets lists the polled players ranked by the leaderboard endpoints
+	Targets TargetLister
+
+	// Relabel rewrites every served series (METRIC_NAMESPACE_*, METRIC_DROP_LABELS, METRIC_STATIC_LABELS)
+	Relabel relabel.Rules
+
+	// SampleTimestamps stamps a target's samples with the time their cached data was fetched
+	SampleTimestamps bool
+
+	// Exemplars serves /metrics as OpenMetrics to scrapers accepting it, the only format carrying
+	// the trace exemplars of the duration histograms (TRACING_ENABLED)
+	Exemplars bool
 }
 
 type SteamCollector interface {
-	Collect(steamId string) error
+	Collect(ctx context.Context, steamId string) error
+	Profile(ctx context.Context, steamId string) (steam.Profile, error)
+	OwnedGames(ctx context.Context, steamId string) (steam.OwnedGamesResponse, error)
+	DebugOwnedGames(ctx context.Context, steamId string) (steam.OwnedGamesResponse, error)
 }
 
 type OSRSCollector interface {
-	CollectPlayerStats(rsn string, mode string) error
-	CollectAllModes(rsn string) map[string]error
-	CollectWorldData() error
+	CollectPlayerStats(ctx context.Context, rsn string, mode string) error
+	CollectAllModes(ctx context.Context, rsn string) map[string]error
+	CollectWorldData(ctx context.Context) error
+	PlayerStats(ctx context.Context, rsn string, mode string) ([]osrs.SkillInfo, []osrs.MinigameInfo, error)
+	Worlds(ctx context.Context) ([]osrs.World, error)
+	Modes() []string               // Built-in and configured tournament modes
+	PlayerLabel(rsn string) string // The player label an RSN is reported with
+	SearchItems(ctx context.Context, query string, limit int) ([]osrs.Item, error)
+	DebugPlayerStats(ctx context.Context, rsn string, mode string) ([]osrs.SkillInfo, []osrs.MinigameInfo, error)
+	DebugWorlds(ctx context.Context) ([]osrs.World, error)
+}
+
+type PriceCollector interface {
+	CollectPrices(ctx context.Context) error
+}
+
+type TargetLister interface {
+	Targets(kind string) []string
+}
+
+type FamilyCollector interface {
+	Collect(ctx context.Context, name string) error
+}
+
+type EpicCollector interface {
+	Collect(ctx context.Context, accountId string) error
+}
+
+type NintendoCollector interface {
+	Collect(ctx context.Context) error
+}
+
+type BattleNetCollector interface {
+	Collect(ctx context.Context, game string, profile string) error
+}
+
+type ClashCollector interface {
+	Collect(ctx context.Context, tag string) error
+}
+
+type BattleMetricsCollector interface {
+	Collect(ctx context.Context) error
+}
+
+type RCONCollector interface {
+	Collect(ctx context.Context) error
 }
 
-func NewHandlers(steamCollector SteamCollector, osrsCollector OSRSCollector) *Handlers {
+// DiscordPresence is updated from the Discord gateway in the background, so serving it
+// collects nothing
+type DiscordPresence interface {
+	Connected() bool
+}
+
+type HistoryReader interface {
+	SkillXP(ctx context.Context, rsn string, mode string, skill string, since time.Time) ([]history.Point, error)
+	Playtime(ctx context.Context, steamId string, appId uint64, since time.Time) ([]history.Point, error)
+	Unlocks(ctx context.Context, steamId string, appId uint64) ([]history.Unlock, error)
+}
+
+func NewHandlers(steamCollector SteamCollector, osrsCollector OSRSCollector, options HandlerOptions) *Handlers {
 	return &Handlers{
 		steamCollector: steamCollector,
 		osrsCollector:  osrsCollector,
+		options:        options,
+		snapshots:      newSnapshotStore(),
+		drain:          newDrain(),
 	}
 }
 
 // HandleAllMetrics handles /metrics - serves only system metrics (Go runtime, process, etc.)
 func (h *Handlers) HandleAllMetrics(w http.ResponseWriter, r *http.Request) {
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
 		"path":   r.URL.Path,
 		"method": r.Method,
 		"ip":     r.RemoteAddr,
 	}).Info("System metrics request received")
 
 	// Serve only system metrics (excludes steam_* and osrs_* application metrics)
-	SystemMetricsHandler().ServeHTTP(w, r)
+	SystemMetricsHandler(h.options.Relabel, h.options.Exemplars).ServeHTTP(w, r)
 }
 
 // HandleSteamMetrics handles /metrics/steam/{steam_id}
@@ -50,7 +180,7 @@ func (h *Handlers) HandleSteamMetrics(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	steamId := chi.URLParam(r, "steam_id")
 
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
 		"path":     r.URL.Path,
 		"method":   r.Method,
 		"steam_id": steamId,
@@ -58,78 +188,466 @@ func (h *Handlers) HandleSteamMetrics(w http.ResponseWriter, r *http.Request) {
 	}).Info("Steam metrics request received")
 
 	if steamId == "" {
-		logger.Log.Error("Steam metrics request missing steam_id parameter")
+		logger.FromContext(r.Context()).Error("Steam metrics request missing steam_id parameter")
 		http.Error(w, "steam_id is required", http.StatusBadRequest)
 		return
 	}
 
 	if h.steamCollector == nil {
-		logger.Log.Error("Steam collector not initialized - STEAM_KEY not set")
+		logger.FromContext(r.Context()).Error("Steam collector not initialized - STEAM_KEY not set")
 		http.Error(w, "Steam collector not initialized - STEAM_KEY environment variable is required", http.StatusInternalServerError)
 		return
 	}
 
 	// Collect metrics for this user
-	logger.Log.WithField("steam_id", steamId).Info("Collecting Steam metrics")
-	err := h.steamCollector.Collect(steamId)
+	logger.FromContext(r.Context()).WithField("steam_id", steamId).Info("Collecting Steam metrics")
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.steamCollector.Collect(ctx, steamId)
+	})
+	if steam.IsPartial(err) {
+		// The games that failed are left out (counted in steam_collection_partial), the rest
+		// are served as a successful collection
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+		}).Warn("Steam metrics collected partially")
+		err = nil
+	}
 	if err != nil {
-		// If rate limited, serve whatever metrics are already present (from cache)
-		if strings.Contains(strings.ToLower(err.Error()), "rate limited") {
-			logger.Log.WithFields(logrus.Fields{
-				"steam_id": steamId,
-				"error":    err.Error(),
-				"duration": time.Since(start),
-			}).Warn("Rate limited by Steam - serving cached/last reported metrics only")
-			SteamHandler().ServeHTTP(w, r)
-			return
-		}
-
-		logger.Log.WithFields(logrus.Fields{
+		// Report the failure as a metric (serving the last-known metrics, including while
+		// rate limited by Steam) rather than failing the scrape
+		stale := h.serveFailure(w, r, "steam", steamId)
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
 			"steam_id": steamId,
 			"error":    err.Error(),
 			"duration": time.Since(start),
+			"stale":    stale,
 		}).Error("Failed to collect Steam metrics")
-		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	logger.Log.WithFields(logrus.Fields{
-		"steam_id": steamId,
-		"duration": time.Since(start),
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"steam_id":  steamId,
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
 	}).Info("Steam metrics collection completed successfully")
 
 	// Serve Prometheus metrics (Steam only, filtered)
-	SteamHandler().ServeHTTP(w, r)
+	h.serveCollected(w, r, steamUserMetrics, "steam", steamId, timedOut)
+}
+
+// HandleSteamPriceMetrics handles /metrics/steam/prices
+func (h *Handlers) HandleSteamPriceMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"ip":     r.RemoteAddr,
+	}).Info("Steam price metrics request received")
+
+	if h.options.Prices == nil {
+		http.Error(w, "Steam price tracking is not configured - set STEAM_PRICE_APP_IDS", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, h.options.Prices.CollectPrices)
+	if err != nil {
+		stale := h.serveFailure(w, r, "steam_prices", "prices")
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect Steam prices")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("Steam price metrics collection completed successfully")
+
+	h.serveCollected(w, r, steamPriceMetrics, "steam_prices", "prices", timedOut)
+}
+
+// HandleOSRSGEMetrics handles /metrics/osrs/ge
+func (h *Handlers) HandleOSRSGEMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"ip":     r.RemoteAddr,
+	}).Info("OSRS GE metrics request received")
+
+	if h.options.GE == nil {
+		http.Error(w, "OSRS GE price tracking is not configured - set OSRS_GE_ITEMS", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, h.options.GE.CollectPrices)
+	if err != nil {
+		stale := h.serveFailure(w, r, "osrs_ge", "ge")
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect OSRS GE prices")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("OSRS GE metrics collection completed successfully")
+
+	h.serveCollected(w, r, osrsGEMetrics, "osrs_ge", "ge", timedOut)
+}
+
+// HandleFamilyMetrics handles /metrics/family/{family}
+func (h *Handlers) HandleFamilyMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	name := chi.URLParam(r, "family")
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"family": name,
+		"ip":     r.RemoteAddr,
+	}).Info("Family metrics request received")
+
+	if h.options.Families == nil {
+		http.Error(w, "Family metrics are not enabled", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.options.Families.Collect(ctx, name)
+	})
+	if errors.Is(err, family.ErrUnknownFamily) {
+		http.Error(w, fmt.Sprintf("family %q is not configured - add it to the families section of CONFIG_FILE", name), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		stale := h.serveFailure(w, r, "family", name)
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"family":   name,
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect family metrics")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"family":    name,
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("Family metrics collection completed successfully")
+
+	h.serveCollected(w, r, familyMetrics, "family", name, timedOut)
+}
+
+// HandleEpicMetrics handles /metrics/epic/{account_id}
+func (h *Handlers) HandleEpicMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	accountId := chi.URLParam(r, "account_id")
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":       r.URL.Path,
+		"method":     r.Method,
+		"account_id": accountId,
+		"ip":         r.RemoteAddr,
+	}).Info("Epic metrics request received")
+
+	if h.options.Epic == nil {
+		http.Error(w, "Epic metrics are not configured - set EPIC_ACCOUNTS", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.options.Epic.Collect(ctx, accountId)
+	})
+	if errors.Is(err, epic.ErrUnknownAccount) {
+		http.Error(w, fmt.Sprintf("Epic account %q is not configured - add its access token to EPIC_ACCOUNTS", accountId), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		stale := h.serveFailure(w, r, "epic", accountId)
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"account_id": accountId,
+			"error":      err.Error(),
+			"duration":   time.Since(start),
+			"stale":      stale,
+		}).Error("Failed to collect Epic metrics")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"account_id": accountId,
+		"duration":   time.Since(start),
+		"timed_out":  timedOut,
+	}).Info("Epic metrics collection completed successfully")
+
+	h.serveCollected(w, r, epicMetrics, "epic", accountId, timedOut)
+}
+
+// HandleNintendoMetrics handles /metrics/nintendo
+func (h *Handlers) HandleNintendoMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"ip":     r.RemoteAddr,
+	}).Info("Nintendo metrics request received")
+
+	if h.options.Nintendo == nil {
+		http.Error(w, "Nintendo metrics are not configured - set NINTENDO_SESSION_TOKEN", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.options.Nintendo.Collect(ctx)
+	})
+	if err != nil {
+		stale := h.serveFailure(w, r, "nintendo", "devices")
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect Nintendo metrics")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("Nintendo metrics collection completed successfully")
+
+	h.serveCollected(w, r, nintendoMetrics, "nintendo", "devices", timedOut)
+}
+
+// HandleBattleNetMetrics handles /metrics/bnet/{game}/{profile}
+func (h *Handlers) HandleBattleNetMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	game := chi.URLParam(r, "game")
+	profile := chi.URLParam(r, "profile")
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":    r.URL.Path,
+		"method":  r.Method,
+		"game":    game,
+		"profile": profile,
+		"ip":      r.RemoteAddr,
+	}).Info("Battle.net metrics request received")
+
+	if h.options.BattleNet == nil {
+		http.Error(w, "Battle.net metrics are not configured - set BNET_CLIENT_ID and BNET_CLIENT_SECRET", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.options.BattleNet.Collect(ctx, game, profile)
+	})
+	if errors.Is(err, battlenet.ErrUnknownGame) || errors.Is(err, battlenet.ErrInvalidProfile) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		stale := h.serveFailure(w, r, "bnet", game+"/"+profile)
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"game":     game,
+			"profile":  profile,
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect Battle.net metrics")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"game":      game,
+		"profile":   profile,
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("Battle.net metrics collection completed successfully")
+
+	h.serveCollected(w, r, bnetMetrics, "bnet", game+"/"+profile, timedOut)
+}
+
+// HandleClashMetrics handles /metrics/clash/{tag}
+func (h *Handlers) HandleClashMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	tag := chi.URLParam(r, "tag")
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"tag":    tag,
+		"ip":     r.RemoteAddr,
+	}).Info("Clash metrics request received")
+
+	if h.options.Clash == nil {
+		http.Error(w, "Clash metrics are not configured - set CLASH_OF_CLANS_TOKEN or CLASH_ROYALE_TOKEN", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.options.Clash.Collect(ctx, tag)
+	})
+	if errors.Is(err, clash.ErrInvalidTag) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, clash.ErrPlayerNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		stale := h.serveFailure(w, r, "clash", tag)
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"tag":      tag,
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect Clash metrics")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"tag":       tag,
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("Clash metrics collection completed successfully")
+
+	h.serveCollected(w, r, clashMetrics, "clash", tag, timedOut)
+}
+
+// HandleBattleMetricsMetrics handles /metrics/battlemetrics
+func (h *Handlers) HandleBattleMetricsMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"ip":     r.RemoteAddr,
+	}).Info("BattleMetrics metrics request received")
+
+	if h.options.BattleMetrics == nil {
+		http.Error(w, "BattleMetrics metrics are not configured - set BATTLEMETRICS_SERVERS", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.options.BattleMetrics.Collect(ctx)
+	})
+	if err != nil {
+		stale := h.serveFailure(w, r, "battlemetrics", "servers")
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect BattleMetrics metrics")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("BattleMetrics metrics collection completed successfully")
+
+	h.serveCollected(w, r, battleMetricsMetrics, "battlemetrics", "servers", timedOut)
+}
+
+// HandleRCONMetrics handles /metrics/rcon
+func (h *Handlers) HandleRCONMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"ip":     r.RemoteAddr,
+	}).Info("RCON metrics request received")
+
+	if h.options.RCON == nil {
+		http.Error(w, "RCON metrics are not configured - set RCON_SERVERS", http.StatusNotFound)
+		return
+	}
+
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		return h.options.RCON.Collect(ctx)
+	})
+	if err != nil {
+		stale := h.serveFailure(w, r, "rcon", "servers")
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect RCON metrics")
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("RCON metrics collection completed successfully")
+
+	h.serveCollected(w, r, rconMetrics, "rcon", "servers", timedOut)
+}
+
+// HandleDiscordMetrics handles /metrics/discord. While the bot is disconnected from the gateway
+// the presences are unknown, so the scrape is answered as a failed collection.
+func (h *Handlers) HandleDiscordMetrics(w http.ResponseWriter, r *http.Request) {
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"ip":     r.RemoteAddr,
+	}).Info("Discord metrics request received")
+
+	if h.options.Discord == nil {
+		http.Error(w, "Discord metrics are not configured - set DISCORD_BOT_TOKEN", http.StatusNotFound)
+		return
+	}
+
+	if !h.options.Discord.Connected() {
+		stale := h.serveFailure(w, r, "discord", "presence")
+		logger.FromContext(r.Context()).WithField("stale", stale).Warn("Discord gateway disconnected, presences unknown")
+		return
+	}
+	h.serveCollected(w, r, discordMetrics, "discord", "presence", false)
 }
 
 // HandleOSRSWorldMetrics handles /metrics/osrs/worlds
 func (h *Handlers) HandleOSRSWorldMetrics(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
 		"path":   r.URL.Path,
 		"method": r.Method,
 		"ip":     r.RemoteAddr,
 	}).Info("OSRS world metrics request received")
 
 	// Collect world metrics
-	logger.Log.Info("Collecting OSRS world data")
-	err := h.osrsCollector.CollectWorldData()
+	logger.FromContext(r.Context()).Info("Collecting OSRS world data")
+	timedOut, err := h.collectWithTimeout(r, h.osrsCollector.CollectWorldData)
 	if err != nil {
-		logger.Log.WithFields(logrus.Fields{
+		stale := h.serveFailure(w, r, "osrs_worlds", "worlds")
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
 			"error":    err.Error(),
 			"duration": time.Since(start),
+			"stale":    stale,
 		}).Error("Failed to collect OSRS world data")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	logger.Log.WithFields(logrus.Fields{
-		"duration": time.Since(start),
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
 	}).Info("OSRS world metrics collection completed successfully")
 
 	// Serve Prometheus metrics (OSRS only)
-	OSRSHandler().ServeHTTP(w, r)
+	h.serveCollected(w, r, osrsMetrics, "osrs_worlds", "worlds", timedOut)
 }
 
 // HandleOSRSMetrics handles /metrics/osrs/{mode}/{playerid}
@@ -139,7 +657,7 @@ func (h *Handlers) HandleOSRSMetrics(w http.ResponseWriter, r *http.Request) {
 	mode := chi.URLParam(r, "mode")
 	playerid := chi.URLParam(r, "playerid")
 
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
 		"path":     r.URL.Path,
 		"method":   r.Method,
 		"mode":     mode,
@@ -147,25 +665,34 @@ func (h *Handlers) HandleOSRSMetrics(w http.ResponseWriter, r *http.Request) {
 		"ip":       r.RemoteAddr,
 	}).Info("OSRS metrics request received")
 
-	switch mode {
-	case "all":
+	var timedOut bool
+	switch {
+	case mode == "all":
 		// Collect player stats for all supported modes
 		if playerid == "" {
-			logger.Log.WithField("mode", mode).Error("OSRS metrics request missing playerid parameter")
+			logger.FromContext(r.Context()).WithField("mode", mode).Error("OSRS metrics request missing playerid parameter")
 			http.Error(w, "playerid is required for all mode", http.StatusBadRequest)
 			return
 		}
 
-		logger.Log.WithFields(logrus.Fields{
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
 			"playerid": playerid,
 			"mode":     mode,
 		}).Info("Collecting OSRS player metrics for all modes")
 
-		errors := h.osrsCollector.CollectAllModes(playerid)
+		var errors map[string]error
+		timedOut, _ = h.collectWithTimeout(r, func(ctx context.Context) error {
+			errors = h.osrsCollector.CollectAllModes(ctx, playerid)
+			return nil
+		})
+		if timedOut {
+			// The collection still owns errors, so report nothing about it
+			errors = nil
+		}
 
 		// Log any errors but don't fail the request - we want to return partial results
 		if len(errors) > 0 {
-			logger.Log.WithFields(logrus.Fields{
+			logger.FromContext(r.Context()).WithFields(logrus.Fields{
 				"playerid":     playerid,
 				"errors_count": len(errors),
 				"errors":       errors,
@@ -173,73 +700,59 @@ func (h *Handlers) HandleOSRSMetrics(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Even if some modes failed, we still serve metrics for the modes that succeeded
-		logger.Log.WithFields(logrus.Fields{
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
 			"playerid": playerid,
 			"mode":     mode,
-			"duration": time.Since(start),
-			"errors":   len(errors),
+			"duration":  time.Since(start),
+			"errors":    len(errors),
+			"timed_out": timedOut,
 		}).Info("OSRS player metrics collection for all modes completed")
 
-	case "vanilla", "gridmaster", "deadman", "seasonal":
-		// Collect player stats for vanilla or gridmaster mode
+	case isSupportedMode(h.osrsCollector.Modes(), mode):
+		// Collect player stats for a single mode
 		if playerid == "" {
-			logger.Log.WithField("mode", mode).Error("OSRS metrics request missing playerid parameter")
+			logger.FromContext(r.Context()).WithField("mode", mode).Error("OSRS metrics request missing playerid parameter")
 			http.Error(w, fmt.Sprintf("playerid is required for %s mode", mode), http.StatusBadRequest)
 			return
 		}
 
-		logger.Log.WithFields(logrus.Fields{
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
 			"playerid": playerid,
 			"mode":     mode,
 		}).Info("Collecting OSRS player metrics")
-		err := h.osrsCollector.CollectPlayerStats(playerid, mode)
+		var err error
+		timedOut, err = h.collectWithTimeout(r, func(ctx context.Context) error {
+			return h.osrsCollector.CollectPlayerStats(ctx, playerid, mode)
+		})
+		if errors.Is(err, osrs.ErrPlayerNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		if err != nil {
-			logger.Log.WithFields(logrus.Fields{
+			stale := h.serveFailure(w, r, "osrs", mode+"/"+playerid)
+			logger.FromContext(r.Context()).WithFields(logrus.Fields{
 				"playerid": playerid,
 				"mode":     mode,
 				"error":    err.Error(),
 				"duration": time.Since(start),
+				"stale":    stale,
 			}).Error("Failed to collect OSRS player metrics")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		logger.Log.WithFields(logrus.Fields{
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
 			"playerid": playerid,
-			"mode":     mode,
-			"duration": time.Since(start),
+			"mode":      mode,
+			"duration":  time.Since(start),
+			"timed_out": timedOut,
 		}).Info("OSRS player metrics collection completed successfully")
 
 	default:
-		logger.Log.WithField("mode", mode).Error("Unknown OSRS mode")
-		http.Error(w, "Unknown mode. Supported modes: 'vanilla', 'gridmaster', 'deadman', 'seasonal', 'all' (use /metrics/osrs/worlds for world data)", http.StatusBadRequest)
+		logger.FromContext(r.Context()).WithField("mode", mode).Error("Unknown OSRS mode")
+		http.Error(w, fmt.Sprintf("Unknown mode. Supported modes: %s, 'all' (use /metrics/osrs/worlds for world data)", quotedModes(h.osrsCollector.Modes())), http.StatusBadRequest)
 		return
 	}
 
 	// Serve Prometheus metrics (OSRS only)
-	OSRSHandler().ServeHTTP(w, r)
-}
-
-// HandleRoot serves a simple front page
-func (h *Handlers) HandleRoot(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html")
-	w.Write([]byte(`<html>
-<head><title>Game Stats Exporter</title></head>
-<body>
-	<h1>Game Stats Exporter</h1>
-	<p>Prometheus metrics exporter for Steam and OSRS stats</p>
-	<h2>Endpoints:</h2>
-	<ul>
-		<li><a href="/metrics">/metrics</a> - System metrics only (Go runtime, process, etc.)</li>
-		<li><a href="/metrics/steam/{steam_id}">/metrics/steam/{steam_id}</a> - Steam player metrics (filtered, Steam only)</li>
-		<li><a href="/metrics/osrs/vanilla/{playerid}">/metrics/osrs/vanilla/{playerid}</a> - OSRS vanilla player metrics (filtered, OSRS only)</li>
-		<li><a href="/metrics/osrs/gridmaster/{playerid}">/metrics/osrs/gridmaster/{playerid}</a> - OSRS gridmaster (tournament) player metrics (filtered, OSRS only)</li>
-		<li><a href="/metrics/osrs/deadman/{playerid}">/metrics/osrs/deadman/{playerid}</a> - OSRS deadman mode player metrics (filtered, OSRS only)</li>
-		<li><a href="/metrics/osrs/seasonal/{playerid}">/metrics/osrs/seasonal/{playerid}</a> - OSRS seasonal/leagues player metrics (filtered, OSRS only)</li>
-		<li><a href="/metrics/osrs/all/{playerid}">/metrics/osrs/all/{playerid}</a> - OSRS player metrics for all modes (filtered, OSRS only)</li>
-		<li><a href="/metrics/osrs/worlds">/metrics/osrs/worlds</a> - OSRS world metrics (filtered, OSRS only)</li>
-	</ul>
-</body>
-</html>`))
+	h.serveCollected(w, r, osrsMetrics, "osrs", mode+"/"+playerid, timedOut)
 }
-
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/history"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultHistoryDays = 7
+	maxHistoryDays     = 3650
+)
+
+// historyResponse is the body of the /history endpoints. Points are the recorded values in
+// the window, preceded by the last value before it; gained is the change over the window.
+type historyResponse struct {
+	Days   int             `json:"days"`
+	Gained int64           `json:"gained"`
+	Points []history.Point `json:"points"`
+}
+
+type osrsHistoryResponse struct {
+	RSN   string `json:"rsn"`
+	Mode  string `json:"mode"`
+	Skill string `json:"skill"`
+	historyResponse
+}
+
+type steamHistoryResponse struct {
+	SteamID string `json:"steam_id"`
+	AppID   uint64 `json:"appid,omitempty"` // Omitted for total playtime across games
+	historyResponse
+}
+
+// steamTimelineResponse is the body of the achievement timeline endpoint
+type steamTimelineResponse struct {
+	SteamID string           `json:"steam_id"`
+	AppID   uint64           `json:"appid,omitempty"` // Omitted for every game
+	Unlocks []history.Unlock `json:"unlocks"`
+}
+
+// HandleOSRSHistoryAPI handles /api/v1/osrs/{rsn}/history?skill=Slayer&days=30&mode=vanilla
+func (h *Handlers) HandleOSRSHistoryAPI(w http.ResponseWriter, r *http.Request) {
+	rsn := chi.URLParam(r, "rsn")
+	skill := queryOrDefault(r, "skill", "Overall")
+	mode := queryOrDefault(r, "mode", "vanilla")
+
+	if h.options.History == nil {
+		writeJSONError(w, http.StatusNotFound, "history is not enabled (set HISTORY_DRIVER)")
+		return
+	}
+	days, ok := historyDays(w, r)
+	if !ok {
+		return
+	}
+
+	points, err := h.options.History.SkillXP(r.Context(), rsn, mode, skill, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"rsn":   rsn,
+			"skill": skill,
+			"error": err.Error(),
+		}).Error("Failed to query XP history")
+		writeJSONError(w, http.StatusInternalServerError, "failed to query history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, osrsHistoryResponse{
+		RSN:             rsn,
+		Mode:            mode,
+		Skill:           skill,
+		historyResponse: newHistoryResponse(days, points),
+	})
+}
+
+// HandleSteamHistoryAPI handles /api/v1/steam/{steam_id}/history?app_id=570&days=30
+func (h *Handlers) HandleSteamHistoryAPI(w http.ResponseWriter, r *http.Request) {
+	steamId := chi.URLParam(r, "steam_id")
+
+	if h.options.History == nil {
+		writeJSONError(w, http.StatusNotFound, "history is not enabled (set HISTORY_DRIVER)")
+		return
+	}
+	days, ok := historyDays(w, r)
+	if !ok {
+		return
+	}
+	appId, ok := historyAppID(w, r)
+	if !ok {
+		return
+	}
+
+	points, err := h.options.History.Playtime(r.Context(), steamId, appId, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"app_id":   appId,
+			"error":    err.Error(),
+		}).Error("Failed to query playtime history")
+		writeJSONError(w, http.StatusInternalServerError, "failed to query history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, steamHistoryResponse{
+		SteamID:         steamId,
+		AppID:           appId,
+		historyResponse: newHistoryResponse(days, points),
+	})
+}
+
+// HandleSteamAchievementTimelineAPI handles /api/v1/steam/{steam_id}/achievements/timeline?app_id=570
+func (h *Handlers) HandleSteamAchievementTimelineAPI(w http.ResponseWriter, r *http.Request) {
+	steamId := chi.URLParam(r, "steam_id")
+
+	if h.options.History == nil {
+		writeJSONError(w, http.StatusNotFound, "history is not enabled (set HISTORY_DRIVER)")
+		return
+	}
+	appId, ok := historyAppID(w, r)
+	if !ok {
+		return
+	}
+
+	unlocks, err := h.options.History.Unlocks(r.Context(), steamId, appId)
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"app_id":   appId,
+			"error":    err.Error(),
+		}).Error("Failed to query achievement unlocks")
+		writeJSONError(w, http.StatusInternalServerError, "failed to query history")
+		return
+	}
+	if unlocks == nil {
+		unlocks = []history.Unlock{}
+	}
+
+	writeJSON(w, http.StatusOK, steamTimelineResponse{
+		SteamID: steamId,
+		AppID:   appId,
+		Unlocks: unlocks,
+	})
+}
+
+func newHistoryResponse(days int, points []history.Point) historyResponse {
+	response := historyResponse{
+		Days:   days,
+		Points: points,
+	}
+	if points == nil {
+		response.Points = []history.Point{}
+	}
+	if len(points) > 1 {
+		response.Gained = points[len(points)-1].Value - points[0].Value
+	}
+	return response
+}
+
+// historyDays parses the days query parameter, writing a 400 if it's invalid
+func historyDays(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("days")
+	if value == "" {
+		return defaultHistoryDays, true
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 || days > maxHistoryDays {
+		writeJSONError(w, http.StatusBadRequest, "days must be a number between 1 and 3650")
+		return 0, false
+	}
+	return days, true
+}
+
+// historyAppID parses the optional app_id query parameter (0 when omitted), writing a 400 if
+// it's invalid
+func historyAppID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	value := r.URL.Query().Get("app_id")
+	if value == "" {
+		return 0, true
+	}
+	appId, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "app_id must be a number")
+		return 0, false
+	}
+	return appId, true
+}
+
+func queryOrDefault(r *http.Request, name string, defaultValue string) string {
+	if value := r.URL.Query().Get(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// Leaderboard metrics, selected with ?metric=
+const (
+	leaderboardOSRSXP        = "osrs_xp"
+	leaderboardOSRSLevel     = "osrs_level"
+	leaderboardSteamPlaytime = "steam_playtime"
+)
+
+// leaderboardMode is the OSRS mode tracked players are ranked in, matching background polling
+const leaderboardMode = "vanilla"
+
+// leaderboardEntry is one ranked player; tied players share a position
+type leaderboardEntry struct {
+	Position int    `json:"position"`
+	Player   string `json:"player"`
+	Value    int64  `json:"value"`
+}
+
+// leaderboardResponse is the body of /api/v1/leaderboard. Unavailable lists tracked players
+// whose data couldn't be read, unranked ones without hiscores in the skill.
+type leaderboardResponse struct {
+	Metric      string             `json:"metric"`
+	Skill       string             `json:"skill,omitempty"`
+	AppID       uint64             `json:"appid,omitempty"` // Omitted for total playtime across games
+	Entries     []leaderboardEntry `json:"entries"`
+	Unranked    []string           `json:"unranked,omitempty"`
+	Unavailable []string           `json:"unavailable,omitempty"`
+}
+
+// HandleLeaderboardAPI handles /api/v1/leaderboard?metric=osrs_xp&skill=Slayer, ranking the
+// polled players (POLL_* and the config file) from their cached data
+func (h *Handlers) HandleLeaderboardAPI(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	metric := queryOrDefault(r, "metric", leaderboardOSRSXP)
+
+	if h.options.Targets == nil {
+		writeJSONError(w, http.StatusNotFound, "the leaderboard is not enabled")
+		return
+	}
+
+	var response leaderboardResponse
+	switch metric {
+	case leaderboardOSRSXP, leaderboardOSRSLevel:
+		skill, ok := osrsSkill(queryOrDefault(r, "skill", "Overall"))
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "unknown skill")
+			return
+		}
+		values, unranked, unavailable := h.osrsSkillValues(r.Context(), skill, metric == leaderboardOSRSLevel)
+		response = leaderboardResponse{Skill: skill, Entries: rankLeaderboard(values), Unranked: unranked, Unavailable: unavailable}
+
+	case leaderboardSteamPlaytime:
+		if h.steamCollector == nil {
+			writeJSONError(w, http.StatusNotFound, "Steam collector not initialized - STEAM_KEY environment variable is required")
+			return
+		}
+		var appId uint64
+		if value := r.URL.Query().Get("app_id"); value != "" {
+			var err error
+			if appId, err = strconv.ParseUint(value, 10, 64); err != nil {
+				writeJSONError(w, http.StatusBadRequest, "app_id must be numeric")
+				return
+			}
+		}
+		values, unavailable := h.steamPlaytimeValues(r.Context(), appId)
+		response = leaderboardResponse{AppID: appId, Entries: rankLeaderboard(values), Unavailable: unavailable}
+
+	default:
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("unknown metric. Supported metrics: '%s', '%s', '%s'",
+			leaderboardOSRSXP, leaderboardOSRSLevel, leaderboardSteamPlaytime))
+		return
+	}
+	response.Metric = metric
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"metric":      metric,
+		"entries":     len(response.Entries),
+		"unavailable": len(response.Unavailable),
+		"duration":    time.Since(start),
+	}).Info("Served leaderboard")
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleOSRSLeaderboardMetrics handles /metrics/osrs/leaderboard: every polled player's
+// position by XP in each skill (or only ?skill=)
+func (h *Handlers) HandleOSRSLeaderboardMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"ip":     r.RemoteAddr,
+	}).Info("OSRS leaderboard metrics request received")
+
+	if h.options.Targets == nil {
+		http.Error(w, "The leaderboard is not enabled", http.StatusNotFound)
+		return
+	}
+	skills := osrs.Skills()
+	if value := r.URL.Query().Get("skill"); value != "" {
+		skill, ok := osrsSkill(value)
+		if !ok {
+			http.Error(w, "unknown skill", http.StatusBadRequest)
+			return
+		}
+		skills = []string{skill}
+	}
+
+	// The positions are computed per request, so they live in a throwaway registry rather
+	// than leaking into the player endpoints
+	registry := prometheus.NewRegistry()
+	positionGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "leaderboard",
+		Name:      "position",
+		Help:      "Position of a polled player among the polled players by XP in a skill (1 is highest)",
+	}, []string{"skill", "player"})
+	registry.MustRegister(positionGauge)
+
+	var failed bool
+	timedOut, _ := h.collectWithTimeout(r, func(ctx context.Context) error {
+		stats, unavailable := h.osrsTrackedStats(ctx)
+		failed = len(stats) == 0 && len(unavailable) > 0
+		for _, skill := range skills {
+			values := make(map[string]int64)
+			for player, skillValues := range stats {
+				if xp, ok := skillValues[skill]; ok {
+					values[player] = xp
+				}
+			}
+			for _, entry := range rankLeaderboard(values) {
+				positionGauge.WithLabelValues(skill, entry.Player).Set(float64(entry.Position))
+			}
+		}
+		return nil
+	})
+	if !timedOut && failed {
+		h.serveFailure(w, r, "osrs_leaderboard", "leaderboard")
+		logger.FromContext(r.Context()).WithField("duration", time.Since(start)).Error("Failed to read any polled player for the OSRS leaderboard")
+		return
+	}
+	if timedOut {
+		// The collection still owns the gauge
+		registry = prometheus.NewRegistry()
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("OSRS leaderboard metrics completed successfully")
+
+	h.serveCollected(w, r, registry, "osrs_leaderboard", "leaderboard", timedOut)
+}
+
+// osrsSkillValues returns each polled player's XP (or level) in a skill
+func (h *Handlers) osrsSkillValues(ctx context.Context, skill string, level bool) (values map[string]int64, unranked []string, unavailable []string) {
+	values = make(map[string]int64)
+	for _, rsn := range h.options.Targets.Targets("osrs") {
+		skills, _, err := h.osrsCollector.PlayerStats(ctx, rsn, leaderboardMode)
+		if err != nil {
+			logLeaderboardFailure(ctx, "rsn", rsn, err)
+			unavailable = append(unavailable, rsn)
+			continue
+		}
+		ranked := false
+		for _, info := range skills {
+			if info.Name != skill {
+				continue
+			}
+			value := info.XP
+			if level {
+				value = info.Level
+			}
+			// Skills below the hiscores threshold are reported as -1
+			if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && parsed >= 0 {
+				values[h.osrsCollector.PlayerLabel(rsn)] = parsed
+				ranked = true
+			}
+		}
+		if !ranked {
+			unranked = append(unranked, rsn)
+		}
+	}
+	return values, unranked, unavailable
+}
+
+// osrsTrackedStats returns each polled player's XP per skill; unranked skills are left out
+func (h *Handlers) osrsTrackedStats(ctx context.Context) (stats map[string]map[string]int64, unavailable []string) {
+	stats = make(map[string]map[string]int64)
+	for _, rsn := range h.options.Targets.Targets("osrs") {
+		skills, _, err := h.osrsCollector.PlayerStats(ctx, rsn, leaderboardMode)
+		if err != nil {
+			logLeaderboardFailure(ctx, "rsn", rsn, err)
+			unavailable = append(unavailable, rsn)
+			continue
+		}
+		player := h.osrsCollector.PlayerLabel(rsn)
+		stats[player] = make(map[string]int64, len(skills))
+		for _, info := range skills {
+			if xp, err := strconv.ParseInt(info.XP, 10, 64); err == nil && xp >= 0 {
+				stats[player][info.Name] = xp
+			}
+		}
+	}
+	return stats, unavailable
+}
+
+// steamPlaytimeValues returns each polled user's playtime in minutes in a game, or across
+// games when appId is 0
+func (h *Handlers) steamPlaytimeValues(ctx context.Context, appId uint64) (values map[string]int64, unavailable []string) {
+	values = make(map[string]int64)
+	for _, steamId := range h.options.Targets.Targets("steam") {
+		ownedGames, err := h.steamCollector.OwnedGames(ctx, steamId)
+		if err != nil {
+			logLeaderboardFailure(ctx, "steam_id", steamId, err)
+			unavailable = append(unavailable, steamId)
+			continue
+		}
+		var total int64
+		owned := appId == 0
+		for _, game := range ownedGames.Games {
+			if appId == 0 || game.AppId == appId {
+				total += int64(game.PlaytimeForever)
+				owned = true
+			}
+		}
+		if owned {
+			values[steamId] = total
+		}
+	}
+	return values, unavailable
+}
+
+func logLeaderboardFailure(ctx context.Context, field string, target string, err error) {
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		field:   target,
+		"error": err.Error(),
+	}).Warn("Failed to read tracked player for the leaderboard, leaving them out")
+}
+
+// rankLeaderboard orders players by value, highest first. Tied players share a position and
+// the next one skips ahead (1, 2, 2, 4).
+func rankLeaderboard(values map[string]int64) []leaderboardEntry {
+	entries := make([]leaderboardEntry, 0, len(values))
+	for player, value := range values {
+		entries = append(entries, leaderboardEntry{Player: player, Value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].Player < entries[j].Player
+	})
+	for i := range entries {
+		if i > 0 && entries[i].Value == entries[i-1].Value {
+			entries[i].Position = entries[i-1].Position
+		} else {
+			entries[i].Position = i + 1
+		}
+	}
+	return entries
+}
+
+// osrsSkill returns the hiscores name of a skill, matched case-insensitively
+func osrsSkill(name string) (string, bool) {
+	for _, skill := range osrs.Skills() {
+		if strings.EqualFold(skill, name) {
+			return skill, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// saturatedRetryAfter is the Retry-After sent when all collection slots are busy
+const saturatedRetryAfter = 5 * time.Second
+
+var httpRequestsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "http",
+	Name:      "requests_rejected_total",
+	Help:      "Collection requests rejected by the rate limit or concurrency cap",
+}, []string{"reason"})
+
+func init() {
+	prometheus.MustRegister(httpRequestsRejected)
+}
+
+// LimitConfig bounds how much upstream work clients can trigger through on-demand collection
+type LimitConfig struct {
+	PerIPRate     float64 // Requests per second allowed per client IP, 0 disables the rate limit
+	PerIPBurst    int     // Requests a client IP may make in a burst
+	MaxConcurrent int     // Collections allowed to run at once, 0 means unlimited
+}
+
+// LimitCollections applies the per-IP rate limit (429) and the global concurrency cap (503)
+// to the endpoints it wraps. Both responses carry Retry-After so well-behaved clients back off.
+func LimitCollections(cfg LimitConfig) func(http.Handler) http.Handler {
+	var limiter *ipRateLimiter
+	if cfg.PerIPRate > 0 {
+		limiter = newIPRateLimiter(cfg.PerIPRate, cfg.PerIPBurst)
+	}
+	var slots chan struct{}
+	if cfg.MaxConcurrent > 0 {
+		slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+
+	return func(next http.Handler) http.Handler {
+		if limiter == nil && slots == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limiter != nil {
+				ip := clientIP(r)
+				if ok, wait := limiter.allow(ip, time.Now()); !ok {
+					reject(w, r, "rate_limited", http.StatusTooManyRequests, wait)
+					return
+				}
+			}
+
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				default:
+					reject(w, r, "saturated", http.StatusServiceUnavailable, saturatedRetryAfter)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// reject answers a limited request with a Retry-After header
+func reject(w http.ResponseWriter, r *http.Request, reason string, status int, retryAfter time.Duration) {
+	httpRequestsRejected.WithLabelValues(reason).Inc()
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":        r.URL.Path,
+		"ip":          r.RemoteAddr,
+		"reason":      reason,
+		"retry_after": seconds,
+	}).Warn("Rejected collection request")
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, http.StatusText(status), status)
+}
+
+// clientIP returns the host part of the request's remote address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ipRateLimiter is a token bucket per client IP
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newIPRateLimiter(rate float64, burst int) *ipRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &ipRateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the IP's bucket. When the bucket is empty it returns false
+// and how long until the next token is available.
+func (l *ipRateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+
+	b, exists := l.buckets[ip]
+	if !exists {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune drops buckets that have refilled completely, as they behave the same as a new one.
+// Runs at most once a minute so the map can't grow without bound.
+func (l *ipRateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for ip, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, ip)
+		}
+	}
+}
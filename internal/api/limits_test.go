@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// limited serves a request from remoteAddr through handler
+func limited(handler http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/metrics/steam/76561197960287930", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestLimitCollectionsRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := LimitCollections(LimitConfig{PerIPRate: 0.5, PerIPBurst: 2})(ok)
+
+	for i := 0; i < 2; i++ {
+		if got := limited(handler, "192.0.2.1:50000").Code; got != http.StatusOK {
+			t.Fatalf("request %d within the burst = %d, want 200", i+1, got)
+		}
+	}
+	// A new port is the same client
+	w := limited(handler, "192.0.2.1:50001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request beyond the burst = %d, want 429", w.Code)
+	}
+	// At half a request a second, the next token is two seconds away
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+
+	if got := limited(handler, "192.0.2.2:50000").Code; got != http.StatusOK {
+		t.Errorf("request from another IP = %d, want 200", got)
+	}
+}
+
+func TestIPRateLimiterRefill(t *testing.T) {
+	limiter := newIPRateLimiter(2, 1)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if ok, _ := limiter.allow("192.0.2.1", now); !ok {
+		t.Fatal("first request was limited")
+	}
+	ok, wait := limiter.allow("192.0.2.1", now.Add(100*time.Millisecond))
+	if ok || wait != 400*time.Millisecond {
+		t.Errorf("allow before the refill = %v, %v, want false, 400ms", ok, wait)
+	}
+	if ok, _ := limiter.allow("192.0.2.1", now.Add(600*time.Millisecond)); !ok {
+		t.Error("request after the refill was limited")
+	}
+
+	// Refilled buckets are pruned, and a pruned client starts with a full burst
+	if ok, _ := limiter.allow("192.0.2.2", now.Add(2*time.Minute)); !ok {
+		t.Error("request from another IP was limited")
+	}
+	if _, exists := limiter.buckets["192.0.2.1"]; exists {
+		t.Error("refilled bucket wasn't pruned")
+	}
+}
+
+func TestLimitCollectionsSaturated(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+	handler := LimitCollections(LimitConfig{MaxConcurrent: 1})(blocking)
+
+	done := make(chan int)
+	go func() {
+		done <- limited(handler, "192.0.2.1:50000").Code
+	}()
+	<-entered
+
+	w := limited(handler, "192.0.2.2:50000")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("request while saturated = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "5" {
+		t.Errorf("Retry-After = %q, want 5", got)
+	}
+
+	close(release)
+	if got := <-done; got != http.StatusOK {
+		t.Errorf("running request = %d, want 200", got)
+	}
+	// The slot is free again once the collection finishes
+	go func() { <-entered }()
+	if got := limited(handler, "192.0.2.2:50000").Code; got != http.StatusOK {
+		t.Errorf("request after the release = %d, want 200", got)
+	}
+}
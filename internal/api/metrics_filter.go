@@ -3,11 +3,30 @@ package api
 import (
 	"net/http"
 
+	"github.com/joshhsoj1902/game-stats-exporter/internal/relabel"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	dto "github.com/prometheus/client_model/go"
 )
 
+// The metrics served by each collection endpoint. Store prices (steam_app_*) and GE prices
+// (osrs_ge_*) aren't per user, so they have their own endpoints rather than showing up on every
+// user's.
+var (
+	steamUserMetrics     = NewExcludedPrefixGatherer(NewFilteredGatherer(prometheus.DefaultGatherer, "steam_"), []string{"steam_app_"})
+	steamPriceMetrics    = NewFilteredGatherer(prometheus.DefaultGatherer, "steam_app_")
+	osrsMetrics          = NewExcludedPrefixGatherer(NewFilteredGatherer(prometheus.DefaultGatherer, "osrs_"), []string{"osrs_ge_"})
+	osrsGEMetrics        = NewFilteredGatherer(prometheus.DefaultGatherer, "osrs_ge_")
+	familyMetrics        = NewFilteredGatherer(prometheus.DefaultGatherer, "family_")
+	epicMetrics          = NewFilteredGatherer(prometheus.DefaultGatherer, "epic_")
+	nintendoMetrics      = NewFilteredGatherer(prometheus.DefaultGatherer, "nintendo_")
+	bnetMetrics          = NewFilteredGatherer(prometheus.DefaultGatherer, "bnet_")
+	clashMetrics         = NewFilteredGatherer(prometheus.DefaultGatherer, "clash_")
+	discordMetrics       = NewFilteredGatherer(prometheus.DefaultGatherer, "discord_")
+	battleMetricsMetrics = NewFilteredGatherer(prometheus.DefaultGatherer, "battlemetrics_")
+	rconMetrics          = NewFilteredGatherer(prometheus.DefaultGatherer, "rcon_")
+)
+
 // FilteredGatherer wraps a gatherer to only return metrics matching a prefix
 type FilteredGatherer struct {
 	gatherer prometheus.Gatherer
@@ -79,22 +98,10 @@ func (eg *ExcludedPrefixGatherer) Gather() ([]*dto.MetricFamily, error) {
 	return filtered, nil
 }
 
-// SystemMetricsHandler returns a handler that only serves system metrics (excludes application metrics)
-func SystemMetricsHandler() http.Handler {
-	// Exclude steam_* and osrs_* metrics, keep only system metrics (go_*, promhttp_*, process_*, etc.)
-	excluded := NewExcludedPrefixGatherer(prometheus.DefaultGatherer, []string{"steam_", "osrs_"})
-	return promhttp.HandlerFor(excluded, promhttp.HandlerOpts{})
-}
-
-// SteamHandler returns a handler that only serves Steam metrics
-func SteamHandler() http.Handler {
-	filtered := NewFilteredGatherer(prometheus.DefaultGatherer, "steam_")
-	return promhttp.HandlerFor(filtered, promhttp.HandlerOpts{})
+// SystemMetricsHandler returns a handler that only serves system metrics (excludes application metrics),
+// in OpenMetrics when negotiated and openMetrics is set so exemplars are included
+func SystemMetricsHandler(rules relabel.Rules, openMetrics bool) http.Handler {
+	// Exclude steam_*, osrs_*, family_*, epic_*, nintendo_*, bnet_*, clash_*, battlemetrics_*, rcon_* and discord_* metrics, keep only system metrics (go_*, promhttp_*, process_*, etc.)
+	excluded := NewExcludedPrefixGatherer(prometheus.DefaultGatherer, []string{"steam_", "osrs_", "family_", "epic_", "nintendo_", "bnet_", "clash_", "battlemetrics_", "rcon_", "discord_"})
+	return promhttp.HandlerFor(relabel.Gatherer(excluded, rules), promhttp.HandlerOpts{EnableOpenMetrics: openMetrics})
 }
-
-// OSRSHandler returns a handler that only serves OSRS metrics
-func OSRSHandler() http.Handler {
-	filtered := NewFilteredGatherer(prometheus.DefaultGatherer, "osrs_")
-	return promhttp.HandlerFor(filtered, promhttp.HandlerOpts{})
-}
-
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var (
+	httpRequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests handled, by route pattern and status code",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern (includes on-demand collection time)",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method", "route"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestsCounter)
+	prometheus.MustRegister(httpRequestDuration)
+}
+
+// RequestMetrics logs every request and records http_requests_total and
+// http_request_duration_seconds, with the request's trace as exemplar when it's sampled.
+// Routes are labelled by their chi pattern (e.g. /metrics/steam/{steam_id}) so per-target
+// paths don't explode cardinality.
+func RequestMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		duration := time.Since(start)
+		status := ww.Status()
+		if status == 0 {
+			// Nothing written, net/http sends 200
+			status = http.StatusOK
+		}
+		route := routePattern(r)
+
+		httpRequestsCounter.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		tracing.Observe(r.Context(), httpRequestDuration.WithLabelValues(r.Method, route), duration.Seconds())
+
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"method":   r.Method,
+			"route":    route,
+			"path":     r.URL.Path,
+			"status":   status,
+			"bytes":    ww.BytesWritten(),
+			"duration": duration,
+			"ip":       r.RemoteAddr,
+		}).Info("HTTP request completed")
+	})
+}
+
+// RequestIDHeader carries the request ID in both directions; an incoming value is reused
+// so IDs from an upstream proxy can be followed through the exporter logs
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID assigns each request an ID, stores it in the request context (so collector
+// log entries for that scrape include it) and echoes it in the response headers
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// newRequestID returns a random 16 character hex ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Recoverer turns a panic in a handler (usually a collector tripping over an unexpected
+// API response) into a 500 with a logged stack trace instead of killing the process
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				// Deliberate abort of the response, let net/http handle it
+				panic(rec)
+			}
+
+			logger.FromContext(r.Context()).WithFields(logrus.Fields{
+				"method": r.Method,
+				"path":   r.URL.Path,
+				"panic":  rec,
+				"stack":  string(debug.Stack()),
+			}).Error("Recovered from panic while handling request")
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Tracing starts a server span for each request (continuing a trace propagated by the
+// caller), which parents the cache, upstream API and reporting spans of the collection
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.StartServer(r.Context(), r.Header, r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("request_id", logger.RequestID(r.Context())),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// The route is only known once chi has matched it
+		route := routePattern(r)
+		span.SetName(r.Method + " " + route)
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// CollectionOptions applies the per-scrape controls, so Prometheus jobs scraping the same
+// target can pick their own freshness and upstream cost:
+//   - ?fresh=true refetches cached data
+//   - ?cached_only=true serves cached data only and never calls upstream APIs
+//   - ?skip_achievements=true leaves out Steam achievements
+func CollectionOptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var flags [3]bool
+		for i, name := range []string{"fresh", "cached_only", "skip_achievements"} {
+			value := r.URL.Query().Get(name)
+			if value == "" {
+				continue
+			}
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("%s must be true or false", name), http.StatusBadRequest)
+				return
+			}
+			flags[i] = parsed
+		}
+		fresh, cachedOnly, skipAchievements := flags[0], flags[1], flags[2]
+		if fresh && cachedOnly {
+			http.Error(w, "fresh and cached_only can't be combined", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		switch {
+		case fresh:
+			ctx = cache.WithPolicy(ctx, cache.PolicyFresh)
+		case cachedOnly:
+			ctx = cache.WithPolicy(ctx, cache.PolicyCachedOnly)
+		}
+		if skipAchievements {
+			ctx = steam.WithoutAchievements(ctx)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// TrackDataAge records when the cached upstream data a collection reads was fetched, for
+// exporter_data_collected_timestamp_seconds
+func TrackDataAge(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, _ := cache.WithFreshness(r.Context())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// routePattern returns the matched chi route pattern, or a fixed label for unmatched paths
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
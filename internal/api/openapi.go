@@ -0,0 +1,486 @@
+package api
+
+import (
+	"net/http"
+)
+
+// apiVersion is the current route version. Routes are served under /v1 (metrics) and
+// /api/v1 (JSON); the unversioned metrics routes remain as aliases of v1.
+const apiVersion = "v1"
+
+// openAPIParam describes a path parameter, or an optional query parameter
+type openAPIParam struct {
+	name        string
+	description string
+	enum        []string
+	query       bool
+}
+
+// openAPIOperation describes one GET endpoint
+type openAPIOperation struct {
+	path        string
+	summary     string
+	tag         string
+	params      []openAPIParam
+	contentType string // text/plain for Prometheus endpoints, application/json for the JSON API
+	schema      string // Component schema of a JSON response, if any
+	limited     bool   // Subject to the collection rate limit and concurrency cap
+}
+
+// osrsModeParam has no enum: tournament modes can be added to the built-in ones by the config file
+var osrsModeParam = openAPIParam{
+	name:        "mode",
+	description: "Hiscores game mode: vanilla, ironman, hardcore_ironman, ultimate_ironman, gridmaster, deadman, seasonal or a configured tournament mode",
+}
+
+// collectionParams are accepted by every limited endpoint (see CollectionOptions)
+var collectionParams = []openAPIParam{
+	{name: "fresh", description: "Refetch cached data from upstream", enum: []string{"true", "false"}, query: true},
+	{name: "cached_only", description: "Serve cached data only, never calling upstream APIs", enum: []string{"true", "false"}, query: true},
+	{name: "skip_achievements", description: "Leave out Steam achievements", enum: []string{"true", "false"}, query: true},
+}
+
+// openAPIOperations lists every endpoint in the document
+var openAPIOperations = []openAPIOperation{
+	{
+		path:        "/metrics",
+		summary:     "System metrics (Go runtime, process, HTTP, polling and push)",
+		tag:         "metrics",
+		contentType: "text/plain",
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/steam/{steam_id}",
+		summary:     "Collect and serve a Steam user's playtime and achievement metrics",
+		tag:         "metrics",
+		params:      []openAPIParam{{name: "steam_id", description: "64-bit Steam ID"}},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/steam/prices",
+		summary:     "Collect and serve the store price and discount of the tracked apps (STEAM_PRICE_APP_IDS)",
+		tag:         "metrics",
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/family/{family}",
+		summary:     "Collect and serve the combined Steam playtime and OSRS XP of a family's accounts (CONFIG_FILE families)",
+		tag:         "metrics",
+		params:      []openAPIParam{{name: "family", description: "Family name from the config file"}},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/epic/{account_id}",
+		summary:     "Collect and serve an Epic Games account's playtime metrics (EPIC_ACCOUNTS)",
+		tag:         "metrics",
+		params:      []openAPIParam{{name: "account_id", description: "Epic account ID"}},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/nintendo",
+		summary:     "Collect and serve today's play on the Nintendo Switch consoles of a Parental Controls account (NINTENDO_SESSION_TOKEN)",
+		tag:         "metrics",
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:    "/" + apiVersion + "/metrics/bnet/{game}/{profile}",
+		summary: "Collect and serve a Battle.net profile's stats (BNET_CLIENT_ID and BNET_CLIENT_SECRET)",
+		tag:     "metrics",
+		params: []openAPIParam{
+			{name: "game", description: "d3 (Diablo III) or sc2 (StarCraft II)"},
+			{name: "profile", description: "BattleTag as Name-1234 (d3), or region-realm-profile ID as in the profile's URL (sc2)"},
+		},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/clash/{tag}",
+		summary:     "Collect and serve a player tag's Clash of Clans and Clash Royale stats (CLASH_OF_CLANS_TOKEN, CLASH_ROYALE_TOKEN)",
+		tag:         "metrics",
+		params:      []openAPIParam{{name: "tag", description: "Player tag, with or without the #"}},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/battlemetrics",
+		summary:     "Collect and serve the players, rank and uptime of the game servers in BATTLEMETRICS_SERVERS",
+		tag:         "metrics",
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/rcon",
+		summary:     "Collect and serve the players online and tick performance of the dedicated servers in RCON_SERVERS",
+		tag:         "metrics",
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/discord",
+		summary:     "Serve the games the CONFIG_FILE users with a discord_id are playing, from their Discord presence (DISCORD_BOT_TOKEN)",
+		tag:         "metrics",
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/user/{name}",
+		summary:     "Collect and serve a person's Steam and OSRS (vanilla) metrics with a user label (CONFIG_FILE users)",
+		tag:         "metrics",
+		params:      []openAPIParam{{name: "name", description: "User name from the config file"}},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/osrs/worlds",
+		summary:     "Collect and serve OSRS world player counts",
+		tag:         "metrics",
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/osrs/ge",
+		summary:     "Collect and serve the Grand Exchange prices and flip margins of the watched items (OSRS_GE_ITEMS)",
+		tag:         "metrics",
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/" + apiVersion + "/metrics/osrs/leaderboard",
+		summary:     "Serve each polled OSRS player's position among the polled players by XP in each skill",
+		tag:         "metrics",
+		params:      []openAPIParam{{name: "skill", description: "Only rank this skill", query: true}},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:    "/" + apiVersion + "/metrics/osrs/{mode}/{playerid}",
+		summary: "Collect and serve an OSRS player's hiscores metrics",
+		tag:     "metrics",
+		params: []openAPIParam{
+			{
+				name:        "mode",
+				description: osrsModeParam.description + ", or all to collect every mode",
+			},
+			{name: "playerid", description: "RuneScape name"},
+		},
+		contentType: "text/plain",
+		limited:     true,
+	},
+	{
+		path:        "/api/" + apiVersion + "/steam/{steam_id}",
+		summary:     "A Steam user's library and cached achievements",
+		tag:         "json",
+		params:      []openAPIParam{{name: "steam_id", description: "64-bit Steam ID"}},
+		contentType: "application/json",
+		schema:      "SteamProfile",
+		limited:     true,
+	},
+	{
+		path:        "/api/" + apiVersion + "/osrs/worlds",
+		summary:     "The OSRS world list",
+		tag:         "json",
+		contentType: "application/json",
+		schema:      "OSRSWorlds",
+		limited:     true,
+	},
+	{
+		path:    "/api/" + apiVersion + "/osrs/items/search",
+		summary: "Grand Exchange items whose name contains a query, with their IDs",
+		tag:     "json",
+		params: []openAPIParam{
+			{name: "q", description: "Part of the item name, ignoring case", query: true},
+			{name: "limit", description: "Maximum number of items (default 20)", query: true},
+		},
+		contentType: "application/json",
+		schema:      "OSRSItems",
+		limited:     true,
+	},
+	{
+		path:        "/api/" + apiVersion + "/osrs/{mode}/{rsn}",
+		summary:     "An OSRS player's hiscores",
+		tag:         "json",
+		params:      []openAPIParam{osrsModeParam, {name: "rsn", description: "RuneScape name"}},
+		contentType: "application/json",
+		schema:      "OSRSPlayer",
+		limited:     true,
+	},
+	{
+		path:    "/api/" + apiVersion + "/leaderboard",
+		summary: "The polled players ranked from their cached data",
+		tag:     "json",
+		params: []openAPIParam{
+			{name: "metric", description: "What to rank by (default osrs_xp)", enum: []string{"osrs_xp", "osrs_level", "steam_playtime"}, query: true},
+			{name: "skill", description: "OSRS skill to rank (default Overall)", query: true},
+			{name: "app_id", description: "Game to rank steam_playtime in; total playtime across games when omitted", query: true},
+		},
+		contentType: "application/json",
+		schema:      "Leaderboard",
+		limited:     true,
+	},
+	{
+		path:    "/api/" + apiVersion + "/steam/{steam_id}/history",
+		summary: "Recorded playtime of a polled Steam user",
+		tag:     "history",
+		params: []openAPIParam{
+			{name: "steam_id", description: "64-bit Steam ID"},
+			{name: "app_id", description: "Game to return; total playtime across games when omitted", query: true},
+			{name: "days", description: "Window in days (default 7)", query: true},
+		},
+		contentType: "application/json",
+		schema:      "History",
+		limited:     true,
+	},
+	{
+		path:    "/api/" + apiVersion + "/steam/{steam_id}/achievements/timeline",
+		summary: "Recorded achievement unlocks of a polled Steam user, oldest first",
+		tag:     "history",
+		params: []openAPIParam{
+			{name: "steam_id", description: "64-bit Steam ID"},
+			{name: "app_id", description: "Game to return; every game when omitted", query: true},
+		},
+		contentType: "application/json",
+		schema:      "AchievementTimeline",
+		limited:     true,
+	},
+	{
+		path:    "/api/" + apiVersion + "/osrs/{rsn}/history",
+		summary: "Recorded XP of a polled OSRS player",
+		tag:     "history",
+		params: []openAPIParam{
+			{name: "rsn", description: "RuneScape name"},
+			{name: "skill", description: "Skill to return (default Overall)", query: true},
+			{name: "mode", description: "Hiscores game mode (default vanilla)", query: true},
+			{name: "days", description: "Window in days (default 7)", query: true},
+		},
+		contentType: "application/json",
+		schema:      "History",
+		limited:     true,
+	},
+}
+
+// openAPISchemas are the JSON response bodies, matching the types served by rest.go
+var openAPISchemas = map[string]interface{}{
+	"Error": object(map[string]interface{}{
+		"error": str(),
+	}),
+	"SteamProfile": object(map[string]interface{}{
+		"steam_id":   str(),
+		"username":   str(),
+		"game_count": integer(),
+		"games": array(object(map[string]interface{}{
+			"appid":            integer(),
+			"name":             str(),
+			"playtime_forever": withDescription(integer(), "Minutes played"),
+			"achievements": withDescription(array(object(map[string]interface{}{
+				"name":     str(),
+				"achieved": integer(),
+			})), "Present once the game's achievements have been collected"),
+		})),
+	}),
+	"OSRSPlayer": object(map[string]interface{}{
+		"rsn":  str(),
+		"mode": str(),
+		"skills": array(object(map[string]interface{}{
+			"rank":   str(),
+			"level":  str(),
+			"xp":     str(),
+			"name":   str(),
+			"player": str(),
+		})),
+		"minigames": array(object(map[string]interface{}{
+			"rank":   str(),
+			"score":  str(),
+			"name":   str(),
+			"player": str(),
+		})),
+	}),
+	"History": object(map[string]interface{}{
+		"days":   integer(),
+		"gained": withDescription(integer(), "Change over the window (XP, or playtime in minutes)"),
+		"points": withDescription(array(object(map[string]interface{}{
+			"time":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"value": integer(),
+		})), "Values recorded in the window, preceded by the last value before it"),
+	}),
+	"AchievementTimeline": object(map[string]interface{}{
+		"steam_id": str(),
+		"appid":    withDescription(integer(), "Omitted for every game"),
+		"unlocks": array(object(map[string]interface{}{
+			"time":        map[string]interface{}{"type": "string", "format": "date-time"},
+			"appid":       integer(),
+			"game_name":   str(),
+			"achievement": withDescription(str(), "Achievement API name"),
+		})),
+	}),
+	"Leaderboard": object(map[string]interface{}{
+		"metric": str(),
+		"skill":  str(),
+		"appid":  integer(),
+		"entries": array(object(map[string]interface{}{
+			"position": withDescription(integer(), "Tied players share a position"),
+			"player":   withDescription(str(), "RuneScape name or Steam ID"),
+			"value":    withDescription(integer(), "XP, level, or playtime in minutes"),
+		})),
+		"unranked":    withDescription(array(str()), "Polled players without hiscores in the skill"),
+		"unavailable": withDescription(array(str()), "Polled players whose data couldn't be read"),
+	}),
+	"OSRSItems": object(map[string]interface{}{
+		"items": array(object(map[string]interface{}{
+			"id":       integer(),
+			"name":     str(),
+			"examine":  str(),
+			"members":  boolean(),
+			"limit":    integer(),
+			"value":    integer(),
+			"highalch": integer(),
+			"lowalch":  integer(),
+		})),
+	}),
+	"OSRSWorlds": object(map[string]interface{}{
+		"worlds": array(object(map[string]interface{}{
+			"id":       integer(),
+			"types":    array(str()),
+			"address":  str(),
+			"activity": str(),
+			"location": str(),
+			"players":  integer(),
+		})),
+	}),
+}
+
+// openAPIDocument builds the OpenAPI 3 document describing the routes registered by NewRouter
+func openAPIDocument(auth AuthConfig) map[string]interface{} {
+	paths := make(map[string]interface{})
+	for _, op := range openAPIOperations {
+		paths[op.path] = map[string]interface{}{"get": op.document()}
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Game Stats Exporter",
+			"description": "Prometheus metrics and JSON data for Steam and Old School RuneScape. The unversioned /metrics/steam and /metrics/osrs routes are aliases of /" + apiVersion + ".",
+			"version":     apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": openAPISchemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"basicAuth":  map[string]interface{}{"type": "http", "scheme": "basic"},
+			},
+		},
+	}
+
+	if auth.Enabled() {
+		var security []interface{}
+		if auth.BearerToken != "" {
+			security = append(security, map[string]interface{}{"bearerAuth": []string{}})
+		}
+		if auth.Username != "" {
+			security = append(security, map[string]interface{}{"basicAuth": []string{}})
+		}
+		document["security"] = security
+	}
+
+	return document
+}
+
+func (op openAPIOperation) document() map[string]interface{} {
+	ops := op.params
+	if op.limited {
+		ops = append(append([]openAPIParam{}, op.params...), collectionParams...)
+	}
+	var params []interface{}
+	for _, p := range ops {
+		schema := str()
+		if len(p.enum) > 0 {
+			schema["enum"] = p.enum
+		}
+		in := "path"
+		if p.query {
+			in = "query"
+		}
+		params = append(params, map[string]interface{}{
+			"name":        p.name,
+			"in":          in,
+			"required":    !p.query,
+			"description": p.description,
+			"schema":      schema,
+		})
+	}
+
+	content := map[string]interface{}{"schema": str()}
+	if op.schema != "" {
+		content = map[string]interface{}{"schema": ref(op.schema)}
+	}
+	responses := map[string]interface{}{
+		"200": map[string]interface{}{
+			"description": "OK",
+			"content":     map[string]interface{}{op.contentType: content},
+		},
+		"401": map[string]interface{}{"description": "Authentication required"},
+	}
+	if op.limited {
+		responses["429"] = map[string]interface{}{"description": "Per-IP rate limit exceeded"}
+		responses["503"] = map[string]interface{}{"description": "Too many collections in flight"}
+	}
+	if op.schema != "" {
+		errorContent := map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": ref("Error")},
+		}
+		responses["400"] = map[string]interface{}{"description": "Invalid parameter", "content": errorContent}
+		responses["502"] = map[string]interface{}{"description": "Upstream API failed", "content": errorContent}
+	}
+
+	operation := map[string]interface{}{
+		"summary":   op.summary,
+		"tags":      []string{op.tag},
+		"responses": responses,
+	}
+	if len(params) > 0 {
+		operation["parameters"] = params
+	}
+	return operation
+}
+
+func object(properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func array(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func str() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func integer() map[string]interface{} {
+	return map[string]interface{}{"type": "integer"}
+}
+
+func boolean() map[string]interface{} {
+	return map[string]interface{}{"type": "boolean"}
+}
+
+func withDescription(schema map[string]interface{}, description string) map[string]interface{} {
+	schema["description"] = description
+	return schema
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// OpenAPIHandler serves the OpenAPI document at /api/openapi.json
+func OpenAPIHandler(auth AuthConfig) http.HandlerFunc {
+	document := openAPIDocument(auth)
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, document)
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/sirupsen/logrus"
+)
+
+// The /api/v1 endpoints serve the same data as the metrics endpoints as JSON, for consumers
+// (Discord bots, web frontends) that don't want to parse the Prometheus text format.
+// Data comes from the cache, so they only reach upstream APIs on a cache miss.
+
+// osrsPlayerResponse is the body of /api/v1/osrs/{mode}/{rsn}
+type osrsPlayerResponse struct {
+	RSN       string              `json:"rsn"`
+	Mode      string              `json:"mode"`
+	Skills    []osrs.SkillInfo    `json:"skills"`
+	Minigames []osrs.MinigameInfo `json:"minigames"`
+}
+
+// osrsWorldsResponse is the body of /api/v1/osrs/worlds
+type osrsWorldsResponse struct {
+	Worlds []osrs.World `json:"worlds"`
+}
+
+// osrsItemsResponse is the body of /api/v1/osrs/items/search
+type osrsItemsResponse struct {
+	Items []osrs.Item `json:"items"`
+}
+
+// defaultItemSearchLimit is the number of items an item search returns without ?limit=
+const defaultItemSearchLimit = 20
+
+// HandleSteamAPI handles /api/v1/steam/{steam_id}
+func (h *Handlers) HandleSteamAPI(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	steamId := chi.URLParam(r, "steam_id")
+
+	if h.steamCollector == nil {
+		logger.FromContext(r.Context()).Error("Steam collector not initialized - STEAM_KEY not set")
+		writeJSONError(w, http.StatusInternalServerError, "Steam collector not initialized - STEAM_KEY environment variable is required")
+		return
+	}
+
+	profile, err := h.steamCollector.Profile(r.Context(), steamId)
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+			"duration": time.Since(start),
+		}).Error("Failed to get Steam profile")
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"steam_id": steamId,
+		"games":    len(profile.Games),
+		"duration": time.Since(start),
+	}).Info("Served Steam profile")
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// HandleOSRSPlayerAPI handles /api/v1/osrs/{mode}/{rsn}
+func (h *Handlers) HandleOSRSPlayerAPI(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	mode := chi.URLParam(r, "mode")
+	rsn := chi.URLParam(r, "rsn")
+
+	if modes := h.osrsCollector.Modes(); !isSupportedMode(modes, mode) {
+		writeJSONError(w, http.StatusBadRequest, "Unknown mode. Supported modes: "+quotedModes(modes))
+		return
+	}
+
+	skills, minigames, err := h.osrsCollector.PlayerStats(r.Context(), rsn, mode)
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"rsn":      rsn,
+			"mode":     mode,
+			"error":    err.Error(),
+			"duration": time.Since(start),
+		}).Error("Failed to get OSRS player stats")
+		writeJSONError(w, osrsErrorStatus(err), err.Error())
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"rsn":      rsn,
+		"mode":     mode,
+		"duration": time.Since(start),
+	}).Info("Served OSRS player stats")
+
+	writeJSON(w, http.StatusOK, osrsPlayerResponse{
+		RSN:       rsn,
+		Mode:      mode,
+		Skills:    skills,
+		Minigames: minigames,
+	})
+}
+
+// HandleOSRSWorldsAPI handles /api/v1/osrs/worlds
+func (h *Handlers) HandleOSRSWorldsAPI(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	worlds, err := h.osrsCollector.Worlds(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"duration": time.Since(start),
+		}).Error("Failed to get OSRS world data")
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"worlds_num": len(worlds),
+		"duration":   time.Since(start),
+	}).Info("Served OSRS world data")
+
+	writeJSON(w, http.StatusOK, osrsWorldsResponse{Worlds: worlds})
+}
+
+// HandleOSRSItemSearchAPI handles /api/v1/osrs/items/search?q=twisted&limit=20, for looking up
+// item IDs by name
+func (h *Handlers) HandleOSRSItemSearchAPI(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeJSONError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	limit := defaultItemSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	items, err := h.osrsCollector.SearchItems(r.Context(), query, limit)
+	if err != nil {
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"query":    query,
+			"error":    err.Error(),
+			"duration": time.Since(start),
+		}).Error("Failed to search OSRS items")
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"query":    query,
+		"items":    len(items),
+		"duration": time.Since(start),
+	}).Info("Served OSRS item search")
+
+	writeJSON(w, http.StatusOK, osrsItemsResponse{Items: items})
+}
+
+// osrsErrorStatus answers an unknown player with a 404 and hiscores that keep failing with a
+// 503, so clients know whether retrying can help
+func osrsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, osrs.ErrPlayerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, osrs.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func isSupportedMode(modes []string, mode string) bool {
+	for _, supported := range modes {
+		if mode == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// quotedModes lists modes for an error message, e.g. 'vanilla', 'gridmaster'
+func quotedModes(modes []string) string {
+	quoted := make([]string, len(modes))
+	for i, mode := range modes {
+		quoted[i] = "'" + mode + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// writeJSONError writes an {"error": message} response
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
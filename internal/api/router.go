@@ -2,26 +2,153 @@ package api
 
 import (
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
-func NewRouter(handlers *Handlers) *chi.Mux {
+// jsonCompressionLevel is the gzip level of JSON API responses; promhttp gzips the metrics
+// responses itself when the scraper accepts it
+const jsonCompressionLevel = 5
+
+// RouterConfig holds the protection applied to the HTTP routes
+type RouterConfig struct {
+	Auth   AuthConfig  // Credentials required on everything except the OpenAPI document
+	Limits LimitConfig // Rate limit and concurrency cap for on-demand collection endpoints
+}
+
+// NewRouter builds the HTTP routes
+func NewRouter(handlers *Handlers, admin *AdminHandlers, config RouterConfig) *chi.Mux {
 	r := chi.NewRouter()
 
-	r.Get("/", handlers.HandleRoot)
+	// RequestID runs first so every log entry (including the panic log) carries the ID,
+	// RequestMetrics inside Tracing so the request durations carry the trace as exemplar,
+	// and Recoverer inside both so recovered panics are recorded as 500s
+	r.Use(RequestID)
+	r.Use(Tracing)
+	r.Use(RequestMetrics)
+	r.Use(Recoverer)
+
+	r.With(middleware.Compress(jsonCompressionLevel, "application/json")).Get("/api/openapi.json", OpenAPIHandler(config.Auth))
+
+	// Metrics and admin endpoints expose per-player data, so they sit behind auth
+	r.Group(func(r chi.Router) {
+		r.Use(RequireAuth(config.Auth))
+
+		// Status page, listing every polled target
+		r.With(OperatorOnly).Get("/", newStatusPage(handlers, admin).HandleRoot)
+
+		// Generic metrics endpoint - serves all metrics (including Go runtime metrics)
+		r.With(OperatorOnly).Get("/metrics", handlers.HandleAllMetrics)
+
+		// Collection endpoints trigger upstream fetches, so they are rate limited
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RejectWhileDraining)
+			r.Use(LimitCollections(config.Limits))
+			r.Use(CollectionOptions)
+			r.Use(TrackDataAge)
+
+			// Versioned collection endpoints, with the unversioned routes kept as aliases of v1
+			// so existing scrape configs keep working
+			r.Route("/"+apiVersion, func(r chi.Router) {
+				metricsRoutes(r, handlers)
+			})
+			metricsRoutes(r, handlers)
+
+			// JSON API serving the parsed data (cache misses fetch upstream, so also limited)
+			r.Route("/api/"+apiVersion, func(r chi.Router) {
+				// A Steam library's achievements run to megabytes of JSON
+				r.Use(middleware.Compress(jsonCompressionLevel, "application/json"))
+
+				r.With(TenantTarget(tenantSteam, "steam_id")).Get("/steam/{steam_id}", handlers.HandleSteamAPI)
+				r.Get("/osrs/worlds", handlers.HandleOSRSWorldsAPI)
+				r.Get("/osrs/items/search", handlers.HandleOSRSItemSearchAPI)
+				r.With(TenantTarget(tenantOSRS, "rsn")).Get("/osrs/{mode}/{rsn}", handlers.HandleOSRSPlayerAPI)
+
+				// Polled players ranked from their cached data
+				r.With(OperatorOnly).Get("/leaderboard", handlers.HandleLeaderboardAPI)
+
+				// History from the history store (HISTORY_DRIVER)
+				r.With(TenantTarget(tenantSteam, "steam_id")).Get("/steam/{steam_id}/history", handlers.HandleSteamHistoryAPI)
+				r.With(TenantTarget(tenantSteam, "steam_id")).Get("/steam/{steam_id}/achievements/timeline", handlers.HandleSteamAchievementTimelineAPI)
+				r.With(TenantTarget(tenantOSRS, "rsn")).Get("/osrs/{rsn}/history", handlers.HandleOSRSHistoryAPI)
+			})
+
+			// Raw upstream responses of a target next to their parsed structs, fetched uncached
+			r.With(OperatorOnly).Get("/debug/raw/{collector}/{target}", handlers.HandleDebugRaw)
+		})
+
+		// Operator endpoints
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(OperatorOnly)
+
+			r.Post("/cache/flush", admin.HandleCacheFlush)
+			r.Get("/cache/stats", admin.HandleCacheStats)
+
+			r.Get("/polling", admin.HandlePollingStatus)
+			r.Post("/polling/pause", admin.HandlePollingPause)
+			r.Post("/polling/resume", admin.HandlePollingResume)
+
+			r.Get("/dashboards/{kind}", admin.HandleDashboard)
+
+			r.Get("/check", admin.HandleCheck)
+
+			r.Post("/reload", admin.HandleReload)
 
-	// Generic metrics endpoint - serves all metrics (including Go runtime metrics)
-	r.Get("/metrics", handlers.HandleAllMetrics)
+			r.Post("/ratelimit/reset", admin.HandleRateLimitReset)
+		})
+	})
+
+	return r
+}
+
+// metricsRoutes registers the per-target Prometheus endpoints. Tenants reach the endpoints of
+// their own Steam and OSRS targets and the shared ones (prices, worlds); the rest serve accounts
+// of the whole instance, so they're the operator's.
+func metricsRoutes(r chi.Router, handlers *Handlers) {
+	operator := r.With(OperatorOnly)
 
 	// Service-specific filtered endpoints
-	r.Get("/metrics/steam/{steam_id}", handlers.HandleSteamMetrics)
+	r.With(TenantTarget(tenantSteam, "steam_id")).Get("/metrics/steam/{steam_id}", handlers.HandleSteamMetrics)
+
+	// Store prices of the tracked apps (no steam_id needed)
+	r.Get("/metrics/steam/prices", handlers.HandleSteamPriceMetrics)
+
+	// Combined metrics of a family's accounts, configured in CONFIG_FILE
+	operator.Get("/metrics/family/{family}", handlers.HandleFamilyMetrics)
+
+	// Playtime of an Epic Games account, configured in EPIC_ACCOUNTS
+	operator.Get("/metrics/epic/{account_id}", handlers.HandleEpicMetrics)
+
+	// Today's Nintendo Switch play, from the Parental Controls account in NINTENDO_SESSION_TOKEN
+	operator.Get("/metrics/nintendo", handlers.HandleNintendoMetrics)
+
+	// Diablo III and StarCraft II profile stats, with the Blizzard API client of BNET_CLIENT_ID
+	operator.Get("/metrics/bnet/{game}/{profile}", handlers.HandleBattleNetMetrics)
+
+	// Clash of Clans and Clash Royale stats of a player tag, with the tokens of CLASH_*_TOKEN
+	operator.Get("/metrics/clash/{tag}", handlers.HandleClashMetrics)
+
+	// Players, rank and uptime of the game servers in BATTLEMETRICS_SERVERS
+	operator.Get("/metrics/battlemetrics", handlers.HandleBattleMetricsMetrics)
+
+	// Players and tick performance of the dedicated servers in RCON_SERVERS
+	operator.Get("/metrics/rcon", handlers.HandleRCONMetrics)
+
+	// "Now playing" from the Discord presence of the CONFIG_FILE users with a discord_id
+	operator.Get("/metrics/discord", handlers.HandleDiscordMetrics)
+
+	// A person's Steam and OSRS metrics, configured in CONFIG_FILE
+	operator.Get("/metrics/user/{name}", handlers.HandleUserMetrics)
 
 	// Worlds endpoint (no playerid needed)
 	r.Get("/metrics/osrs/worlds", handlers.HandleOSRSWorldMetrics)
 
+	// Grand Exchange prices of the items in OSRS_GE_ITEMS
+	r.Get("/metrics/osrs/ge", handlers.HandleOSRSGEMetrics)
+
+	// Polled players' positions among each other
+	operator.Get("/metrics/osrs/leaderboard", handlers.HandleOSRSLeaderboardMetrics)
+
 	// Mode-based endpoints: /metrics/osrs/{mode}/{playerid}
 	// mode can be "vanilla" (for player stats) or other future modes
-	r.Get("/metrics/osrs/{mode}/{playerid}", handlers.HandleOSRSMetrics)
-
-	return r
+	r.With(TenantTarget(tenantOSRS, "playerid")).Get("/metrics/osrs/{mode}/{playerid}", handlers.HandleOSRSMetrics)
 }
-
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// scrapeTimeoutHeader is sent by Prometheus with the scrape_timeout of the job
+	scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+	// scrapeTimeoutOffset is kept back from Prometheus's timeout so there's time to
+	// encode and send the response before Prometheus gives up
+	scrapeTimeoutOffset = 500 * time.Millisecond
+)
+
+// scrapeTimeout returns how long collection may run for this request: the configured
+// timeout, shortened to fit the scraper's own timeout when it sends one
+func (h *Handlers) scrapeTimeout(r *http.Request) time.Duration {
+	timeout := h.options.ScrapeTimeout
+	if header := r.Header.Get(scrapeTimeoutHeader); header != "" {
+		if seconds, err := strconv.ParseFloat(header, 64); err == nil && seconds > 0 {
+			scraper := time.Duration(seconds*float64(time.Second)) - scrapeTimeoutOffset
+			if scraper <= 0 {
+				scraper = time.Duration(seconds * float64(time.Second) / 2)
+			}
+			if timeout <= 0 || scraper < timeout {
+				timeout = scraper
+			}
+		}
+	}
+	return timeout
+}
+
+// collectWithTimeout runs collect, giving up waiting once the scrape timeout passes.
+// Collection keeps running in the background after a timeout so the cache is warm for the
+// next scrape; it gets a context detached from the request's cancellation for that reason,
+// cancelled instead when shutdown begins (see Drain).
+// Results written by collect may only be read when timedOut is false.
+func (h *Handlers) collectWithTimeout(r *http.Request, collect func(ctx context.Context) error) (timedOut bool, err error) {
+	ctx, finished, err := h.drain.start(r.Context())
+	if err != nil {
+		return false, err
+	}
+
+	timeout := h.scrapeTimeout(r)
+	if timeout <= 0 {
+		defer finished()
+		return false, collect(ctx)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer finished()
+		done <- collect(ctx)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return false, err
+	case <-timer.C:
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"path":    r.URL.Path,
+			"timeout": timeout,
+		}).Warn("Scrape timeout reached, serving partial metrics while collection continues")
+		return true, nil
+	case <-r.Context().Done():
+		// Client went away, nothing left to serve
+		return false, r.Context().Err()
+	}
+}
+
+// scrapeResult describes the outcome of one target's collection for the exporter metrics
+type scrapeResult struct {
+	collector   string    // steam, steam_prices, osrs, osrs_worlds, osrs_ge, family or user
+	target      string    // Steam ID, <mode>/<rsn>, worlds, prices, family or user name
+	failed      bool      // Collection failed
+	timedOut    bool      // Collection hit the scrape timeout, metrics may be partial
+	stale       bool      // Collection failed, metrics are from an earlier collection
+	lastSuccess time.Time // When the target was last collected successfully, zero if never
+
+	dataCollected time.Time // When the cached upstream data behind the metrics was fetched, zero if unknown
+}
+
+// serveMetrics writes the gathered metrics plus the per-scrape exporter metrics, rewritten by
+// the relabel rules. A tenant is served its own targets' series, under its prefix.
+func (h *Handlers) serveMetrics(w http.ResponseWriter, r *http.Request, metrics prometheus.Gatherer, result scrapeResult) {
+	scrape := prometheus.NewRegistry()
+	timedOutGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "exporter",
+		Name:      "scrape_timed_out",
+		Help:      "Whether collection hit the scrape timeout, in which case metrics may be partial (1) or not (0)",
+	})
+	if result.timedOut {
+		timedOutGauge.Set(1)
+	}
+	scrape.MustRegister(timedOutGauge)
+
+	if result.collector != "" {
+		h.snapshots.recordResult(result)
+
+		labels := prometheus.Labels{"collector": result.collector, "target": result.target}
+
+		successGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   "exporter",
+			Name:        "collection_success",
+			Help:        "Whether the collection for the target succeeded (1) or failed (0)",
+			ConstLabels: labels,
+		})
+		if !result.failed {
+			successGauge.Set(1)
+		}
+		scrape.MustRegister(successGauge)
+
+		staleGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   "exporter",
+			Name:        "data_stale",
+			Help:        "Whether collection failed and the metrics are from an earlier successful collection (1) or fresh (0)",
+			ConstLabels: labels,
+		})
+		if result.stale {
+			staleGauge.Set(1)
+		}
+		scrape.MustRegister(staleGauge)
+
+		if !result.lastSuccess.IsZero() {
+			lastSuccessGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+				Namespace:   "exporter",
+				Name:        "last_success_timestamp_seconds",
+				Help:        "Unix time of the last successful collection for the target",
+				ConstLabels: labels,
+			})
+			lastSuccessGauge.Set(float64(result.lastSuccess.UnixNano()) / 1e9)
+			scrape.MustRegister(lastSuccessGauge)
+		}
+
+		if !result.dataCollected.IsZero() {
+			dataCollectedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+				Namespace:   "exporter",
+				Name:        "data_collected_timestamp_seconds",
+				Help:        "Unix time the oldest cached upstream data behind the target's metrics was fetched",
+				ConstLabels: labels,
+			})
+			dataCollectedGauge.Set(float64(result.dataCollected.UnixNano()) / 1e9)
+			scrape.MustRegister(dataCollectedGauge)
+
+			if h.options.SampleTimestamps {
+				metrics = timestampedGatherer(metrics, result.dataCollected)
+			}
+		}
+	}
+
+	rules := h.options.Relabel
+	if tenant, ok := TenantFromContext(r.Context()); ok {
+		// The gauges hold every target's series, a tenant only gets its own
+		targets := metrics
+		metrics = prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			families, err := targets.Gather()
+			return h.tenantFamilies(tenant, families), err
+		})
+		rules.Prefix = tenant.Prefix
+	}
+
+	gatherers := prometheus.Gatherers{metrics, scrape}
+	promhttp.HandlerFor(relabel.Gatherer(gatherers, rules), promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// serveCollected serves the collector's metrics after a collection for the target
+// and keeps them as the target's snapshot in case a later collection fails
+func (h *Handlers) serveCollected(w http.ResponseWriter, r *http.Request, metrics prometheus.Gatherer, collector string, target string, timedOut bool) {
+	families, err := metrics.Gather()
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("Failed to gather metrics")
+		http.Error(w, "failed to gather metrics", http.StatusInternalServerError)
+		return
+	}
+
+	result := scrapeResult{
+		collector:     collector,
+		target:        target,
+		timedOut:      timedOut,
+		dataCollected: cache.FreshnessFromContext(r.Context()).Oldest(),
+	}
+	if timedOut {
+		// Partial metrics aren't worth keeping over the last complete set
+		if snapshot, ok := h.snapshots.load(collector, target); ok {
+			result.lastSuccess = snapshot.collected
+		}
+	} else {
+		result.lastSuccess = h.snapshots.store(collector, target, families, result.dataCollected)
+	}
+
+	h.serveMetrics(w, r, staticGatherer(families), result)
+}
+
+// serveFailure answers a scrape whose collection failed with a 200 and
+// exporter_collection_success 0, so Prometheus keeps the target up and the failure stays
+// alertable. The target's metrics from its last successful collection are included, marked
+// stale; stale reports whether there were any.
+func (h *Handlers) serveFailure(w http.ResponseWriter, r *http.Request, collector string, target string) (stale bool) {
+	result := scrapeResult{collector: collector, target: target, failed: true}
+
+	snapshot, ok := h.snapshots.load(collector, target)
+	if !ok {
+		h.serveMetrics(w, r, staticGatherer(nil), result)
+		return false
+	}
+
+	result.stale = true
+	result.lastSuccess = snapshot.collected
+	result.dataCollected = snapshot.dataCollected
+	h.serveMetrics(w, r, staticGatherer(snapshot.families), result)
+	return true
+}
+
+// timestampedGatherer stamps every sample with the time its data was fetched, leaving the
+// gathered metrics (possibly a snapshot) unmodified
+func timestampedGatherer(metrics prometheus.Gatherer, collected time.Time) prometheus.Gatherer {
+	timestamp := collected.UnixMilli()
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := metrics.Gather()
+		if err != nil {
+			return nil, err
+		}
+		stamped := make([]*dto.MetricFamily, 0, len(families))
+		for _, mf := range families {
+			series := make([]*dto.Metric, 0, len(mf.Metric))
+			for _, m := range mf.Metric {
+				series = append(series, &dto.Metric{
+					Label:       m.Label,
+					Gauge:       m.Gauge,
+					Counter:     m.Counter,
+					Untyped:     m.Untyped,
+					Summary:     m.Summary,
+					Histogram:   m.Histogram,
+					TimestampMs: &timestamp,
+				})
+			}
+			stamped = append(stamped, &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type, Metric: series})
+		}
+		return stamped, nil
+	})
+}
+
+// staticGatherer serves previously gathered metric families
+func staticGatherer(families []*dto.MetricFamily) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return families, nil
+	})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// metricsSnapshot is the set of metrics from a target's last successful collection
+type metricsSnapshot struct {
+	families      []*dto.MetricFamily
+	collected     time.Time
+	dataCollected time.Time // When the cached data behind the metrics was fetched, zero if unknown
+}
+
+// snapshotStore keeps the last successful collection per target in memory, so a scrape
+// whose collection fails can still serve the last-known values instead of an error
+type snapshotStore struct {
+	mu      sync.RWMutex
+	entries map[string]metricsSnapshot
+	results map[string]servedResult // Last outcome per target, for the status page
+}
+
+// servedResult is the outcome of a target's last scrape
+type servedResult struct {
+	scrapeResult
+	served time.Time
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{
+		entries: make(map[string]metricsSnapshot),
+		results: make(map[string]servedResult),
+	}
+}
+
+// recordResult keeps the outcome of a target's scrape, successful or not
+func (s *snapshotStore) recordResult(result scrapeResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[result.collector+"/"+result.target] = servedResult{scrapeResult: result, served: time.Now()}
+}
+
+// lastResults returns the outcome of each scraped target's last scrape, sorted by collector and
+// target
+func (s *snapshotStore) lastResults() []servedResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make([]servedResult, 0, len(s.results))
+	for _, result := range s.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].collector != results[j].collector {
+			return results[i].collector < results[j].collector
+		}
+		return results[i].target < results[j].target
+	})
+	return results
+}
+
+// store records the metrics for a target and returns the collection time
+func (s *snapshotStore) store(collector string, target string, families []*dto.MetricFamily, dataCollected time.Time) time.Time {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[collector+"/"+target] = metricsSnapshot{
+		families:      families,
+		collected:     now,
+		dataCollected: dataCollected,
+	}
+	return now
+}
+
+// load returns the last successful collection for a target
+func (s *snapshotStore) load(collector string, target string) (metricsSnapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshot, ok := s.entries[collector+"/"+target]
+	return snapshot, ok
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/polling"
+)
+
+// statusPingTimeout bounds the cache round trip of the status page
+const statusPingTimeout = 2 * time.Second
+
+// statusPage serves the front page: which collectors are configured, the polled targets, cache
+// and rate limit health and each target's last scrape, so an operator can tell at a glance
+// whether the exporter is healthy
+type statusPage struct {
+	handlers *Handlers
+	admin    *AdminHandlers
+	started  time.Time
+}
+
+func newStatusPage(handlers *Handlers, admin *AdminHandlers) *statusPage {
+	return &statusPage{handlers: handlers, admin: admin, started: time.Now()}
+}
+
+// statusCollector is a row of the collectors table
+type statusCollector struct {
+	Name     string
+	Endpoint string
+	Enabled  bool
+	Setting  string // What enables it
+	State    string // Its state when enabled, if it has one
+}
+
+// statusResult is a row of the last scrapes table
+type statusResult struct {
+	Collector     string
+	Target        string
+	Served        time.Time
+	Failed        bool
+	Stale         bool
+	TimedOut      bool
+	LastSuccess   time.Time
+	DataCollected time.Time
+}
+
+// statusData is rendered by statusTemplate
+type statusData struct {
+	Now     time.Time
+	Started time.Time
+
+	Collectors []statusCollector
+
+	CacheError   string
+	CacheLatency time.Duration
+
+	SteamConfigured  bool
+	SteamRateLimited bool
+	PollingPaused    bool
+	Draining         bool
+
+	Targets []polling.TargetStatus
+	Results []statusResult
+}
+
+// HandleRoot handles GET /
+func (p *statusPage) HandleRoot(w http.ResponseWriter, r *http.Request) {
+	data := statusData{
+		Now:        time.Now(),
+		Started:    p.started,
+		Collectors: p.collectors(),
+
+		SteamConfigured: p.admin.steam != nil,
+		PollingPaused:   p.admin.polling.Paused(),
+		Draining:        p.handlers.drain.stopping(),
+
+		Targets: p.admin.polling.Status(),
+	}
+	for _, result := range p.handlers.snapshots.lastResults() {
+		data.Results = append(data.Results, statusResult{
+			Collector:     result.collector,
+			Target:        result.target,
+			Served:        result.served,
+			Failed:        result.failed,
+			Stale:         result.stale,
+			TimedOut:      result.timedOut,
+			LastSuccess:   result.lastSuccess,
+			DataCollected: result.dataCollected,
+		})
+	}
+	if p.admin.steam != nil {
+		data.SteamRateLimited = p.admin.steam.RateLimited()
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), statusPingTimeout)
+	defer cancel()
+	pinged := time.Now()
+	if err := p.admin.cache.Ping(ctx); err != nil {
+		data.CacheError = err.Error()
+	}
+	data.CacheLatency = time.Since(pinged).Round(time.Microsecond)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(w, data); err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("Failed to render status page")
+	}
+}
+
+// collectors lists every collector with whether it's configured
+func (p *statusPage) collectors() []statusCollector {
+	options := p.handlers.options
+	discord := statusCollector{Name: "Discord presence", Endpoint: "/metrics/discord", Enabled: options.Discord != nil, Setting: "DISCORD_BOT_TOKEN"}
+	if options.Discord != nil {
+		discord.State = "gateway disconnected"
+		if options.Discord.Connected() {
+			discord.State = "gateway connected"
+		}
+	}
+
+	return []statusCollector{
+		{Name: "Steam", Endpoint: "/metrics/steam/{steam_id}", Enabled: p.admin.steam != nil, Setting: "STEAM_KEY"},
+		{Name: "Steam store prices", Endpoint: "/metrics/steam/prices", Enabled: options.Prices != nil, Setting: "STEAM_PRICE_APP_IDS"},
+		{Name: "OSRS hiscores and worlds", Endpoint: "/metrics/osrs/{mode}/{playerid}", Enabled: true},
+		{Name: "OSRS Grand Exchange", Endpoint: "/metrics/osrs/ge", Enabled: options.GE != nil, Setting: "OSRS_GE_ITEMS"},
+		{Name: "Families", Endpoint: "/metrics/family/{family}", Enabled: options.Families != nil, Setting: "CONFIG_FILE families"},
+		{Name: "Users", Endpoint: "/metrics/user/{name}", Enabled: options.Users != nil, Setting: "CONFIG_FILE users"},
+		{Name: "Epic Games", Endpoint: "/metrics/epic/{account_id}", Enabled: options.Epic != nil, Setting: "EPIC_ACCOUNTS"},
+		{Name: "Nintendo Switch", Endpoint: "/metrics/nintendo", Enabled: options.Nintendo != nil, Setting: "NINTENDO_SESSION_TOKEN"},
+		{Name: "Battle.net", Endpoint: "/metrics/bnet/{game}/{profile}", Enabled: options.BattleNet != nil, Setting: "BNET_CLIENT_ID"},
+		{Name: "Clash of Clans and Clash Royale", Endpoint: "/metrics/clash/{tag}", Enabled: options.Clash != nil, Setting: "CLASH_OF_CLANS_TOKEN, CLASH_ROYALE_TOKEN"},
+		{Name: "BattleMetrics", Endpoint: "/metrics/battlemetrics", Enabled: options.BattleMetrics != nil, Setting: "BATTLEMETRICS_SERVERS"},
+		{Name: "RCON", Endpoint: "/metrics/rcon", Enabled: options.RCON != nil, Setting: "RCON_SERVERS"},
+		discord,
+		{Name: "History", Endpoint: "/api/v1/osrs/{rsn}/history", Enabled: options.History != nil, Setting: "HISTORY_DRIVER"},
+	}
+}
+
+// since formats how long ago t was, to the second
+func since(now time.Time, t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return now.Sub(t).Round(time.Second).String() + " ago"
+}
+
+// until formats how long until t, to the second
+func until(now time.Time, t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	if !t.After(now) {
+		return "due"
+	}
+	return "in " + t.Sub(now).Round(time.Second).String()
+}
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"since": since,
+	"until": until,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>Game Stats Exporter</title>
+	<style>
+		body { font-family: sans-serif; margin: 2em; }
+		table { border-collapse: collapse; margin-bottom: 1em; }
+		th, td { border: 1px solid #ccc; padding: 0.25em 0.75em; text-align: left; }
+		.ok { color: #1a7f37; }
+		.bad { color: #cf222e; }
+		.off { color: #888; }
+	</style>
+</head>
+<body>
+	<h1>Game Stats Exporter</h1>
+	<p>Prometheus metrics exporter for Steam, OSRS and other game stats. Up {{since .Now .Started}}{{if .Draining}}, <span class="bad">shutting down</span>{{end}}.</p>
+
+	<h2>Health</h2>
+	<table>
+		<tr><th>Cache</th><td>{{if .CacheError}}<span class="bad">{{.CacheError}}</span>{{else}}<span class="ok">ok</span> ({{.CacheLatency}}){{end}}</td></tr>
+		<tr><th>Steam API</th><td>{{if not .SteamConfigured}}<span class="off">not configured</span>{{else if .SteamRateLimited}}<span class="bad">rate limited, backing off</span>{{else}}<span class="ok">ok</span>{{end}}</td></tr>
+		<tr><th>Background polling</th><td>{{if .PollingPaused}}<span class="bad">paused</span>{{else}}<span class="ok">running</span>{{end}}, {{len .Targets}} targets</td></tr>
+	</table>
+
+	<h2>Collectors</h2>
+	<table>
+		<tr><th>Collector</th><th>Endpoint</th><th>Status</th></tr>
+		{{range .Collectors}}<tr><td>{{.Name}}</td><td>{{.Endpoint}}</td><td>{{if .Enabled}}<span class="ok">enabled</span>{{if .State}} ({{.State}}){{end}}{{else}}<span class="off">not configured ({{.Setting}})</span>{{end}}</td></tr>
+		{{end}}
+	</table>
+
+	<h2>Polling Targets</h2>
+	{{if .Targets}}<table>
+		<tr><th>Collector</th><th>Target</th><th>Last poll</th><th>Next poll</th><th>Activity</th><th>Consecutive failures</th></tr>
+		{{range .Targets}}<tr><td>{{.Kind}}</td><td>{{.ID}}</td><td>{{if .Running}}running{{else}}{{since $.Now .LastPoll}}{{end}}</td><td>{{until $.Now .NextRun}}</td><td>{{if .Active}}active{{else}}idle{{end}}</td><td>{{if .Failures}}<span class="bad">{{.Failures}}</span>{{else}}0{{end}}</td></tr>
+		{{end}}
+	</table>{{else}}<p>No targets are polled (POLL_STEAM_IDS, POLL_OSRS_PLAYERS, CONFIG_FILE).</p>{{end}}
+
+	<h2>Last Scrapes</h2>
+	{{if .Results}}<table>
+		<tr><th>Collector</th><th>Target</th><th>Scraped</th><th>Result</th><th>Last success</th><th>Data fetched</th></tr>
+		{{range .Results}}<tr><td>{{.Collector}}</td><td>{{.Target}}</td><td>{{since $.Now .Served}}</td><td>{{if .Failed}}<span class="bad">failed{{if .Stale}}, served stale metrics{{end}}</span>{{else if .TimedOut}}<span class="bad">timed out, partial metrics</span>{{else}}<span class="ok">ok</span>{{end}}</td><td>{{since $.Now .LastSuccess}}</td><td>{{since $.Now .DataCollected}}</td></tr>
+		{{end}}
+	</table>{{else}}<p>Nothing has been scraped since startup.</p>{{end}}
+
+	<h2>Endpoints</h2>
+	<ul>
+		<li><a href="/metrics">/metrics</a> - System metrics only (Go runtime, process, etc.)</li>
+		<li><a href="/metrics/steam/{steam_id}">/metrics/steam/{steam_id}</a> - Steam player metrics (filtered, Steam only)</li>
+		<li><a href="/metrics/steam/prices">/metrics/steam/prices</a> - Steam store prices and discounts of the tracked apps (STEAM_PRICE_APP_IDS)</li>
+		<li><a href="/metrics/osrs/ge">/metrics/osrs/ge</a> - Grand Exchange prices and flip margins of the watched items (OSRS_GE_ITEMS)</li>
+		<li><a href="/metrics/family/{family}">/metrics/family/{family}</a> - Combined playtime and XP of a family's accounts (families section of CONFIG_FILE)</li>
+		<li><a href="/metrics/epic/{account_id}">/metrics/epic/{account_id}</a> - Playtime of an Epic Games account (EPIC_ACCOUNTS)</li>
+		<li><a href="/metrics/nintendo">/metrics/nintendo</a> - Today's play on Nintendo Switch consoles, per player and title (NINTENDO_SESSION_TOKEN)</li>
+		<li><a href="/metrics/bnet/{game}/{profile}">/metrics/bnet/{game}/{profile}</a> - Diablo III (d3, BattleTag) or StarCraft II (sc2, region-realm-profile) profile stats (BNET_CLIENT_ID)</li>
+		<li><a href="/metrics/clash/{tag}">/metrics/clash/{tag}</a> - Clash of Clans and Clash Royale stats of a player tag (CLASH_OF_CLANS_TOKEN, CLASH_ROYALE_TOKEN)</li>
+		<li><a href="/metrics/battlemetrics">/metrics/battlemetrics</a> - Players, rank and uptime of game servers tracked by BattleMetrics (BATTLEMETRICS_SERVERS)</li>
+		<li><a href="/metrics/rcon">/metrics/rcon</a> - Players online and tick performance of Minecraft, Factorio and Valheim servers, over RCON (RCON_SERVERS)</li>
+		<li><a href="/metrics/discord">/metrics/discord</a> - Games the users with a discord_id are playing right now, from their Discord presence (DISCORD_BOT_TOKEN)</li>
+		<li><a href="/metrics/user/{name}">/metrics/user/{name}</a> - A person's Steam and OSRS vanilla metrics with a user label (users section of CONFIG_FILE)</li>
+		<li><a href="/metrics/osrs/vanilla/{playerid}">/metrics/osrs/vanilla/{playerid}</a> - OSRS vanilla player metrics (filtered, OSRS only)</li>
+		<li><a href="/metrics/osrs/ironman/{playerid}">/metrics/osrs/ironman/{playerid}</a> - OSRS ironman player metrics (also hardcore_ironman and ultimate_ironman; filtered, OSRS only)</li>
+		<li><a href="/metrics/osrs/gridmaster/{playerid}">/metrics/osrs/gridmaster/{playerid}</a> - OSRS gridmaster (tournament) player metrics (filtered, OSRS only)</li>
+		<li><a href="/metrics/osrs/deadman/{playerid}">/metrics/osrs/deadman/{playerid}</a> - OSRS deadman mode player metrics (filtered, OSRS only)</li>
+		<li><a href="/metrics/osrs/seasonal/{playerid}">/metrics/osrs/seasonal/{playerid}</a> - OSRS seasonal/leagues player metrics (filtered, OSRS only)</li>
+		<li><a href="/metrics/osrs/all/{playerid}">/metrics/osrs/all/{playerid}</a> - OSRS player metrics for all modes in one scrape, one mode label each (filtered, OSRS only)</li>
+		<li><a href="/metrics/osrs/worlds">/metrics/osrs/worlds</a> - OSRS world metrics (filtered, OSRS only)</li>
+		<li><a href="/metrics/osrs/leaderboard">/metrics/osrs/leaderboard</a> - Position of each polled OSRS player by XP in each skill</li>
+		<li><a href="/api/v1/steam/{steam_id}">/api/v1/steam/{steam_id}</a> - Steam library and cached achievements as JSON</li>
+		<li><a href="/api/v1/osrs/vanilla/{rsn}">/api/v1/osrs/{mode}/{rsn}</a> - OSRS player hiscores as JSON</li>
+		<li><a href="/api/v1/osrs/worlds">/api/v1/osrs/worlds</a> - OSRS world list as JSON</li>
+		<li><a href="/api/v1/osrs/items/search?q=twisted">/api/v1/osrs/items/search?q={name}</a> - Grand Exchange items by name, with their IDs, as JSON</li>
+		<li><a href="/api/v1/leaderboard?metric=osrs_xp&amp;skill=Slayer">/api/v1/leaderboard</a> - Polled players ranked by OSRS XP or level, or Steam playtime, as JSON</li>
+		<li><a href="/api/v1/steam/{steam_id}/history">/api/v1/steam/{steam_id}/history</a>, <a href="/api/v1/osrs/{rsn}/history">/api/v1/osrs/{rsn}/history</a> - Recorded playtime and XP (requires HISTORY_DRIVER)</li>
+		<li><a href="/api/openapi.json">/api/openapi.json</a> - OpenAPI specification (metrics endpoints are also served under /v1)</li>
+		<li><a href="/admin/polling">/admin/polling</a>, <a href="/admin/cache/stats">/admin/cache/stats</a>, <a href="/admin/check">/admin/check</a> - Operator endpoints as JSON</li>
+	</ul>
+</body>
+</html>
+`))
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+// Tenant is a household or clan sharing the exporter (CONFIG_FILE tenants). Its bearer token
+// reaches the Steam and OSRS endpoints of its own targets only, and the series it's served are
+// limited to those targets and carry its prefix.
+type Tenant struct {
+	Name        string
+	Token       string
+	Prefix      string // Prepended to every series name, e.g. smiths_ for smiths_steam_...
+	SteamIDs    []string
+	OSRSPlayers []string // Normalized RSNs
+}
+
+// TenantDirectory lists the configured tenants; it changes on config reload
+type TenantDirectory interface {
+	Tenants() []Tenant
+}
+
+// Target kinds a tenant's requests are scoped to
+const (
+	tenantSteam = "steam"
+	tenantOSRS  = "osrs"
+)
+
+type tenantKey struct{}
+
+// withTenant returns a context for a request made with a tenant's token
+func withTenant(ctx context.Context, tenant Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant a request was made by, false for the operator
+func TenantFromContext(ctx context.Context) (Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(Tenant)
+	return tenant, ok
+}
+
+// findTenant returns the tenant whose token the request bears. Every token is compared, in
+// constant time, so timing doesn't reveal which tenant came close.
+func findTenant(tenants TenantDirectory, r *http.Request) (Tenant, bool) {
+	if tenants == nil {
+		return Tenant{}, false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return Tenant{}, false
+	}
+
+	var found Tenant
+	matched := false
+	for _, tenant := range tenants.Tenants() {
+		if secureCompare(token, tenant.Token) && !matched {
+			found, matched = tenant, true
+		}
+	}
+	return found, matched
+}
+
+// owns reports whether a target of the given kind is one of the tenant's
+func (t Tenant) owns(kind string, target string) bool {
+	switch kind {
+	case tenantSteam:
+		return slices.Contains(t.SteamIDs, target)
+	case tenantOSRS:
+		return slices.Contains(t.OSRSPlayers, osrs.NormalizeRSN(target))
+	}
+	return false
+}
+
+// OperatorOnly rejects tenants' requests with a 403, for endpoints serving the whole instance
+// (admin, other accounts, leaderboards)
+func OperatorOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenant, ok := TenantFromContext(r.Context()); ok {
+			logger.FromContext(r.Context()).WithFields(logrus.Fields{
+				"path":   r.URL.Path,
+				"tenant": tenant.Name,
+			}).Warn("Rejected tenant request to an operator endpoint")
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TenantTarget limits tenants to the targets of theirs named by the route parameter; any other
+// target is answered with a 404, so tenants can't tell which targets exist
+func TenantTarget(kind string, param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tenant, ok := TenantFromContext(r.Context()); ok && !tenant.owns(kind, chi.URLParam(r, param)) {
+				logger.FromContext(r.Context()).WithFields(logrus.Fields{
+					"path":   r.URL.Path,
+					"tenant": tenant.Name,
+				}).Warn("Rejected tenant request for another target")
+				http.NotFound(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tenantFamilies returns the Steam and OSRS series of the tenant's targets; series of other
+// targets and other collectors' metrics are left out, since the gauges are shared by everyone's
+// targets. Series without a target label (store and GE prices, worlds) are kept.
+func (h *Handlers) tenantFamilies(tenant Tenant, families []*dto.MetricFamily) []*dto.MetricFamily {
+	players := make([]string, 0, len(tenant.OSRSPlayers))
+	for _, rsn := range tenant.OSRSPlayers {
+		players = append(players, h.osrsCollector.PlayerLabel(rsn))
+	}
+	owned := func(m *dto.Metric) bool {
+		for _, pair := range m.Label {
+			switch pair.GetName() {
+			case "steam_id":
+				if !slices.Contains(tenant.SteamIDs, pair.GetValue()) {
+					return false
+				}
+			case "player":
+				if !slices.Contains(players, pair.GetValue()) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	kept := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		if !strings.HasPrefix(mf.GetName(), "steam_") && !strings.HasPrefix(mf.GetName(), "osrs_") {
+			continue
+		}
+		metrics := slices.DeleteFunc(slices.Clone(mf.Metric), func(m *dto.Metric) bool { return !owned(m) })
+		if len(metrics) > 0 {
+			kept = append(kept, &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type, Metric: metrics})
+		}
+	}
+	return kept
+}
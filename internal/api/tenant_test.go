@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+)
+
+const (
+	smithsSteamID  = "76561197960287930"
+	jonesesSteamID = "76561197960287931"
+)
+
+// testTenants is a fixed TenantDirectory
+type testTenants []Tenant
+
+func (t testTenants) Tenants() []Tenant {
+	return t
+}
+
+var tenants = testTenants{
+	{Name: "smiths", Token: "smiths-token", Prefix: "smiths_", SteamIDs: []string{smithsSteamID}, OSRSPlayers: []string{"zezima"}},
+	{Name: "joneses", Token: "joneses-token", Prefix: "joneses_", SteamIDs: []string{jonesesSteamID}},
+}
+
+// fakeSteam reports one game per user instead of calling Steam
+type fakeSteam struct{}
+
+func (fakeSteam) Collect(ctx context.Context, steamId string) error {
+	steam.ReportOwnedGame(steam.OwnedGame{AppId: 440, Name: "Team Fortress 2", PlaytimeForever: 60}, steamId, "user")
+	return nil
+}
+
+func (fakeSteam) Profile(ctx context.Context, steamId string) (steam.Profile, error) {
+	return steam.Profile{}, errors.New("not implemented")
+}
+
+func (fakeSteam) OwnedGames(ctx context.Context, steamId string) (steam.OwnedGamesResponse, error) {
+	return steam.OwnedGamesResponse{}, errors.New("not implemented")
+}
+
+func (fakeSteam) DebugOwnedGames(ctx context.Context, steamId string) (steam.OwnedGamesResponse, error) {
+	return steam.OwnedGamesResponse{}, errors.New("not implemented")
+}
+
+// newTestRouter returns the exporter's routes with the given protection, collecting Steam
+// metrics from fakeSteam and OSRS from a test server
+func newTestRouter(t *testing.T, config RouterConfig) http.Handler {
+	t.Helper()
+	srv := testserver.New(t)
+	handlers := NewHandlers(fakeSteam{}, osrs.NewCollector(testserver.NewCache(t), srv.Transport()), HandlerOptions{})
+	return NewRouter(handlers, NewAdminHandlers(nil, nil, nil, nil, nil), config)
+}
+
+// get requests path from router, with the Authorization header when authorization isn't empty
+func get(router http.Handler, path string, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenantOtherTargets(t *testing.T) {
+	router := newTestRouter(t, RouterConfig{Auth: AuthConfig{BearerToken: "operator-token", Tenants: tenants}})
+
+	// Another tenant's targets and targets of no tenant look the same: not found
+	for _, path := range []string{
+		"/metrics/steam/" + jonesesSteamID,
+		"/v1/metrics/steam/" + jonesesSteamID,
+		"/metrics/steam/76561197960287999",
+		"/api/v1/steam/" + jonesesSteamID,
+		"/api/v1/steam/" + jonesesSteamID + "/history",
+		"/metrics/osrs/vanilla/Lynx%20Titan",
+		"/api/v1/osrs/vanilla/Lynx%20Titan",
+	} {
+		if got := get(router, path, "Bearer smiths-token").Code; got != http.StatusNotFound {
+			t.Errorf("tenant request for %s = %d, want 404", path, got)
+		}
+	}
+}
+
+func TestTenantOperatorRoutes(t *testing.T) {
+	router := newTestRouter(t, RouterConfig{Auth: AuthConfig{BearerToken: "operator-token", Tenants: tenants}})
+
+	for _, path := range []string{
+		"/",
+		"/metrics",
+		"/metrics/family/smiths",
+		"/metrics/user/alex",
+		"/metrics/osrs/leaderboard",
+		"/api/v1/leaderboard",
+		"/debug/raw/steam/" + smithsSteamID,
+		"/admin/polling",
+		"/admin/cache/stats",
+	} {
+		if got := get(router, path, "Bearer smiths-token").Code; got != http.StatusForbidden {
+			t.Errorf("tenant request for %s = %d, want 403", path, got)
+		}
+	}
+	for _, path := range []string{"/admin/cache/flush", "/admin/reload", "/admin/polling/pause"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer smiths-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("tenant POST %s = %d, want 403", path, w.Code)
+		}
+	}
+
+	// The operator's token still reaches them
+	if got := get(router, "/metrics", "Bearer operator-token").Code; got != http.StatusOK {
+		t.Errorf("operator request for /metrics = %d, want 200", got)
+	}
+}
+
+func TestTenantUnauthenticated(t *testing.T) {
+	// Without operator credentials, configuring tenants still closes the endpoints
+	router := newTestRouter(t, RouterConfig{Auth: AuthConfig{Tenants: tenants}})
+
+	for _, authorization := range []string{"", "Bearer wrong-token", "smiths-token"} {
+		w := get(router, "/metrics/steam/"+smithsSteamID, authorization)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("request with Authorization %q = %d, want 401", authorization, w.Code)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Bearer ") {
+			t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", got)
+		}
+	}
+	if got := get(router, "/metrics/steam/"+smithsSteamID, "Bearer smiths-token").Code; got != http.StatusOK {
+		t.Errorf("tenant request for its own target = %d, want 200", got)
+	}
+
+	// With no tenants left (after a reload), the endpoints are open as without any auth
+	router = newTestRouter(t, RouterConfig{Auth: AuthConfig{Tenants: testTenants{}}})
+	if got := get(router, "/metrics/steam/"+smithsSteamID, "").Code; got != http.StatusOK {
+		t.Errorf("request without tenants = %d, want 200", got)
+	}
+}
+
+func TestTenantMetrics(t *testing.T) {
+	router := newTestRouter(t, RouterConfig{Auth: AuthConfig{BearerToken: "operator-token", Tenants: tenants}})
+
+	// Both users' series are in the shared gauges once both have been collected
+	smiths := get(router, "/metrics/steam/"+smithsSteamID, "Bearer smiths-token")
+	joneses := get(router, "/metrics/steam/"+jonesesSteamID, "Bearer joneses-token")
+	smiths = get(router, "/metrics/steam/"+smithsSteamID, "Bearer smiths-token")
+	if smiths.Code != http.StatusOK || joneses.Code != http.StatusOK {
+		t.Fatalf("tenant requests = %d and %d, want 200", smiths.Code, joneses.Code)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		want   []string
+		absent []string
+	}{
+		{
+			name: "smiths",
+			body: smiths.Body.String(),
+			want: []string{
+				`smiths_steam_owned_games_playtime_seconds{app_id="440",game_name="Team Fortress 2",steam_id="` + smithsSteamID + `",username="user"} 3600`,
+				`smiths_exporter_collection_success{collector="steam",target="` + smithsSteamID + `"} 1`,
+			},
+			absent: []string{jonesesSteamID, "joneses_", "\nsteam_", "\nexporter_"},
+		},
+		{
+			name: "joneses",
+			body: joneses.Body.String(),
+			want: []string{
+				`joneses_steam_owned_games_playtime_seconds{app_id="440",game_name="Team Fortress 2",steam_id="` + jonesesSteamID + `",username="user"} 3600`,
+				`joneses_exporter_collection_success{collector="steam",target="` + jonesesSteamID + `"} 1`,
+			},
+			absent: []string{smithsSteamID, "smiths_", "\nsteam_", "\nexporter_"},
+		},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.body, want) {
+				t.Errorf("%s metrics lack %s", tt.name, want)
+			}
+		}
+		for _, absent := range tt.absent {
+			if strings.Contains(tt.body, absent) {
+				t.Errorf("%s metrics contain %q", tt.name, absent)
+			}
+		}
+	}
+
+	// The operator gets the series unprefixed
+	operator := get(router, "/metrics/steam/"+smithsSteamID, "Bearer operator-token").Body.String()
+	if !strings.Contains(operator, "\nsteam_owned_games_playtime_seconds{") || strings.Contains(operator, "smiths_") {
+		t.Error("operator metrics aren't served unprefixed")
+	}
+}
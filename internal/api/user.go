@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+// userLabel is added to every metric served by /metrics/user/{name}
+const userLabel = "user"
+
+// UserAccounts are the accounts of one person
+type UserAccounts struct {
+	SteamID     string // Empty when the person has no Steam account
+	OSRSPlayers []string
+}
+
+type UserDirectory interface {
+	User(name string) (UserAccounts, bool)
+}
+
+// HandleUserMetrics handles /metrics/user/{name}: the person's Steam and OSRS (vanilla) metrics,
+// labelled with the user so one dashboard variable selects all of them
+func (h *Handlers) HandleUserMetrics(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	name := chi.URLParam(r, "name")
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"user":   name,
+		"ip":     r.RemoteAddr,
+	}).Info("User metrics request received")
+
+	if h.options.Users == nil {
+		http.Error(w, "User metrics are not enabled", http.StatusNotFound)
+		return
+	}
+	accounts, ok := h.options.Users.User(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("user %q is not configured - add it to the users section of CONFIG_FILE", name), http.StatusNotFound)
+		return
+	}
+
+	var families []*dto.MetricFamily
+	timedOut, err := h.collectWithTimeout(r, func(ctx context.Context) error {
+		var err error
+		families, err = h.collectUser(ctx, name, accounts)
+		return err
+	})
+	if err != nil {
+		stale := h.serveFailure(w, r, "user", name)
+		logger.FromContext(r.Context()).WithFields(logrus.Fields{
+			"user":     name,
+			"error":    err.Error(),
+			"duration": time.Since(start),
+			"stale":    stale,
+		}).Error("Failed to collect user metrics")
+		return
+	}
+	if timedOut {
+		// The collection still owns families
+		families = nil
+	}
+
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"user":      name,
+		"duration":  time.Since(start),
+		"timed_out": timedOut,
+	}).Info("User metrics collection completed successfully")
+
+	h.serveCollected(w, r, staticGatherer(families), "user", name, timedOut)
+}
+
+// collectUser collects each of the person's accounts and gathers its metrics right away, as
+// the OSRS gauges only hold the last collected player. Accounts that fail are left out; it
+// fails only when all of them do.
+func (h *Handlers) collectUser(ctx context.Context, name string, accounts UserAccounts) ([]*dto.MetricFamily, error) {
+	var collected [][]*dto.MetricFamily
+	var errs []error
+
+	if accounts.SteamID != "" {
+		if h.steamCollector == nil {
+			errs = append(errs, errors.New("Steam collector not initialized - STEAM_KEY not set"))
+		} else if err := h.steamCollector.Collect(ctx, accounts.SteamID); err != nil && !steam.IsPartial(err) {
+			errs = append(errs, fmt.Errorf("steam %s: %w", accounts.SteamID, err))
+		} else if families, err := gatherTarget(steamUserMetrics, "steam_id", accounts.SteamID); err != nil {
+			errs = append(errs, err)
+		} else {
+			collected = append(collected, families)
+		}
+	}
+
+	for _, rsn := range accounts.OSRSPlayers {
+		if err := h.osrsCollector.CollectPlayerStats(ctx, rsn, "vanilla"); err != nil {
+			errs = append(errs, fmt.Errorf("osrs %s: %w", rsn, err))
+			continue
+		}
+		families, err := gatherTarget(osrsMetrics, "player", h.osrsCollector.PlayerLabel(rsn))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		collected = append(collected, families)
+	}
+
+	if len(errs) > 0 {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"user":   name,
+			"errors": errors.Join(errs...).Error(),
+		}).Warn("Some of the user's accounts failed to collect, serving the others")
+	}
+	if len(collected) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return labelFamilies(mergeFamilies(collected), userLabel, name), nil
+}
+
+// gatherTarget gathers the metrics whose label is value, leaving out other targets'
+func gatherTarget(metrics prometheus.Gatherer, label string, value string) ([]*dto.MetricFamily, error) {
+	all, err := metrics.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	var families []*dto.MetricFamily
+	for _, mf := range all {
+		var kept []*dto.Metric
+		for _, m := range mf.Metric {
+			for _, pair := range m.Label {
+				if pair.GetName() == label && pair.GetValue() == value {
+					kept = append(kept, m)
+					break
+				}
+			}
+		}
+		if len(kept) > 0 {
+			families = append(families, &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type, Metric: kept})
+		}
+	}
+	return families, nil
+}
+
+// mergeFamilies combines separately gathered metrics into one family per name
+func mergeFamilies(gathered [][]*dto.MetricFamily) []*dto.MetricFamily {
+	byName := make(map[string]*dto.MetricFamily)
+	for _, families := range gathered {
+		for _, mf := range families {
+			if merged, ok := byName[mf.GetName()]; ok {
+				merged.Metric = append(merged.Metric, mf.Metric...)
+				continue
+			}
+			byName[mf.GetName()] = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type, Metric: append([]*dto.Metric{}, mf.Metric...)}
+		}
+	}
+
+	merged := make([]*dto.MetricFamily, 0, len(byName))
+	for _, mf := range byName {
+		merged = append(merged, mf)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].GetName() < merged[j].GetName()
+	})
+	return merged
+}
+
+// labelFamilies adds a label to every metric, replacing the metrics so the gathered ones
+// aren't modified
+func labelFamilies(families []*dto.MetricFamily, name string, value string) []*dto.MetricFamily {
+	for _, mf := range families {
+		for i, m := range mf.Metric {
+			labels := make([]*dto.LabelPair, 0, len(m.Label)+1)
+			labels = append(labels, m.Label...)
+			labels = append(labels, &dto.LabelPair{Name: &name, Value: &value})
+			sort.Slice(labels, func(i, j int) bool {
+				return labels[i].GetName() < labels[j].GetName()
+			})
+			mf.Metric[i] = &dto.Metric{
+				Label:       labels,
+				Gauge:       m.Gauge,
+				Counter:     m.Counter,
+				Untyped:     m.Untyped,
+				Summary:     m.Summary,
+				Histogram:   m.Histogram,
+				TimestampMs: m.TimestampMs,
+			}
+		}
+	}
+	return families
+}
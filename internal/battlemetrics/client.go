@@ -0,0 +1,125 @@
+package battlemetrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	APIOrigin       = "https://api.battlemetrics.com"
+	ServerEndpoint  = "/servers/%s"
+	OutagesEndpoint = "/servers/%s/relationships/outages"
+)
+
+var (
+	// ErrUnauthorized is returned when BattleMetrics rejects the API token
+	ErrUnauthorized = errors.New("battlemetrics: API token rejected")
+	// errNotFound is returned for a server ID BattleMetrics doesn't know
+	errNotFound = errors.New("battlemetrics: server not found")
+)
+
+type Client struct {
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a BattleMetrics API client; the token is optional (anonymous clients get
+// a lower rate limit). transport is the upstream transport (e.g. fixture replay), nil for the default
+func NewClient(token string, transport http.RoundTripper) *Client {
+	return &Client{
+		token: token,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// GetServer retrieves a server's name, population, rank and status
+func (c *Client) GetServer(ctx context.Context, serverId string) (server Server, err error) {
+	ctx, span := tracing.Start(ctx, "battlemetrics.api", attribute.String("battlemetrics.endpoint", "server"), attribute.String("battlemetrics.server_id", serverId))
+	defer func() { tracing.End(span, err) }()
+
+	var resp serverResponse
+	if err := c.get(ctx, fmt.Sprintf(ServerEndpoint, neturl.PathEscape(serverId)), &resp); err != nil {
+		return Server{}, err
+	}
+	attributes := resp.Data.Attributes
+	return Server{
+		ID:         resp.Data.ID,
+		Name:       attributes.Name,
+		Game:       resp.Data.Relationships.Game.Data.ID,
+		Players:    attributes.Players,
+		MaxPlayers: attributes.MaxPlayers,
+		Rank:       attributes.Rank,
+		Status:     attributes.Status,
+	}, nil
+}
+
+// GetOutages retrieves a server's most recent outages
+func (c *Client) GetOutages(ctx context.Context, serverId string) (outages []Outage, err error) {
+	ctx, span := tracing.Start(ctx, "battlemetrics.api", attribute.String("battlemetrics.endpoint", "outages"), attribute.String("battlemetrics.server_id", serverId))
+	defer func() { tracing.End(span, err) }()
+
+	var resp outagesResponse
+	if err := c.get(ctx, fmt.Sprintf(OutagesEndpoint, neturl.PathEscape(serverId)), &resp); err != nil {
+		return nil, err
+	}
+	outages = make([]Outage, 0, len(resp.Data))
+	for _, outage := range resp.Data {
+		outages = append(outages, outage.Attributes)
+	}
+	return outages, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	if err := cache.CheckUpstream(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", APIOrigin+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return errNotFound
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited by BattleMetrics (%d)", resp.StatusCode)
+	default:
+		return fmt.Errorf("unexpected status code %d from BattleMetrics", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
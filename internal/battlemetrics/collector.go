@@ -0,0 +1,143 @@
+package battlemetrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BattleMetrics refreshes populations every few minutes, like the OSRS world list
+	serverTTL = 2 * time.Minute
+	// Outages are only needed for the time of the last restart
+	outagesTTL = 15 * time.Minute
+)
+
+// Config configures the BattleMetrics collector
+type Config struct {
+	Token     string            // API token; optional, raises the rate limit
+	ServerIDs []string          // BattleMetrics IDs of the servers to report
+	Transport http.RoundTripper // Upstream transport, e.g. fixture replay; nil for the default
+}
+
+// Collector reports the population, rank and uptime of game servers tracked by BattleMetrics
+type Collector struct {
+	client    *Client
+	serverIds []string
+	cache     *cache.Cache
+}
+
+func NewCollector(config Config, cache *cache.Cache) *Collector {
+	return &Collector{
+		client:    NewClient(config.Token, config.Transport),
+		serverIds: config.ServerIDs,
+		cache:     cache,
+	}
+}
+
+// Collect reports every configured server; servers BattleMetrics doesn't know are skipped
+func (c *Collector) Collect(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "battlemetrics.collect")
+	defer func() { tracing.End(span, err) }()
+
+	reported := 0
+	for _, serverId := range c.serverIds {
+		server, err := c.Server(ctx, serverId)
+		if err != nil {
+			return err
+		}
+		if server == nil {
+			logger.FromContext(ctx).WithField("server_id", serverId).Warn("BattleMetrics server not found")
+			DeleteServer(serverId)
+			continue
+		}
+
+		// The uptime runs from the end of the last outage; it's 0 while down and unknown for
+		// a server never seen down
+		var uptime *time.Duration
+		if !server.Online() {
+			zero := time.Duration(0)
+			uptime = &zero
+		} else {
+			outages, err := c.Outages(ctx, serverId)
+			if err != nil {
+				return err
+			}
+			var since time.Time
+			for _, outage := range outages {
+				if outage.Stop != nil && outage.Stop.After(since) {
+					since = *outage.Stop
+				}
+			}
+			if !since.IsZero() {
+				up := time.Since(since)
+				uptime = &up
+			}
+		}
+
+		ReportServer(serverId, *server, uptime)
+		reported++
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"servers": reported,
+		"missing": len(c.serverIds) - reported,
+	}).Info("Completed BattleMetrics metrics collection")
+	return nil
+}
+
+// Server returns a server from the cache, fetching on a miss; nil when BattleMetrics doesn't
+// know the ID (which is cached too)
+func (c *Collector) Server(ctx context.Context, serverId string) (*Server, error) {
+	cacheKey := fmt.Sprintf("battlemetrics:server:%s", serverId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, serverTTL, serverTTL, func(ctx context.Context) ([]byte, error) {
+		server, err := c.client.GetServer(ctx, serverId)
+		if errors.Is(err, errNotFound) {
+			return json.Marshal(nil)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server: %w", err)
+		}
+		return json.Marshal(server)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var server *Server
+	if err := json.Unmarshal(data, &server); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached server: %w", err)
+	}
+	return server, nil
+}
+
+// Outages returns a server's recent outages from the cache, fetching on a miss
+func (c *Collector) Outages(ctx context.Context, serverId string) ([]Outage, error) {
+	cacheKey := fmt.Sprintf("battlemetrics:outages:%s", serverId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, outagesTTL, outagesTTL, func(ctx context.Context) ([]byte, error) {
+		outages, err := c.client.GetOutages(ctx, serverId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get outages: %w", err)
+		}
+		return json.Marshal(outages)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var outages []Outage
+	if err := json.Unmarshal(data, &outages); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached outages: %w", err)
+	}
+	return outages, nil
+}
@@ -0,0 +1,80 @@
+package battlemetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestCollector(t *testing.T, srv *testserver.Server, config Config) *Collector {
+	t.Helper()
+	config.Transport = srv.Transport()
+	return NewCollector(config, testserver.NewCache(t))
+}
+
+func TestCollect(t *testing.T) {
+	srv := testserver.New(t)
+	restarted := time.Now().Add(-3 * time.Hour)
+	srv.AddBattleMetricsServer("1001", testserver.BattleMetricsServer{
+		Name: "Rustoria US Main", Game: "rust", Players: 180, MaxPlayers: 200, Rank: 12, Status: "online",
+		Outages: []testserver.BattleMetricsOutage{
+			{Start: restarted.Add(-10 * time.Minute), Stop: restarted},
+			{Start: restarted.Add(-48 * time.Hour), Stop: restarted.Add(-47 * time.Hour)},
+		},
+	})
+	srv.AddBattleMetricsServer("1002", testserver.BattleMetricsServer{
+		Name: "DayZ Underground", Game: "dayz", MaxPlayers: 60, Status: "offline",
+		Outages: []testserver.BattleMetricsOutage{{Start: time.Now().Add(-time.Hour)}},
+	})
+	srv.AddBattleMetricsServer("1003", testserver.BattleMetricsServer{Name: "New Ark", Game: "ark", Players: 5, MaxPlayers: 70, Status: "online"})
+	collector := newTestCollector(t, srv, Config{ServerIDs: []string{"1001", "1002", "1003", "9999"}})
+	ctx := context.Background()
+
+	// An unknown server is skipped rather than failing the others
+	if err := collector.Collect(ctx); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(playersGauge.WithLabelValues("1001", "Rustoria US Main", "rust")); got != 180 {
+		t.Errorf("players = %v, want 180", got)
+	}
+	if got := testutil.ToFloat64(rankGauge.WithLabelValues("1001", "Rustoria US Main", "rust")); got != 12 {
+		t.Errorf("rank = %v, want 12", got)
+	}
+	uptime := testutil.ToFloat64(uptimeGauge.WithLabelValues("1001", "Rustoria US Main", "rust"))
+	if want := (3 * time.Hour).Seconds(); uptime < want || uptime > want+60 {
+		t.Errorf("uptime = %v, want about %v (since the last outage ended)", uptime, want)
+	}
+
+	// A server that's down reports 0 uptime without fetching outages
+	if got := testutil.ToFloat64(upGauge.WithLabelValues("1002", "DayZ Underground", "dayz")); got != 0 {
+		t.Errorf("up = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(uptimeGauge.WithLabelValues("1002", "DayZ Underground", "dayz")); got != 0 {
+		t.Errorf("uptime while down = %v, want 0", got)
+	}
+	if got := srv.Requests("/servers/1002/relationships/outages"); got != 0 {
+		t.Errorf("outage requests for a down server = %d, want 0", got)
+	}
+
+	// Unranked and never-down servers leave those series out
+	if got := testutil.CollectAndCount(rankGauge); got != 1 {
+		t.Errorf("reported %d rank series, want 1", got)
+	}
+	if got := testutil.CollectAndCount(uptimeGauge); got != 2 {
+		t.Errorf("reported %d uptime series, want 2", got)
+	}
+
+	// Servers and the unknown ID are served from the cache
+	if err := collector.Collect(ctx); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/servers/1001"); got != 1 {
+		t.Errorf("server requests = %d, want 1", got)
+	}
+	if got := srv.Requests("/servers/9999"); got != 1 {
+		t.Errorf("unknown server requests = %d, want 1", got)
+	}
+}
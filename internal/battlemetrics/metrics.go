@@ -0,0 +1,82 @@
+package battlemetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	playersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "battlemetrics",
+		Subsystem: "server",
+		Name:      "players",
+		Help:      "Players on a game server",
+	}, []string{"server_id", "name", "game"})
+
+	maxPlayersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "battlemetrics",
+		Subsystem: "server",
+		Name:      "max_players",
+		Help:      "Player slots of a game server",
+	}, []string{"server_id", "name", "game"})
+
+	rankGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "battlemetrics",
+		Subsystem: "server",
+		Name:      "rank",
+		Help:      "A game server's BattleMetrics rank among its game's servers (1 is the most popular); unranked servers are left out",
+	}, []string{"server_id", "name", "game"})
+
+	upGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "battlemetrics",
+		Subsystem: "server",
+		Name:      "up",
+		Help:      "Whether BattleMetrics last saw a game server online (1) or not (0)",
+	}, []string{"server_id", "name", "game"})
+
+	uptimeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "battlemetrics",
+		Subsystem: "server",
+		Name:      "uptime_seconds",
+		Help:      "Time since a game server's last outage ended (in seconds), 0 while it's down; left out for servers never seen down",
+	}, []string{"server_id", "name", "game"})
+
+	serverGauges = []*prometheus.GaugeVec{playersGauge, maxPlayersGauge, rankGauge, upGauge, uptimeGauge}
+)
+
+func init() {
+	prometheus.MustRegister(playersGauge)
+	prometheus.MustRegister(maxPlayersGauge)
+	prometheus.MustRegister(rankGauge)
+	prometheus.MustRegister(upGauge)
+	prometheus.MustRegister(uptimeGauge)
+}
+
+// ReportServer replaces the reported state of a server, so a renamed server doesn't leave its
+// old series behind; uptime is nil when unknown
+func ReportServer(serverId string, server Server, uptime *time.Duration) {
+	DeleteServer(serverId)
+
+	labels := prometheus.Labels{"server_id": serverId, "name": server.Name, "game": server.Game}
+	playersGauge.With(labels).Set(float64(server.Players))
+	maxPlayersGauge.With(labels).Set(float64(server.MaxPlayers))
+	if server.Rank > 0 {
+		rankGauge.With(labels).Set(float64(server.Rank))
+	}
+	if server.Online() {
+		upGauge.With(labels).Set(1)
+	} else {
+		upGauge.With(labels).Set(0)
+	}
+	if uptime != nil {
+		uptimeGauge.With(labels).Set(uptime.Seconds())
+	}
+}
+
+// DeleteServer removes the reported state of a server
+func DeleteServer(serverId string) {
+	for _, gauge := range serverGauges {
+		gauge.DeletePartialMatch(prometheus.Labels{"server_id": serverId})
+	}
+}
@@ -0,0 +1,53 @@
+package battlemetrics
+
+import "time"
+
+// serverResponse is the JSON:API document of GET /servers/{id}
+type serverResponse struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Name       string `json:"name"`
+			Players    int    `json:"players"`
+			MaxPlayers int    `json:"maxPlayers"`
+			Rank       int    `json:"rank"` // 0 when unranked
+			Status     string `json:"status"`
+		} `json:"attributes"`
+		Relationships struct {
+			Game struct {
+				Data struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			} `json:"game"`
+		} `json:"relationships"`
+	} `json:"data"`
+}
+
+// outagesResponse is the JSON:API document of GET /servers/{id}/relationships/outages
+type outagesResponse struct {
+	Data []struct {
+		Attributes Outage `json:"attributes"`
+	} `json:"data"`
+}
+
+// Server is a game server's current state
+type Server struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Game       string `json:"game"` // BattleMetrics game ID, e.g. rust, dayz or ark
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"max_players"`
+	Rank       int    `json:"rank"`
+	Status     string `json:"status"` // online, offline or dead
+}
+
+// Online reports whether BattleMetrics last saw the server up
+func (s Server) Online() bool {
+	return s.Status == "online"
+}
+
+// Outage is a period BattleMetrics saw a server down; Stop is nil while it's still down
+type Outage struct {
+	Start time.Time  `json:"start"`
+	Stop  *time.Time `json:"stop"`
+}
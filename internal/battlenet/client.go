@@ -0,0 +1,174 @@
+package battlenet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// TokenURL issues access tokens for a client credentials grant, for every region but China
+	TokenURL = "https://oauth.battle.net/token"
+	// APIOriginFormat is a region's community API origin ({region}: us, eu, kr or tw)
+	APIOriginFormat = "https://%s.api.blizzard.com"
+
+	D3ProfileEndpoint  = "/d3/profile/%s/"
+	SC2ProfileEndpoint = "/sc2/profile/%s/%s/%s"
+)
+
+var (
+	// ErrUnauthorized is returned when Blizzard rejects the client credentials
+	ErrUnauthorized = errors.New("battlenet: client credentials rejected")
+	// ErrProfileNotFound is returned for a profile Blizzard doesn't know (or that is private)
+	ErrProfileNotFound = errors.New("battlenet: profile not found")
+)
+
+type Client struct {
+	clientID     string
+	clientSecret string
+	origin       string
+	httpClient   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time // When the access token must be renewed
+}
+
+// NewClient creates a client for a region's community APIs, authenticating with an API
+// client's credentials; transport is the upstream transport (e.g. fixture replay), nil for the default
+func NewClient(clientID string, clientSecret string, region string, transport http.RoundTripper) *Client {
+	return &Client{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		origin:       fmt.Sprintf(APIOriginFormat, region),
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// GetD3Profile retrieves a Diablo III career profile by BattleTag (Name-1234)
+func (c *Client) GetD3Profile(ctx context.Context, battleTag string) (D3Profile, error) {
+	var profile D3Profile
+	url := c.origin + fmt.Sprintf(D3ProfileEndpoint, neturl.PathEscape(battleTag)) + "?locale=en_US"
+	if err := c.getJSON(ctx, url, &profile); err != nil {
+		return D3Profile{}, err
+	}
+	return profile, nil
+}
+
+// GetSC2Profile retrieves a StarCraft II profile by its region, realm and profile IDs
+func (c *Client) GetSC2Profile(ctx context.Context, regionId string, realmId string, profileId string) (SC2Profile, error) {
+	var profile SC2Profile
+	url := c.origin + fmt.Sprintf(SC2ProfileEndpoint, regionId, realmId, profileId) + "?locale=en_US"
+	if err := c.getJSON(ctx, url, &profile); err != nil {
+		return SC2Profile{}, err
+	}
+	return profile, nil
+}
+
+// accessToken returns a valid access token, requesting a new one when the current one is
+// about to expire. Tokens last a day, so this is rarely more than one request per day.
+func (c *Client) accessToken(ctx context.Context) (token string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "battlenet.token")
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", TokenURL, strings.NewReader("grant_type=client_credentials"))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+	var resp tokenResponse
+	if err := c.do(req, &resp); err != nil {
+		if errors.Is(err, ErrProfileNotFound) {
+			return "", fmt.Errorf("failed to get access token: unexpected 404")
+		}
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	c.token = resp.AccessToken
+	// Renew a minute early so a token doesn't expire mid-collection
+	c.expires = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, target interface{}) (err error) {
+	endpoint := strings.SplitN(strings.TrimPrefix(url, c.origin), "?", 2)[0]
+	ctx, span := tracing.Start(ctx, "battlenet.api", attribute.String("battlenet.endpoint", endpoint))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return err
+	}
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	err = c.do(req, target)
+	if errors.Is(err, ErrUnauthorized) {
+		// The token may have been revoked early; request a new one on the next request
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func (c *Client) do(req *http.Request, target interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrProfileNotFound
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited by Blizzard (%d)", resp.StatusCode)
+	default:
+		return fmt.Errorf("unexpected status code %d from Blizzard", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package battlenet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Profiles update after each game, but are read from a cache refreshed by Blizzard every few
+// hours anyway
+const profileTTL = time.Hour
+
+var (
+	// ErrUnknownGame is returned when collecting a game without a profile API. Overwatch has
+	// none: its career profiles are only shown on the website.
+	ErrUnknownGame = errors.New("unknown Battle.net game (supported: d3, sc2)")
+	// ErrInvalidProfile is returned for a profile that isn't a BattleTag (d3) or a
+	// region-realm-profile ID triple (sc2)
+	ErrInvalidProfile = errors.New("invalid Battle.net profile")
+)
+
+var (
+	battleTagPattern  = regexp.MustCompile(`^[^#-]+[#-][0-9]+$`)
+	sc2ProfilePattern = regexp.MustCompile(`^([0-9]+)-([0-9]+)-([0-9]+)$`)
+)
+
+// Config configures the Battle.net collector
+type Config struct {
+	ClientID     string // API client from the Blizzard developer portal
+	ClientSecret string
+	Region       string            // us, eu, kr or tw
+	Transport    http.RoundTripper // Upstream transport, e.g. fixture replay; nil for the default
+}
+
+// Collector reports Diablo III and StarCraft II profile stats from Blizzard's community APIs
+type Collector struct {
+	client *Client
+	region string
+	cache  *cache.Cache
+}
+
+func NewCollector(config Config, cache *cache.Cache) *Collector {
+	region := config.Region
+	if region == "" {
+		region = "us"
+	}
+	return &Collector{
+		client: NewClient(config.ClientID, config.ClientSecret, region, config.Transport),
+		region: region,
+		cache:  cache,
+	}
+}
+
+// Collect reports a game's profile stats: game is d3 (profile is a BattleTag, Name-1234) or
+// sc2 (profile is region-realm-profile, as in the profile's URL)
+func (c *Collector) Collect(ctx context.Context, game string, profile string) (err error) {
+	ctx, span := tracing.Start(ctx, "battlenet.collect", attribute.String("battlenet.game", game), attribute.String("battlenet.profile", profile))
+	defer func() { tracing.End(span, err) }()
+
+	switch game {
+	case "d3":
+		if !battleTagPattern.MatchString(profile) {
+			return fmt.Errorf("%w: %q is not a BattleTag (Name-1234)", ErrInvalidProfile, profile)
+		}
+		battleTag := strings.Replace(profile, "#", "-", 1)
+		d3, err := c.D3Profile(ctx, battleTag)
+		if err != nil {
+			return err
+		}
+		ReportD3Profile(d3, battleTag)
+	case "sc2":
+		sc2, err := c.SC2Profile(ctx, profile)
+		if err != nil {
+			return err
+		}
+		ReportSC2Profile(sc2, profile)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownGame, game)
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"game":    game,
+		"profile": profile,
+	}).Info("Completed Battle.net metrics collection")
+	return nil
+}
+
+// D3Profile returns a Diablo III profile from the cache, fetching on a miss
+func (c *Collector) D3Profile(ctx context.Context, battleTag string) (D3Profile, error) {
+	var profile D3Profile
+	err := c.cachedProfile(ctx, fmt.Sprintf("bnet:d3:%s:%s", c.region, strings.ToLower(battleTag)), &profile, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetD3Profile(ctx, battleTag)
+	})
+	return profile, err
+}
+
+// SC2Profile returns a StarCraft II profile (region-realm-profile) from the cache, fetching on a miss
+func (c *Collector) SC2Profile(ctx context.Context, id string) (SC2Profile, error) {
+	parts := sc2ProfilePattern.FindStringSubmatch(id)
+	if parts == nil {
+		return SC2Profile{}, fmt.Errorf("%w: %q is not a region-realm-profile ID (e.g. 1-1-123456)", ErrInvalidProfile, id)
+	}
+	var profile SC2Profile
+	err := c.cachedProfile(ctx, fmt.Sprintf("bnet:sc2:%s:%s", c.region, id), &profile, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetSC2Profile(ctx, parts[1], parts[2], parts[3])
+	})
+	return profile, err
+}
+
+// cachedProfile decodes the profile cached under cacheKey into target, fetching it on a miss
+func (c *Collector) cachedProfile(ctx context.Context, cacheKey string, target interface{}, fetch func(ctx context.Context) (interface{}, error)) error {
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, profileTTL, profileTTL, func(ctx context.Context) ([]byte, error) {
+		profile, err := fetch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get profile: %w", err)
+		}
+		return json.Marshal(profile)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return fmt.Errorf("failed to decode cached profile: %w", err)
+	}
+	return nil
+}
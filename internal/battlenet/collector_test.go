@@ -0,0 +1,102 @@
+package battlenet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestCollector(t *testing.T, srv *testserver.Server, clientSecret string) *Collector {
+	t.Helper()
+	return NewCollector(Config{ClientID: "client", ClientSecret: clientSecret, Transport: srv.Transport()}, testserver.NewCache(t))
+}
+
+func TestCollectD3(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetBattleNetClient("client", "secret")
+	srv.AddD3Profile("Nephalem-1234", testserver.D3Profile{
+		ParagonLevel: 812,
+		MonsterKills: 250000,
+		Heroes: []testserver.D3Hero{
+			{ID: 1, Name: "Valla", Class: "demon-hunter", Level: 70},
+			{ID: 2, Name: "Li", Class: "monk", Level: 42, Hardcore: true},
+		},
+	})
+	collector := newTestCollector(t, srv, "secret")
+	ctx := context.Background()
+
+	// A BattleTag is accepted with its # too
+	if err := collector.Collect(ctx, "d3", "Nephalem#1234"); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(d3ParagonLevelGauge.WithLabelValues("Nephalem-1234", "normal")); got != 812 {
+		t.Errorf("paragon level = %v, want 812", got)
+	}
+	if got := testutil.ToFloat64(d3KillsGauge.WithLabelValues("Nephalem-1234", "monsters")); got != 250000 {
+		t.Errorf("monster kills = %v, want 250000", got)
+	}
+	if got := testutil.ToFloat64(d3HeroLevelGauge.WithLabelValues("Nephalem-1234", "2", "Li", "monk", "true", "false", "false")); got != 42 {
+		t.Errorf("Li's level = %v, want 42", got)
+	}
+
+	// A second collection is served from the cache with the same access token
+	if err := collector.Collect(ctx, "d3", "Nephalem-1234"); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/d3/profile/Nephalem-1234/"); got != 1 {
+		t.Errorf("profile requests = %d, want 1", got)
+	}
+	if got := srv.Requests("/token"); got != 1 {
+		t.Errorf("token requests = %d, want 1", got)
+	}
+}
+
+func TestCollectSC2(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetBattleNetClient("client", "secret")
+	srv.AddSC2Profile("1-1-123456", testserver.SC2Profile{
+		Name:        "Raynor",
+		SwarmLevels: map[string]int{"terran": 30, "zerg": 12, "protoss": 8},
+		Wins:        map[string]int{"terran": 140},
+		Games:       410,
+	})
+	collector := newTestCollector(t, srv, "secret")
+
+	if err := collector.Collect(context.Background(), "sc2", "1-1-123456"); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(sc2SwarmLevelGauge.WithLabelValues("1-1-123456", "Raynor", "total")); got != 50 {
+		t.Errorf("total swarm level = %v, want 50", got)
+	}
+	if got := testutil.ToFloat64(sc2WinsGauge.WithLabelValues("1-1-123456", "Raynor", "terran")); got != 140 {
+		t.Errorf("terran wins = %v, want 140", got)
+	}
+	if got := testutil.ToFloat64(sc2GamesGauge.WithLabelValues("1-1-123456", "Raynor", "career")); got != 410 {
+		t.Errorf("career games = %v, want 410", got)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetBattleNetClient("client", "secret")
+	ctx := context.Background()
+
+	collector := newTestCollector(t, srv, "secret")
+	if err := collector.Collect(ctx, "ow", "Tracer-1234"); !errors.Is(err, ErrUnknownGame) {
+		t.Errorf("Collect of Overwatch = %v, want ErrUnknownGame", err)
+	}
+	if err := collector.Collect(ctx, "sc2", "Raynor"); !errors.Is(err, ErrInvalidProfile) {
+		t.Errorf("Collect of a malformed SC2 profile = %v, want ErrInvalidProfile", err)
+	}
+	if err := collector.Collect(ctx, "d3", "Unknown-1"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Collect of an unknown profile = %v, want ErrProfileNotFound", err)
+	}
+
+	rejected := newTestCollector(t, srv, "wrong")
+	if err := rejected.Collect(ctx, "d3", "Nephalem-1234"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Collect with rejected credentials = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,134 @@
+package battlenet
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	d3ParagonLevelGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "d3",
+		Name:      "paragon_level",
+		Help:      "Diablo III paragon level by mode (normal, hardcore, season, season_hardcore)",
+	}, []string{"battletag", "mode"})
+
+	d3KillsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "d3",
+		Name:      "kills",
+		Help:      "Diablo III career kills by type (monsters, elites, hardcore_monsters)",
+	}, []string{"battletag", "type"})
+
+	d3HeroLevelGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "d3",
+		Name:      "hero_level",
+		Help:      "Level of a Diablo III hero",
+	}, []string{"battletag", "hero_id", "hero", "class", "hardcore", "seasonal", "dead"})
+
+	d3TimePlayedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "d3",
+		Name:      "time_played_ratio",
+		Help:      "Diablo III time played as a class, relative to the most played class (1)",
+	}, []string{"battletag", "class"})
+
+	sc2SwarmLevelGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "sc2",
+		Name:      "swarm_level",
+		Help:      "StarCraft II level by race (terran, zerg, protoss), and their total",
+	}, []string{"profile", "name", "race"})
+
+	sc2WinsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "sc2",
+		Name:      "wins",
+		Help:      "StarCraft II career wins by race",
+	}, []string{"profile", "name", "race"})
+
+	sc2GamesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "sc2",
+		Name:      "games",
+		Help:      "StarCraft II games played by period (career, season)",
+	}, []string{"profile", "name", "period"})
+
+	sc2AchievementPointsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bnet",
+		Subsystem: "sc2",
+		Name:      "achievement_points",
+		Help:      "StarCraft II achievement points",
+	}, []string{"profile", "name"})
+)
+
+func init() {
+	prometheus.MustRegister(d3ParagonLevelGauge)
+	prometheus.MustRegister(d3KillsGauge)
+	prometheus.MustRegister(d3HeroLevelGauge)
+	prometheus.MustRegister(d3TimePlayedGauge)
+	prometheus.MustRegister(sc2SwarmLevelGauge)
+	prometheus.MustRegister(sc2WinsGauge)
+	prometheus.MustRegister(sc2GamesGauge)
+	prometheus.MustRegister(sc2AchievementPointsGauge)
+}
+
+// ReportD3Profile replaces the reported stats of a Diablo III profile, so deleted heroes drop off
+func ReportD3Profile(profile D3Profile, battleTag string) {
+	labels := prometheus.Labels{"battletag": battleTag}
+	d3ParagonLevelGauge.DeletePartialMatch(labels)
+	d3KillsGauge.DeletePartialMatch(labels)
+	d3HeroLevelGauge.DeletePartialMatch(labels)
+	d3TimePlayedGauge.DeletePartialMatch(labels)
+
+	d3ParagonLevelGauge.WithLabelValues(battleTag, "normal").Set(float64(profile.ParagonLevel))
+	d3ParagonLevelGauge.WithLabelValues(battleTag, "hardcore").Set(float64(profile.ParagonLevelHardcore))
+	d3ParagonLevelGauge.WithLabelValues(battleTag, "season").Set(float64(profile.ParagonLevelSeason))
+	d3ParagonLevelGauge.WithLabelValues(battleTag, "season_hardcore").Set(float64(profile.ParagonLevelSeasonHardcore))
+
+	d3KillsGauge.WithLabelValues(battleTag, "monsters").Set(float64(profile.Kills.Monsters))
+	d3KillsGauge.WithLabelValues(battleTag, "elites").Set(float64(profile.Kills.Elites))
+	d3KillsGauge.WithLabelValues(battleTag, "hardcore_monsters").Set(float64(profile.Kills.HardcoreMonsters))
+
+	for _, hero := range profile.Heroes {
+		d3HeroLevelGauge.With(prometheus.Labels{
+			"battletag": battleTag,
+			"hero_id":   strconv.FormatInt(hero.ID, 10),
+			"hero":      hero.Name,
+			"class":     hero.Class,
+			"hardcore":  strconv.FormatBool(hero.Hardcore),
+			"seasonal":  strconv.FormatBool(hero.Seasonal),
+			"dead":      strconv.FormatBool(hero.Dead),
+		}).Set(float64(hero.Level))
+	}
+
+	for class, ratio := range profile.TimePlayed {
+		d3TimePlayedGauge.WithLabelValues(battleTag, class).Set(ratio)
+	}
+}
+
+// ReportSC2Profile replaces the reported stats of a StarCraft II profile
+func ReportSC2Profile(profile SC2Profile, id string) {
+	labels := prometheus.Labels{"profile": id}
+	sc2SwarmLevelGauge.DeletePartialMatch(labels)
+	sc2WinsGauge.DeletePartialMatch(labels)
+	sc2GamesGauge.DeletePartialMatch(labels)
+	sc2AchievementPointsGauge.DeletePartialMatch(labels)
+
+	name := profile.Summary.DisplayName
+	sc2SwarmLevelGauge.WithLabelValues(id, name, "total").Set(float64(profile.Summary.TotalSwarmLevel))
+	sc2SwarmLevelGauge.WithLabelValues(id, name, "terran").Set(float64(profile.SwarmLevels.Terran.Level))
+	sc2SwarmLevelGauge.WithLabelValues(id, name, "zerg").Set(float64(profile.SwarmLevels.Zerg.Level))
+	sc2SwarmLevelGauge.WithLabelValues(id, name, "protoss").Set(float64(profile.SwarmLevels.Protoss.Level))
+
+	sc2WinsGauge.WithLabelValues(id, name, "terran").Set(float64(profile.Career.TerranWins))
+	sc2WinsGauge.WithLabelValues(id, name, "zerg").Set(float64(profile.Career.ZergWins))
+	sc2WinsGauge.WithLabelValues(id, name, "protoss").Set(float64(profile.Career.ProtossWins))
+
+	sc2GamesGauge.WithLabelValues(id, name, "career").Set(float64(profile.Career.TotalCareerGames))
+	sc2GamesGauge.WithLabelValues(id, name, "season").Set(float64(profile.Career.TotalGamesThisSeason))
+
+	sc2AchievementPointsGauge.WithLabelValues(id, name).Set(float64(profile.Summary.TotalAchievementPoints))
+}
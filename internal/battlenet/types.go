@@ -0,0 +1,61 @@
+package battlenet
+
+// tokenResponse is the OAuth server's answer to a client credentials grant
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"` // Seconds
+}
+
+// D3Profile is a Diablo III career profile
+type D3Profile struct {
+	BattleTag                  string             `json:"battleTag"`
+	ParagonLevel               int                `json:"paragonLevel"`
+	ParagonLevelHardcore       int                `json:"paragonLevelHardcore"`
+	ParagonLevelSeason         int                `json:"paragonLevelSeason"`
+	ParagonLevelSeasonHardcore int                `json:"paragonLevelSeasonHardcore"`
+	Heroes                     []D3Hero           `json:"heroes"`
+	Kills                      D3Kills            `json:"kills"`
+	TimePlayed                 map[string]float64 `json:"timePlayed"` // Share of playtime by class slug, the most played being 1
+}
+
+// D3Hero is a hero of a Diablo III profile
+type D3Hero struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Class    string `json:"classSlug"`
+	Level    int    `json:"level"`
+	Hardcore bool   `json:"hardcore"`
+	Seasonal bool   `json:"seasonal"`
+	Dead     bool   `json:"dead"`
+}
+
+type D3Kills struct {
+	Monsters         int64 `json:"monsters"`
+	Elites           int64 `json:"elites"`
+	HardcoreMonsters int64 `json:"hardcoreMonsters"`
+}
+
+// SC2Profile is a StarCraft II profile
+type SC2Profile struct {
+	Summary struct {
+		DisplayName            string `json:"displayName"`
+		TotalSwarmLevel        int    `json:"totalSwarmLevel"`
+		TotalAchievementPoints int    `json:"totalAchievementPoints"`
+	} `json:"summary"`
+	Career struct {
+		TerranWins           int `json:"terranWins"`
+		ZergWins             int `json:"zergWins"`
+		ProtossWins          int `json:"protossWins"`
+		TotalCareerGames     int `json:"totalCareerGames"`
+		TotalGamesThisSeason int `json:"totalGamesThisSeason"`
+	} `json:"career"`
+	SwarmLevels struct {
+		Terran  SC2SwarmLevel `json:"terran"`
+		Zerg    SC2SwarmLevel `json:"zerg"`
+		Protoss SC2SwarmLevel `json:"protoss"`
+	} `json:"swarmLevels"`
+}
+
+type SC2SwarmLevel struct {
+	Level int `json:"level"`
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scanBatchSize is the number of keys processed per batch when walking the keyspace
+const scanBatchSize = 500
+
+// PrefixStats summarises the keys sharing a prefix
+type PrefixStats struct {
+	Prefix      string `json:"prefix"`
+	Keys        int64  `json:"keys"`
+	MemoryBytes int64  `json:"memory_bytes"` // Approximate
+}
+
+// Stats summarises the cache contents
+type Stats struct {
+	TotalKeys        int64         `json:"total_keys"`
+	TotalMemoryBytes int64         `json:"total_memory_bytes"`
+	UsedMemoryBytes  int64         `json:"used_memory_bytes"` // Whole backend (Redis INFO memory / file size)
+	Prefixes         []PrefixStats `json:"prefixes"`
+}
+
+// FlushPrefix deletes every key starting with prefix and returns how many were removed
+func (c *Cache) FlushPrefix(ctx context.Context, prefix string) (int64, error) {
+	if prefix == "" {
+		return 0, fmt.Errorf("prefix cannot be empty")
+	}
+
+	var deleted int64
+	batch := make([]string, 0, scanBatchSize)
+	deleteBatch := func() error {
+		n, err := c.backend.Delete(ctx, batch...)
+		if err != nil {
+			return fmt.Errorf("failed to delete keys: %w", err)
+		}
+		deleted += n
+		batch = batch[:0]
+		return nil
+	}
+
+	err := c.backend.Scan(ctx, prefix, func(key string, _ int64) error {
+		batch = append(batch, key)
+		if len(batch) >= scanBatchSize {
+			return deleteBatch()
+		}
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+
+	if len(batch) > 0 {
+		if err := deleteBatch(); err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+// Stats walks the keyspace and reports key counts and approximate memory per prefix.
+// Prefixes are the first two colon-separated segments of a key (e.g. "steam:owned_games").
+func (c *Cache) Stats(ctx context.Context) (Stats, error) {
+	byPrefix := make(map[string]*PrefixStats)
+	var stats Stats
+
+	err := c.backend.Scan(ctx, "", func(key string, size int64) error {
+		prefix := keyPrefix(key)
+		ps, exists := byPrefix[prefix]
+		if !exists {
+			ps = &PrefixStats{Prefix: prefix}
+			byPrefix[prefix] = ps
+		}
+		ps.Keys++
+		ps.MemoryBytes += size
+		stats.TotalKeys++
+		stats.TotalMemoryBytes += size
+		return nil
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats.Prefixes = make([]PrefixStats, 0, len(byPrefix))
+	for _, ps := range byPrefix {
+		stats.Prefixes = append(stats.Prefixes, *ps)
+	}
+	sort.Slice(stats.Prefixes, func(i, j int) bool {
+		return stats.Prefixes[i].Prefix < stats.Prefixes[j].Prefix
+	})
+
+	stats.UsedMemoryBytes = c.backend.UsedMemory(ctx)
+
+	return stats, nil
+}
+
+// keyPrefix groups a key by its first two colon-separated segments
+func keyPrefix(key string) string {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) < 2 {
+		return key
+	}
+	return parts[0] + ":" + parts[1]
+}
+
+// pingKey is written and read back by Ping
+const pingKey = "check:ping"
+
+// Ping checks the backend is reachable and writable with a write/read round trip.
+// Unlike the other cache methods, failures are returned rather than treated as misses.
+func (c *Cache) Ping(ctx context.Context) error {
+	opCtx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	if err := c.backend.Set(opCtx, pingKey, []byte("ok"), time.Minute); err != nil {
+		return fmt.Errorf("cache write failed: %w", err)
+	}
+	if _, _, err := c.backend.GetWithTTL(opCtx, pingKey); err != nil {
+		return fmt.Errorf("cache read failed: %w", err)
+	}
+	if _, err := c.backend.Delete(opCtx, pingKey); err != nil {
+		return fmt.Errorf("cache delete failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrNotFound is returned by backends when a key doesn't exist (or has expired)
+var ErrNotFound = errors.New("cache: key not found")
+
+// Backend is the storage behind Cache. Values passed to and from a backend are
+// already encoded (see codec.go); backends only store bytes with a TTL.
+type Backend interface {
+	// GetWithTTL returns a value and its remaining TTL (-1 if it never expires)
+	GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error)
+	// MGet returns the values for keys that exist, keyed by key
+	MGet(ctx context.Context, keys []string) (map[string][]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) (int64, error)
+	// Scan calls fn for every key starting with prefix, with the approximate memory it uses
+	Scan(ctx context.Context, prefix string, fn func(key string, size int64) error) error
+	// UsedMemory reports the total storage used by the backend, 0 if unknown
+	UsedMemory(ctx context.Context) int64
+	// AcquireLease sets key to owner if it is unset or already owned by owner, refreshing its ttl
+	AcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease deletes key only if it is owned by owner
+	ReleaseLease(ctx context.Context, key string, owner string) error
+	Close() error
+}
+
+const (
+	// BackendRedis stores the cache in Redis (default)
+	BackendRedis = "redis"
+	// BackendFile stores the cache in an embedded BoltDB file, for single-node deployments without Redis
+	BackendFile = "file"
+)
+
+type Cache struct {
+	backend   Backend
+	opTimeout time.Duration
+	compress  bool
+
+	// Keys with a background refresh in flight (see GetOrRefresh)
+	refreshing   map[string]struct{}
+	refreshingMu sync.Mutex
+	refreshes    sync.WaitGroup
+	draining     bool // No new background refreshes once Drain is called
+}
+
+// Options configures the cache backend
+type Options struct {
+	// Backend selects the storage: "redis" (default) or "file"
+	Backend string
+
+	Addr     string
+	Username string // ACL user (Redis 6+), empty for the default user
+	Password string
+	DB       int
+
+	// TLS enables TLS for managed Redis services (Elasticache, Upstash, Azure)
+	TLS           bool
+	TLSSkipVerify bool
+	TLSCAFile     string // Optional PEM bundle used instead of the system roots
+
+	// FilePath is the BoltDB file used by the file backend
+	FilePath string
+
+	// OpTimeout bounds every cache operation; a timed out read is treated as a miss.
+	// Zero disables the per-operation timeout.
+	OpTimeout time.Duration
+
+	// Compress gzips large values before storing them. Reads handle both
+	// compressed and uncompressed values regardless of this setting.
+	Compress bool
+}
+
+// RefreshFunc fetches a fresh value for a key served by GetOrRefresh.
+// Background refreshes get a context detached from the caller's cancellation.
+type RefreshFunc func(ctx context.Context) ([]byte, error)
+
+func New(opts Options) (*Cache, error) {
+	var backend Backend
+	var err error
+
+	switch opts.Backend {
+	case "", BackendRedis:
+		backend, err = newRedisBackend(opts)
+	case BackendFile:
+		backend, err = newFileBackend(opts.FilePath)
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q (supported: %s, %s)", opts.Backend, BackendRedis, BackendFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		backend:    backend,
+		opTimeout:  opts.OpTimeout,
+		compress:   opts.Compress,
+		refreshing: make(map[string]struct{}),
+	}, nil
+}
+
+// Close the cache backend
+func (c *Cache) Close() error {
+	return c.backend.Close()
+}
+
+// Drain stops starting background refreshes and waits for the running ones to write their
+// values, or for ctx to be done, so a shutdown doesn't lose fetched data
+func (c *Cache) Drain(ctx context.Context) error {
+	c.refreshingMu.Lock()
+	c.draining = true
+	c.refreshingMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.refreshes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// opContext derives the context for a single cache operation, applying the operation timeout
+func (c *Cache) opContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opTimeout > 0 {
+		return context.WithTimeout(ctx, c.opTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// logOpError logs a failed cache operation; callers degrade gracefully rather than failing
+func logOpError(op string, key string, err error) {
+	fields := logrus.Fields{
+		"op":    op,
+		"key":   key,
+		"error": err.Error(),
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Log.WithFields(fields).Warn("Cache operation timed out")
+		return
+	}
+	logger.Log.WithFields(fields).Warn("Cache operation failed")
+}
+
+// ========== Generic Cache Methods ==========
+
+// Get retrieves a value from cache by key
+// Errors and timeouts are reported as a miss
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, _, exists := c.getWithTTL(ctx, key)
+	return value, exists
+}
+
+// Set stores a value in cache with TTL
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	ctx, span := tracing.Start(ctx, "cache.set", attribute.String("cache.key", key))
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	data, err := encodeValue(value, c.compress)
+	if err != nil {
+		logOpError("encode", key, err)
+		return
+	}
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	if err = c.backend.Set(ctx, key, data, ttl); err != nil {
+		logOpError("set", key, err)
+	}
+}
+
+// Delete removes a key from cache
+func (c *Cache) Delete(ctx context.Context, key string) {
+	ctx, span := tracing.Start(ctx, "cache.delete", attribute.String("cache.key", key))
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	if _, err = c.backend.Delete(ctx, key); err != nil {
+		logOpError("delete", key, err)
+	}
+}
+
+// MGet retrieves several keys in a single round trip
+// Only keys that exist (and decode cleanly) are present in the returned map
+func (c *Cache) MGet(ctx context.Context, keys ...string) map[string][]byte {
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values
+	}
+
+	ctx, span := tracing.Start(ctx, "cache.mget", attribute.Int("cache.keys", len(keys)))
+	var err error
+	defer func() {
+		span.SetAttributes(attribute.Int("cache.hits", len(values)))
+		tracing.End(span, err)
+	}()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	results, err := c.backend.MGet(ctx, keys)
+	if err != nil {
+		logOpError("mget", fmt.Sprintf("%d keys", len(keys)), err)
+		return values
+	}
+
+	for key, data := range results {
+		value, err := decodeValue(data)
+		if err != nil {
+			logOpError("decode", key, err)
+			continue
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// getWithTTL retrieves a value along with its remaining TTL (-1 if the key has no expiry)
+func (c *Cache) getWithTTL(ctx context.Context, key string) ([]byte, time.Duration, bool) {
+	ctx, span := tracing.Start(ctx, "cache.get", attribute.String("cache.key", key))
+	hit := false
+	var err error
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", hit))
+		tracing.End(span, err)
+	}()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	data, ttl, err := c.backend.GetWithTTL(ctx, key)
+	if err != nil {
+		if err != ErrNotFound {
+			logOpError("get", key, err)
+		} else {
+			// A miss isn't an error for the trace
+			err = nil
+		}
+		return nil, 0, false
+	}
+
+	value, err := decodeValue(data)
+	if err != nil {
+		logOpError("decode", key, err)
+		return nil, 0, false
+	}
+	hit = true
+	return value, ttl, true
+}
+
+// ========== Stale-While-Revalidate ==========
+
+// GetOrRefresh returns the cached value for key, calling refresh on a miss.
+// Values are stored for ttl+staleTTL: once an entry is older than ttl it is still
+// returned immediately, and a single background refresh replaces it so callers
+// never block on an upstream fetch for an entry that merely expired.
+//
+// The context's Policy can skip the cache (PolicyFresh) or upstream (PolicyCachedOnly),
+// and its Freshness records when the returned value was fetched.
+func (c *Cache) GetOrRefresh(ctx context.Context, key string, ttl, staleTTL time.Duration, refresh RefreshFunc) ([]byte, error) {
+	freshness := FreshnessFromContext(ctx)
+	policy := PolicyFromContext(ctx)
+	if policy == PolicyFresh {
+		data, err := refresh(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.Set(ctx, key, data, ttl+staleTTL)
+		freshness.record(time.Now())
+		return data, nil
+	}
+
+	data, remaining, exists := c.getWithTTL(ctx, key)
+	if exists && remaining >= 0 {
+		// The entry was stored for ttl+staleTTL, so the time it has used is its age
+		age := ttl + staleTTL - remaining
+		if age < 0 {
+			age = 0
+		}
+		freshness.record(time.Now().Add(-age))
+	}
+	if policy == PolicyCachedOnly {
+		if !exists {
+			return nil, ErrNotCached
+		}
+		return data, nil
+	}
+	if exists {
+		// Remaining TTL at or below the stale window means the fresh period is over
+		if remaining >= 0 && remaining <= staleTTL {
+			c.refreshInBackground(ctx, key, ttl, staleTTL, refresh)
+		}
+		return data, nil
+	}
+
+	data, err := refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(ctx, key, data, ttl+staleTTL)
+	freshness.record(time.Now())
+	return data, nil
+}
+
+// refreshInBackground runs refresh for key unless one is already in flight
+func (c *Cache) refreshInBackground(ctx context.Context, key string, ttl, staleTTL time.Duration, refresh RefreshFunc) {
+	c.refreshingMu.Lock()
+	if _, inFlight := c.refreshing[key]; inFlight || c.draining {
+		c.refreshingMu.Unlock()
+		return
+	}
+	c.refreshing[key] = struct{}{}
+	c.refreshes.Add(1)
+	c.refreshingMu.Unlock()
+
+	// The refresh outlives the request that triggered it, so it runs detached from its
+	// cancellation (but keeps its request ID and trace)
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			c.refreshingMu.Lock()
+			delete(c.refreshing, key)
+			c.refreshingMu.Unlock()
+			c.refreshes.Done()
+		}()
+
+		data, err := refresh(ctx)
+		if err != nil {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"key":   key,
+				"error": err.Error(),
+			}).Warn("Background cache refresh failed, continuing to serve stale value")
+			return
+		}
+		c.Set(ctx, key, data, ttl+staleTTL)
+		logger.FromContext(ctx).WithField("key", key).Debug("Refreshed stale cache entry in background")
+	}()
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Cached values may be stored compressed. Compressed values start with a magic
+// header followed by a format version byte; anything without the header is a
+// legacy raw value (plain JSON written before compression existed) and is
+// returned untouched, so existing Redis data keeps working after an upgrade.
+var valueMagic = []byte{0x00, 'G', 'S', 'E'}
+
+const (
+	// valueVersionGzip marks a gzip-compressed payload
+	valueVersionGzip byte = 1
+
+	// compressMinBytes is the smallest value worth compressing - below this the
+	// gzip header overhead outweighs the savings
+	compressMinBytes = 1024
+)
+
+// encodeValue compresses a value for storage when compression is enabled and worthwhile
+func encodeValue(value []byte, compress bool) ([]byte, error) {
+	if !compress || len(value) < compressMinBytes {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	buf.Write(valueMagic)
+	buf.WriteByte(valueVersionGzip)
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(value); err != nil {
+		return nil, fmt.Errorf("failed to compress value: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress value: %w", err)
+	}
+
+	// Not worth it if compression didn't actually shrink the payload
+	if buf.Len() >= len(value) {
+		return value, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeValue reverses encodeValue, passing legacy uncompressed values through
+func decodeValue(data []byte) ([]byte, error) {
+	if len(data) <= len(valueMagic) || !bytes.HasPrefix(data, valueMagic) {
+		return data, nil
+	}
+
+	version := data[len(valueMagic)]
+	payload := data[len(valueMagic)+1:]
+
+	switch version {
+	case valueVersionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress value: %w", err)
+		}
+		defer zr.Close()
+		value, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress value: %w", err)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unknown cached value version %d", version)
+	}
+}
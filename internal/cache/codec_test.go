@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValueRoundTrip(t *testing.T) {
+	header := append(append([]byte{}, valueMagic...), valueVersionGzip)
+	large := []byte(`{"games":[` + strings.Repeat(`{"appid":440,"name":"Team Fortress 2"},`, 100) + `]}`)
+
+	tests := []struct {
+		name       string
+		value      []byte
+		compress   bool
+		compressed bool
+	}{
+		{name: "large value", value: large, compress: true, compressed: true},
+		{name: "compression disabled", value: large, compress: false},
+		{name: "small value", value: []byte(`{"appid":440}`), compress: true},
+		{name: "empty value", value: []byte{}, compress: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := encodeValue(tt.value, tt.compress)
+			if err != nil {
+				t.Fatalf("encodeValue: %v", err)
+			}
+			if got := bytes.HasPrefix(encoded, header); got != tt.compressed {
+				t.Errorf("compressed = %v, want %v", got, tt.compressed)
+			}
+			if tt.compressed && len(encoded) >= len(tt.value) {
+				t.Errorf("compressed to %d bytes from %d", len(encoded), len(tt.value))
+			}
+
+			decoded, err := decodeValue(encoded)
+			if err != nil {
+				t.Fatalf("decodeValue: %v", err)
+			}
+			if !bytes.Equal(decoded, tt.value) {
+				t.Errorf("round trip = %q, want %q", decoded, tt.value)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyValues(t *testing.T) {
+	// Values written before compression, or that merely start like the header, pass
+	// through untouched
+	for _, value := range [][]byte{
+		[]byte(`{"appid":440}`),
+		[]byte(`"GSE"`),
+		{},
+		{0x00},
+		{0x00, 0x01, 0x02},
+		{0x00, 'G', 'S', 'E'},
+		{0x00, 'G', 'S', 'X', valueVersionGzip, 0x1f, 0x8b},
+	} {
+		decoded, err := decodeValue(value)
+		if err != nil {
+			t.Errorf("decodeValue(%q): %v", value, err)
+			continue
+		}
+		if !bytes.Equal(decoded, value) {
+			t.Errorf("decodeValue(%q) = %q, want it untouched", value, decoded)
+		}
+	}
+}
+
+func TestDecodeInvalidValues(t *testing.T) {
+	for name, value := range map[string][]byte{
+		"unknown version": append(append([]byte{}, valueMagic...), 2, '{', '}'),
+		"corrupt gzip":    append(append([]byte{}, valueMagic...), valueVersionGzip, '{', '}'),
+	} {
+		if _, err := decodeValue(value); err == nil {
+			t.Errorf("decodeValue of %s succeeded", name)
+		}
+	}
+}
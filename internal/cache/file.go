@@ -0,0 +1,296 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// fileSweepInterval is how often expired entries are purged from the file backend
+	fileSweepInterval = 10 * time.Minute
+
+	// expiryHeaderLen is the size of the expiry timestamp stored before each value
+	expiryHeaderLen = 8
+)
+
+var fileBucket = []byte("cache")
+
+// fileBackend stores the cache in an embedded BoltDB file. Each value is prefixed
+// with its expiry time (unix nanoseconds, 0 = never); expired entries are treated
+// as missing on read and purged periodically.
+type fileBackend struct {
+	db   *bolt.DB
+	path string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func newFileBackend(path string) (*fileBackend, error) {
+	if path == "" {
+		return nil, fmt.Errorf("cache file path is required for the file backend")
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache file %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(fileBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize cache file: %w", err)
+	}
+
+	b := &fileBackend{
+		db:   db,
+		path: path,
+		stop: make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.sweepLoop()
+
+	logger.Log.WithField("path", path).Info("Using file-backed cache")
+
+	return b, nil
+}
+
+// decodeEntry splits a stored entry into its value and remaining TTL
+// Returns false if the entry is malformed or has expired
+func decodeEntry(entry []byte, now time.Time) ([]byte, time.Duration, bool) {
+	if len(entry) < expiryHeaderLen {
+		return nil, 0, false
+	}
+
+	expiresAt := int64(binary.BigEndian.Uint64(entry[:expiryHeaderLen]))
+	ttl := time.Duration(-1)
+	if expiresAt != 0 {
+		ttl = time.Unix(0, expiresAt).Sub(now)
+		if ttl <= 0 {
+			return nil, 0, false
+		}
+	}
+
+	// Bolt's memory is only valid inside the transaction, so copy the value out
+	value := make([]byte, len(entry)-expiryHeaderLen)
+	copy(value, entry[expiryHeaderLen:])
+	return value, ttl, true
+}
+
+func (b *fileBackend) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	var value []byte
+	var ttl time.Duration
+	found := false
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		entry := tx.Bucket(fileBucket).Get([]byte(key))
+		value, ttl, found = decodeEntry(entry, time.Now())
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if !found {
+		return nil, 0, ErrNotFound
+	}
+	return value, ttl, nil
+}
+
+func (b *fileBackend) MGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	now := time.Now()
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(fileBucket)
+		for _, key := range keys {
+			if value, _, found := decodeEntry(bucket.Get([]byte(key)), now); found {
+				values[key] = value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (b *fileBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := make([]byte, expiryHeaderLen+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(entry[:expiryHeaderLen], uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	copy(entry[expiryHeaderLen:], value)
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(fileBucket).Put([]byte(key), entry)
+	})
+}
+
+func (b *fileBackend) Delete(ctx context.Context, keys ...string) (int64, error) {
+	var deleted int64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(fileBucket)
+		for _, key := range keys {
+			if bucket.Get([]byte(key)) == nil {
+				continue
+			}
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (b *fileBackend) Scan(ctx context.Context, prefix string, fn func(key string, size int64) error) error {
+	type scanned struct {
+		key  string
+		size int64
+	}
+	var matches []scanned
+	now := time.Now()
+
+	// Collect matches first so fn can modify the database (e.g. FlushPrefix deleting keys)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(fileBucket).Cursor()
+		p := []byte(prefix)
+		for k, v := cursor.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = cursor.Next() {
+			if _, _, live := decodeEntry(v, now); !live {
+				continue
+			}
+			matches = append(matches, scanned{key: string(k), size: int64(len(k) + len(v))})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan keys: %w", err)
+	}
+
+	for _, m := range matches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(m.key, m.size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AcquireLease runs in a single write transaction, which serialises it against other
+// lease operations on the same file
+func (b *fileBackend) AcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(fileBucket)
+		current, _, held := decodeEntry(bucket.Get([]byte(key)), time.Now())
+		if held && string(current) != owner {
+			return nil
+		}
+
+		entry := make([]byte, expiryHeaderLen+len(owner))
+		binary.BigEndian.PutUint64(entry[:expiryHeaderLen], uint64(time.Now().Add(ttl).UnixNano()))
+		copy(entry[expiryHeaderLen:], owner)
+		acquired = true
+		return bucket.Put([]byte(key), entry)
+	})
+	return acquired, err
+}
+
+func (b *fileBackend) ReleaseLease(ctx context.Context, key string, owner string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(fileBucket)
+		current, _, held := decodeEntry(bucket.Get([]byte(key)), time.Now())
+		if !held || string(current) != owner {
+			return nil
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// UsedMemory reports the size of the cache file on disk
+func (b *fileBackend) UsedMemory(ctx context.Context) int64 {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (b *fileBackend) Close() error {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+	})
+	b.wg.Wait()
+	return b.db.Close()
+}
+
+// sweepLoop periodically purges expired entries so the file doesn't grow unbounded
+func (b *fileBackend) sweepLoop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(fileSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			if purged, err := b.sweep(); err != nil {
+				logger.Log.WithError(err).Warn("Failed to purge expired cache entries")
+			} else if purged > 0 {
+				logger.Log.WithField("purged", purged).Debug("Purged expired cache entries")
+			}
+		}
+	}
+}
+
+// sweep deletes every expired entry
+func (b *fileBackend) sweep() (int, error) {
+	purged := 0
+	now := time.Now()
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(fileBucket)
+
+		var expired [][]byte
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if _, _, live := decodeEntry(v, now); !live {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+			purged++
+		}
+		return nil
+	})
+	return purged, err
+}
@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestFileBackend(t *testing.T) *fileBackend {
+	t.Helper()
+	b, err := newFileBackend(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("newFileBackend: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+// putExpired stores an entry that expired a minute ago, as Set can't
+func putExpired(t *testing.T, b *fileBackend, key string, value string) {
+	t.Helper()
+	entry := make([]byte, expiryHeaderLen+len(value))
+	binary.BigEndian.PutUint64(entry[:expiryHeaderLen], uint64(time.Now().Add(-time.Minute).UnixNano()))
+	copy(entry[expiryHeaderLen:], value)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(fileBucket).Put([]byte(key), entry)
+	})
+	if err != nil {
+		t.Fatalf("failed to store %s: %v", key, err)
+	}
+}
+
+// stored reports whether key is in the file at all, expired or not
+func stored(t *testing.T, b *fileBackend, key string) bool {
+	t.Helper()
+	found := false
+	err := b.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(fileBucket).Get([]byte(key)) != nil
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", key, err)
+	}
+	return found
+}
+
+func TestFileBackendTTL(t *testing.T) {
+	b := newTestFileBackend(t)
+	ctx := context.Background()
+
+	if err := b.Set(ctx, "expiring", []byte("value"), time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := b.Set(ctx, "permanent", []byte("value"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	putExpired(t, b, "expired", "value")
+
+	value, ttl, err := b.GetWithTTL(ctx, "expiring")
+	if err != nil || string(value) != "value" {
+		t.Fatalf("GetWithTTL(expiring) = %q, %v", value, err)
+	}
+	if ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("TTL = %v, want about an hour", ttl)
+	}
+	// Like Redis, an entry without an expiry has a TTL of -1
+	if _, ttl, err := b.GetWithTTL(ctx, "permanent"); err != nil || ttl != -1 {
+		t.Errorf("GetWithTTL(permanent) TTL = %v, %v, want -1", ttl, err)
+	}
+	if _, _, err := b.GetWithTTL(ctx, "expired"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetWithTTL(expired) = %v, want ErrNotFound", err)
+	}
+	if _, _, err := b.GetWithTTL(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetWithTTL(missing) = %v, want ErrNotFound", err)
+	}
+
+	// A short TTL runs out on its own
+	if err := b.Set(ctx, "short", []byte("value"), 10*time.Millisecond); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, _, err := b.GetWithTTL(ctx, "short"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetWithTTL after the TTL = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileBackendSweep(t *testing.T) {
+	b := newTestFileBackend(t)
+	ctx := context.Background()
+
+	if err := b.Set(ctx, "live", []byte("value"), time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := b.Set(ctx, "permanent", []byte("value"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	putExpired(t, b, "expired-1", "value")
+	putExpired(t, b, "expired-2", "value")
+
+	// Expired entries are hidden from reads but stay in the file until swept
+	if !stored(t, b, "expired-1") {
+		t.Fatal("expired entry was removed before the sweep")
+	}
+
+	purged, err := b.sweep()
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("purged = %d, want 2", purged)
+	}
+	for key, want := range map[string]bool{"live": true, "permanent": true, "expired-1": false, "expired-2": false} {
+		if got := stored(t, b, key); got != want {
+			t.Errorf("%s stored = %v, want %v", key, got, want)
+		}
+	}
+
+	if purged, err := b.sweep(); err != nil || purged != 0 {
+		t.Errorf("second sweep = %d, %v, want nothing purged", purged, err)
+	}
+}
+
+func TestFileBackendMGet(t *testing.T) {
+	b := newTestFileBackend(t)
+	ctx := context.Background()
+
+	for key, value := range map[string]string{"a": "1", "b": "2", "c": "3"} {
+		if err := b.Set(ctx, key, []byte(value), time.Hour); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	putExpired(t, b, "expired", "4")
+
+	// Missing and expired keys are left out rather than returned empty
+	values, err := b.MGet(ctx, []string{"a", "c", "expired", "missing"})
+	if err != nil {
+		t.Fatalf("MGet: %v", err)
+	}
+	want := map[string]string{"a": "1", "c": "3"}
+	if len(values) != len(want) {
+		t.Errorf("MGet = %q, want %q", values, want)
+	}
+	for key, value := range want {
+		if string(values[key]) != value {
+			t.Errorf("MGet[%s] = %q, want %q", key, values[key], value)
+		}
+	}
+
+	values, err = b.MGet(ctx, nil)
+	if err != nil || len(values) != 0 {
+		t.Errorf("MGet of no keys = %q, %v", values, err)
+	}
+}
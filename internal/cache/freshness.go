@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Freshness records when the cached data read during one request was fetched from upstream,
+// so responses can tell how old the data behind them is
+type Freshness struct {
+	mu     sync.Mutex
+	oldest time.Time
+}
+
+type freshnessKey struct{}
+
+// WithFreshness returns a context whose GetOrRefresh reads are recorded in the returned Freshness
+func WithFreshness(ctx context.Context) (context.Context, *Freshness) {
+	freshness := &Freshness{}
+	return context.WithValue(ctx, freshnessKey{}, freshness), freshness
+}
+
+// FreshnessFromContext returns the context's Freshness, nil if none was set
+func FreshnessFromContext(ctx context.Context) *Freshness {
+	freshness, _ := ctx.Value(freshnessKey{}).(*Freshness)
+	return freshness
+}
+
+// Oldest returns when the oldest data read was fetched, zero if nothing was read
+func (f *Freshness) Oldest() time.Time {
+	if f == nil {
+		return time.Time{}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.oldest
+}
+
+// Record notes data fetched at the given time that was read from somewhere other than
+// GetOrRefresh, such as a long-lived snapshot
+func (f *Freshness) Record(fetched time.Time) {
+	f.record(fetched)
+}
+
+// record notes data fetched at fetched
+func (f *Freshness) record(fetched time.Time) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.oldest.IsZero() || fetched.Before(f.oldest) {
+		f.oldest = fetched
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InFlight runs at most one collection per target at a time. A caller arriving while its
+// target is being collected, e.g. a scrape during a background poll, waits for that collection
+// and shares its result rather than starting an overlapping one that interleaves cache writes.
+// The zero value is ready to use.
+type InFlight struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+	joined  chan<- string // Receives the key of each caller joining a flight, for tests
+}
+
+// flight is a running collection and the callers waiting for it
+type flight struct {
+	done    chan struct{} // Closed once the collection has returned
+	err     error
+	oldest  time.Time // Oldest data read by the collection
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do runs collect for the target unless a collection of it under the same cache policy is
+// already running, in which case it waits for that one and returns its error. The data read by
+// the collection is recorded in the Freshness of every caller sharing it.
+//
+// The collection runs with the values (request ID, logger fields, trace span) of the caller that
+// started it, but not its cancellation: a caller whose ctx is done stops waiting, and the
+// collection carries on for the others. It's only cancelled once every caller has stopped waiting.
+func (f *InFlight) Do(ctx context.Context, target string, collect func(ctx context.Context) error) error {
+	// A fresh or cache-only caller can't make do with a collection under another policy
+	key := fmt.Sprintf("%s:%d", target, PolicyFromContext(ctx))
+
+	f.mu.Lock()
+	fl, running := f.flights[key]
+	// A flight every caller has left is cancelled, so it's no use to a new one
+	if !running || fl.waiters == 0 {
+		collectCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		fl = &flight{done: make(chan struct{}), cancel: cancel}
+		if f.flights == nil {
+			f.flights = make(map[string]*flight)
+		}
+		f.flights[key] = fl
+		go f.run(collectCtx, key, fl, collect)
+	}
+	fl.waiters++
+	if f.joined != nil {
+		f.joined <- key
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-fl.done:
+		if !fl.oldest.IsZero() {
+			FreshnessFromContext(ctx).record(fl.oldest)
+		}
+		return fl.err
+	case <-ctx.Done():
+		f.mu.Lock()
+		fl.waiters--
+		if fl.waiters == 0 {
+			fl.cancel()
+		}
+		f.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// run runs a flight's collection and hands its result to the waiting callers
+func (f *InFlight) run(ctx context.Context, key string, fl *flight, collect func(ctx context.Context) error) {
+	defer fl.cancel()
+
+	ctx, freshness := WithFreshness(ctx)
+	fl.err = collect(ctx)
+	fl.oldest = freshness.Oldest()
+
+	f.mu.Lock()
+	if f.flights[key] == fl {
+		delete(f.flights, key)
+	}
+	f.mu.Unlock()
+	close(fl.done)
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// blockingCollect returns a collection that signals started and returns err once release is closed,
+// or the context's error if it's cancelled first
+func blockingCollect(started chan<- context.Context, release <-chan struct{}, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		started <- ctx
+		select {
+		case <-release:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestInFlightShares(t *testing.T) {
+	joined := make(chan string, 2)
+	f := &InFlight{joined: joined}
+	started := make(chan context.Context, 2)
+	release := make(chan struct{})
+	errCollect := errors.New("collection failed")
+
+	results := make(chan error, 2)
+	for range 2 {
+		go func() {
+			results <- f.Do(context.Background(), "user", blockingCollect(started, release, errCollect))
+		}()
+	}
+	<-joined
+	<-joined
+	close(release)
+
+	for range 2 {
+		if err := <-results; !errors.Is(err, errCollect) {
+			t.Errorf("Do = %v, want the shared collection's error", err)
+		}
+	}
+	if got := len(started); got != 1 {
+		t.Errorf("collections = %d, want 1", got)
+	}
+}
+
+func TestInFlightFirstCallerCancelled(t *testing.T) {
+	joined := make(chan string, 2)
+	f := &InFlight{joined: joined}
+	started := make(chan context.Context, 2)
+	release := make(chan struct{})
+
+	type requestIDKey struct{}
+	first, cancel := context.WithCancel(context.WithValue(context.Background(), requestIDKey{}, "first"))
+	firstResult := make(chan error, 1)
+	go func() { firstResult <- f.Do(first, "user", blockingCollect(started, release, nil)) }()
+	collectCtx := <-started
+	<-joined
+
+	secondResult := make(chan error, 1)
+	go func() { secondResult <- f.Do(context.Background(), "user", blockingCollect(started, release, nil)) }()
+	<-joined
+
+	// The first caller leaving doesn't cancel the collection the second is waiting for
+	cancel()
+	if err := <-firstResult; !errors.Is(err, context.Canceled) {
+		t.Errorf("first Do = %v, want context.Canceled", err)
+	}
+	if err := collectCtx.Err(); err != nil {
+		t.Fatalf("collection cancelled with the first caller: %v", err)
+	}
+	// It still carries the values of the caller that started it
+	if got := collectCtx.Value(requestIDKey{}); got != "first" {
+		t.Errorf("collection request ID = %v, want first", got)
+	}
+
+	close(release)
+	if err := <-secondResult; err != nil {
+		t.Errorf("second Do = %v, want success", err)
+	}
+	if got := len(started); got != 0 {
+		t.Errorf("%d more collections started, want none", got)
+	}
+}
+
+func TestInFlightEveryCallerCancelled(t *testing.T) {
+	joined := make(chan string, 2)
+	f := &InFlight{joined: joined}
+	started := make(chan context.Context, 2)
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- f.Do(ctx, "user", blockingCollect(started, release, nil)) }()
+	collectCtx := <-started
+	<-joined
+
+	// Once nobody is waiting, the collection is cancelled
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("Do = %v, want context.Canceled", err)
+	}
+	<-collectCtx.Done()
+
+	// A caller arriving afterwards gets a collection of its own rather than the cancelled one
+	next := make(chan struct{})
+	close(next)
+	if err := f.Do(context.Background(), "user", blockingCollect(started, next, nil)); err != nil {
+		t.Errorf("Do after the cancellation = %v, want success", err)
+	}
+	if got := len(started); got != 1 {
+		t.Errorf("collections started after the cancellation = %d, want 1", got)
+	}
+}
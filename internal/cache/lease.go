@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// AcquireLease claims key for owner for ttl. It succeeds if the lease is free or
+// already held by owner (in which case it is extended), and fails while another
+// owner holds it. Leases expire on their own, so a crashed owner is taken over
+// once its ttl passes.
+func (c *Cache) AcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	return c.backend.AcquireLease(ctx, key, owner, ttl)
+}
+
+// ReleaseLease gives up key if it is still held by owner
+func (c *Cache) ReleaseLease(ctx context.Context, key string, owner string) error {
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	return c.backend.ReleaseLease(ctx, key, owner)
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// Policy controls how GetOrRefresh treats cached entries for one request, so different
+// scrapers can trade freshness for upstream cost
+type Policy int
+
+const (
+	// PolicyDefault serves cached entries, refreshing stale ones in the background
+	PolicyDefault Policy = iota
+	// PolicyFresh refetches even when an entry is cached (the result is cached as usual)
+	PolicyFresh
+	// PolicyCachedOnly never refetches; a miss fails with ErrNotCached
+	PolicyCachedOnly
+)
+
+// ErrNotCached is returned by GetOrRefresh on a miss under PolicyCachedOnly
+var ErrNotCached = errors.New("cache: not cached and upstream requests are disabled for this request")
+
+type policyKey struct{}
+
+// WithPolicy returns a context whose cache lookups follow policy
+func WithPolicy(ctx context.Context, policy Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, policy)
+}
+
+// PolicyFromContext returns the context's cache policy, PolicyDefault if none was set
+func PolicyFromContext(ctx context.Context) Policy {
+	if policy, ok := ctx.Value(policyKey{}).(Policy); ok {
+		return policy
+	}
+	return PolicyDefault
+}
+
+// CheckUpstream returns ErrNotCached when the context's policy forbids upstream requests.
+// API clients call it before each request, so reads that bypass GetOrRefresh are covered too.
+func CheckUpstream(ctx context.Context) error {
+	if PolicyFromContext(ctx) == PolicyCachedOnly {
+		return ErrNotCached
+	}
+	return nil
+}
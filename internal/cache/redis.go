@@ -2,56 +2,205 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-type Cache struct {
+// acquireLeaseScript claims or extends a lease atomically
+// KEYS[1] = lease key, ARGV[1] = owner, ARGV[2] = ttl in milliseconds
+var acquireLeaseScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if current then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// releaseLeaseScript deletes a lease only if it is still held by the owner
+// KEYS[1] = lease key, ARGV[1] = owner
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// redisBackend stores the cache in Redis
+type redisBackend struct {
 	client *redis.Client
 }
 
-func New(addr string, password string, db int) *Cache {
-	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
-	})
+func newRedisBackend(opts Options) (*redisBackend, error) {
+	redisOpts := &redis.Options{
+		Addr:     opts.Addr,
+		Username: opts.Username,
+		Password: opts.Password,
+		DB:       opts.DB,
+	}
 
-	return &Cache{
-		client: client,
+	if opts.TLS {
+		tlsConfig, err := buildTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		redisOpts.TLSConfig = tlsConfig
 	}
+
+	return &redisBackend{
+		client: redis.NewClient(redisOpts),
+	}, nil
 }
 
-// Close the Redis connection
-func (c *Cache) Close() error {
-	return c.client.Close()
+// buildTLSConfig creates the TLS configuration for the Redis connection
+func buildTLSConfig(opts Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.TLSSkipVerify,
+	}
+
+	if opts.TLSCAFile != "" {
+		caCert, err := os.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificates found in Redis CA file %s", opts.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
 }
 
-// ========== Generic Cache Methods ==========
+func (b *redisBackend) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	pipe := b.client.Pipeline()
+	getCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if err == redis.Nil {
+			return nil, 0, ErrNotFound
+		}
+		return nil, 0, err
+	}
+
+	data, err := getCmd.Bytes()
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, ttlCmd.Val(), nil
+}
 
-// Get retrieves a value from cache by key
-func (c *Cache) Get(key string) ([]byte, bool) {
-	ctx := context.Background()
-	data, err := c.client.Get(ctx, key).Result()
+func (b *redisBackend) MGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	results, err := b.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		if err == redis.Nil {
-			return nil, false
+		return nil, err
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for i, result := range results {
+		// nil means the key doesn't exist
+		if raw, ok := result.(string); ok {
+			values[keys[i]] = []byte(raw)
+		}
+	}
+	return values, nil
+}
+
+func (b *redisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (b *redisBackend) Delete(ctx context.Context, keys ...string) (int64, error) {
+	return b.client.Del(ctx, keys...).Result()
+}
+
+func (b *redisBackend) Scan(ctx context.Context, prefix string, fn func(key string, size int64) error) error {
+	iter := b.client.Scan(ctx, 0, escapeMatchPattern(prefix)+"*", scanBatchSize).Iterator()
+	batch := make([]string, 0, scanBatchSize)
+
+	flush := func() error {
+		pipe := b.client.Pipeline()
+		cmds := make([]*redis.IntCmd, len(batch))
+		for i, key := range batch {
+			cmds[i] = pipe.MemoryUsage(ctx, key)
+		}
+		// Keys may expire between SCAN and MEMORY USAGE, so per-command errors are ignored
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to read memory usage: %w", err)
+		}
+		for i, key := range batch {
+			size, _ := cmds[i].Result()
+			if err := fn(key, size); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) >= scanBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan keys: %w", err)
+	}
+	if len(batch) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+// UsedMemory reads used_memory from INFO memory
+func (b *redisBackend) UsedMemory(ctx context.Context) int64 {
+	info, err := b.client.Info(ctx, "memory").Result()
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(info, "\r\n") {
+		if value, found := strings.CutPrefix(line, "used_memory:"); found {
+			var used int64
+			fmt.Sscanf(value, "%d", &used)
+			return used
 		}
-		return nil, false
 	}
-	return []byte(data), true
+	return 0
+}
+
+func (b *redisBackend) AcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireLeaseScript.Run(ctx, b.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
 }
 
-// Set stores a value in cache with TTL
-func (c *Cache) Set(key string, value []byte, ttl time.Duration) {
-	ctx := context.Background()
-	c.client.Set(ctx, key, value, ttl)
+func (b *redisBackend) ReleaseLease(ctx context.Context, key string, owner string) error {
+	return releaseLeaseScript.Run(ctx, b.client, []string{key}, owner).Err()
 }
 
-// Delete removes a key from cache
-func (c *Cache) Delete(key string) {
-	ctx := context.Background()
-	c.client.Del(ctx, key)
+func (b *redisBackend) Close() error {
+	return b.client.Close()
 }
 
+// escapeMatchPattern escapes glob metacharacters so a prefix matches literally in SCAN MATCH
+func escapeMatchPattern(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(s)
+}
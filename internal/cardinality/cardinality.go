@@ -0,0 +1,67 @@
+// Package cardinality caps how many series a collector creates, so a huge game library or a
+// misconfigured target can't flood Prometheus with millions of series.
+package cardinality
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var seriesDroppedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "exporter",
+	Name:      "series_dropped_total",
+	Help:      "Series left out of a collection because a cardinality budget was exhausted",
+}, []string{"collector", "metric"})
+
+func init() {
+	prometheus.MustRegister(seriesDroppedCounter)
+}
+
+// Budget admits up to a limit of distinct series. Series already admitted stay admitted, so
+// a full budget keeps reporting the series it has and only refuses new ones.
+type Budget struct {
+	collector string
+	limit     int // 0 means unlimited
+
+	mu     sync.Mutex
+	series map[string]struct{}
+}
+
+// NewBudget creates a budget of limit series (0 for unlimited) for a collector
+func NewBudget(collector string, limit int) *Budget {
+	return &Budget{
+		collector: collector,
+		limit:     limit,
+		series:    make(map[string]struct{}),
+	}
+}
+
+// Allow reports whether the series of metric identified by labelValues may be reported,
+// counting it in exporter_series_dropped_total when it may not. A nil budget allows everything.
+func (b *Budget) Allow(metric string, labelValues ...string) bool {
+	if b == nil || b.limit <= 0 {
+		return true
+	}
+	key := metric + "\xff" + strings.Join(labelValues, "\xff")
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.series[key]; ok {
+		return true
+	}
+	if len(b.series) >= b.limit {
+		Dropped(b.collector, metric, 1)
+		return false
+	}
+	b.series[key] = struct{}{}
+	return true
+}
+
+// Dropped counts series a collector left out under its own limit (e.g. per game)
+func Dropped(collector string, metric string, count int) {
+	if count > 0 {
+		seriesDroppedCounter.WithLabelValues(collector, metric).Add(float64(count))
+	}
+}
@@ -0,0 +1,97 @@
+package clash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// Each game has its own API, with tokens issued by its own developer site
+	ClansAPIOrigin  = "https://api.clashofclans.com"
+	RoyaleAPIOrigin = "https://api.clashroyale.com"
+	PlayerEndpoint  = "/v1/players/%s"
+)
+
+var (
+	// ErrUnauthorized is returned when Supercell rejects the API token. Tokens are bound to the IP
+	// addresses given when creating them, so this is also what a request from another IP gets.
+	ErrUnauthorized = errors.New("clash: API token rejected (invalid, or not allowed from this IP)")
+	// errNotFound is returned for a tag that isn't a player of the game
+	errNotFound = errors.New("clash: player not found")
+)
+
+type Client struct {
+	origin     string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of a game's API (ClansAPIOrigin or RoyaleAPIOrigin); transport
+// is the upstream transport (e.g. fixture replay), nil for the default
+func NewClient(origin string, token string, transport http.RoundTripper) *Client {
+	return &Client{
+		origin: origin,
+		token:  token,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// GetPlayer retrieves a player by tag (without the #)
+func (c *Client) GetPlayer(ctx context.Context, tag string) (player Player, err error) {
+	ctx, span := tracing.Start(ctx, "clash.api", attribute.String("clash.origin", c.origin), attribute.String("clash.tag", tag))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return Player{}, err
+	}
+
+	url := c.origin + fmt.Sprintf(PlayerEndpoint, neturl.PathEscape("#"+tag))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return Player{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Player{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Player{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		return Player{}, ErrUnauthorized
+	case http.StatusNotFound:
+		return Player{}, errNotFound
+	case http.StatusTooManyRequests:
+		return Player{}, fmt.Errorf("rate limited by Supercell (%d)", resp.StatusCode)
+	case http.StatusServiceUnavailable:
+		return Player{}, fmt.Errorf("Supercell API in maintenance (%d)", resp.StatusCode)
+	default:
+		return Player{}, fmt.Errorf("unexpected status code %d from Supercell", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, &player); err != nil {
+		return Player{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return player, nil
+}
@@ -0,0 +1,134 @@
+package clash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Trophies change with every battle; the API itself caches profiles for a few minutes
+const playerTTL = 10 * time.Minute
+
+// The games' label values, in the order they're collected
+const (
+	GameClans  = "clans"
+	GameRoyale = "royale"
+)
+
+var (
+	// ErrInvalidTag is returned for a tag with characters Supercell doesn't use
+	ErrInvalidTag = errors.New("invalid player tag")
+	// ErrPlayerNotFound is returned for a tag that isn't a player of any configured game
+	ErrPlayerNotFound = errors.New("player not found")
+)
+
+// tagPattern matches a tag (without the #); tags only use these characters
+var tagPattern = regexp.MustCompile(`^[0289PYLQGRJCUV]+$`)
+
+// Config configures the Clash collector; a game without a token isn't collected
+type Config struct {
+	ClansToken  string            // Clash of Clans API token (developer.clashofclans.com)
+	RoyaleToken string            // Clash Royale API token (developer.clashroyale.com)
+	Transport   http.RoundTripper // Upstream transport, e.g. fixture replay; nil for the default
+}
+
+// Collector reports Clash of Clans and Clash Royale player stats by tag
+type Collector struct {
+	clients map[string]*Client // By game
+	cache   *cache.Cache
+}
+
+func NewCollector(config Config, cache *cache.Cache) *Collector {
+	clients := make(map[string]*Client)
+	if config.ClansToken != "" {
+		clients[GameClans] = NewClient(ClansAPIOrigin, config.ClansToken, config.Transport)
+	}
+	if config.RoyaleToken != "" {
+		clients[GameRoyale] = NewClient(RoyaleAPIOrigin, config.RoyaleToken, config.Transport)
+	}
+	return &Collector{
+		clients: clients,
+		cache:   cache,
+	}
+}
+
+// NormalizeTag returns a tag without its # and uppercased, with the commonly mistyped O as 0
+func NormalizeTag(tag string) string {
+	tag = strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	return strings.ReplaceAll(tag, "O", "0")
+}
+
+// Collect reports a tag's stats in each configured game it's a player of
+func (c *Collector) Collect(ctx context.Context, tag string) (err error) {
+	ctx, span := tracing.Start(ctx, "clash.collect", attribute.String("clash.tag", tag))
+	defer func() { tracing.End(span, err) }()
+
+	tag = NormalizeTag(tag)
+	if !tagPattern.MatchString(tag) {
+		return fmt.Errorf("%w: %s", ErrInvalidTag, tag)
+	}
+
+	found := 0
+	for _, game := range []string{GameClans, GameRoyale} {
+		if _, ok := c.clients[game]; !ok {
+			continue
+		}
+		player, err := c.player(ctx, game, tag)
+		if err != nil {
+			return err
+		}
+		if player == nil {
+			DeletePlayer(game, "#"+tag)
+			continue
+		}
+		found++
+		ReportPlayer(game, "#"+tag, *player)
+	}
+	if found == 0 {
+		return fmt.Errorf("%w: #%s", ErrPlayerNotFound, tag)
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"tag":   tag,
+		"games": found,
+	}).Info("Completed Clash metrics collection")
+	return nil
+}
+
+// player returns a tag's player in a game from the cache, fetching on a miss; nil when the
+// tag isn't a player of the game (which is cached too)
+func (c *Collector) player(ctx context.Context, game string, tag string) (*Player, error) {
+	client := c.clients[game]
+	cacheKey := fmt.Sprintf("clash:%s:%s", game, tag)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, playerTTL, playerTTL, func(ctx context.Context) ([]byte, error) {
+		player, err := client.GetPlayer(ctx, tag)
+		if errors.Is(err, errNotFound) {
+			return json.Marshal(nil)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s player: %w", game, err)
+		}
+		return json.Marshal(player)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var player *Player
+	if err := json.Unmarshal(data, &player); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached player: %w", err)
+	}
+	return player, nil
+}
@@ -0,0 +1,76 @@
+package clash
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestCollector(t *testing.T, srv *testserver.Server, config Config) *Collector {
+	t.Helper()
+	config.Transport = srv.Transport()
+	return NewCollector(config, testserver.NewCache(t))
+}
+
+func TestCollect(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetClashToken(GameClans, "clans-token")
+	srv.SetClashToken(GameRoyale, "royale-token")
+	srv.AddClashPlayer("#2PP", testserver.ClashPlayer{Game: GameClans, Name: "Chief", Trophies: 3200, WarStars: 812, TownHallLevel: 14, Donations: 420})
+	srv.AddClashPlayer("#2PP", testserver.ClashPlayer{Game: GameRoyale, Name: "Chief", Trophies: 6100, ArenaID: 54000013, ArenaName: "Legendary Arena"})
+	srv.AddClashPlayer("#8QU", testserver.ClashPlayer{Game: GameRoyale, Name: "King", Trophies: 4000})
+	collector := newTestCollector(t, srv, Config{ClansToken: "clans-token", RoyaleToken: "royale-token"})
+	ctx := context.Background()
+
+	// A tag is accepted without its # and lowercased
+	if err := collector.Collect(ctx, "2pp"); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(trophiesGauge.WithLabelValues(GameClans, "#2PP", "Chief")); got != 3200 {
+		t.Errorf("Clash of Clans trophies = %v, want 3200", got)
+	}
+	if got := testutil.ToFloat64(trophiesGauge.WithLabelValues(GameRoyale, "#2PP", "Chief")); got != 6100 {
+		t.Errorf("Clash Royale trophies = %v, want 6100", got)
+	}
+	if got := testutil.ToFloat64(warStarsGauge.WithLabelValues("#2PP", "Chief")); got != 812 {
+		t.Errorf("war stars = %v, want 812", got)
+	}
+	if got := testutil.ToFloat64(arenaGauge.WithLabelValues("#2PP", "Chief", "Legendary Arena")); got != 54000013 {
+		t.Errorf("arena = %v, want 54000013", got)
+	}
+
+	// A player of only one game is reported in that game, and the other game's 404 is cached
+	for i := 0; i < 2; i++ {
+		if err := collector.Collect(ctx, "#8QU"); err != nil {
+			t.Fatalf("Collect: %v", err)
+		}
+	}
+	if got := testutil.ToFloat64(trophiesGauge.WithLabelValues(GameRoyale, "#8QU", "King")); got != 4000 {
+		t.Errorf("King's trophies = %v, want 4000", got)
+	}
+	if got := srv.Requests("/v1/players/#8QU"); got != 2 {
+		t.Errorf("player requests = %d, want 2 (one per game)", got)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetClashToken(GameClans, "clans-token")
+	ctx := context.Background()
+
+	collector := newTestCollector(t, srv, Config{ClansToken: "clans-token"})
+	if err := collector.Collect(ctx, "#NOTATAG"); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("Collect of a malformed tag = %v, want ErrInvalidTag", err)
+	}
+	if err := collector.Collect(ctx, "#2PP"); !errors.Is(err, ErrPlayerNotFound) {
+		t.Errorf("Collect of an unknown tag = %v, want ErrPlayerNotFound", err)
+	}
+
+	rejected := newTestCollector(t, srv, Config{ClansToken: "other-ip"})
+	if err := rejected.Collect(ctx, "#2PP"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Collect with a rejected token = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,105 @@
+package clash
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	trophiesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "trophies",
+		Help:      "A player's current trophies",
+	}, []string{"game", "tag", "name"})
+
+	bestTrophiesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "best_trophies",
+		Help:      "A player's highest trophies",
+	}, []string{"game", "tag", "name"})
+
+	expLevelGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "exp_level",
+		Help:      "A player's experience level",
+	}, []string{"game", "tag", "name"})
+
+	donationsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "donations",
+		Help:      "Troops or cards a player donated to their clan this season",
+	}, []string{"game", "tag", "name"})
+
+	donationsReceivedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "donations_received",
+		Help:      "Troops or cards a player received from their clan this season",
+	}, []string{"game", "tag", "name"})
+
+	warStarsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "war_stars",
+		Help:      "Stars a player won in Clash of Clans clan wars",
+	}, []string{"tag", "name"})
+
+	townHallLevelGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "town_hall_level",
+		Help:      "A player's Clash of Clans town hall level",
+	}, []string{"tag", "name"})
+
+	arenaGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clash",
+		Name:      "arena",
+		Help:      "ID of a player's current Clash Royale arena (IDs grow with trophies)",
+	}, []string{"tag", "name", "arena"})
+
+	gameGauges = []*prometheus.GaugeVec{trophiesGauge, bestTrophiesGauge, expLevelGauge, donationsGauge, donationsReceivedGauge}
+)
+
+func init() {
+	prometheus.MustRegister(trophiesGauge)
+	prometheus.MustRegister(bestTrophiesGauge)
+	prometheus.MustRegister(expLevelGauge)
+	prometheus.MustRegister(donationsGauge)
+	prometheus.MustRegister(donationsReceivedGauge)
+	prometheus.MustRegister(warStarsGauge)
+	prometheus.MustRegister(townHallLevelGauge)
+	prometheus.MustRegister(arenaGauge)
+}
+
+// ReportPlayer replaces the reported stats of a tag's player in a game, so a renamed player
+// or a new arena doesn't leave the old series behind
+func ReportPlayer(game string, tag string, player Player) {
+	DeletePlayer(game, tag)
+
+	labels := prometheus.Labels{"game": game, "tag": tag, "name": player.Name}
+	trophiesGauge.With(labels).Set(float64(player.Trophies))
+	bestTrophiesGauge.With(labels).Set(float64(player.BestTrophies))
+	expLevelGauge.With(labels).Set(float64(player.ExpLevel))
+	donationsGauge.With(labels).Set(float64(player.Donations))
+	donationsReceivedGauge.With(labels).Set(float64(player.DonationsReceived))
+
+	switch game {
+	case GameClans:
+		warStarsGauge.WithLabelValues(tag, player.Name).Set(float64(player.WarStars))
+		townHallLevelGauge.WithLabelValues(tag, player.Name).Set(float64(player.TownHallLevel))
+	case GameRoyale:
+		if player.Arena != nil {
+			arenaGauge.WithLabelValues(tag, player.Name, player.Arena.Name).Set(float64(player.Arena.ID))
+		}
+	}
+}
+
+// DeletePlayer removes the reported stats of a tag's player in a game
+func DeletePlayer(game string, tag string) {
+	for _, gauge := range gameGauges {
+		gauge.DeletePartialMatch(prometheus.Labels{"game": game, "tag": tag})
+	}
+	switch game {
+	case GameClans:
+		warStarsGauge.DeletePartialMatch(prometheus.Labels{"tag": tag})
+		townHallLevelGauge.DeletePartialMatch(prometheus.Labels{"tag": tag})
+	case GameRoyale:
+		arenaGauge.DeletePartialMatch(prometheus.Labels{"tag": tag})
+	}
+}
@@ -0,0 +1,25 @@
+package clash
+
+// Player is a player profile; the fields a game doesn't have are left zero
+type Player struct {
+	Tag               string `json:"tag"`
+	Name              string `json:"name"`
+	ExpLevel          int    `json:"expLevel"`
+	Trophies          int    `json:"trophies"`
+	BestTrophies      int    `json:"bestTrophies"`
+	Donations         int    `json:"donations"` // This season's
+	DonationsReceived int    `json:"donationsReceived"`
+
+	// Clash of Clans
+	TownHallLevel int `json:"townHallLevel"`
+	WarStars      int `json:"warStars"`
+
+	// Clash Royale
+	Arena *Arena `json:"arena"`
+}
+
+// Arena is a Clash Royale arena; IDs grow with trophies
+type Arena struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
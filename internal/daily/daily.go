@@ -0,0 +1,84 @@
+// Package daily turns cumulative values (playtime, XP) into how much they grew today, reset at
+// local midnight, so dashboards don't need increase() over irregular poll gaps.
+package daily
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+)
+
+// snapshotTTL keeps a target's snapshot across a day without collections, so yesterday's
+// last values can still start today
+const snapshotTTL = 48 * time.Hour
+
+// snapshot is the cached state of one target
+type snapshot struct {
+	Day      string           `json:"day"`      // Local date the baseline belongs to (2006-01-02)
+	Baseline map[string]int64 `json:"baseline"` // Values when the day started
+	Last     map[string]int64 `json:"last"`     // Values at the latest collection
+}
+
+// Tracker keeps a snapshot per target in the cache, so every replica and restart agrees on
+// where the day started
+type Tracker struct {
+	cache    *cache.Cache
+	location *time.Location
+}
+
+// NewTracker creates a tracker whose days start at midnight in location (nil for the local
+// time zone)
+func NewTracker(cache *cache.Cache, location *time.Location) *Tracker {
+	if location == nil {
+		location = time.Local
+	}
+	return &Tracker{
+		cache:    cache,
+		location: location,
+	}
+}
+
+// Today records a target's current values under key and returns how much each grew since the
+// day started. The first collection of a day starts from the last values of the day before,
+// when there were any, so play between then and the first collection still counts; otherwise
+// today starts from the current values. Values missing from the baseline (a new game) count
+// from 0.
+func (t *Tracker) Today(ctx context.Context, key string, values map[string]int64) map[string]int64 {
+	now := time.Now().In(t.location)
+	day := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+
+	var previous snapshot
+	if data, ok := t.cache.Get(ctx, key); ok {
+		if err := json.Unmarshal(data, &previous); err != nil {
+			previous = snapshot{}
+		}
+	}
+
+	current := snapshot{Day: day, Baseline: previous.Baseline, Last: values}
+	switch previous.Day {
+	case day:
+	case yesterday:
+		current.Baseline = previous.Last
+	default:
+		current.Baseline = values
+	}
+	if current.Baseline == nil {
+		current.Baseline = values
+	}
+
+	if data, err := json.Marshal(current); err == nil {
+		t.cache.Set(ctx, key, data, snapshotTTL)
+	}
+
+	today := make(map[string]int64, len(values))
+	for name, value := range values {
+		// A value that went down (e.g. a refunded game) didn't grow
+		if grown := value - current.Baseline[name]; grown > 0 {
+			today[name] = grown
+		}
+	}
+	return today
+}
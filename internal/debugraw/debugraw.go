@@ -0,0 +1,48 @@
+// Package debugraw records the raw upstream responses behind a request, so /debug/raw can show
+// them next to what they were parsed into when an upstream format changes under a decoder
+package debugraw
+
+import (
+	"context"
+	"sync"
+)
+
+// Response is one upstream response as received, before parsing
+type Response struct {
+	URL    string // Secrets (API keys) redacted
+	Status int
+	Body   []byte
+}
+
+// Recorder collects the responses of the upstream requests made with its context
+type Recorder struct {
+	mu        sync.Mutex
+	responses []Response
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose upstream responses are recorded in the returned Recorder
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	recorder := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, recorder), recorder
+}
+
+// Record records a response in the context's Recorder; API clients call it for every response
+// they read, and it does nothing outside of a debug request
+func Record(ctx context.Context, url string, status int, body []byte) {
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	if recorder == nil {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.responses = append(recorder.responses, Response{URL: url, Status: status, Body: body})
+}
+
+// Responses returns the recorded responses in the order they were received
+func (r *Recorder) Responses() []Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Response(nil), r.responses...)
+}
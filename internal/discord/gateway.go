@@ -0,0 +1,230 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+// GatewayURL is Discord's gateway, version 10 with JSON payloads (uncompressed)
+const GatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+
+// Gateway opcodes
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+	opHeartbeatAck   = 11
+)
+
+// Gateway intents: GUILDS delivers GUILD_CREATE (with the members' current presences) and
+// GUILD_PRESENCES the PRESENCE_UPDATE events. GUILD_PRESENCES is privileged, so it must be
+// enabled for the bot in the developer portal.
+const intents = 1<<0 | 1<<8
+
+// activityPlaying is the activity type of a game ("Playing ...")
+const activityPlaying = 0
+
+// errZombie is returned when Discord stops acknowledging heartbeats
+var errZombie = errors.New("discord: heartbeat not acknowledged")
+
+type payload struct {
+	Op       int             `json:"op"`
+	Data     json.RawMessage `json:"d"`
+	Sequence *int64          `json:"s"`
+	Type     string          `json:"t"`
+}
+
+type outgoing struct {
+	Op   int         `json:"op"`
+	Data interface{} `json:"d"`
+}
+
+// presence is a user's presence, as in GUILD_CREATE and PRESENCE_UPDATE
+type presence struct {
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Status     string `json:"status"`
+	Activities []struct {
+		Name string `json:"name"`
+		Type int    `json:"type"`
+	} `json:"activities"`
+}
+
+// games returns the games of a presence's activities; none when offline
+func (p presence) games() []string {
+	if p.Status == "offline" {
+		return nil
+	}
+	var games []string
+	for _, activity := range p.Activities {
+		if activity.Type == activityPlaying && activity.Name != "" {
+			games = append(games, activity.Name)
+		}
+	}
+	return games
+}
+
+// session connects to the gateway, identifies and feeds presences to onPresence until the
+// connection fails or ctx is done. onReady is called once identified.
+func session(ctx context.Context, gatewayURL string, token string, onReady func(), onPresence func(presence)) error {
+	config, err := websocket.NewConfig(gatewayURL, "http://localhost/")
+	if err != nil {
+		return fmt.Errorf("invalid gateway URL: %w", err)
+	}
+	config.Dialer = &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the gateway: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read loop when stopping
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	var hello payload
+	if err := websocket.JSON.Receive(conn, &hello); err != nil {
+		return fmt.Errorf("failed to receive hello: %w", err)
+	}
+	if hello.Op != opHello {
+		return fmt.Errorf("expected hello, got opcode %d", hello.Op)
+	}
+	var helloData struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"` // Milliseconds
+	}
+	if err := json.Unmarshal(hello.Data, &helloData); err != nil || helloData.HeartbeatInterval <= 0 {
+		return fmt.Errorf("invalid hello")
+	}
+
+	err = websocket.JSON.Send(conn, outgoing{Op: opIdentify, Data: map[string]interface{}{
+		"token":   token,
+		"intents": intents,
+		"properties": map[string]string{
+			"os":      "linux",
+			"browser": "game-stats-exporter",
+			"device":  "game-stats-exporter",
+		},
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to identify: %w", err)
+	}
+
+	var sequence atomic.Int64
+	sequence.Store(-1)
+	var acked atomic.Bool
+	acked.Store(true)
+	heartbeat := func() error {
+		var seq interface{}
+		if s := sequence.Load(); s >= 0 {
+			seq = s
+		}
+		return websocket.JSON.Send(conn, outgoing{Op: opHeartbeat, Data: seq})
+	}
+
+	// Heartbeat at the interval Discord asked for; a heartbeat left unacknowledged means
+	// the connection is dead, so it's closed to make the read loop reconnect
+	heartbeatErr := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(time.Duration(helloData.HeartbeatInterval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !acked.Swap(false) {
+					heartbeatErr <- errZombie
+					conn.Close()
+					return
+				}
+				if err := heartbeat(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var p payload
+		if err := websocket.JSON.Receive(conn, &p); err != nil {
+			select {
+			case err := <-heartbeatErr:
+				return err
+			default:
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("gateway connection lost: %w", err)
+		}
+		if p.Sequence != nil {
+			sequence.Store(*p.Sequence)
+		}
+
+		switch p.Op {
+		case opHeartbeatAck:
+			acked.Store(true)
+		case opHeartbeat:
+			if err := heartbeat(); err != nil {
+				return fmt.Errorf("failed to send heartbeat: %w", err)
+			}
+		case opReconnect:
+			return fmt.Errorf("gateway asked to reconnect")
+		case opInvalidSession:
+			return fmt.Errorf("gateway session invalidated (is the token valid and the presence intent enabled?)")
+		case opDispatch:
+			dispatch(ctx, p, onReady, onPresence)
+		}
+	}
+}
+
+// dispatch handles the events carrying presences
+func dispatch(ctx context.Context, p payload, onReady func(), onPresence func(presence)) {
+	switch p.Type {
+	case "READY":
+		onReady()
+	case "GUILD_CREATE":
+		var guild struct {
+			ID        string     `json:"id"`
+			Presences []presence `json:"presences"`
+		}
+		if err := json.Unmarshal(p.Data, &guild); err != nil {
+			logger.FromContext(ctx).WithError(err).Warn("Failed to decode Discord guild")
+			return
+		}
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"guild_id":  guild.ID,
+			"presences": len(guild.Presences),
+		}).Debug("Discord guild available")
+		for _, presence := range guild.Presences {
+			onPresence(presence)
+		}
+	case "PRESENCE_UPDATE":
+		var presence presence
+		if err := json.Unmarshal(p.Data, &presence); err != nil {
+			logger.FromContext(ctx).WithError(err).Warn("Failed to decode Discord presence")
+			return
+		}
+		onPresence(presence)
+	}
+}
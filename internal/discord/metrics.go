@@ -0,0 +1,46 @@
+package discord
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	playingGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "discord",
+		Subsystem: "presence",
+		Name:      "playing",
+		Help:      "Whether a watched user is playing a game right now according to their Discord presence (1); games not being played are left out",
+	}, []string{"user", "game"})
+
+	connectedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "discord",
+		Subsystem: "gateway",
+		Name:      "connected",
+		Help:      "Whether the bot is connected to the Discord gateway (1), so the presences are current",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(playingGauge)
+	prometheus.MustRegister(connectedGauge)
+}
+
+// reportPlaying replaces the games a user is reported playing
+func reportPlaying(user string, games []string) {
+	deletePlaying(user)
+	for _, game := range games {
+		playingGauge.WithLabelValues(user, game).Set(1)
+	}
+}
+
+func deletePlaying(user string) {
+	playingGauge.DeletePartialMatch(prometheus.Labels{"user": user})
+}
+
+func setConnected(connected bool) {
+	if connected {
+		connectedGauge.Set(1)
+	} else {
+		connectedGauge.Set(0)
+	}
+}
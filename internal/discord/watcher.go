@@ -0,0 +1,218 @@
+package discord
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Reconnects back off from a second to a minute; a session that lasted long enough is
+	// treated as healthy and reconnects right away
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+	healthySession    = time.Minute
+)
+
+// Config configures the Discord watcher
+type Config struct {
+	Token      string // Bot token
+	GatewayURL string // Gateway to connect to; empty for Discord's
+
+	// OnPlaying, when set, is called with a watched user's name when they start (true) or stop
+	// (false) playing any game, e.g. to poll their accounts at the active interval. It must
+	// return quickly and not call back into the watcher.
+	OnPlaying func(user string, playing bool)
+}
+
+// Watcher keeps a Discord bot connected to the gateway and reports which games the watched
+// users are playing. The bot only sees the users of the servers it's a member of.
+type Watcher struct {
+	token      string
+	gatewayURL string
+	onPlaying  func(user string, playing bool)
+
+	mu        sync.Mutex
+	users     map[string]string   // Watched user names by Discord user ID
+	games     map[string][]string // Games being played by Discord user ID, for every user seen
+	connected bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWatcher(config Config) *Watcher {
+	gatewayURL := config.GatewayURL
+	if gatewayURL == "" {
+		gatewayURL = GatewayURL
+	}
+	return &Watcher{
+		token:      config.Token,
+		gatewayURL: gatewayURL,
+		onPlaying:  config.OnPlaying,
+		users:      make(map[string]string),
+		games:      make(map[string][]string),
+	}
+}
+
+// SetUsers replaces the watched users (names by Discord user ID); the series of users no
+// longer watched are removed
+func (w *Watcher) SetUsers(users map[string]string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	previous := w.users
+	w.users = users
+	for id, name := range previous {
+		if users[id] != name {
+			deletePlaying(name)
+			if len(w.games[id]) > 0 {
+				w.notify(name, false)
+			}
+		}
+	}
+	for id, name := range users {
+		if previous[id] != name {
+			reportPlaying(name, w.games[id])
+			if len(w.games[id]) > 0 {
+				w.notify(name, true)
+			}
+		}
+	}
+}
+
+// Connected reports whether the bot is connected to the gateway, so presences are current
+func (w *Watcher) Connected() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.connected
+}
+
+// Start connects to the gateway in the background, reconnecting until Stop
+func (w *Watcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.wg.Add(1)
+	go w.run(ctx)
+}
+
+// Stop disconnects from the gateway
+func (w *Watcher) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.wg.Wait()
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := session(ctx, w.gatewayURL, w.token, w.ready, w.update)
+		w.disconnected()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > healthySession {
+			delay = minReconnectDelay
+		}
+		logger.Log.WithFields(logrus.Fields{
+			"error":        err.Error(),
+			"reconnect_in": delay.String(),
+		}).Warn("Discord gateway disconnected")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+func (w *Watcher) ready() {
+	w.mu.Lock()
+	w.connected = true
+	watched := len(w.users)
+	w.mu.Unlock()
+
+	setConnected(true)
+	logger.Log.WithField("watched_users", watched).Info("Connected to the Discord gateway")
+}
+
+// disconnected forgets every presence: they're unknown until the next session's guilds arrive
+func (w *Watcher) disconnected() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.connected = false
+	setConnected(false)
+	for id, games := range w.games {
+		if name, watched := w.users[id]; watched {
+			deletePlaying(name)
+			if len(games) > 0 {
+				w.notify(name, false)
+			}
+		}
+	}
+	w.games = make(map[string][]string)
+}
+
+// update applies a user's presence
+func (w *Watcher) update(p presence) {
+	games := p.games()
+	sort.Strings(games)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	previous := w.games[p.User.ID]
+	if len(games) == 0 {
+		delete(w.games, p.User.ID)
+	} else {
+		w.games[p.User.ID] = games
+	}
+
+	name, watched := w.users[p.User.ID]
+	if !watched || equal(previous, games) {
+		return
+	}
+	reportPlaying(name, games)
+	if (len(previous) > 0) != (len(games) > 0) {
+		w.notify(name, len(games) > 0)
+	}
+	logger.Log.WithFields(logrus.Fields{
+		"user":  name,
+		"games": games,
+	}).Debug("Discord presence changed")
+}
+
+// notify calls OnPlaying; it's called with w.mu held, so changes arrive in order
+func (w *Watcher) notify(name string, playing bool) {
+	if w.onPlaying != nil {
+		w.onPlaying(name, playing)
+	}
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package discord
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// waitFor polls cond until it holds or a few seconds pass
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetDiscordPresence("100", "Old School RuneScape")
+	srv.SetDiscordPresence("200", "Hades")
+
+	var mu sync.Mutex
+	playing := make(map[string]bool)
+	watcher := NewWatcher(Config{
+		Token:      "token",
+		GatewayURL: srv.DiscordGatewayURL(),
+		OnPlaying: func(user string, isPlaying bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			playing[user] = isPlaying
+		},
+	})
+	watcher.SetUsers(map[string]string{"100": "alex"})
+	watcher.Start()
+	defer watcher.Stop()
+
+	// The guild's presences are applied on connecting; user 200 isn't watched
+	waitFor(t, "the initial presence", func() bool {
+		return testutil.ToFloat64(playingGauge.WithLabelValues("alex", "Old School RuneScape")) == 1
+	})
+	if !watcher.Connected() {
+		t.Error("Connected() = false after the guild arrived")
+	}
+	if got := testutil.CollectAndCount(playingGauge); got != 1 {
+		t.Errorf("reported %d series, want 1 (only watched users)", got)
+	}
+
+	// Switching games replaces the series; stopping removes it and reports not playing
+	srv.SetDiscordPresence("100", "Stardew Valley")
+	waitFor(t, "the game switch", func() bool {
+		return testutil.ToFloat64(playingGauge.WithLabelValues("alex", "Stardew Valley")) == 1
+	})
+	if got := testutil.CollectAndCount(playingGauge); got != 1 {
+		t.Errorf("reported %d series after switching games, want 1", got)
+	}
+	srv.SetDiscordPresence("100")
+	waitFor(t, "the presence to clear", func() bool {
+		return testutil.CollectAndCount(playingGauge) == 0
+	})
+	mu.Lock()
+	if playing["alex"] {
+		t.Error("OnPlaying left alex playing after they stopped")
+	}
+	mu.Unlock()
+
+	// A newly watched user is reported from the presence already seen
+	watcher.SetUsers(map[string]string{"200": "sam"})
+	if got := testutil.ToFloat64(playingGauge.WithLabelValues("sam", "Hades")); got != 1 {
+		t.Errorf("sam playing Hades = %v, want 1", got)
+	}
+	mu.Lock()
+	if !playing["sam"] {
+		t.Error("OnPlaying wasn't told sam is playing")
+	}
+	mu.Unlock()
+}
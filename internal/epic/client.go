@@ -0,0 +1,120 @@
+package epic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// LibraryServiceOrigin serves the library and playtime of the token's account
+	LibraryServiceOrigin = "https://library-service.live.use1a.on.epicgames.com"
+	PlaytimeEndpoint     = "/library/api/public/playtime/account/%s/all"
+	LibraryItemsEndpoint = "/library/api/public/items"
+)
+
+// ErrUnauthorized is returned when Epic rejects the access token (expired, or for another account)
+var ErrUnauthorized = errors.New("epic: access token rejected (expired, or not the account's own)")
+
+type Client struct {
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client authenticating with an account's access token; transport is the
+// upstream transport (e.g. fixture replay), nil for the default
+func NewClient(token string, transport http.RoundTripper) *Client {
+	return &Client{
+		token: token,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// GetPlaytime retrieves the total playtime of every title the account has played
+func (c *Client) GetPlaytime(ctx context.Context, accountId string) ([]Playtime, error) {
+	var playtime []Playtime
+	url := LibraryServiceOrigin + fmt.Sprintf(PlaytimeEndpoint, neturl.PathEscape(accountId))
+	if err := c.getJSON(ctx, url, &playtime); err != nil {
+		return nil, err
+	}
+	return playtime, nil
+}
+
+// GetLibraryItems retrieves every title in the token's account library, following the cursor
+func (c *Client) GetLibraryItems(ctx context.Context) ([]LibraryItem, error) {
+	var items []LibraryItem
+	cursor := ""
+	for {
+		query := neturl.Values{}
+		query.Set("includeMetadata", "true")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		var resp libraryItemsResponse
+		if err := c.getJSON(ctx, LibraryServiceOrigin+LibraryItemsEndpoint+"?"+query.Encode(), &resp); err != nil {
+			return nil, err
+		}
+		items = append(items, resp.Records...)
+
+		next := resp.ResponseMetadata.NextCursor
+		if next == "" || next == cursor || len(resp.Records) == 0 {
+			return items, nil
+		}
+		cursor = next
+	}
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, target interface{}) (err error) {
+	endpoint := strings.SplitN(strings.TrimPrefix(url, LibraryServiceOrigin), "?", 2)[0]
+	ctx, span := tracing.Start(ctx, "epic.api", attribute.String("epic.endpoint", endpoint))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "bearer "+c.token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited by Epic (%d)", resp.StatusCode)
+	default:
+		return fmt.Errorf("unexpected status code %d from Epic", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package epic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// Playtime is cached like Steam's owned games, and served stale for as long again
+	playtimeTTL = 30 * time.Minute
+	// Titles only change when the library does
+	libraryTTL = 24 * time.Hour
+)
+
+// ErrUnknownAccount is returned when collecting an account without a configured token
+var ErrUnknownAccount = errors.New("unknown Epic account")
+
+// Config configures the Epic collector
+type Config struct {
+	Tokens    map[string]string // Access token per account ID; Epic only serves an account's data to its own token
+	Transport http.RoundTripper // Upstream transport, e.g. fixture replay; nil for the default
+}
+
+// Collector reports the playtime of Epic Games accounts, so it can be graphed next to Steam's
+type Collector struct {
+	clients map[string]*Client // By account ID
+	cache   *cache.Cache
+}
+
+func NewCollector(config Config, cache *cache.Cache) *Collector {
+	clients := make(map[string]*Client, len(config.Tokens))
+	for accountId, token := range config.Tokens {
+		clients[accountId] = NewClient(token, config.Transport)
+	}
+	return &Collector{
+		clients: clients,
+		cache:   cache,
+	}
+}
+
+// Collect reports an account's playtime per title
+func (c *Collector) Collect(ctx context.Context, accountId string) (err error) {
+	ctx, span := tracing.Start(ctx, "epic.collect", attribute.String("epic.account_id", accountId))
+	defer func() { tracing.End(span, err) }()
+
+	games, err := c.Playtime(ctx, accountId)
+	if err != nil {
+		return err
+	}
+	for _, game := range games {
+		ReportPlaytime(game, accountId)
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"account_id": accountId,
+		"games":      len(games),
+	}).Info("Completed Epic metrics collection")
+	return nil
+}
+
+// Playtime returns an account's playtime per title from the cache, fetching on a miss. Titles
+// are named from the account's library; when it can't be read they're named by app name.
+func (c *Collector) Playtime(ctx context.Context, accountId string) ([]GamePlaytime, error) {
+	client, ok := c.clients[accountId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, accountId)
+	}
+
+	cacheKey := fmt.Sprintf("epic:playtime:%s", accountId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, playtimeTTL, playtimeTTL, func(ctx context.Context) ([]byte, error) {
+		playtime, err := client.GetPlaytime(ctx, accountId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get playtime: %w", err)
+		}
+		return json.Marshal(playtime)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var playtime []Playtime
+	if err := json.Unmarshal(data, &playtime); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached playtime: %w", err)
+	}
+
+	names, err := c.titles(ctx, client, accountId)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"account_id": accountId,
+			"error":      err.Error(),
+		}).Warn("Failed to get Epic library, naming games by app name")
+	}
+
+	games := make([]GamePlaytime, 0, len(playtime))
+	for _, entry := range playtime {
+		name := names[entry.ArtifactID]
+		if name == "" {
+			name = entry.ArtifactID
+		}
+		games = append(games, GamePlaytime{
+			AppName:         entry.ArtifactID,
+			Name:            name,
+			PlaytimeSeconds: entry.TotalTime,
+		})
+	}
+	return games, nil
+}
+
+// titles maps the app names of the account's library to their titles
+func (c *Collector) titles(ctx context.Context, client *Client, accountId string) (map[string]string, error) {
+	cacheKey := fmt.Sprintf("epic:library:%s", accountId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, libraryTTL, libraryTTL, func(ctx context.Context) ([]byte, error) {
+		items, err := client.GetLibraryItems(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get library: %w", err)
+		}
+		names := make(map[string]string, len(items))
+		for _, item := range items {
+			if item.SandboxName != "" {
+				names[item.AppName] = item.SandboxName
+			}
+		}
+		return json.Marshal(names)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var names map[string]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached library: %w", err)
+	}
+	return names, nil
+}
@@ -0,0 +1,91 @@
+package epic
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const (
+	testAccountID  = "4f1e2c0a9b8d4e6f8a7b6c5d4e3f2a1b"
+	otherAccountID = "0a1b2c3d4e5f60718293a4b5c6d7e8f9"
+)
+
+func newTestCollector(t *testing.T, srv *testserver.Server, tokens map[string]string) *Collector {
+	t.Helper()
+	return NewCollector(Config{Tokens: tokens, Transport: srv.Transport()}, testserver.NewCache(t))
+}
+
+func TestCollectNamesTitlesFromLibrary(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddEpicAccount(testAccountID, testserver.EpicAccount{
+		Token: "token",
+		Games: []testserver.EpicGame{
+			{AppName: "Fortnite", Title: "Fortnite", PlaytimeSeconds: 7200},
+			{AppName: "Sugar", Title: "Rocket League", PlaytimeSeconds: 3600},
+			{AppName: "Kinglet", Title: "Celeste"},
+			{AppName: "Unnamed", PlaytimeSeconds: 60},
+		},
+	})
+	collector := newTestCollector(t, srv, map[string]string{testAccountID: "token"})
+	ctx := context.Background()
+
+	games, err := collector.Playtime(ctx, testAccountID)
+	if err != nil {
+		t.Fatalf("Playtime: %v", err)
+	}
+	// Playtime names titles by app name; the library (two pages) has their titles, and a
+	// title without one keeps its app name. Unplayed titles have no playtime.
+	names := map[string]string{}
+	for _, game := range games {
+		names[game.AppName] = game.Name
+	}
+	want := map[string]string{"Fortnite": "Fortnite", "Sugar": "Rocket League", "Unnamed": "Unnamed"}
+	if len(names) != len(want) {
+		t.Errorf("games = %v, want %v", names, want)
+	}
+	for appName, name := range want {
+		if names[appName] != name {
+			t.Errorf("%s is named %q, want %q", appName, names[appName], name)
+		}
+	}
+	if got := srv.Requests("/library/api/public/items"); got != 2 {
+		t.Errorf("library requests = %d, want 2 (both pages)", got)
+	}
+
+	if err := collector.Collect(ctx, testAccountID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(ownedGamePlaytimeGauge.WithLabelValues("Sugar", "Rocket League", testAccountID)); got != 3600 {
+		t.Errorf("Rocket League playtime = %v, want 3600", got)
+	}
+	// The collection was served from what Playtime cached
+	if got := srv.Requests("/library/api/public/playtime/account/" + testAccountID + "/all"); got != 1 {
+		t.Errorf("playtime requests = %d, want 1", got)
+	}
+}
+
+func TestCollectTokenOfAnotherAccount(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddEpicAccount(testAccountID, testserver.EpicAccount{Token: "token"})
+	srv.AddEpicAccount(otherAccountID, testserver.EpicAccount{Token: "other"})
+	ctx := context.Background()
+
+	// Epic only serves an account's playtime to its own token, so a token pasted under the
+	// wrong account ID is rejected like an expired one
+	collector := newTestCollector(t, srv, map[string]string{otherAccountID: "token"})
+	if err := collector.Collect(ctx, otherAccountID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Collect with another account's token = %v, want ErrUnauthorized", err)
+	}
+
+	collector = newTestCollector(t, srv, map[string]string{testAccountID: "expired"})
+	if err := collector.Collect(ctx, testAccountID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Collect with an expired token = %v, want ErrUnauthorized", err)
+	}
+	if err := collector.Collect(ctx, "unconfigured"); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("Collect of an unconfigured account = %v, want ErrUnknownAccount", err)
+	}
+}
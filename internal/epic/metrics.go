@@ -0,0 +1,25 @@
+package epic
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var ownedGamePlaytimeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "epic",
+	Subsystem: "owned_games",
+	Name:      "playtime_seconds",
+	Help:      "Amount of time an Epic Games title has been played (in seconds)",
+}, []string{"app_name", "game_name", "account_id"})
+
+func init() {
+	prometheus.MustRegister(ownedGamePlaytimeGauge)
+}
+
+// ReportPlaytime reports a title's playtime
+func ReportPlaytime(game GamePlaytime, accountId string) {
+	ownedGamePlaytimeGauge.With(prometheus.Labels{
+		"app_name":   game.AppName,
+		"game_name":  game.Name,
+		"account_id": accountId,
+	}).Set(float64(game.PlaytimeSeconds))
+}
@@ -0,0 +1,30 @@
+package epic
+
+// Playtime is an account's total playtime of one title, as returned by the library service
+type Playtime struct {
+	AccountID  string `json:"accountId"`
+	ArtifactID string `json:"artifactId"` // The title's app name, e.g. Fortnite
+	TotalTime  int64  `json:"totalTime"`  // In seconds
+}
+
+// LibraryItem is a title in the account's library
+type LibraryItem struct {
+	AppName       string `json:"appName"`
+	Namespace     string `json:"namespace"`
+	CatalogItemID string `json:"catalogItemId"`
+	SandboxName   string `json:"sandboxName"` // Usually the game's name
+}
+
+type libraryItemsResponse struct {
+	ResponseMetadata struct {
+		NextCursor string `json:"nextCursor"`
+	} `json:"responseMetadata"`
+	Records []LibraryItem `json:"records"`
+}
+
+// GamePlaytime is a title's playtime with its display name
+type GamePlaytime struct {
+	AppName         string `json:"app_name"`
+	Name            string `json:"name"`
+	PlaytimeSeconds int64  `json:"playtime_seconds"`
+}
@@ -0,0 +1,153 @@
+package family
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// osrsMode is the OSRS mode whose XP is summed
+const osrsMode = "vanilla"
+
+// ErrUnknownFamily is returned when collecting a family that isn't configured
+var ErrUnknownFamily = errors.New("unknown family")
+
+type SteamSource interface {
+	OwnedGames(ctx context.Context, steamId string) (steam.OwnedGamesResponse, error)
+}
+
+type OSRSSource interface {
+	PlayerStats(ctx context.Context, rsn string, mode string) ([]osrs.SkillInfo, []osrs.MinigameInfo, error)
+}
+
+// Family groups the accounts of a person or household whose playtime and XP are summed
+type Family struct {
+	Name        string
+	SteamIDs    []string
+	OSRSPlayers []string
+}
+
+// Aggregator reports the combined playtime and XP of configured families. Member data is
+// read from the cache (fetching on a miss), so aggregating polled accounts costs no upstream
+// requests.
+type Aggregator struct {
+	steam SteamSource // nil when Steam isn't configured
+	osrs  OSRSSource
+
+	mu       sync.RWMutex
+	families map[string]Family
+}
+
+func NewAggregator(steam SteamSource, osrs OSRSSource) *Aggregator {
+	return &Aggregator{
+		steam:    steam,
+		osrs:     osrs,
+		families: make(map[string]Family),
+	}
+}
+
+// SetFamilies replaces the configured families
+func (a *Aggregator) SetFamilies(families []Family) {
+	byName := make(map[string]Family, len(families))
+	for _, family := range families {
+		byName[family.Name] = family
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.families = byName
+}
+
+// Collect reports a family's aggregates. Members that can't be fetched are left out of the
+// sums; it fails only when none could be.
+func (a *Aggregator) Collect(ctx context.Context, name string) (err error) {
+	a.mu.RLock()
+	family, ok := a.families[name]
+	a.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFamily, name)
+	}
+
+	ctx, span := tracing.Start(ctx, "family.collect", attribute.String("family", name))
+	defer func() { tracing.End(span, err) }()
+
+	var errs []error
+	steamAccounts := 0
+	playtime := make(map[string]int) // game -> minutes
+	if len(family.SteamIDs) > 0 && a.steam == nil {
+		logger.FromContext(ctx).WithField("family", name).Warn("Family has Steam IDs but STEAM_KEY is not set, skipping them")
+	}
+	for _, steamId := range family.SteamIDs {
+		if a.steam == nil {
+			break
+		}
+		ownedGames, err := a.steam.OwnedGames(ctx, steamId)
+		if err != nil {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"family":   name,
+				"steam_id": steamId,
+				"error":    err.Error(),
+			}).Warn("Failed to get owned games of family member, continuing")
+			errs = append(errs, err)
+			continue
+		}
+		for _, game := range ownedGames.Games {
+			playtime[game.Name] += game.PlaytimeForever
+		}
+		steamAccounts++
+	}
+
+	osrsAccounts := 0
+	xp := make(map[string]int64) // skill -> XP
+	for _, rsn := range family.OSRSPlayers {
+		skills, _, err := a.osrs.PlayerStats(ctx, rsn, osrsMode)
+		if err != nil {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"family": name,
+				"rsn":    rsn,
+				"error":  err.Error(),
+			}).Warn("Failed to get hiscores of family member, continuing")
+			errs = append(errs, err)
+			continue
+		}
+		for _, skill := range skills {
+			// Unranked skills have no XP (-1)
+			if value, err := strconv.ParseInt(skill.XP, 10, 64); err == nil && value > 0 {
+				xp[skill.Name] += value
+			}
+		}
+		osrsAccounts++
+	}
+
+	if steamAccounts+osrsAccounts == 0 && len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	resetMetrics()
+	reportAccounts(name, "steam", steamAccounts)
+	reportAccounts(name, "osrs", osrsAccounts)
+	for game, minutes := range playtime {
+		reportPlaytime(name, game, minutes)
+	}
+	for skill, value := range xp {
+		reportXP(name, skill, value)
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"family":         name,
+		"steam_accounts": steamAccounts,
+		"osrs_accounts":  osrsAccounts,
+		"games":          len(playtime),
+		"failed":         len(errs),
+	}).Info("Completed family collection")
+	return nil
+}
@@ -0,0 +1,86 @@
+package family
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestAggregator(t *testing.T, srv *testserver.Server) *Aggregator {
+	t.Helper()
+	c := testserver.NewCache(t)
+
+	steamCollector := steam.NewCollector(steam.Config{APIKeys: []string{"key"}, Transport: srv.Transport()}, c)
+	return NewAggregator(steamCollector, osrs.NewCollector(c, srv.Transport()))
+}
+
+func testOSRSPlayer(xp int64) testserver.OSRSPlayer {
+	var player testserver.OSRSPlayer
+	for range osrs.Skills() {
+		player.Skills = append(player.Skills, testserver.Skill{Rank: 5000, Level: 50, XP: xp})
+	}
+	return player
+}
+
+func TestCollect(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddSteamUser("76561197960287930", testserver.SteamUser{
+		Name: "alex",
+		Games: []testserver.Game{
+			{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 120},
+			{AppID: 570, Name: "Dota 2", PlaytimeMinutes: 30},
+		},
+	})
+	srv.AddSteamUser("76561197960287931", testserver.SteamUser{
+		Name:  "sam",
+		Games: []testserver.Game{{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 60}},
+	})
+	srv.AddOSRSPlayer("Alex", testOSRSPlayer(1000))
+	srv.AddOSRSPlayer("Sam", testOSRSPlayer(500))
+
+	aggregator := newTestAggregator(t, srv)
+	aggregator.SetFamilies([]Family{{
+		Name:        "smiths",
+		SteamIDs:    []string{"76561197960287930", "76561197960287931"},
+		OSRSPlayers: []string{"Alex", "Sam", "nobody"},
+	}})
+
+	// The unknown player is left out rather than failing the family
+	if err := aggregator.Collect(context.Background(), "smiths"); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(playtimeGauge.WithLabelValues("smiths", "Team Fortress 2")); got != 180*60 {
+		t.Errorf("Team Fortress 2 playtime = %v, want %v", got, 180*60)
+	}
+	if got := testutil.ToFloat64(playtimeGauge.WithLabelValues("smiths", "Dota 2")); got != 30*60 {
+		t.Errorf("Dota 2 playtime = %v, want %v", got, 30*60)
+	}
+	if got := testutil.ToFloat64(osrsXPGauge.WithLabelValues("smiths", osrs.Skills()[1])); got != 1500 {
+		t.Errorf("%s XP = %v, want 1500", osrs.Skills()[1], got)
+	}
+	if got := testutil.ToFloat64(accountsGauge.WithLabelValues("smiths", "osrs")); got != 2 {
+		t.Errorf("osrs accounts = %v, want 2", got)
+	}
+}
+
+func TestCollectUnknownFamily(t *testing.T) {
+	aggregator := newTestAggregator(t, testserver.New(t))
+
+	if err := aggregator.Collect(context.Background(), "nobody"); !errors.Is(err, ErrUnknownFamily) {
+		t.Fatalf("Collect of an unknown family = %v, want ErrUnknownFamily", err)
+	}
+}
+
+func TestCollectAllMembersFailing(t *testing.T) {
+	aggregator := newTestAggregator(t, testserver.New(t))
+	aggregator.SetFamilies([]Family{{Name: "ghosts", OSRSPlayers: []string{"nobody"}}})
+
+	if err := aggregator.Collect(context.Background(), "ghosts"); err == nil {
+		t.Fatal("Collect succeeded with no member available")
+	}
+}
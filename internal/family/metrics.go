@@ -0,0 +1,60 @@
+package family
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	playtimeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "family",
+		Name:      "playtime_seconds_total",
+		Help:      "Combined Steam playtime of a family's accounts per game",
+	}, []string{"family", "game"})
+
+	osrsXPGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "family",
+		Subsystem: "osrs",
+		Name:      "xp_total",
+		Help:      "Combined OSRS experience of a family's accounts per skill",
+	}, []string{"family", "skill"})
+
+	accountsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "family",
+		Name:      "accounts",
+		Help:      "Number of a family's accounts included in the aggregates; lower than configured when some couldn't be fetched",
+	}, []string{"family", "platform"})
+)
+
+func init() {
+	prometheus.MustRegister(playtimeGauge)
+	prometheus.MustRegister(osrsXPGauge)
+	prometheus.MustRegister(accountsGauge)
+}
+
+// resetMetrics removes the previously reported family, so each scrape only serves one
+func resetMetrics() {
+	playtimeGauge.Reset()
+	osrsXPGauge.Reset()
+	accountsGauge.Reset()
+}
+
+func reportPlaytime(family string, game string, minutes int) {
+	playtimeGauge.With(prometheus.Labels{
+		"family": family,
+		"game":   game,
+	}).Set(float64(60 * minutes))
+}
+
+func reportXP(family string, skill string, xp int64) {
+	osrsXPGauge.With(prometheus.Labels{
+		"family": family,
+		"skill":  skill,
+	}).Set(float64(xp))
+}
+
+func reportAccounts(family string, platform string, accounts int) {
+	accountsGauge.With(prometheus.Labels{
+		"family":   family,
+		"platform": platform,
+	}).Set(float64(accounts))
+}
@@ -0,0 +1,126 @@
+// Package fixtures records upstream HTTP responses to disk and replays them, for offline
+// development and deterministic runs of the parsers against real Steam and OSRS responses.
+package fixtures
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Modes
+const (
+	ModeAuto   = "auto"   // Replay recorded responses, recording the ones missing
+	ModeRecord = "record" // Always call upstream and (re)record every response
+	ModeReplay = "replay" // Never call upstream; a missing fixture is an error
+)
+
+// secretParams are left out of fixture names so fixtures can be shared
+var secretParams = map[string]bool{"key": true}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Transport is an http.RoundTripper that records and replays responses in a directory.
+// Each fixture is a raw HTTP response (status line, headers and body) that can be edited by hand.
+type Transport struct {
+	dir  string
+	mode string
+	next http.RoundTripper
+
+	mu sync.Mutex // Serializes writes of the same fixture
+}
+
+// NewTransport wraps next (http.DefaultTransport when nil) to record and replay in dir
+func NewTransport(dir string, mode string, next http.RoundTripper) (*Transport, error) {
+	switch mode {
+	case "":
+		mode = ModeAuto
+	case ModeAuto, ModeRecord, ModeReplay:
+	default:
+		return nil, fmt.Errorf("invalid fixture mode %q: expected auto, record or replay", mode)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	return &Transport{dir: dir, mode: mode, next: next}, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Join(t.dir, Name(req))
+
+	if t.mode != ModeRecord {
+		if data, err := os.ReadFile(path); err == nil {
+			logger.Log.WithField("fixture", path).Debug("Replaying upstream response from fixture")
+			return http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+		}
+		if t.mode == ModeReplay {
+			return nil, fmt.Errorf("no fixture recorded for %s %s (expected %s)", req.Method, redactedURL(req), path)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// A 304 only makes sense with the validators of the request that got it
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to record fixture: %w", err)
+	}
+
+	t.mu.Lock()
+	err = os.WriteFile(path, data, 0o644)
+	t.mu.Unlock()
+	if err != nil {
+		logger.Log.WithError(err).WithField("fixture", path).Warn("Failed to write fixture")
+	} else {
+		logger.Log.WithFields(logrus.Fields{
+			"fixture": path,
+			"status":  resp.StatusCode,
+		}).Info("Recorded upstream response")
+	}
+	return resp, nil
+}
+
+// Name returns the fixture file name of a request: the host, path and query (without secrets),
+// made file-system safe, plus a short hash so similar requests don't collide
+func Name(req *http.Request) string {
+	url := redactedURL(req)
+	readable := unsafeChars.ReplaceAllString(strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://"), "_")
+	if len(readable) > 120 {
+		readable = readable[:120]
+	}
+	sum := sha256.Sum256([]byte(req.Method + " " + url))
+	return readable + "-" + hex.EncodeToString(sum[:4]) + ".http"
+}
+
+// redactedURL is the request URL without secret parameters, with the query sorted
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	for param := range secretParams {
+		query.Del(param)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,239 @@
+package history
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/sirupsen/logrus"
+)
+
+// Target kinds, matching the polling manager's
+const (
+	kindSteam = "steam"
+	kindOSRS  = "osrs"
+)
+
+// pollingMode is the OSRS mode background polling collects
+const pollingMode = "vanilla"
+
+type SteamSource interface {
+	OwnedGames(ctx context.Context, steamId string) (steam.OwnedGamesResponse, error)
+	Profile(ctx context.Context, steamId string) (steam.Profile, error)
+	AchievementUnlocks(ctx context.Context, steamId string, appId uint64) ([]steam.AchievementUnlock, error)
+}
+
+type OSRSSource interface {
+	PlayerStats(ctx context.Context, rsn string, mode string) ([]osrs.SkillInfo, []osrs.MinigameInfo, error)
+}
+
+// Recorder snapshots each polled target into the store. Only values that changed since the
+// previous snapshot are written (everything is written on the first one after startup), so
+// idle targets don't grow the store.
+type Recorder struct {
+	store *Store
+	steam SteamSource // nil when Steam isn't configured
+	osrs  OSRSSource
+
+	mu   sync.Mutex
+	last map[string]map[string]int64 // kind:id -> series -> last recorded value
+}
+
+func NewRecorder(store *Store, steam SteamSource, osrs OSRSSource) *Recorder {
+	return &Recorder{
+		store: store,
+		steam: steam,
+		osrs:  osrs,
+		last:  make(map[string]map[string]int64),
+	}
+}
+
+// Record snapshots a target that was just collected; the data is read back from the cache.
+// Failures are logged, as history is best effort.
+func (r *Recorder) Record(ctx context.Context, kind string, id string) {
+	var err error
+	switch kind {
+	case kindSteam:
+		if r.steam == nil {
+			return
+		}
+		err = r.recordSteam(ctx, id)
+	case kindOSRS:
+		err = r.recordOSRS(ctx, id)
+	default:
+		return
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"kind":   kind,
+			"target": id,
+			"error":  err.Error(),
+		}).Error("Failed to record history")
+	}
+}
+
+func (r *Recorder) recordSteam(ctx context.Context, steamId string) error {
+	ownedGames, err := r.steam.OwnedGames(ctx, steamId)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]int64, len(ownedGames.Games))
+	for _, game := range ownedGames.Games {
+		values[strconv.FormatUint(game.AppId, 10)] = int64(game.PlaytimeForever)
+	}
+	changed := r.changed(kindSteam+":"+steamId, values)
+
+	var games []GameSnapshot
+	for _, game := range ownedGames.Games {
+		if changed[strconv.FormatUint(game.AppId, 10)] {
+			games = append(games, GameSnapshot{
+				AppID:           game.AppId,
+				Name:            game.Name,
+				PlaytimeMinutes: int64(game.PlaytimeForever),
+			})
+		}
+	}
+	if err := r.store.RecordPlaytime(ctx, steamId, time.Now(), games); err != nil {
+		r.forget(kindSteam + ":" + steamId)
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"steam_id": steamId,
+		"games":    len(games),
+	}).Debug("Recorded playtime history")
+	return r.recordUnlocks(ctx, steamId)
+}
+
+// recordUnlocks stores the unlock times of the achievements in games whose unlocked count
+// changed. A game that fails is retried on the next snapshot.
+func (r *Recorder) recordUnlocks(ctx context.Context, steamId string) error {
+	profile, err := r.steam.Profile(ctx, steamId)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]int64, len(profile.Games))
+	for _, game := range profile.Games {
+		unlocked := int64(0)
+		for _, achievement := range game.Achievements {
+			if achievement.Achieved == 1 {
+				unlocked++
+			}
+		}
+		if unlocked > 0 {
+			values[strconv.FormatUint(game.AppId, 10)] = unlocked
+		}
+	}
+	key := kindSteam + "_achievements:" + steamId
+	changed := r.changed(key, values)
+
+	var unlocks []Unlock
+	for _, game := range profile.Games {
+		series := strconv.FormatUint(game.AppId, 10)
+		if !changed[series] {
+			continue
+		}
+		gameUnlocks, err := r.steam.AchievementUnlocks(ctx, steamId, game.AppId)
+		if err != nil {
+			r.forgetSeries(key, series)
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"steam_id": steamId,
+				"app_id":   game.AppId,
+				"error":    err.Error(),
+			}).Warn("Failed to get achievement unlock times, retrying on the next snapshot")
+			continue
+		}
+		for _, unlock := range gameUnlocks {
+			unlocks = append(unlocks, Unlock{
+				Time:        time.Unix(unlock.UnlockTime, 0),
+				AppID:       game.AppId,
+				GameName:    game.Name,
+				Achievement: unlock.APIName,
+			})
+		}
+	}
+	if err := r.store.RecordUnlocks(ctx, steamId, unlocks); err != nil {
+		r.forget(key)
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"steam_id": steamId,
+		"unlocks":  len(unlocks),
+	}).Debug("Recorded achievement unlocks")
+	return nil
+}
+
+func (r *Recorder) recordOSRS(ctx context.Context, rsn string) error {
+	stats, _, err := r.osrs.PlayerStats(ctx, rsn, pollingMode)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]int64, len(stats))
+	for _, stat := range stats {
+		xp, _ := strconv.ParseInt(stat.XP, 10, 64)
+		values[stat.Name] = xp
+	}
+	changed := r.changed(kindOSRS+":"+rsn, values)
+
+	var skills []SkillSnapshot
+	for _, stat := range stats {
+		if !changed[stat.Name] {
+			continue
+		}
+		level, _ := strconv.Atoi(stat.Level)
+		skills = append(skills, SkillSnapshot{
+			Skill: stat.Name,
+			Level: level,
+			XP:    values[stat.Name],
+		})
+	}
+	if err := r.store.RecordSkills(ctx, rsn, pollingMode, time.Now(), skills); err != nil {
+		r.forget(kindOSRS + ":" + rsn)
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"rsn":    rsn,
+		"skills": len(skills),
+	}).Debug("Recorded XP history")
+	return nil
+}
+
+// changed returns the series whose value differs from the last snapshot, and remembers the new values
+func (r *Recorder) changed(key string, values map[string]int64) map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := r.last[key]
+	changed := make(map[string]bool)
+	for series, value := range values {
+		if previous, ok := last[series]; !ok || previous != value {
+			changed[series] = true
+		}
+	}
+	r.last[key] = values
+	return changed
+}
+
+// forget drops the remembered values after a failed write, so the next snapshot writes everything
+func (r *Recorder) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.last, key)
+}
+
+// forgetSeries drops one remembered value, so the next snapshot writes that series again
+func (r *Recorder) forgetSeries(key string, series string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.last[key], series)
+}
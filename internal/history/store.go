@@ -0,0 +1,303 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" driver
+	_ "modernc.org/sqlite"             // Registers the "sqlite" driver
+)
+
+// Supported backends
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// schema creates the snapshot tables. Times are unix seconds so the same DDL works on both backends.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS osrs_skill_history (
+		recorded_at BIGINT NOT NULL,
+		rsn TEXT NOT NULL,
+		mode TEXT NOT NULL,
+		skill TEXT NOT NULL,
+		level INTEGER NOT NULL,
+		xp BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS osrs_skill_history_lookup ON osrs_skill_history (rsn, mode, skill, recorded_at)`,
+	`CREATE TABLE IF NOT EXISTS steam_playtime_history (
+		recorded_at BIGINT NOT NULL,
+		steam_id TEXT NOT NULL,
+		app_id BIGINT NOT NULL,
+		game_name TEXT NOT NULL,
+		playtime_minutes BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS steam_playtime_history_lookup ON steam_playtime_history (steam_id, app_id, recorded_at)`,
+	`CREATE TABLE IF NOT EXISTS steam_achievement_unlocks (
+		steam_id TEXT NOT NULL,
+		app_id BIGINT NOT NULL,
+		game_name TEXT NOT NULL,
+		achievement TEXT NOT NULL,
+		unlocked_at BIGINT NOT NULL,
+		PRIMARY KEY (steam_id, app_id, achievement)
+	)`,
+	`CREATE INDEX IF NOT EXISTS steam_achievement_unlocks_timeline ON steam_achievement_unlocks (steam_id, unlocked_at)`,
+}
+
+// SkillSnapshot is one skill's XP at a point in time
+type SkillSnapshot struct {
+	Skill string
+	Level int
+	XP    int64
+}
+
+// GameSnapshot is one game's playtime at a point in time
+type GameSnapshot struct {
+	AppID           uint64
+	Name            string
+	PlaytimeMinutes int64
+}
+
+// Unlock is an achievement unlocked at a point in time
+type Unlock struct {
+	Time        time.Time `json:"time"`
+	AppID       uint64    `json:"appid"`
+	GameName    string    `json:"game_name"`
+	Achievement string    `json:"achievement"`
+}
+
+// Point is a recorded value
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value int64     `json:"value"`
+}
+
+// Store keeps snapshots of polled targets in SQLite or Postgres, so history outlives
+// Prometheus retention
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+// Open connects to the store and creates its tables. dsn is a file path for SQLite and a
+// connection URL for Postgres.
+func Open(ctx context.Context, driver string, dsn string) (*Store, error) {
+	var sqlDriver string
+	switch driver {
+	case DriverSQLite:
+		sqlDriver = "sqlite"
+		if dir := filepath.Dir(dsn); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("failed to create history directory: %w", err)
+			}
+		}
+	case DriverPostgres:
+		sqlDriver = "pgx"
+	default:
+		return nil, fmt.Errorf("unknown history driver %q (expected %q or %q)", driver, DriverSQLite, DriverPostgres)
+	}
+
+	db, err := sql.Open(sqlDriver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history store: %w", err)
+	}
+	if driver == DriverSQLite {
+		// SQLite allows a single writer; serialise rather than fail with SQLITE_BUSY
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to history store: %w", err)
+	}
+	for _, statement := range schema {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create history schema: %w", err)
+		}
+	}
+
+	return &Store{
+		db:     db,
+		driver: driver,
+	}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// RecordSkills stores a player's skills for a mode
+func (s *Store) RecordSkills(ctx context.Context, rsn string, mode string, at time.Time, skills []SkillSnapshot) error {
+	return s.insert(ctx, len(skills),
+		"INSERT INTO osrs_skill_history (recorded_at, rsn, mode, skill, level, xp) VALUES (?, ?, ?, ?, ?, ?)",
+		func(i int) []interface{} {
+			return []interface{}{at.Unix(), rsn, mode, skills[i].Skill, skills[i].Level, skills[i].XP}
+		})
+}
+
+// RecordPlaytime stores a user's playtime per game
+func (s *Store) RecordPlaytime(ctx context.Context, steamId string, at time.Time, games []GameSnapshot) error {
+	return s.insert(ctx, len(games),
+		"INSERT INTO steam_playtime_history (recorded_at, steam_id, app_id, game_name, playtime_minutes) VALUES (?, ?, ?, ?, ?)",
+		func(i int) []interface{} {
+			return []interface{}{at.Unix(), steamId, int64(games[i].AppID), games[i].Name, games[i].PlaytimeMinutes}
+		})
+}
+
+// RecordUnlocks stores a user's achievement unlocks. An achievement is stored once, so
+// recording the same unlocks again (e.g. after a restart) changes nothing.
+func (s *Store) RecordUnlocks(ctx context.Context, steamId string, unlocks []Unlock) error {
+	return s.insert(ctx, len(unlocks),
+		`INSERT INTO steam_achievement_unlocks (steam_id, app_id, game_name, achievement, unlocked_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (steam_id, app_id, achievement) DO NOTHING`,
+		func(i int) []interface{} {
+			return []interface{}{steamId, int64(unlocks[i].AppID), unlocks[i].GameName, unlocks[i].Achievement, unlocks[i].Time.Unix()}
+		})
+}
+
+// insert runs the statement once per row in a single transaction
+func (s *Store) insert(ctx context.Context, rows int, query string, args func(i int) []interface{}) error {
+	if rows == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin history transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statement, err := tx.PrepareContext(ctx, s.rebind(query))
+	if err != nil {
+		return fmt.Errorf("failed to prepare history insert: %w", err)
+	}
+	defer statement.Close()
+
+	for i := 0; i < rows; i++ {
+		if _, err := statement.ExecContext(ctx, args(i)...); err != nil {
+			return fmt.Errorf("failed to insert history: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SkillXP returns the XP recorded for a skill since a time, preceded by the last value recorded
+// before it (if any) so gains over the window can be computed
+func (s *Store) SkillXP(ctx context.Context, rsn string, mode string, skill string, since time.Time) ([]Point, error) {
+	return s.series(ctx,
+		`SELECT recorded_at, xp FROM osrs_skill_history
+		WHERE rsn = ? AND mode = ? AND LOWER(skill) = LOWER(?) AND recorded_at %s ?
+		ORDER BY recorded_at %s`,
+		rsn, mode, skill, since.Unix())
+}
+
+// Playtime returns the playtime recorded for a game since a time (appId 0 sums every game),
+// preceded by the last value recorded before it (if any)
+func (s *Store) Playtime(ctx context.Context, steamId string, appId uint64, since time.Time) ([]Point, error) {
+	if appId != 0 {
+		return s.series(ctx,
+			`SELECT recorded_at, playtime_minutes FROM steam_playtime_history
+			WHERE steam_id = ? AND app_id = ? AND recorded_at %s ?
+			ORDER BY recorded_at %s`,
+			steamId, int64(appId), since.Unix())
+	}
+	// Only changed games are recorded after the first snapshot, so the total at each time is
+	// the sum of every game's latest value at or before it
+	return s.series(ctx,
+		`SELECT t.recorded_at, SUM(h.playtime_minutes) FROM
+			(SELECT DISTINCT recorded_at FROM steam_playtime_history WHERE steam_id = ? AND recorded_at %s ?) t
+		JOIN steam_playtime_history h ON h.steam_id = ? AND h.recorded_at = (
+			SELECT MAX(h2.recorded_at) FROM steam_playtime_history h2
+			WHERE h2.steam_id = h.steam_id AND h2.app_id = h.app_id AND h2.recorded_at <= t.recorded_at)
+		GROUP BY t.recorded_at
+		ORDER BY t.recorded_at %s`,
+		steamId, since.Unix(), steamId)
+}
+
+// Unlocks returns a user's recorded achievement unlocks in a game (appId 0 for every game),
+// oldest first
+func (s *Store) Unlocks(ctx context.Context, steamId string, appId uint64) ([]Unlock, error) {
+	query := "SELECT unlocked_at, app_id, game_name, achievement FROM steam_achievement_unlocks WHERE steam_id = ?"
+	args := []interface{}{steamId}
+	if appId != 0 {
+		query += " AND app_id = ?"
+		args = append(args, int64(appId))
+	}
+	query += " ORDER BY unlocked_at, app_id, achievement"
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var unlocks []Unlock
+	for rows.Next() {
+		var unlockedAt, app int64
+		var unlock Unlock
+		if err := rows.Scan(&unlockedAt, &app, &unlock.GameName, &unlock.Achievement); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		unlock.Time = time.Unix(unlockedAt, 0).UTC()
+		unlock.AppID = uint64(app)
+		unlocks = append(unlocks, unlock)
+	}
+	return unlocks, rows.Err()
+}
+
+// series runs a query template (with placeholders for the time comparison and sort order)
+// twice: once for the baseline, the last point before since, and once for the points after it
+func (s *Store) series(ctx context.Context, template string, args ...interface{}) ([]Point, error) {
+	baseline, err := s.points(ctx, fmt.Sprintf(template, "<", "DESC")+" LIMIT 1", args)
+	if err != nil {
+		return nil, err
+	}
+	points, err := s.points(ctx, fmt.Sprintf(template, ">=", "ASC"), args)
+	if err != nil {
+		return nil, err
+	}
+	return append(baseline, points...), nil
+}
+
+func (s *Store) points(ctx context.Context, query string, args []interface{}) ([]Point, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var points []Point
+	for rows.Next() {
+		var recordedAt, value int64
+		if err := rows.Scan(&recordedAt, &value); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		points = append(points, Point{Time: time.Unix(recordedAt, 0).UTC(), Value: value})
+	}
+	return points, rows.Err()
+}
+
+// rebind converts ? placeholders to Postgres's $n
+func (s *Store) rebind(query string) string {
+	if s.driver != DriverPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
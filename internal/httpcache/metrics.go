@@ -0,0 +1,20 @@
+package httpcache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var conditionalRequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "upstream",
+	Name:      "conditional_requests_total",
+	Help:      "Successful requests for conditionally fetched upstream resources, by result (not_modified, modified, or uncacheable when the upstream sends no validators)",
+}, []string{"upstream", "result"})
+
+func init() {
+	prometheus.MustRegister(conditionalRequestsCounter)
+}
+
+// recordRequest counts a conditional request
+func recordRequest(upstream string, result string) {
+	conditionalRequestsCounter.WithLabelValues(upstream, result).Inc()
+}
@@ -1,31 +1,106 @@
 package logger
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
 var Log *logrus.Logger
 
+// Options configures the logger
+type Options struct {
+	Level  string // panic, fatal, error, warn, info, debug, trace
+	Format string // "text" (human readable, default) or "json" (for Loki/ELK)
+	Caller bool   // Include the calling function and file in each entry
+}
+
 func init() {
 	Log = logrus.New()
 	Log.SetOutput(os.Stdout)
-	Log.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-		ForceColors:   false,
-	})
 
-	// Set log level from environment, default to info
-	level := os.Getenv("LOG_LEVEL")
-	if level == "" {
-		level = "info"
+	// Configure from environment, defaulting to info level text logs
+	var errs []error
+	var caller bool
+	if value := os.Getenv("LOG_CALLER"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid LOG_CALLER %q: expected true or false", value))
+		} else {
+			caller = parsed
+		}
+	}
+	opts := Options{
+		Level:  os.Getenv("LOG_LEVEL"),
+		Format: os.Getenv("LOG_FORMAT"),
+		Caller: caller,
+	}
+	if err := Configure(opts); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		// The invalid settings fell back to their defaults rather than refusing to start
+		Log.WithError(err).Warn("Invalid logging configuration, using defaults for the invalid settings")
+	}
+}
+
+// Configure applies logging options. Empty fields use the defaults (info, text). Each
+// option is validated on its own: an invalid level or format uses its default, the valid
+// options are still applied, and the returned error names every invalid one.
+func Configure(opts Options) error {
+	var errs []error
+
+	logLevel := logrus.InfoLevel
+	if opts.Level != "" {
+		level, err := logrus.ParseLevel(opts.Level)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid LOG_LEVEL %q: %w", opts.Level, err))
+		} else {
+			logLevel = level
+		}
 	}
 
-	logLevel, err := logrus.ParseLevel(level)
-	if err != nil {
-		logLevel = logrus.InfoLevel
+	var formatter logrus.Formatter = &logrus.TextFormatter{
+		FullTimestamp: true,
+		ForceColors:   false,
+	}
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+	case "json":
+		formatter = &logrus.JSONFormatter{}
+	default:
+		errs = append(errs, fmt.Errorf("invalid LOG_FORMAT %q: expected text or json", opts.Format))
 	}
+
 	Log.SetLevel(logLevel)
+	Log.SetFormatter(formatter)
+	Log.SetReportCaller(opts.Caller)
+	return errors.Join(errs...)
 }
 
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the given request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns a log entry tagged with the request ID carried by ctx, so all
+// entries logged while serving one scrape can be correlated
+func FromContext(ctx context.Context) *logrus.Entry {
+	if id := RequestID(ctx); id != "" {
+		return Log.WithField("request_id", id)
+	}
+	return logrus.NewEntry(Log)
+}
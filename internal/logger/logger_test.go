@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestConfigure(t *testing.T) {
+	defer Configure(Options{})
+
+	tests := []struct {
+		name   string
+		opts   Options
+		level  logrus.Level
+		json   bool
+		caller bool
+		errs   []string // Settings named in the error, none when empty
+	}{
+		{name: "defaults", opts: Options{}, level: logrus.InfoLevel},
+		{name: "all valid", opts: Options{Level: "debug", Format: "JSON", Caller: true}, level: logrus.DebugLevel, json: true, caller: true},
+		// The valid settings still apply around an invalid one
+		{name: "invalid level", opts: Options{Level: "bogus", Format: "json", Caller: true}, level: logrus.InfoLevel, json: true, caller: true, errs: []string{"LOG_LEVEL"}},
+		{name: "invalid format", opts: Options{Level: "warn", Format: "xml", Caller: true}, level: logrus.WarnLevel, caller: true, errs: []string{"LOG_FORMAT"}},
+		{name: "both invalid", opts: Options{Level: "bogus", Format: "xml", Caller: true}, level: logrus.InfoLevel, caller: true, errs: []string{"LOG_LEVEL", "LOG_FORMAT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Start from settings that differ from every expectation
+			Log.SetLevel(logrus.TraceLevel)
+			Log.SetReportCaller(!tt.caller)
+			if tt.json {
+				Log.SetFormatter(&logrus.TextFormatter{})
+			} else {
+				Log.SetFormatter(&logrus.JSONFormatter{})
+			}
+
+			err := Configure(tt.opts)
+			if len(tt.errs) == 0 && err != nil {
+				t.Errorf("Configure = %v, want no error", err)
+			}
+			for _, setting := range tt.errs {
+				if err == nil || !strings.Contains(err.Error(), setting) {
+					t.Errorf("Configure = %v, want an error naming %s", err, setting)
+				}
+			}
+
+			if got := Log.GetLevel(); got != tt.level {
+				t.Errorf("level = %v, want %v", got, tt.level)
+			}
+			if _, json := Log.Formatter.(*logrus.JSONFormatter); json != tt.json {
+				t.Errorf("JSON formatter = %v, want %v", json, tt.json)
+			}
+			if Log.ReportCaller != tt.caller {
+				t.Errorf("report caller = %v, want %v", Log.ReportCaller, tt.caller)
+			}
+		})
+	}
+}
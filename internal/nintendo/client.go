@@ -0,0 +1,219 @@
+package nintendo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// TokenURL exchanges the session token for an access token
+	TokenURL = "https://accounts.nintendo.com/connect/1.0.0/api/token"
+	// ParentalControlsOrigin is the Parental Controls app's API
+	ParentalControlsOrigin = "https://app.lp1.znma.srv.nintendo.net"
+
+	DevicesEndpoint        = "/v1/users/%s/devices"
+	DailySummariesEndpoint = "/v1/devices/%s/daily_summaries"
+
+	// clientID is the Parental Controls app's, which the session token was issued to
+	clientID = "54789befb391a838"
+	// appVersion is sent as the Parental Controls app's version; older versions are refused
+	appVersion = "1.21.0"
+)
+
+// ErrUnauthorized is returned when Nintendo rejects the session token (revoked or mistyped)
+var ErrUnauthorized = errors.New("nintendo: session token rejected")
+
+type Client struct {
+	sessionToken string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	token     string    // Access token
+	accountID string    // From the ID token
+	expires   time.Time // When the access token must be renewed
+}
+
+// NewClient creates a client logging in with the Parental Controls app's session token;
+// transport is the upstream transport (e.g. fixture replay), nil for the default
+func NewClient(sessionToken string, transport http.RoundTripper) *Client {
+	return &Client{
+		sessionToken: sessionToken,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// GetDevices retrieves the consoles linked to the account
+func (c *Client) GetDevices(ctx context.Context) ([]Device, error) {
+	accountID, err := c.AccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp devicesResponse
+	url := ParentalControlsOrigin + fmt.Sprintf(DevicesEndpoint, neturl.PathEscape(accountID)) + "?filter.device.activated.%24eq=true"
+	if err := c.getJSON(ctx, url, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GetDailySummaries retrieves a console's recent daily play, newest first
+func (c *Client) GetDailySummaries(ctx context.Context, deviceId string) ([]DailySummary, error) {
+	var resp dailySummariesResponse
+	url := ParentalControlsOrigin + fmt.Sprintf(DailySummariesEndpoint, neturl.PathEscape(deviceId))
+	if err := c.getJSON(ctx, url, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// AccountID returns the ID of the session token's account, logging in if needed
+func (c *Client) AccountID(ctx context.Context) (string, error) {
+	if _, err := c.accessToken(ctx); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accountID, nil
+}
+
+// accessToken returns a valid access token, exchanging the session token when the current
+// one is about to expire
+func (c *Client) accessToken(ctx context.Context) (token string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "nintendo.login")
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"client_id":     clientID,
+		"session_token": c.sessionToken,
+		"grant_type":    "urn:ietf:params:oauth:grant-type:jwt-bearer-session-token",
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", TokenURL, strings.NewReader(string(body)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var resp tokenResponse
+	if err := c.do(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to log in: %w", err)
+	}
+
+	accountID, err := tokenSubject(resp.IDToken)
+	if err != nil {
+		return "", err
+	}
+	c.token = resp.AccessToken
+	c.accountID = accountID
+	// Renew a minute early so a token doesn't expire mid-collection
+	c.expires = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+// tokenSubject reads the subject of a JWT. The token comes straight from Nintendo over TLS,
+// so its signature isn't verified.
+func tokenSubject(jwt string) (string, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed ID token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed ID token: %w", err)
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return "", fmt.Errorf("ID token has no subject")
+	}
+	return claims.Subject, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, target interface{}) (err error) {
+	endpoint := strings.SplitN(strings.TrimPrefix(url, ParentalControlsOrigin), "?", 2)[0]
+	ctx, span := tracing.Start(ctx, "nintendo.api", attribute.String("nintendo.endpoint", endpoint))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return err
+	}
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Moon-App-Id", "com.nintendo.znma")
+	req.Header.Set("X-Moon-App-Display-Version", appVersion)
+	req.Header.Set("X-Moon-Os", "IOS")
+	req.Header.Set("X-Moon-App-Language", "en-US")
+	err = c.do(req, target)
+	if errors.Is(err, ErrUnauthorized) {
+		// The access token may have been revoked early; log in again on the next request
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func (c *Client) do(req *http.Request, target interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+		// The token endpoint answers a bad session token with a 400 (invalid_grant)
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited by Nintendo (%d)", resp.StatusCode)
+	default:
+		return fmt.Errorf("unexpected status code %d from Nintendo", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
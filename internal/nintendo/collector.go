@@ -0,0 +1,132 @@
+package nintendo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Consoles are rarely linked or renamed
+	devicesTTL = time.Hour
+	// The app's summaries lag play by a few minutes anyway, so they're only cached to absorb
+	// back-to-back scrapes
+	summariesTTL = 5 * time.Minute
+)
+
+// Config configures the Nintendo collector
+type Config struct {
+	SessionToken string            // The Parental Controls app's session token
+	Transport    http.RoundTripper // Upstream transport, e.g. fixture replay; nil for the default
+	DayLocation  *time.Location    // Which day is today; nil for the local time zone
+}
+
+// Collector reports today's play on the consoles of a Nintendo Switch Parental Controls account
+type Collector struct {
+	client      *Client
+	cache       *cache.Cache
+	dayLocation *time.Location
+}
+
+func NewCollector(config Config, cache *cache.Cache) *Collector {
+	location := config.DayLocation
+	if location == nil {
+		location = time.Local
+	}
+	return &Collector{
+		client:      NewClient(config.SessionToken, config.Transport),
+		cache:       cache,
+		dayLocation: location,
+	}
+}
+
+// Collect reports today's play on every console linked to the account, per player and title
+func (c *Collector) Collect(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "nintendo.collect")
+	defer func() { tracing.End(span, err) }()
+
+	devices, err := c.Devices(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().In(c.dayLocation).Format("2006-01-02")
+	played := make([]Playtime, 0, len(devices))
+	for _, device := range devices {
+		summaries, err := c.DailySummaries(ctx, device.DeviceID)
+		if err != nil {
+			return err
+		}
+		playtime := Playtime{Device: device}
+		for _, summary := range summaries {
+			if summary.Date == today {
+				playtime.Today = &summary
+				break
+			}
+		}
+		played = append(played, playtime)
+	}
+	ReportPlaytime(played)
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"devices": len(devices),
+		"day":     today,
+	}).Info("Completed Nintendo metrics collection")
+	return nil
+}
+
+// Devices returns the consoles linked to the account from the cache, fetching on a miss
+func (c *Collector) Devices(ctx context.Context) ([]Device, error) {
+	accountID, err := c.client.AccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("nintendo:devices:%s", accountID)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, devicesTTL, devicesTTL, func(ctx context.Context) ([]byte, error) {
+		devices, err := c.client.GetDevices(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get devices: %w", err)
+		}
+		return json.Marshal(devices)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	if err := json.Unmarshal(data, &devices); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached devices: %w", err)
+	}
+	return devices, nil
+}
+
+// DailySummaries returns a console's recent daily play from the cache, fetching on a miss
+func (c *Collector) DailySummaries(ctx context.Context, deviceId string) ([]DailySummary, error) {
+	cacheKey := fmt.Sprintf("nintendo:daily_summaries:%s", deviceId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, summariesTTL, summariesTTL, func(ctx context.Context) ([]byte, error) {
+		summaries, err := c.client.GetDailySummaries(ctx, deviceId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daily summaries: %w", err)
+		}
+		return json.Marshal(summaries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []DailySummary
+	if err := json.Unmarshal(data, &summaries); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached daily summaries: %w", err)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,80 @@
+package nintendo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testAccountID = "a1b2c3d4e5f60718"
+
+func newTestCollector(t *testing.T, srv *testserver.Server, sessionToken string) *Collector {
+	t.Helper()
+	return NewCollector(Config{SessionToken: sessionToken, Transport: srv.Transport()}, testserver.NewCache(t))
+}
+
+func TestCollect(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddNintendoAccount(testAccountID, testserver.NintendoAccount{
+		SessionToken: "session",
+		Devices: []testserver.NintendoDevice{
+			{
+				ID:    "device1",
+				Label: "Living Room",
+				Today: []testserver.NintendoPlay{
+					{Player: "Alex", ApplicationID: "01006A800016E000", Title: "Super Smash Bros. Ultimate", Seconds: 1800},
+					{Player: "Sam", ApplicationID: "01006A800016E000", Title: "Super Smash Bros. Ultimate", Seconds: 600},
+					{Player: "Sam", ApplicationID: "0100000000010000", Title: "Super Mario Odyssey", Seconds: 1200},
+				},
+				Yesterday: []testserver.NintendoPlay{
+					{Player: "Alex", ApplicationID: "01007EF00011E000", Title: "The Legend of Zelda: Breath of the Wild", Seconds: 3600},
+				},
+			},
+			{ID: "device2", Label: "Bedroom"},
+		},
+	})
+	collector := newTestCollector(t, srv, "session")
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(playtimeTodayGauge.WithLabelValues("Living Room", "Sam", "0100000000010000", "Super Mario Odyssey")); got != 1200 {
+		t.Errorf("Sam's Super Mario Odyssey playtime = %v, want 1200", got)
+	}
+	if got := testutil.ToFloat64(devicePlaytimeTodayGauge.WithLabelValues("Living Room")); got != 3600 {
+		t.Errorf("Living Room playtime = %v, want 3600", got)
+	}
+	// Nothing was played on the Bedroom console today, so it's reported as 0
+	if got := testutil.ToFloat64(devicePlaytimeTodayGauge.WithLabelValues("Bedroom")); got != 0 {
+		t.Errorf("Bedroom playtime = %v, want 0", got)
+	}
+	// Yesterday's play isn't reported
+	if got := testutil.CollectAndCount(playtimeTodayGauge); got != 3 {
+		t.Errorf("reported %d player titles, want 3", got)
+	}
+
+	// A second collection reuses the access token and is served from the cache
+	if err := collector.Collect(ctx); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/connect/1.0.0/api/token"); got != 1 {
+		t.Errorf("token requests = %d, want 1", got)
+	}
+	if got := srv.Requests("/v1/devices/device1/daily_summaries"); got != 1 {
+		t.Errorf("daily summary requests = %d, want 1", got)
+	}
+}
+
+func TestCollectRejectedSessionToken(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddNintendoAccount(testAccountID, testserver.NintendoAccount{SessionToken: "session"})
+	collector := newTestCollector(t, srv, "revoked")
+
+	if err := collector.Collect(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Collect with a rejected session token = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,63 @@
+package nintendo
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	playtimeTodayGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "nintendo",
+		Name:      "playtime_today_seconds",
+		Help:      "Amount of time a player played a title on a console today (in seconds); titles not played today are left out",
+	}, []string{"device", "player", "application_id", "title"})
+
+	devicePlaytimeTodayGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "nintendo",
+		Subsystem: "device",
+		Name:      "playtime_today_seconds",
+		Help:      "Amount of time a console was played today (in seconds), every player combined",
+	}, []string{"device"})
+)
+
+func init() {
+	prometheus.MustRegister(playtimeTodayGauge)
+	prometheus.MustRegister(devicePlaytimeTodayGauge)
+}
+
+// ReportPlaytime replaces the reported play with today's play on the given consoles, so the
+// previous day's titles drop off at midnight
+func ReportPlaytime(played []Playtime) {
+	playtimeTodayGauge.Reset()
+	devicePlaytimeTodayGauge.Reset()
+
+	for _, playtime := range played {
+		device := playtime.Device.Label
+		if device == "" {
+			device = playtime.Device.DeviceID
+		}
+		if playtime.Today == nil {
+			devicePlaytimeTodayGauge.WithLabelValues(device).Set(0)
+			continue
+		}
+		devicePlaytimeTodayGauge.WithLabelValues(device).Set(float64(playtime.Today.PlayingTime))
+
+		titles := make(map[string]string, len(playtime.Today.PlayedApps))
+		for _, app := range playtime.Today.PlayedApps {
+			titles[app.ApplicationID] = app.Title
+		}
+		for _, player := range playtime.Today.Players {
+			for _, game := range player.PlayedGames {
+				title := titles[game.ApplicationID]
+				if title == "" {
+					title = game.ApplicationID
+				}
+				playtimeTodayGauge.With(prometheus.Labels{
+					"device":         device,
+					"player":         player.Profile.Nickname,
+					"application_id": game.ApplicationID,
+					"title":          title,
+				}).Set(float64(game.PlayingTime))
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package nintendo
+
+// tokenResponse is the accounts service's answer to a session token exchange
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"` // JWT whose subject is the account's ID
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Device is a console linked to Parental Controls
+type Device struct {
+	DeviceID string `json:"deviceId"`
+	Label    string `json:"label"` // Name given in the app, e.g. Living Room
+}
+
+type devicesResponse struct {
+	Items []Device `json:"items"`
+}
+
+// DailySummary is a console's play on one day
+type DailySummary struct {
+	DeviceID    string       `json:"deviceId"`
+	Date        string       `json:"date"`        // 2006-01-02, in the console's time zone
+	PlayingTime int64        `json:"playingTime"` // Seconds, every player combined
+	PlayedApps  []PlayedApp  `json:"playedApps"`
+	Players     []PlayerPlay `json:"players"`
+}
+
+// PlayedApp is a title played on the day
+type PlayedApp struct {
+	ApplicationID string `json:"applicationId"`
+	Title         string `json:"title"`
+}
+
+// PlayerPlay is one user profile's play on the day
+type PlayerPlay struct {
+	Profile struct {
+		PlayerID string `json:"playerId"`
+		Nickname string `json:"nickname"`
+	} `json:"profile"`
+	PlayingTime int64 `json:"playingTime"` // Seconds
+	PlayedGames []struct {
+		ApplicationID string `json:"applicationId"`
+		PlayingTime   int64  `json:"playingTime"` // Seconds
+	} `json:"playedGames"`
+}
+
+type dailySummariesResponse struct {
+	Items []DailySummary `json:"items"`
+}
+
+// Playtime is a console's play today; Today is nil when nothing was played
+type Playtime struct {
+	Device Device
+	Today  *DailySummary
+}
@@ -0,0 +1,99 @@
+// Package notify sends one-shot events (an OSRS level 99, a GE price alert) to a webhook, for
+// things worth a message rather than a graph.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Event is one notification
+type Event struct {
+	Kind    string            `json:"kind"`    // e.g. osrs_milestone
+	Message string            `json:"message"` // Human readable summary
+	Labels  map[string]string `json:"labels,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// webhookPayload is the JSON posted for an event. content and text carry the message, so
+// Discord and Slack incoming webhooks display it as is.
+type webhookPayload struct {
+	Event
+	Content string `json:"content"`
+	Text    string `json:"text"`
+}
+
+// Notifier posts events to a webhook. A nil Notifier drops them, so callers don't need to
+// check whether notifications are configured.
+type Notifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewNotifier creates a notifier posting to url, nil when url is empty
+func NewNotifier(url string) *Notifier {
+	if url == "" {
+		return nil
+	}
+	return &Notifier{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notify sends an event in the background; failures are logged, not returned, so a down
+// webhook never fails a collection
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := n.send(ctx, event); err != nil {
+			logger.Log.WithFields(logrus.Fields{
+				"kind":  event.Kind,
+				"error": err.Error(),
+			}).Warn("Failed to send notification")
+		}
+	}()
+}
+
+func (n *Notifier) send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(webhookPayload{Event: event, Content: event.Message, Text: event.Message})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "game-stats-exporter")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook failed (status: %d): %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
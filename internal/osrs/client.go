@@ -2,58 +2,43 @@ package osrs
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/debugraw"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/httpcache"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-const (
-	PlayerStatsURL      = "https://oldschool.runescape.wiki/cors/m=hiscore_oldschool/index_lite.ws"
-	PlayerStatsHTMLURL  = "https://secure.runescape.com/m=hiscore_oldschool/hiscorepersonal"
-	TournamentStatsURL  = "https://oldschool.runescape.wiki/cors/m=hiscore_oldschool_tournament/index_lite.ws"
-	TournamentHTMLURL   = "https://secure.runescape.com/m=hiscore_oldschool_tournament/hiscorepersonal"
-	DeadmanStatsURL     = "https://oldschool.runescape.wiki/cors/m=hiscore_oldschool_deadman/index_lite.ws"
-	DeadmanHTMLURL      = "https://secure.runescape.com/m=hiscore_oldschool_deadman/hiscorepersonal"
-	SeasonalStatsURL    = "https://oldschool.runescape.wiki/cors/m=hiscore_oldschool_seasonal/index_lite.ws"
-	SeasonalHTMLURL     = "https://secure.runescape.com/m=hiscore_oldschool_seasonal/hiscorepersonal"
-	WorldDataURL        = "https://www.runescape.com/g=oldscape/slr.ws?order=LPWM"
+const WorldDataURL = "https://www.runescape.com/g=oldscape/slr.ws?order=LPWM"
+
+var (
+	// ErrPlayerNotFound is returned for an RSN the hiscores have no entry for in a mode
+	ErrPlayerNotFound = errors.New("player not found")
+	// ErrUnavailable is returned when the hiscores keep failing (5xx, 429 or no response)
+	ErrUnavailable = errors.New("hiscores unavailable")
 )
 
-var Skills = []string{
-	"Overall",
-	"Attack",
-	"Defence",
-	"Strength",
-	"Hitpoints",
-	"Ranged",
-	"Prayer",
-	"Magic",
-	"Cooking",
-	"Woodcutting",
-	"Fletching",
-	"Fishing",
-	"Firemaking",
-	"Crafting",
-	"Smithing",
-	"Mining",
-	"Herblore",
-	"Agility",
-	"Thieving",
-	"Slayer",
-	"Farming",
-	"Runecrafting",
-	"Hunter",
-	"Construction",
-	"Stuff",
-}
+// Failed hiscores requests are made hiscoresAttempts times, waiting hiscoresBackoff (doubled
+// each time) in between
+const hiscoresAttempts = 3
+
+var hiscoresBackoff = 500 * time.Millisecond
 
 // Known minigame names in order (as they appear in the CSV API)
 // This list is based on the OSRS hiscores API order and is kept up-to-date
@@ -101,21 +86,25 @@ var knownMinigameNames = []string{
 
 // getMinigameNames fetches and parses minigame names from the HTML highscores page
 // Falls back to known list if HTML fetch fails or doesn't return enough names
-func getMinigameNames(rsn string, mode string) ([]string, error) {
-	var htmlURL string
-	switch mode {
-	case "gridmaster":
-		htmlURL = TournamentHTMLURL
-	case "deadman":
-		htmlURL = DeadmanHTMLURL
-	case "seasonal":
-		htmlURL = SeasonalHTMLURL
-	default:
-		htmlURL = PlayerStatsHTMLURL
+func (c *Client) getMinigameNames(ctx context.Context, rsn string, mode string) (names []string, err error) {
+	ctx, span := tracing.Start(ctx, "osrs.hiscores_html", attribute.String("osrs.mode", mode))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return nil, err
 	}
-	url := fmt.Sprintf("%s?user1=%s", htmlURL, rsn)
 
-	resp, err := http.Get(url)
+	htmlURL := fmt.Sprintf(hiscoresHTMLURL, c.modes.table(mode))
+	url := fmt.Sprintf("%s?user1=%s", htmlURL, neturl.QueryEscape(rsn))
+	if err = c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch HTML highscores: %w", err)
 	}
@@ -129,6 +118,7 @@ func getMinigameNames(rsn string, mode string) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read HTML response: %w", err)
 	}
+	debugraw.Record(ctx, url, resp.StatusCode, body)
 
 	// Extract minigame names with their table numbers
 	// Format: <a href="...table=N...category_type=1...">Name</a>
@@ -192,48 +182,106 @@ func getMinigameNames(rsn string, mode string) ([]string, error) {
 
 type Client struct {
 	httpClient *http.Client
+	responses  httpcache.Store // Validators for conditional world list requests
+	modes      modeTables
+	limiter    rateLimiter
+	lastWorlds decodedWorlds // The last decoded world list, reused while the payload is unchanged
 }
 
-func NewClient() *Client {
+// NewClient creates an OSRS client; transport is the upstream transport (e.g. fixture
+// replay), nil for the default
+func NewClient(responses httpcache.Store, transport http.RoundTripper) *Client {
 	return &Client{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second, // Longer timeout for world data
+			Timeout:   30 * time.Second, // Longer timeout for world data
+			Transport: transport,
 		},
+		responses: responses,
 	}
 }
 
-// GetPlayerStats retrieves player stats from the OSRS hiscores API
-func (c *Client) GetPlayerStats(rsn string, mode string) ([]SkillInfo, []MinigameInfo, error) {
-	var statsURL string
-	switch mode {
-	case "gridmaster":
-		statsURL = TournamentStatsURL
-	case "deadman":
-		statsURL = DeadmanStatsURL
-	case "seasonal":
-		statsURL = SeasonalStatsURL
-	default:
-		statsURL = PlayerStatsURL
+// fetchHiscores fetches a hiscores CSV. A 404 is ErrPlayerNotFound; 5xx responses, 429s and
+// network errors are retried with backoff, and are ErrUnavailable once the attempts run out.
+func (c *Client) fetchHiscores(ctx context.Context, url string) ([]byte, error) {
+	backoff := hiscoresBackoff
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		body, retry, err := c.fetchHiscoresOnce(ctx, url)
+		if err == nil || !retry {
+			return body, err
+		}
+		lastErr = err
+		if attempt == hiscoresAttempts {
+			break
+		}
+
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"attempt": attempt,
+			"backoff": backoff,
+			"error":   err.Error(),
+		}).Warn("OSRS hiscores request failed, retrying")
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
 	}
-	url := fmt.Sprintf("%s?player=%s", statsURL, rsn)
+	return nil, fmt.Errorf("%w: %v", ErrUnavailable, lastErr)
+}
 
-	resp, err := c.httpClient.Get(url)
+// fetchHiscoresOnce makes one hiscores request; retry reports whether a failure is worth retrying
+func (c *Client) fetchHiscoresOnce(ctx context.Context, url string) (body []byte, retry bool, err error) {
+	if err = c.limiter.wait(ctx); err != nil {
+		return nil, false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch player stats: %w", err)
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// A cancelled scrape isn't the upstream's fault
+		return nil, ctx.Err() == nil, fmt.Errorf("failed to fetch player stats: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("player not found (status: %d)", resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, ErrPlayerNotFound
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("hiscores returned status %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("hiscores returned status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, true, fmt.Errorf("failed to read response: %w", err)
+	}
+	debugraw.Record(ctx, url, resp.StatusCode, body)
+	return body, false, nil
+}
+
+// GetPlayerStats retrieves player stats from the OSRS hiscores API
+func (c *Client) GetPlayerStats(ctx context.Context, rsn string, mode string) (skills []SkillInfo, minigames []MinigameInfo, err error) {
+	ctx, span := tracing.Start(ctx, "osrs.hiscores", attribute.String("osrs.mode", mode))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	statsURL := fmt.Sprintf(hiscoresURL, c.modes.table(mode))
+	url := fmt.Sprintf("%s?player=%s", statsURL, neturl.QueryEscape(rsn))
+
+	body, err := c.fetchHiscores(ctx, url)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Fetch minigame names from HTML page
-	minigameNames, err := getMinigameNames(rsn, mode)
+	minigameNames, err := c.getMinigameNames(ctx, rsn, mode)
 	if err != nil {
 		logger.Log.WithFields(logrus.Fields{
 			"rsn":   rsn,
@@ -248,10 +296,11 @@ func (c *Client) GetPlayerStats(rsn string, mode string) ([]SkillInfo, []Minigam
 	}
 
 	// Parse CSV format: rank,level,xp per line for skills, rank,score for minigames
+	_, parseSpan := tracing.Start(ctx, "osrs.parse_player_stats")
+	defer parseSpan.End()
 	lines := strings.Split(string(body), "\n")
-	var skills []SkillInfo
-	var minigames []MinigameInfo
 
+	skillNames := Skills()
 	skillIndex := 0
 	minigameIndex := 0
 
@@ -263,13 +312,13 @@ func (c *Client) GetPlayerStats(rsn string, mode string) ([]SkillInfo, []Minigam
 
 		parts := strings.Split(line, ",")
 
-		// Skills have 3 values: rank,level,xp
-		if len(parts) == 3 && skillIndex < len(Skills) {
+		// Skills have 3 values: rank,level,xp; they all come before the minigames
+		if len(parts) == 3 && minigameIndex == 0 {
 			skill := SkillInfo{
 				Rank:   parts[0],
 				Level:  parts[1],
 				XP:     parts[2],
-				Name:   Skills[skillIndex],
+				Name:   skillName(skillNames, skillIndex),
 				Player: rsn,
 			}
 			skills = append(skills, skill)
@@ -277,9 +326,9 @@ func (c *Client) GetPlayerStats(rsn string, mode string) ([]SkillInfo, []Minigam
 		} else if len(parts) == 2 {
 			// Minigames have 2 values: rank,score
 			// Parse dynamically - no hardcoded list needed
-			// If we've parsed all expected skills OR we get a 2-part line after parsing at least one skill,
-			// then treat it as a minigame (API may return fewer skills than our list)
-			if skillIndex >= len(Skills) || (skillIndex > 0 && len(skills) == skillIndex) {
+			// A 2-part line after at least one skill is a minigame (the API may return fewer or
+			// more skills than our list)
+			if skillIndex > 0 {
 				// Check if this minigame has actual scores (not -1,-1)
 				rank := parts[0]
 				score := parts[1]
@@ -324,8 +373,18 @@ func (c *Client) GetPlayerStats(rsn string, mode string) ([]SkillInfo, []Minigam
 }
 
 // GetWorldData retrieves world data from the OSRS world list API
-func (c *Client) GetWorldData() ([]World, error) {
-	req, err := http.NewRequest("GET", WorldDataURL, nil)
+func (c *Client) GetWorldData(ctx context.Context) (worlds []World, err error) {
+	ctx, span := tracing.Start(ctx, "osrs.world_list")
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return nil, err
+	}
+
+	if err = c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", WorldDataURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -334,7 +393,7 @@ func (c *Client) GetWorldData() ([]World, error) {
 	req.Header.Set("User-Agent", "game-stats-exporter/1.0")
 	req.Header.Set("Accept", "*/*")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpcache.Do(c.httpClient, c.responses, "osrs", "osrs:worlds", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch world data: %w", err)
 	}
@@ -354,6 +413,7 @@ func (c *Client) GetWorldData() ([]World, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	debugraw.Record(ctx, WorldDataURL, resp.StatusCode, body)
 
 	if len(body) == 0 {
 		return nil, fmt.Errorf("received empty response body")
@@ -375,13 +435,71 @@ func (c *Client) GetWorldData() ([]World, error) {
 		"first_bytes": fmt.Sprintf("%x", body[:firstBytesLen]),
 	}).Debug("OSRS world data response received")
 
-	return decodeWorldData(body)
+	// The list only changes every few minutes; an identical payload decodes to the same worlds
+	sum := sha256.Sum256(body)
+	reportWorldPayloadSize(len(body))
+	if worlds, decode, ok := c.lastWorlds.get(sum, len(body)); ok {
+		logger.Log.WithField("body_length", len(body)).Debug("OSRS world data unchanged, skipping decode")
+		reportWorldDecode(decode, len(worlds))
+		return worlds, nil
+	}
+
+	_, decodeSpan := tracing.Start(ctx, "osrs.decode_world_data", attribute.Int("osrs.body_size", len(body)))
+	worlds, decode, err := decodeWorldData(body)
+	tracing.End(decodeSpan, err)
+	if err == nil {
+		c.lastWorlds.set(sum, len(body), worlds, decode)
+		reportWorldDecode(decode, len(worlds))
+	}
+	return worlds, err
+}
+
+// decodedWorlds holds the last decoded world list payload by checksum
+type decodedWorlds struct {
+	mu     sync.Mutex
+	sum    [sha256.Size]byte
+	size   int // 0 until a payload is decoded
+	worlds []World
+	decode worldDecode
+}
+
+// get returns the worlds decoded from the payload with checksum sum, if it was the last one.
+// The worlds are shared between callers, which must not modify them.
+func (d *decodedWorlds) get(sum [sha256.Size]byte, size int) ([]World, worldDecode, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.size == 0 || d.sum != sum {
+		return nil, worldDecode{}, false
+	}
+	return d.worlds, d.decode, true
+}
+
+// set records a decoded payload, logging when its size differs from the previous one's, which
+// is the first sign of the upstream format or the truncation point moving
+func (d *decodedWorlds) set(sum [sha256.Size]byte, size int, worlds []World, decode worldDecode) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.size != 0 && d.size != size {
+		logger.Log.WithFields(logrus.Fields{
+			"previous_size": d.size,
+			"size":          size,
+			"worlds":        len(worlds),
+		}).Info("OSRS world data payload size changed")
+	}
+	d.sum, d.size, d.worlds, d.decode = sum, size, worlds, decode
+}
+
+// worldDecode describes how a world list response decoded, for the decode anomaly metrics
+type worldDecode struct {
+	truncated bool // Cut short (the 30KB limit), so worlds after the cut are missing
+	realigned bool // The first world was only found by scanning past misaligned bytes
 }
 
 // decodeWorldData decodes the binary world data format
-func decodeWorldData(data []byte) ([]World, error) {
+func decodeWorldData(data []byte) ([]World, worldDecode, error) {
+	var decode worldDecode
 	if len(data) < 6 {
-		return nil, fmt.Errorf("response too short: got %d bytes, need at least 6", len(data))
+		return nil, decode, fmt.Errorf("response too short: got %d bytes, need at least 6", len(data))
 	}
 
 	reader := bytes.NewReader(data)
@@ -390,7 +508,7 @@ func decodeWorldData(data []byte) ([]World, error) {
 	// This is read but the result is ignored - we just need to advance past it
 	var bufferSizeRaw int32
 	if err := binary.Read(reader, binary.LittleEndian, &bufferSizeRaw); err != nil {
-		return nil, fmt.Errorf("failed to read buffer size: %w", err)
+		return nil, decode, fmt.Errorf("failed to read buffer size: %w", err)
 	}
 	bufferSize := bufferSizeRaw + 4 // Rust code does: read_i32() + 4
 
@@ -405,9 +523,9 @@ func decodeWorldData(data []byte) ([]World, error) {
 	var numWorlds int16
 	if err := binary.Read(reader, binary.LittleEndian, &numWorlds); err != nil {
 		if err == io.EOF {
-			return nil, fmt.Errorf("unexpected EOF reading world count - response may be empty or corrupted")
+			return nil, decode, fmt.Errorf("unexpected EOF reading world count - response may be empty or corrupted")
 		}
-		return nil, fmt.Errorf("failed to read world count: %w", err)
+		return nil, decode, fmt.Errorf("failed to read world count: %w", err)
 	}
 
 	logger.Log.WithFields(logrus.Fields{
@@ -426,6 +544,7 @@ func decodeWorldData(data []byte) ([]World, error) {
 			"remaining_data_bytes": reader.Len(),
 		}).Warn("Invalid world count detected - response truncated, will parse iteratively until invalid data")
 		parseIteratively = true
+		decode.truncated = true
 		// Estimate reasonable number of worlds we can parse from 30KB
 		// Each world averages ~40-60 bytes (varies with string lengths)
 		// With 30KB, we can fit roughly 500-750 worlds, but OSRS only has ~160 worlds
@@ -449,6 +568,7 @@ func decodeWorldData(data []byte) ([]World, error) {
 				"remaining":      reader.Len(),
 				"worlds_read":    len(worlds),
 			}).Info("Insufficient data remaining - response truncated, returning parsed worlds")
+			decode.truncated = true
 			break
 		}
 
@@ -463,12 +583,14 @@ func decodeWorldData(data []byte) ([]World, error) {
 					"world_num":   i + 1,
 					"worlds_read": len(worlds),
 				}).Info("EOF reached - response truncated")
+				decode.truncated = true
 				break
 			}
 			logger.Log.WithFields(logrus.Fields{
 				"world_num": i + 1,
 				"error":     err.Error(),
 			}).Warn("Error reading world ID")
+			decode.truncated = true
 			break
 		}
 
@@ -502,6 +624,7 @@ func decodeWorldData(data []byte) ([]World, error) {
 						}).Info("Found valid world ID at offset, adjusting alignment")
 						worldID = testID
 						foundValid = true
+						decode.realigned = true
 						break
 					}
 
@@ -511,7 +634,7 @@ func decodeWorldData(data []byte) ([]World, error) {
 
 				if !foundValid {
 					logger.Log.Warn("Could not find valid world ID alignment in corrupted data")
-					return []World{}, nil
+					return []World{}, decode, nil
 				}
 			} else {
 				// Not first world, just stop
@@ -520,6 +643,7 @@ func decodeWorldData(data []byte) ([]World, error) {
 					"world_num": i + 1,
 				}).Info("Invalid world ID detected - reached corrupted/truncated data, stopping")
 				reader.Seek(currentPos, 0)
+				decode.truncated = true
 				break
 			}
 		}
@@ -527,31 +651,31 @@ func decodeWorldData(data []byte) ([]World, error) {
 		// Read world type flags (4 bytes)
 		var worldTypeFlags int32
 		if err := binary.Read(reader, binary.LittleEndian, &worldTypeFlags); err != nil {
-			return nil, fmt.Errorf("failed to read world type flags: %w", err)
+			return nil, decode, fmt.Errorf("failed to read world type flags: %w", err)
 		}
 
 		// Read address string (null-terminated)
 		address, err := readNullTerminatedString(reader)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read address: %w", err)
+			return nil, decode, fmt.Errorf("failed to read address: %w", err)
 		}
 
 		// Read activity string (null-terminated)
 		activity, err := readNullTerminatedString(reader)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read activity: %w", err)
+			return nil, decode, fmt.Errorf("failed to read activity: %w", err)
 		}
 
 		// Read location (1 byte)
 		var locationByte int8
 		if err := binary.Read(reader, binary.LittleEndian, &locationByte); err != nil {
-			return nil, fmt.Errorf("failed to read location: %w", err)
+			return nil, decode, fmt.Errorf("failed to read location: %w", err)
 		}
 
 		// Read player count (2 bytes)
 		var playerCount int16
 		if err := binary.Read(reader, binary.LittleEndian, &playerCount); err != nil {
-			return nil, fmt.Errorf("failed to read player count: %w", err)
+			return nil, decode, fmt.Errorf("failed to read player count: %w", err)
 		}
 
 		// Convert location
@@ -572,7 +696,7 @@ func decodeWorldData(data []byte) ([]World, error) {
 		worlds = append(worlds, world)
 	}
 
-	return worlds, nil
+	return worlds, decode, nil
 }
 
 // readNullTerminatedString reads a null-terminated string from the reader
@@ -607,58 +731,28 @@ func locationFromByte(b int8) WorldLocation {
 	}
 }
 
-// parseWorldTypes parses world type flags into a slice of WorldType
+// unknownWorldFlags holds the unknown flag bits already logged, so each is logged once
+var unknownWorldFlags sync.Map
+
+// parseWorldTypes parses world type flags into a slice of WorldType. Bits missing from
+// worldTypeFlags are kept as bit_<n>, so a new world type shows up before it's named.
 func parseWorldTypes(flags int32) []WorldType {
 	var types []WorldType
-
-	// Check each flag bit
-	if flags&1 != 0 {
-		types = append(types, WorldTypeMembers)
-	}
-	if flags&(1<<2) != 0 {
-		types = append(types, WorldTypePVP)
-	}
-	if flags&(1<<5) != 0 {
-		types = append(types, WorldTypeBounty)
-	}
-	if flags&(1<<6) != 0 {
-		types = append(types, WorldTypePVPArena)
-	}
-	if flags&(1<<7) != 0 {
-		types = append(types, WorldTypeSkillTotal)
-	}
-	if flags&(1<<8) != 0 {
-		types = append(types, WorldTypeQuestSpeedrunning)
-	}
-	if flags&(1<<10) != 0 {
-		types = append(types, WorldTypeHighRisk)
-	}
-	if flags&(1<<14) != 0 {
-		types = append(types, WorldTypeLastManStanding)
-	}
-	if flags&(1<<22) != 0 {
-		types = append(types, WorldTypeSoulWars)
-	}
-	if flags&(1<<23) != 0 {
-		types = append(types, WorldTypeBeta)
-	}
-	if flags&(1<<25) != 0 {
-		types = append(types, WorldTypeNoSaveMode)
-	}
-	if flags&(1<<26) != 0 {
-		types = append(types, WorldTypeTournament)
-	}
-	if flags&(1<<27) != 0 {
-		types = append(types, WorldTypeFreshStartWorld)
-	}
-	if flags&(1<<28) != 0 {
-		types = append(types, WorldTypeMinigame)
-	}
-	if flags&(1<<29) != 0 {
-		types = append(types, WorldTypeDeadman)
-	}
-	if flags&(1<<30) != 0 {
-		types = append(types, WorldTypeSeasonal)
+	for bit := uint(0); bit < 32; bit++ {
+		if flags&(1<<bit) == 0 {
+			continue
+		}
+		if worldType, ok := worldTypeFlags[bit]; ok {
+			types = append(types, worldType)
+			continue
+		}
+		types = append(types, WorldType(fmt.Sprintf("bit_%d", bit)))
+		if _, logged := unknownWorldFlags.LoadOrStore(bit, true); !logged {
+			logger.Log.WithFields(logrus.Fields{
+				"bit":   bit,
+				"flags": flags,
+			}).Warn("Unknown OSRS world type flag, reported as bit_<n>")
+		}
 	}
 
 	// If no types found, default to FreeToPlay
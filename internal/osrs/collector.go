@@ -1,112 +1,176 @@
 package osrs
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/daily"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// SupportedModes is the list of all OSRS game modes that can be collected
-// These are the modes that have accessible API endpoints via the CORS proxy
-var SupportedModes = []string{"vanilla", "gridmaster", "deadman", "seasonal"}
+// SupportedModes is the list of built-in OSRS game modes that can be collected
+// These are the modes that have accessible API endpoints via the CORS proxy; tournament modes
+// from the config file are added to them (see Collector.Modes)
+var SupportedModes = []string{"vanilla", "ironman", "hardcore_ironman", "ultimate_ironman", "gridmaster", "deadman", "seasonal"}
+
+// Default cache freshness (see SetTTLs); entries may be served stale for as long again
+const (
+	defaultPlayerStatsTTL = 15 * time.Minute
+	defaultWorldDataTTL   = 5 * time.Minute
+)
+
+// playerStatsCacheEntry is the cached form of a player's hiscores for one mode
+type playerStatsCacheEntry struct {
+	Stats      []SkillInfo    `json:"stats"`
+	Minigames  []MinigameInfo `json:"minigames"`
+	LastUpdate time.Time      `json:"last_update"`
+}
+
+// label sets the player label of the entry's skills and minigames
+func (e playerStatsCacheEntry) label(player string) {
+	for i := range e.Stats {
+		e.Stats[i].Player = player
+	}
+	for i := range e.Minigames {
+		e.Minigames[i].Player = player
+	}
+}
 
 type Collector struct {
 	client *Client
 	cache  *cache.Cache
+	daily  *daily.Tracker // XP gained since local midnight
+
+	aliases rsnAliases // Player labels by normalized RSN, changed on config reload
+
+	onMilestone func(ctx context.Context, milestone Milestone) // See SetMilestoneHandler
+
+	// time.Durations, changed on config reload
+	playerStatsTTL atomic.Int64
+	worldDataTTL   atomic.Int64
+
+	collections cache.InFlight // One collection per player and mode at a time
 }
 
-func NewCollector(cache *cache.Cache) *Collector {
-	return &Collector{
-		client: NewClient(),
+// NewCollector creates an OSRS collector; transport is the upstream transport (e.g. fixture
+// replay), nil for the default
+func NewCollector(cache *cache.Cache, transport http.RoundTripper) *Collector {
+	c := &Collector{
+		client: NewClient(cache, transport),
 		cache:  cache,
+		daily:  daily.NewTracker(cache, nil),
+	}
+	if cache != nil {
+		c.client.limiter.leases = cache
+	}
+	c.SetTTLs(defaultPlayerStatsTTL, defaultWorldDataTTL)
+	return c
+}
+
+// SetDayLocation sets where osrs_xp_today resets at midnight (nil for the local time zone).
+// It must be called before collecting.
+func (c *Collector) SetDayLocation(location *time.Location) {
+	c.daily = daily.NewTracker(c.cache, location)
+}
+
+// SetTTLs changes how long player stats and world data are cached (0 restores the default);
+// existing entries keep their TTL
+func (c *Collector) SetTTLs(playerStats time.Duration, worldData time.Duration) {
+	if playerStats <= 0 {
+		playerStats = defaultPlayerStatsTTL
+	}
+	if worldData <= 0 {
+		worldData = defaultWorldDataTTL
 	}
+	c.playerStatsTTL.Store(int64(playerStats))
+	c.worldDataTTL.Store(int64(worldData))
 }
 
-// CollectPlayerStats collects and reports player stats
-func (c *Collector) CollectPlayerStats(rsn string, mode string) error {
-	logger.Log.WithFields(logrus.Fields{
+// SetRateLimit limits requests to the OSRS endpoints to rps a second (0 for unlimited). The
+// limit is shared with every collector using the same cache, so replicas sharing a Redis
+// stay under it together.
+func (c *Collector) SetRateLimit(rps float64) {
+	c.client.limiter.setRate(rps)
+}
+
+// SetTournamentModes replaces the tournament modes collected alongside the built-in ones, given
+// as mode -> hiscores table suffix (e.g. tournament for hiscore_oldschool_tournament). An invalid
+// mode changes nothing.
+func (c *Collector) SetTournamentModes(modes map[string]string) error {
+	return c.client.modes.set(modes)
+}
+
+// Modes returns the built-in modes followed by the configured tournament modes
+func (c *Collector) Modes() []string {
+	return c.client.modes.names()
+}
+
+// SetAliases replaces the player labels reported for RSNs (e.g. b0aty -> B0aty); other players
+// are labelled with their normalized RSN
+func (c *Collector) SetAliases(aliases map[string]string) {
+	c.aliases.set(aliases)
+}
+
+// PlayerLabel returns the player label an RSN is reported with
+func (c *Collector) PlayerLabel(rsn string) string {
+	return c.aliases.label(NormalizeRSN(rsn))
+}
+
+// CollectPlayerStats collects and reports player stats. A call made while the player is already
+// being collected in the mode waits for that collection and returns its result.
+func (c *Collector) CollectPlayerStats(ctx context.Context, rsn string, mode string) error {
+	rsn = NormalizeRSN(rsn)
+	return c.collections.Do(ctx, mode+":"+rsn, func(ctx context.Context) error {
+		return c.collectPlayerStats(ctx, rsn, mode)
+	})
+}
+
+// collectPlayerStats collects and reports player stats (rsn normalized)
+func (c *Collector) collectPlayerStats(ctx context.Context, rsn string, mode string) (err error) {
+	ctx, span := tracing.Start(ctx, "osrs.collect_player",
+		attribute.String("osrs.rsn", rsn),
+		attribute.String("osrs.mode", mode),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
 		"rsn":  rsn,
 		"mode": mode,
 	}).Info("Starting OSRS player stats collection")
 
-	// Check cache first
-	var stats []SkillInfo
-	var minigames []MinigameInfo
-	cacheKey := fmt.Sprintf("osrs:player_stats:%s:%s", mode, rsn)
-	if cachedData, exists := c.cache.Get(cacheKey); exists {
-		type cacheEntry struct {
-			Stats     []SkillInfo    `json:"stats"`
-			Minigames []MinigameInfo `json:"minigames"`
-			LastUpdate time.Time     `json:"last_update"`
-		}
-		var entry cacheEntry
-		if err := json.Unmarshal(cachedData, &entry); err == nil {
-			stats = entry.Stats
-			minigames = entry.Minigames
-			logger.Log.WithFields(logrus.Fields{
-				"rsn":   rsn,
-				"cache": "hit",
-			}).Info("Retrieved player stats from cache")
-		} else {
-			logger.Log.WithFields(logrus.Fields{
-				"rsn": rsn,
-			}).Warn("Cache hit but failed to unmarshal, fetching fresh")
-			stats = nil
-			minigames = nil
-		}
-	}
-
-	// Fetch fresh data if not cached
-	if stats == nil {
-		logger.Log.WithFields(logrus.Fields{
+	entry, err := c.getPlayerStats(ctx, rsn, mode)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
 			"rsn":   rsn,
-			"cache": "miss",
-		}).Info("Fetching player stats from API")
-
-		freshStats, freshMinigames, err := c.client.GetPlayerStats(rsn, mode)
-		if err != nil {
-			logger.Log.WithFields(logrus.Fields{
-				"rsn":   rsn,
-				"error": err.Error(),
-			}).Error("Failed to get player stats from API")
-			return fmt.Errorf("failed to get player stats: %w", err)
-		}
-		stats = freshStats
-		minigames = freshMinigames
-
-		// Cache with default TTL (15 minutes)
-		type cacheEntry struct {
-			Stats     []SkillInfo    `json:"stats"`
-			Minigames []MinigameInfo `json:"minigames"`
-			LastUpdate time.Time     `json:"last_update"`
-		}
-		entry := cacheEntry{
-			Stats:     stats,
-			Minigames: minigames,
-			LastUpdate: time.Now(),
-		}
-		if data, err := json.Marshal(entry); err == nil {
-			c.cache.Set(cacheKey, data, 15*time.Minute)
-			logger.Log.WithFields(logrus.Fields{
-				"rsn": rsn,
-				"ttl": "15m",
-			}).Debug("Cached player stats")
-		}
+			"error": err.Error(),
+		}).Error("Failed to get player stats from API")
+		return fmt.Errorf("failed to get player stats: %w", err)
 	}
+	stats, minigames := entry.Stats, entry.Minigames
 
+	_, reportSpan := tracing.Start(ctx, "osrs.report_metrics")
 	// Reset world metrics first to ensure they don't leak into player endpoint
 	ResetWorldMetrics()
 
 	// Report metrics - this will reset player metrics
 	ReportPlayerStats(stats, mode)
 	ReportMinigames(minigames, mode)
+	reportLastUpdated(c.aliases.label(rsn), mode, entry.LastUpdate)
+	c.reportXPToday(ctx, rsn, mode, stats)
+	c.reportMilestones(ctx, rsn, mode, stats)
+	reportSpan.End()
 
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(ctx).WithFields(logrus.Fields{
 		"rsn":           rsn,
 		"skills_count":  len(stats),
 		"minigames_count": len(minigames),
@@ -115,11 +179,82 @@ func (c *Collector) CollectPlayerStats(rsn string, mode string) error {
 	return nil
 }
 
-// CollectAllModes collects player stats from all supported modes
+// getPlayerStats retrieves player stats for a mode (rsn normalized), labelled with the player's
+// alias
+func (c *Collector) getPlayerStats(ctx context.Context, rsn string, mode string) (playerStatsCacheEntry, error) {
+	entry, err := c.cachedPlayerStats(ctx, rsn, mode)
+	if err != nil {
+		return playerStatsCacheEntry{}, err
+	}
+	entry.label(c.aliases.label(rsn))
+	return entry, nil
+}
+
+// cachedPlayerStats retrieves player stats for a mode, using cache if available
+// Expired entries are served stale while a background refresh fetches a new copy
+func (c *Collector) cachedPlayerStats(ctx context.Context, rsn string, mode string) (playerStatsCacheEntry, error) {
+	cacheKey := fmt.Sprintf("osrs:player_stats:%s:%s", mode, rsn)
+	ttl := time.Duration(c.playerStatsTTL.Load())
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, ttl, ttl, func(ctx context.Context) ([]byte, error) {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"rsn":   rsn,
+			"mode":  mode,
+			"cache": "miss",
+		}).Info("Fetching player stats from API")
+
+		stats, minigames, err := c.client.GetPlayerStats(ctx, rsn, mode)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(playerStatsCacheEntry{
+			Stats:      stats,
+			Minigames:  minigames,
+			LastUpdate: time.Now(),
+		})
+	})
+	if err != nil {
+		return playerStatsCacheEntry{}, err
+	}
+
+	var entry playerStatsCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"rsn":  rsn,
+			"mode": mode,
+		}).Warn("Cache hit but failed to unmarshal, fetching fresh")
+		c.cache.Delete(ctx, cacheKey)
+		stats, minigames, err := c.client.GetPlayerStats(ctx, rsn, mode)
+		if err != nil {
+			return playerStatsCacheEntry{}, err
+		}
+		return playerStatsCacheEntry{Stats: stats, Minigames: minigames, LastUpdate: time.Now()}, nil
+	}
+
+	return entry, nil
+}
+
+// reportXPToday reports the XP gained in each skill since local midnight
+func (c *Collector) reportXPToday(ctx context.Context, rsn string, mode string, stats []SkillInfo) {
+	xp := make(map[string]int64, len(stats))
+	for _, stat := range stats {
+		// Unranked skills are -1 and leave the baseline alone
+		if value, err := strconv.ParseInt(stat.XP, 10, 64); err == nil && value >= 0 {
+			xp[stat.Name] = value
+		}
+	}
+	reportXPToday(c.daily.Today(ctx, fmt.Sprintf("osrs:xp_today:%s:%s", mode, rsn), xp), c.aliases.label(rsn), mode)
+}
+
+// CollectAllModes collects player stats from all supported modes, tournament modes included
 // Returns a map of mode -> error for any failures, but continues collecting other modes
 // This allows partial results even if some modes fail
-func (c *Collector) CollectAllModes(rsn string) map[string]error {
+func (c *Collector) CollectAllModes(ctx context.Context, rsn string) map[string]error {
+	rsn = NormalizeRSN(rsn)
+	ctx, span := tracing.Start(ctx, "osrs.collect_all_modes", attribute.String("osrs.rsn", rsn))
+	defer span.End()
+
 	errors := make(map[string]error)
+	modes := c.Modes()
 
 	// Reset world metrics first to ensure they don't leak into player endpoint
 	ResetWorldMetrics()
@@ -127,91 +262,41 @@ func (c *Collector) CollectAllModes(rsn string) map[string]error {
 	// Reset player metrics at the start to ensure clean state
 	ResetPlayerMetrics()
 
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(ctx).WithFields(logrus.Fields{
 		"rsn":          rsn,
-		"modes_count":  len(SupportedModes),
+		"modes_count":  len(modes),
 	}).Info("Starting OSRS player stats collection for all modes")
 
-	for _, mode := range SupportedModes {
-		logger.Log.WithFields(logrus.Fields{
+	for _, mode := range modes {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
 			"rsn":  rsn,
 			"mode": mode,
 		}).Info("Collecting stats for mode")
 
-		// Try to collect stats for this mode
-		// We'll collect the data ourselves and report it without resetting between modes
-		var stats []SkillInfo
-		var minigames []MinigameInfo
-
-		// Check cache first
-		cacheKey := fmt.Sprintf("osrs:player_stats:%s:%s", mode, rsn)
-		if cachedData, exists := c.cache.Get(cacheKey); exists {
-			type cacheEntry struct {
-				Stats     []SkillInfo    `json:"stats"`
-				Minigames []MinigameInfo `json:"minigames"`
-				LastUpdate time.Time     `json:"last_update"`
-			}
-			var entry cacheEntry
-			if err := json.Unmarshal(cachedData, &entry); err == nil {
-				stats = entry.Stats
-				minigames = entry.Minigames
-				logger.Log.WithFields(logrus.Fields{
-					"rsn":    rsn,
-					"mode":   mode,
-					"cache":  "hit",
-				}).Info("Retrieved player stats from cache")
-			}
-		}
-
-		// Fetch fresh data if not cached
-		if stats == nil {
-			logger.Log.WithFields(logrus.Fields{
+		entry, err := c.getPlayerStats(ctx, rsn, mode)
+		if err != nil {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
 				"rsn":   rsn,
 				"mode":  mode,
-				"cache": "miss",
-			}).Info("Fetching player stats from API")
-
-			freshStats, freshMinigames, err := c.client.GetPlayerStats(rsn, mode)
-			if err != nil {
-				logger.Log.WithFields(logrus.Fields{
-					"rsn":   rsn,
-					"mode":  mode,
-					"error": err.Error(),
-				}).Warn("Failed to get player stats from API for mode, continuing with other modes")
-				errors[mode] = err
-				// Continue with other modes - don't fail the entire request
-				continue
-			}
-			stats = freshStats
-			minigames = freshMinigames
-
-			// Cache with default TTL (15 minutes)
-			type cacheEntry struct {
-				Stats     []SkillInfo    `json:"stats"`
-				Minigames []MinigameInfo `json:"minigames"`
-				LastUpdate time.Time     `json:"last_update"`
-			}
-			entry := cacheEntry{
-				Stats:     stats,
-				Minigames: minigames,
-				LastUpdate: time.Now(),
-			}
-			if data, err := json.Marshal(entry); err == nil {
-				c.cache.Set(cacheKey, data, 15*time.Minute)
-				logger.Log.WithFields(logrus.Fields{
-					"rsn":  rsn,
-					"mode": mode,
-					"ttl":  "15m",
-				}).Debug("Cached player stats")
-			}
+				"error": err.Error(),
+			}).Warn("Failed to get player stats from API for mode, continuing with other modes")
+			errors[mode] = err
+			// Continue with other modes - don't fail the entire request
+			continue
 		}
 
 		// Report metrics for this mode (without resetting - we already reset at the start)
 		// Use a helper function that doesn't reset
+		_, reportSpan := tracing.Start(ctx, "osrs.report_metrics", attribute.String("osrs.mode", mode))
+		stats, minigames := entry.Stats, entry.Minigames
 		reportPlayerStatsWithoutReset(stats, mode)
 		reportMinigamesWithoutReset(minigames, mode)
+		reportLastUpdated(c.aliases.label(rsn), mode, entry.LastUpdate)
+		c.reportXPToday(ctx, rsn, mode, stats)
+		c.reportMilestones(ctx, rsn, mode, stats)
+		reportSpan.End()
 
-		logger.Log.WithFields(logrus.Fields{
+		logger.FromContext(ctx).WithFields(logrus.Fields{
 			"rsn":            rsn,
 			"mode":            mode,
 			"skills_count":  len(stats),
@@ -219,9 +304,9 @@ func (c *Collector) CollectAllModes(rsn string) map[string]error {
 		}).Info("Successfully collected stats for mode")
 	}
 
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(ctx).WithFields(logrus.Fields{
 		"rsn":           rsn,
-		"modes_count":   len(SupportedModes),
+		"modes_count":   len(modes),
 		"errors_count":  len(errors),
 	}).Info("Completed OSRS player stats collection for all modes")
 
@@ -229,64 +314,95 @@ func (c *Collector) CollectAllModes(rsn string) map[string]error {
 }
 
 // CollectWorldData collects and reports world data
-func (c *Collector) CollectWorldData() error {
-	logger.Log.Info("Starting OSRS world data collection")
+func (c *Collector) CollectWorldData(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "osrs.collect_worlds")
+	defer func() { tracing.End(span, err) }()
 
-	// Check cache first
-	var worlds []World
-	cacheKey := "osrs:world_data"
-	if cachedData, exists := c.cache.Get(cacheKey); exists {
-		if err := json.Unmarshal(cachedData, &worlds); err == nil {
-			logger.Log.WithFields(logrus.Fields{
-				"cache":      "hit",
-				"worlds_num": len(worlds),
-			}).Info("Retrieved world data from cache")
-			// Use cached data
-		} else {
-			logger.Log.WithFields(logrus.Fields{
-				"error": err.Error(),
-			}).Warn("Cache hit but failed to unmarshal, fetching fresh")
-			worlds = nil
-		}
+	logger.FromContext(ctx).Info("Starting OSRS world data collection")
+
+	worlds, sum, err := c.getWorldData(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Fetch fresh data if not cached
-	if worlds == nil {
-		logger.Log.WithField("cache", "miss").Info("Fetching world data from API")
+	_, reportSpan := tracing.Start(ctx, "osrs.report_metrics")
+	// Reset player metrics first to ensure they don't leak into world endpoint
+	ResetPlayerMetrics()
 
-		freshWorlds, err := c.client.GetWorldData()
-		if err != nil {
-			logger.Log.WithFields(logrus.Fields{
-				"error": err.Error(),
-			}).Error("Failed to get world data from API")
-			return fmt.Errorf("failed to get world data: %w", err)
-		}
-		worlds = freshWorlds
+	// Report metrics - this will reset world metrics, unless they already hold this world list
+	reported := reportWorldDataIfChanged(sum, worlds)
+	reportSpan.End()
 
-		logger.Log.WithField("worlds_num", len(worlds)).Info("Successfully fetched world data from API")
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"worlds_num": len(worlds),
+		"unchanged":  !reported,
+	}).Info("Completed OSRS world data collection")
 
-		// Cache with 5 minute TTL
-		if data, err := json.Marshal(worlds); err == nil {
-			c.cache.Set(cacheKey, data, 5*time.Minute)
-			logger.Log.WithField("ttl", "5m").Debug("Cached world data")
+	return nil
+}
+
+// getWorldData retrieves the world list with the checksum of its cache entry, using cache if
+// available. Expired entries are served stale while a background refresh fetches a new copy
+func (c *Collector) getWorldData(ctx context.Context) ([]World, [sha256.Size]byte, error) {
+	cacheKey := "osrs:world_data"
+	ttl := time.Duration(c.worldDataTTL.Load())
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, ttl, ttl, func(ctx context.Context) ([]byte, error) {
+		logger.FromContext(ctx).WithField("cache", "miss").Info("Fetching world data from API")
+
+		freshWorlds, err := c.client.GetWorldData(ctx)
+		if err != nil {
+			return nil, err
 		}
+		logger.FromContext(ctx).WithField("worlds_num", len(freshWorlds)).Info("Successfully fetched world data from API")
+		return json.Marshal(freshWorlds)
+	})
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Error("Failed to get world data from API")
+		return nil, [sha256.Size]byte{}, fmt.Errorf("failed to get world data: %w", err)
 	}
 
-	// Reset player metrics first to ensure they don't leak into world endpoint
-	ResetPlayerMetrics()
+	var worlds []World
+	if err := json.Unmarshal(data, &worlds); err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Warn("Cache hit but failed to unmarshal, fetching fresh")
+		c.cache.Delete(ctx, cacheKey)
+		worlds, err = c.client.GetWorldData(ctx)
+		if err != nil {
+			return nil, [sha256.Size]byte{}, fmt.Errorf("failed to get world data: %w", err)
+		}
+		data, err = json.Marshal(worlds)
+		if err != nil {
+			return nil, [sha256.Size]byte{}, fmt.Errorf("failed to encode world data: %w", err)
+		}
+	}
 
-	// Report metrics - this will reset world metrics
-	ReportWorldData(worlds)
+	return worlds, sha256.Sum256(data), nil
+}
 
-	logger.Log.WithField("worlds_num", len(worlds)).Info("Completed OSRS world data collection")
+// PlayerStats returns a player's hiscores for a mode from the cache, fetching on a miss
+func (c *Collector) PlayerStats(ctx context.Context, rsn string, mode string) ([]SkillInfo, []MinigameInfo, error) {
+	entry, err := c.getPlayerStats(ctx, NormalizeRSN(rsn), mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry.Stats, entry.Minigames, nil
+}
 
-	return nil
+// Worlds returns the world list from the cache, fetching on a miss
+func (c *Collector) Worlds(ctx context.Context) ([]World, error) {
+	worlds, _, err := c.getWorldData(ctx)
+	return worlds, err
 }
 
 // IsActive detects if a player is actively playing by checking XP increases
-func (c *Collector) IsActive(rsn string, mode string) (bool, error) {
+func (c *Collector) IsActive(ctx context.Context, rsn string, mode string) (bool, error) {
+	rsn = NormalizeRSN(rsn)
+
 	// Get current stats
-	stats, _, err := c.client.GetPlayerStats(rsn, mode)
+	stats, _, err := c.client.GetPlayerStats(ctx, rsn, mode)
 	if err != nil {
 		return false, err
 	}
@@ -294,7 +410,7 @@ func (c *Collector) IsActive(rsn string, mode string) (bool, error) {
 	// Get last known XP values from cache
 	cacheKey := fmt.Sprintf("osrs:last_xp:%s:%s", mode, rsn)
 	lastXP := make(map[string]int64)
-	if cachedData, exists := c.cache.Get(cacheKey); exists {
+	if cachedData, exists := c.cache.Get(ctx, cacheKey); exists {
 		if err := json.Unmarshal(cachedData, &lastXP); err != nil {
 			lastXP = make(map[string]int64)
 		}
@@ -309,7 +425,7 @@ func (c *Collector) IsActive(rsn string, mode string) (bool, error) {
 			currentXP[stat.Name] = xp
 		}
 		if data, err := json.Marshal(currentXP); err == nil {
-			c.cache.Set(cacheKey, data, 24*time.Hour)
+			c.cache.Set(ctx, cacheKey, data, 24*time.Hour)
 		}
 		return false, nil
 	}
@@ -330,9 +446,39 @@ func (c *Collector) IsActive(rsn string, mode string) (bool, error) {
 
 	// Update cached XP values
 	if data, err := json.Marshal(currentXP); err == nil {
-		c.cache.Set(cacheKey, data, 24*time.Hour)
+		c.cache.Set(ctx, cacheKey, data, 24*time.Hour)
 	}
 
 	return active, nil
 }
 
+
+// checkPlayer is a long-standing ranked player used to check the hiscores are reachable
+const checkPlayer = "Lynx Titan"
+
+// CheckHiscores makes a single uncached hiscores request
+func (c *Collector) CheckHiscores(ctx context.Context) error {
+	if _, _, err := c.client.GetPlayerStats(ctx, checkPlayer, "vanilla"); err != nil {
+		return fmt.Errorf("hiscores check failed: %w", err)
+	}
+	return nil
+}
+
+// CheckWorldList makes a single uncached world list request
+func (c *Collector) CheckWorldList(ctx context.Context) error {
+	if _, err := c.client.GetWorldData(ctx); err != nil {
+		return fmt.Errorf("world list check failed: %w", err)
+	}
+	return nil
+}
+
+// DebugPlayerStats fetches a player's hiscores uncached for /debug/raw, without caching or
+// reporting them
+func (c *Collector) DebugPlayerStats(ctx context.Context, rsn string, mode string) ([]SkillInfo, []MinigameInfo, error) {
+	return c.client.GetPlayerStats(ctx, NormalizeRSN(rsn), mode)
+}
+
+// DebugWorlds fetches the world list uncached for /debug/raw, without caching or reporting it
+func (c *Collector) DebugWorlds(ctx context.Context) ([]World, error) {
+	return c.client.GetWorldData(ctx)
+}
@@ -0,0 +1,676 @@
+package osrs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/debugraw"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestCollector returns a collector with a fresh file cache that sends every request to srv
+func newTestCollector(t *testing.T, srv *testserver.Server) *Collector {
+	t.Helper()
+	return NewCollector(testserver.NewCache(t), srv.Transport())
+}
+
+func testPlayer() testserver.OSRSPlayer {
+	player := testserver.OSRSPlayer{
+		Minigames: []testserver.Minigame{
+			{Name: "League Points", Rank: -1, Score: -1},
+			{Name: "Clue Scrolls (all)", Rank: 1234, Score: 56},
+			{Name: "Zulrah", Rank: 999, Score: 250},
+		},
+	}
+	for range Skills() {
+		player.Skills = append(player.Skills, testserver.Skill{Rank: 5000, Level: 70, XP: 737627})
+	}
+	return player
+}
+
+func TestPlayerStats(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Zezima", testPlayer())
+	collector := newTestCollector(t, srv)
+
+	skills, minigames, err := collector.PlayerStats(context.Background(), "Zezima", "normal")
+	if err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+	if len(skills) != len(Skills()) {
+		t.Fatalf("got %d skills, want %d", len(skills), len(Skills()))
+	}
+	if skills[0].Name != Skills()[0] || skills[0].Level != "70" || skills[0].XP != "737627" {
+		t.Errorf("first skill = %+v", skills[0])
+	}
+
+	// Minigames without a score are left out; the others are named from the HTML hiscores
+	if len(minigames) != 2 {
+		t.Fatalf("got %d minigames, want 2: %+v", len(minigames), minigames)
+	}
+	if minigames[0].Name != "Clue Scrolls (all)" || minigames[0].Score != "56" {
+		t.Errorf("first minigame = %+v", minigames[0])
+	}
+	if minigames[1].Name != "Zulrah" || minigames[1].Rank != "999" {
+		t.Errorf("second minigame = %+v", minigames[1])
+	}
+}
+
+func TestPlayerStatsUnknownSkill(t *testing.T) {
+	srv := testserver.New(t)
+	player := testPlayer()
+	player.Skills = append(player.Skills, testserver.Skill{Rank: 100, Level: 50, XP: 101333})
+	srv.AddOSRSPlayer("Zezima", player)
+	srv.AddOSRSPlayer("Woox", player)
+	collector := newTestCollector(t, srv)
+	ctx := context.Background()
+
+	// A skill past the table is kept by position, and the minigames after it still parse
+	skills, minigames, err := collector.PlayerStats(ctx, "Zezima", "vanilla")
+	if err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+	unknown := fmt.Sprintf("Skill %d", len(Skills()))
+	if len(skills) != len(Skills())+1 || skills[len(skills)-1].Name != unknown || skills[len(skills)-1].XP != "101333" {
+		t.Errorf("last skill = %+v, want %s", skills[len(skills)-1], unknown)
+	}
+	if len(minigames) != 2 {
+		t.Errorf("got %d minigames, want 2: %+v", len(minigames), minigames)
+	}
+
+	// Naming it in the config file names it on the next fetch
+	SetSkills(append(Skills(), "Necromancy"))
+	t.Cleanup(func() { SetSkills(nil) })
+	skills, _, err = collector.PlayerStats(ctx, "Woox", "vanilla")
+	if err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+	if name := skills[len(skills)-1].Name; name != "Necromancy" {
+		t.Errorf("last skill = %s, want Necromancy", name)
+	}
+
+	if err := CheckSkills([]string{"Overall", "Attack", "Overall"}); err == nil {
+		t.Error("CheckSkills accepted a duplicate skill")
+	}
+}
+
+func TestPlayerStatsRecordsDataAge(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Zezima", testPlayer())
+	collector := newTestCollector(t, srv)
+
+	start := time.Now()
+	if _, _, err := collector.PlayerStats(context.Background(), "Zezima", "normal"); err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+
+	// A cache hit records when the hiscores were fetched
+	ctx, freshness := cache.WithFreshness(context.Background())
+	if _, _, err := collector.PlayerStats(ctx, "Zezima", "normal"); err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+	if oldest := freshness.Oldest(); oldest.Before(start.Add(-time.Second)) || oldest.After(start.Add(time.Second)) {
+		t.Errorf("data fetched at %v, want about %v", oldest, start)
+	}
+}
+
+func TestCollectPlayerStatsReportsLastUpdated(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Zezima", testPlayer())
+	collector := newTestCollector(t, srv)
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := collector.CollectPlayerStats(context.Background(), "Zezima", "vanilla"); err != nil {
+			t.Fatalf("CollectPlayerStats: %v", err)
+		}
+	}
+
+	// The timestamp is the fetch's, not the (cached) second collection's
+	got := testutil.ToFloat64(playerLastUpdatedGauge.WithLabelValues("zezima", "vanilla"))
+	if got < float64(start.Unix()-1) || got > float64(start.Unix()+1) {
+		t.Errorf("last updated = %v, want about %d", got, start.Unix())
+	}
+}
+
+func TestCollectPlayerStatsNormalizesRSN(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Lynx Titan", testPlayer())
+	srv.AddOSRSPlayer("B0aty", testPlayer())
+	collector := newTestCollector(t, srv)
+	collector.SetAliases(map[string]string{"b0aty": "B0aty"})
+	ctx := context.Background()
+
+	// Spellings of one name share a cache entry and a label
+	for _, rsn := range []string{"Lynx Titan", "lynx_titan", "LYNX%20TITAN", " Lynx-Titan "} {
+		if err := collector.CollectPlayerStats(ctx, rsn, "vanilla"); err != nil {
+			t.Fatalf("CollectPlayerStats(%q): %v", rsn, err)
+		}
+		if got := testutil.ToFloat64(playerXPGauge.WithLabelValues("Overall", "lynx titan", "vanilla")); got != 737627 {
+			t.Errorf("%q: lynx titan Overall XP = %v, want 737627", rsn, got)
+		}
+	}
+	if got := srv.Requests("/cors/m=hiscore_oldschool/index_lite.ws"); got != 1 {
+		t.Errorf("got %d hiscores requests, want 1", got)
+	}
+
+	// An alias replaces the label
+	if err := collector.CollectPlayerStats(ctx, "b0aty", "vanilla"); err != nil {
+		t.Fatalf("CollectPlayerStats: %v", err)
+	}
+	if got := testutil.CollectAndCount(playerLastUpdatedGauge); got != 1 {
+		t.Errorf("reported %d last updated series, want 1", got)
+	}
+	if got := testutil.ToFloat64(playerXPGauge.WithLabelValues("Overall", "B0aty", "vanilla")); got != 737627 {
+		t.Errorf("B0aty Overall XP = %v, want 737627", got)
+	}
+	if label := collector.PlayerLabel("B0ATY"); label != "B0aty" {
+		t.Errorf("PlayerLabel(B0ATY) = %s, want B0aty", label)
+	}
+}
+
+func TestCollectPlayerStatsMilestones(t *testing.T) {
+	srv := testserver.New(t)
+	player := testPlayer()
+	player.Skills[1] = testserver.Skill{Rank: 100, Level: 98, XP: 11_800_000} // Attack
+	srv.AddOSRSPlayer("Zezima", player)
+	collector := newTestCollector(t, srv)
+	var milestones []Milestone
+	collector.SetMilestoneHandler(func(ctx context.Context, milestone Milestone) {
+		milestones = append(milestones, milestone)
+	})
+	ctx := context.Background()
+
+	collect := func() {
+		t.Helper()
+		collector.cache.Delete(ctx, "osrs:player_stats:vanilla:zezima")
+		if err := collector.CollectPlayerStats(ctx, "Zezima", "vanilla"); err != nil {
+			t.Fatalf("CollectPlayerStats: %v", err)
+		}
+	}
+
+	// The first collection only records what was already reached
+	collect()
+	if len(milestones) != 0 {
+		t.Errorf("first collection reported %v, want none", milestones)
+	}
+
+	player.Skills[1] = testserver.Skill{Rank: 90, Level: 99, XP: 13_100_000}
+	srv.AddOSRSPlayer("Zezima", player)
+	collect()
+	want := Milestone{Player: "zezima", Mode: "vanilla", Kind: MilestoneLevel99, Skill: "Attack", Value: 99}
+	if len(milestones) != 1 || milestones[0] != want {
+		t.Errorf("got milestones %v, want %v", milestones, want)
+	}
+	if got := testutil.ToFloat64(playerSkillsAt99Gauge.WithLabelValues("zezima", "vanilla")); got != 1 {
+		t.Errorf("skills at 99 = %v, want 1", got)
+	}
+
+	// Each milestone is reported once
+	collect()
+	if len(milestones) != 1 {
+		t.Errorf("got %d milestones after a third collection, want 1", len(milestones))
+	}
+}
+
+func TestCollectPlayerStatsSkillTotals(t *testing.T) {
+	srv := testserver.New(t)
+	player := testPlayer()
+	player.Skills[0] = testserver.Skill{Rank: 1, Level: 2277, XP: 4_600_000_000} // Overall
+	player.Skills[1] = testserver.Skill{Rank: 1, Level: 99, XP: 200_000_000}     // Attack
+	player.Skills[2] = testserver.Skill{Rank: 50, Level: 99, XP: 50_000_000}     // Defence
+	srv.AddOSRSPlayer("Zezima", player)
+	collector := newTestCollector(t, srv)
+
+	if err := collector.CollectPlayerStats(context.Background(), "Zezima", "vanilla"); err != nil {
+		t.Fatalf("CollectPlayerStats: %v", err)
+	}
+
+	// Overall isn't a skill of its own
+	if got := testutil.ToFloat64(playerSkillsAt99Gauge.WithLabelValues("zezima", "vanilla")); got != 2 {
+		t.Errorf("skills at 99 = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(playerSkillsAt200mGauge.WithLabelValues("zezima", "vanilla")); got != 1 {
+		t.Errorf("skills at 200m = %v, want 1", got)
+	}
+}
+
+func TestCollectAllModes(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Zezima", testPlayer())
+	collector := newTestCollector(t, srv)
+
+	if errs := collector.CollectAllModes(context.Background(), "Zezima"); len(errs) != 0 {
+		t.Fatalf("CollectAllModes: %v", errs)
+	}
+
+	// Every mode is reported side by side, none resetting the ones before it
+	if got := testutil.CollectAndCount(playerXPGauge); got != len(Skills())*len(SupportedModes) {
+		t.Errorf("reported %d XP series, want %d", got, len(Skills())*len(SupportedModes))
+	}
+	for _, mode := range []string{"vanilla", "ironman", "seasonal"} {
+		if got := testutil.ToFloat64(playerLastUpdatedGauge.WithLabelValues("zezima", mode)); got == 0 {
+			t.Errorf("%s last updated not reported", mode)
+		}
+	}
+	if got := srv.Requests("/cors/m=hiscore_oldschool_ultimate/index_lite.ws"); got != 1 {
+		t.Errorf("got %d ultimate ironman hiscores requests, want 1", got)
+	}
+}
+
+func TestTournamentModes(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Zezima", testPlayer())
+	collector := newTestCollector(t, srv)
+
+	if err := collector.SetTournamentModes(map[string]string{"fresh_start": "fresh_start"}); err != nil {
+		t.Fatalf("SetTournamentModes: %v", err)
+	}
+	if _, _, err := collector.PlayerStats(context.Background(), "Zezima", "fresh_start"); err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+	if got := srv.Requests("/cors/m=hiscore_oldschool_fresh_start/index_lite.ws"); got != 1 {
+		t.Errorf("got %d fresh_start hiscores requests, want 1", got)
+	}
+
+	// The built-in modes can't be redefined, and an invalid mode keeps the previous set
+	for _, modes := range []map[string]string{{"gridmaster": "fresh_start"}, {"all": "x"}, {"Leagues": "x"}, {"leagues": "../x"}} {
+		if err := collector.SetTournamentModes(modes); err == nil {
+			t.Errorf("SetTournamentModes(%v) succeeded, want an error", modes)
+		}
+	}
+	modes := collector.Modes()
+	if len(modes) != len(SupportedModes)+1 || modes[len(modes)-1] != "fresh_start" {
+		t.Errorf("Modes() = %v, want the built-in modes and fresh_start", modes)
+	}
+}
+
+func TestPlayerStatsUnknownPlayer(t *testing.T) {
+	srv := testserver.New(t)
+	collector := newTestCollector(t, srv)
+
+	if _, _, err := collector.PlayerStats(context.Background(), "nobody", "normal"); !errors.Is(err, ErrPlayerNotFound) {
+		t.Fatalf("PlayerStats of an unknown player = %v, want ErrPlayerNotFound", err)
+	}
+	if got := srv.Requests("/cors/m=hiscore_oldschool/index_lite.ws"); got != 1 {
+		t.Errorf("got %d hiscores requests, want 1 (a 404 isn't retried)", got)
+	}
+}
+
+func TestPlayerStatsRetries(t *testing.T) {
+	backoff := hiscoresBackoff
+	hiscoresBackoff = time.Millisecond
+	t.Cleanup(func() { hiscoresBackoff = backoff })
+
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Zezima", testPlayer())
+	srv.AddOSRSPlayer("Woox", testPlayer())
+	collector := newTestCollector(t, srv)
+	ctx := context.Background()
+
+	// Server errors are retried
+	srv.FailHiscores(http.StatusServiceUnavailable, http.StatusBadGateway)
+	if _, _, err := collector.PlayerStats(ctx, "Zezima", "vanilla"); err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+	if got := srv.Requests("/cors/m=hiscore_oldschool/index_lite.ws"); got != 3 {
+		t.Errorf("got %d hiscores requests, want 3", got)
+	}
+
+	// Until the attempts run out
+	srv.FailHiscores(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	if _, _, err := collector.PlayerStats(ctx, "Woox", "vanilla"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("PlayerStats with the hiscores down = %v, want ErrUnavailable", err)
+	}
+}
+
+func testWorlds() []testserver.World {
+	return []testserver.World{
+		{ID: 301, Flags: 0, Address: "oldschool1.runescape.com", Activity: "Trade - Free", Location: 0, Players: 812},
+		{ID: 302, Flags: 1, Address: "oldschool2.runescape.com", Activity: "Trade - Members", Location: 0, Players: 1544},
+		{ID: 303, Flags: 1, Address: "oldschool3.runescape.com", Activity: "-", Location: 7, Players: 1020},
+	}
+}
+
+func TestWorlds(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetWorlds(testWorlds()...)
+	collector := newTestCollector(t, srv)
+
+	worlds, err := collector.Worlds(context.Background())
+	if err != nil {
+		t.Fatalf("Worlds: %v", err)
+	}
+	if len(worlds) != 3 {
+		t.Fatalf("got %d worlds, want 3", len(worlds))
+	}
+	if worlds[1].ID != 302 || worlds[1].Players != 1544 || !worlds[1].IsMembers() {
+		t.Errorf("second world = %+v", worlds[1])
+	}
+	if got := testutil.ToFloat64(worldsParsedGauge); got != 3 {
+		t.Errorf("worlds parsed = %v, want 3", got)
+	}
+}
+
+func TestWorldsTruncated(t *testing.T) {
+	worlds := testWorlds()
+	full := testserver.EncodeWorlds(worlds, 0)
+	last := testserver.EncodeWorlds(worlds[2:], 0)
+
+	tests := []struct {
+		name  string
+		count int16
+	}{
+		{name: "valid count", count: 0},
+		{name: "corrupted count", count: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := testserver.New(t)
+			srv.SetWorlds(worlds...)
+			srv.SetWorldCount(tt.count)
+			// Cut the response a few bytes into the last world, as the 30KB limit does
+			srv.TruncateWorlds(len(full) - (len(last) - 6) + 5)
+			collector := newTestCollector(t, srv)
+			truncated := testutil.ToFloat64(worldDecodeTruncatedCounter)
+
+			got, err := collector.Worlds(context.Background())
+			if err != nil {
+				t.Fatalf("Worlds: %v", err)
+			}
+			if len(got) != 2 || got[0].ID != 301 || got[1].ID != 302 {
+				t.Errorf("got %+v, want the two complete worlds", got)
+			}
+			if got := testutil.ToFloat64(worldDecodeTruncatedCounter) - truncated; got != 1 {
+				t.Errorf("truncated responses counted = %v, want 1", got)
+			}
+			if got := testutil.ToFloat64(worldsParsedGauge); got != 2 {
+				t.Errorf("worlds parsed = %v, want 2", got)
+			}
+		})
+	}
+}
+
+func TestDebugWorldsRecordsRawResponse(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetWorlds(testWorlds()...)
+	collector := newTestCollector(t, srv)
+
+	ctx, recorder := debugraw.WithRecorder(context.Background())
+	worlds, err := collector.DebugWorlds(ctx)
+	if err != nil {
+		t.Fatalf("DebugWorlds: %v", err)
+	}
+	if len(worlds) != 3 {
+		t.Fatalf("got %d worlds, want 3", len(worlds))
+	}
+
+	responses := recorder.Responses()
+	if len(responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(responses))
+	}
+	if want := testserver.EncodeWorlds(testWorlds(), 0); !bytes.Equal(responses[0].Body, want) {
+		t.Errorf("recorded body = %x, want %x", responses[0].Body, want)
+	}
+
+	// The parsed world list is left out of the cache
+	if _, exists := collector.cache.Get(context.Background(), "osrs:world_data"); exists {
+		t.Error("DebugWorlds cached the world list")
+	}
+}
+
+func TestWorldsUnchangedPayloadNotDecoded(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetWorlds(testWorlds()...)
+	collector := newTestCollector(t, srv)
+
+	first, err := collector.DebugWorlds(context.Background())
+	if err != nil {
+		t.Fatalf("DebugWorlds: %v", err)
+	}
+	second, err := collector.DebugWorlds(context.Background())
+	if err != nil {
+		t.Fatalf("DebugWorlds: %v", err)
+	}
+	if &first[0] != &second[0] {
+		t.Error("unchanged payload was decoded again")
+	}
+	if got, want := testutil.ToFloat64(worldPayloadBytesGauge), float64(len(testserver.EncodeWorlds(testWorlds(), 0))); got != want {
+		t.Errorf("payload bytes = %v, want %v", got, want)
+	}
+
+	srv.SetWorlds(testWorlds()[:2]...)
+	changed, err := collector.DebugWorlds(context.Background())
+	if err != nil {
+		t.Fatalf("DebugWorlds: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("got %d worlds after the payload changed, want 2", len(changed))
+	}
+}
+
+func TestCollectWorldData(t *testing.T) {
+	srv := testserver.New(t)
+	worlds := testWorlds()
+	// Members, PVP and High Risk
+	worlds = append(worlds, testserver.World{ID: 337, Flags: 1 | 1<<2 | 1<<10, Address: "oldschool37.runescape.com", Activity: "High Risk World", Location: 1, Players: 300})
+	// Members and a bit no world type is known for yet
+	worlds = append(worlds, testserver.World{ID: 338, Flags: 1 | 1<<12, Address: "oldschool38.runescape.com", Activity: "New World Type", Location: 1, Players: 40})
+	worlds = append(worlds, testserver.World{ID: 339, Flags: 1, Address: "oldschool39.runescape.com", Activity: "-", Location: 7, Players: 2000})
+	srv.SetWorlds(worlds...)
+	collector := newTestCollector(t, srv)
+
+	if err := collector.CollectWorldData(context.Background()); err != nil {
+		t.Fatalf("CollectWorldData: %v", err)
+	}
+
+	// The players gauge only carries one type; every flag has its own type series
+	if got := testutil.ToFloat64(worldPlayersGauge.WithLabelValues("337", "UK", "true", "PVP")); got != 300 {
+		t.Errorf("world 337 players = %v, want 300", got)
+	}
+	for _, worldType := range []WorldType{WorldTypeMembers, WorldTypePVP, WorldTypeHighRisk} {
+		if got := testutil.ToFloat64(worldTypeGauge.WithLabelValues("337", string(worldType))); got != 1 {
+			t.Errorf("world 337 %s = %v, want 1", worldType, got)
+		}
+	}
+	if got := testutil.ToFloat64(worldTypeGauge.WithLabelValues("301", string(WorldTypeFreeToPlay))); got != 1 {
+		t.Errorf("world 301 FreeToPlay = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(worldTypeGauge.WithLabelValues("338", "bit_12")); got != 1 {
+		t.Errorf("world 338 bit_12 = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(worldTypeGauge); got != 9 {
+		t.Errorf("reported %d world type series, want 9", got)
+	}
+
+	if got := testutil.ToFloat64(worldFreeSlotsGauge.WithLabelValues("302", "USA", "true", "Members")); got != 456 {
+		t.Errorf("world 302 free slots = %v, want 456", got)
+	}
+	if got := testutil.ToFloat64(worldFullGauge.WithLabelValues("302", "USA", "true", "Members")); got != 0 {
+		t.Errorf("world 302 full = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(worldFullGauge.WithLabelValues("339", "Germany", "true", "Members")); got != 1 {
+		t.Errorf("world 339 full = %v, want 1", got)
+	}
+}
+
+func TestCollectWorldDataCorruptCache(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetWorlds(testWorlds()...)
+	collector := newTestCollector(t, srv)
+	ctx := context.Background()
+
+	// A cached world list that no longer decodes is replaced rather than failing the scrape
+	collector.cache.Set(ctx, "osrs:world_data", []byte("not json"), time.Hour)
+	if err := collector.CollectWorldData(ctx); err != nil {
+		t.Fatalf("CollectWorldData: %v", err)
+	}
+	if got := srv.Requests("/g=oldscape/slr.ws"); got != 1 {
+		t.Errorf("world list requests = %d, want 1", got)
+	}
+	if got := testutil.ToFloat64(worldPlayersGauge.WithLabelValues("302", "USA", "true", "Members")); got != 1544 {
+		t.Errorf("world 302 players = %v, want 1544", got)
+	}
+	if _, exists := collector.cache.Get(ctx, "osrs:world_data"); exists {
+		t.Error("corrupt world list was left in the cache")
+	}
+}
+
+func TestRateLimitSharedAcrossCollectors(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddOSRSPlayer("Zezima", testPlayer())
+	srv.AddOSRSPlayer("Woox", testPlayer())
+	c := testserver.NewCache(t)
+
+	// Two replicas sharing a cache, each making a hiscores and a minigame names request
+	first, second := NewCollector(c, srv.Transport()), NewCollector(c, srv.Transport())
+	first.SetRateLimit(20)
+	second.SetRateLimit(20)
+
+	ctx := context.Background()
+	start := time.Now()
+	errs := make(chan error, 2)
+	go func() { _, _, err := first.PlayerStats(ctx, "Zezima", "vanilla"); errs <- err }()
+	go func() { _, _, err := second.PlayerStats(ctx, "Woox", "vanilla"); errs <- err }()
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatalf("PlayerStats: %v", err)
+		}
+	}
+
+	// Four requests at 20 a second take at least three intervals
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("4 requests took %v, want at least 150ms at 20 requests a second", elapsed)
+	}
+}
+
+func TestItems(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddGEItem(testserver.GEItem{ID: 20997, Name: "Twisted bow", Members: true, Limit: 8, HighAlch: 720000})
+	srv.AddGEItem(testserver.GEItem{ID: 21000, Name: "Twisted buckler", Members: true, Limit: 8, HighAlch: 72000})
+	srv.AddGEItem(testserver.GEItem{ID: 12924, Name: "Toxic blowpipe (empty)", Members: true, Limit: 8, HighAlch: 32400})
+	srv.AddGEItem(testserver.GEItem{ID: 841, Name: "Shortbow", Limit: 18000, HighAlch: 30})
+	srv.AddGEItem(testserver.GEItem{ID: 25865, Name: "Bow of faerdhinen (c)", Members: true, Limit: 8})
+	collector := newTestCollector(t, srv)
+	ctx := context.Background()
+
+	// By name, ignoring case, or by ID
+	for _, ref := range []string{"Twisted bow", "twisted BOW", "20997"} {
+		item, err := collector.ResolveItem(ctx, ref)
+		if err != nil {
+			t.Fatalf("ResolveItem(%q): %v", ref, err)
+		}
+		if item.ID != 20997 || item.Name != "Twisted bow" || item.Limit != 8 {
+			t.Errorf("ResolveItem(%q) = %+v, want the Twisted bow", ref, item)
+		}
+	}
+	if _, err := collector.ResolveItem(ctx, "Twisted"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("ResolveItem of a partial name = %v, want ErrItemNotFound", err)
+	}
+
+	// Names starting with the query come first
+	items, err := collector.SearchItems(ctx, "bow", 10)
+	if err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	if fmt.Sprint(names) != "[Bow of faerdhinen (c) Shortbow Twisted bow]" {
+		t.Errorf("SearchItems(bow) = %v, want [Bow of faerdhinen (c) Shortbow Twisted bow]", names)
+	}
+	if items, _ := collector.SearchItems(ctx, "twisted", 1); len(items) != 1 {
+		t.Errorf("SearchItems with limit 1 returned %d items", len(items))
+	}
+
+	// The mapping is fetched once
+	if got := srv.Requests("/api/v1/osrs/mapping"); got != 1 {
+		t.Errorf("got %d mapping requests, want 1", got)
+	}
+}
+
+func TestGEPrices(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddGEItem(testserver.GEItem{ID: 20997, Name: "Twisted bow", High: 1_500_000_000, Low: 1_440_000_000})
+	srv.AddGEItem(testserver.GEItem{ID: 841, Name: "Shortbow", High: 40, Low: 30})
+	watches, err := ParseGEWatches([]string{"twisted bow=50000000", "841", "Not an item"})
+	if err != nil {
+		t.Fatalf("ParseGEWatches: %v", err)
+	}
+	collector := NewGECollector(newTestCollector(t, srv), watches)
+	var alerts []string
+	collector.SetAlertHandler(func(ctx context.Context, price GEPrice, threshold int64) {
+		alerts = append(alerts, fmt.Sprintf("%s %d > %d", price.Item.Name, price.Margin(), threshold))
+	})
+	ctx := context.Background()
+
+	collect := func() {
+		t.Helper()
+		collector.osrs.cache.Delete(ctx, "osrs:ge_price:20997")
+		if err := collector.CollectPrices(ctx); err != nil {
+			t.Fatalf("CollectPrices: %v", err)
+		}
+	}
+
+	// The GE tax is capped at 5m, and items under 50 coins are tax free
+	collect()
+	if got := testutil.ToFloat64(geMarginGauge.WithLabelValues("20997", "Twisted bow")); got != 55_000_000 {
+		t.Errorf("Twisted bow margin = %v, want 55000000", got)
+	}
+	if got := testutil.ToFloat64(geMarginGauge.WithLabelValues("841", "Shortbow")); got != 10 {
+		t.Errorf("Shortbow margin = %v, want 10", got)
+	}
+	if got := testutil.CollectAndCount(geHighPriceGauge); got != 2 {
+		t.Errorf("reported %d high prices, want 2 (unknown items are skipped)", got)
+	}
+
+	// An alert is raised once while the margin stays above the threshold
+	collect()
+	if len(alerts) != 1 || alerts[0] != "Twisted bow 55000000 > 50000000" {
+		t.Errorf("got alerts %v, want one for the Twisted bow", alerts)
+	}
+
+	// and again once it has dropped back and risen
+	srv.SetGEPrice(20997, 1_480_000_000, 1_440_000_000)
+	collect()
+	srv.SetGEPrice(20997, 1_500_000_000, 1_440_000_000)
+	collect()
+	if len(alerts) != 2 {
+		t.Errorf("got %d alerts, want 2", len(alerts))
+	}
+}
+
+func TestGroupMembers(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetWOMGroup(139, "Zezima", "B0aty", "Lynx Titan")
+	collector := newTestCollector(t, srv)
+	ctx := context.Background()
+
+	rsns, err := collector.GroupMembers(ctx, 139)
+	if err != nil {
+		t.Fatalf("GroupMembers: %v", err)
+	}
+	if got, want := fmt.Sprint(rsns), "[b0aty lynx titan zezima]"; got != want {
+		t.Errorf("got members %s, want %s", got, want)
+	}
+
+	// The member list is cached
+	if _, err := collector.GroupMembers(ctx, 139); err != nil {
+		t.Fatalf("GroupMembers: %v", err)
+	}
+	if got := srv.Requests("/v2/groups/139"); got != 1 {
+		t.Errorf("made %d group requests, want 1", got)
+	}
+
+	if _, err := collector.GroupMembers(ctx, 404); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("got error %v for an unknown group, want ErrGroupNotFound", err)
+	}
+}
@@ -0,0 +1,283 @@
+package osrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GELatestPricesURL serves the latest instant-buy (high) and instant-sell (low) price of an item,
+// from the OSRS wiki's real-time prices API
+const GELatestPricesURL = "https://prices.runescape.wiki/api/v1/osrs/latest"
+
+// gePriceTTL is how long an item's prices are cached; the wiki refreshes them every minute
+const gePriceTTL = 5 * time.Minute
+
+// geAlertTTL bounds how long an alert is remembered without a collection seeing the margin again
+const geAlertTTL = 24 * time.Hour
+
+// GE tax: 2% of the sell price, rounded down and capped per item; items sold under
+// geTaxFreeBelow are exempt
+const (
+	geTaxPercent   = 2
+	geTaxCap       = 5_000_000
+	geTaxFreeBelow = 50
+)
+
+// GEWatch is an item whose Grand Exchange prices are collected
+type GEWatch struct {
+	Item        string // Item name or ID, resolved with ResolveItem
+	AlertMargin int64  // Margin above which an alert is raised, 0 for no alerts
+}
+
+// GEPrice is the latest price of a watched item
+type GEPrice struct {
+	Item     Item      `json:"item"`
+	High     int64     `json:"high"` // Latest instant-buy price, 0 if it never traded
+	Low      int64     `json:"low"`  // Latest instant-sell price, 0 if it never traded
+	HighTime time.Time `json:"high_time"`
+	LowTime  time.Time `json:"low_time"`
+
+	alertMargin int64 // The watch's AlertMargin
+}
+
+// Margin is the profit of buying at the low price and selling at the high price, after tax
+func (p GEPrice) Margin() int64 {
+	return p.High - p.Low - geTax(p.High)
+}
+
+// geTax returns the tax on selling an item at price
+func geTax(price int64) int64 {
+	if price < geTaxFreeBelow {
+		return 0
+	}
+	return min(price*geTaxPercent/100, geTaxCap)
+}
+
+// ParseGEWatches parses OSRS_GE_ITEMS entries: an item name or ID, optionally followed by
+// =<margin> to alert when the item's margin exceeds it, e.g. "Twisted bow=5000000"
+func ParseGEWatches(entries []string) ([]GEWatch, error) {
+	watches := make([]GEWatch, 0, len(entries))
+	for _, entry := range entries {
+		item, margin, hasMargin := strings.Cut(entry, "=")
+		watch := GEWatch{Item: strings.TrimSpace(item)}
+		if watch.Item == "" {
+			return nil, fmt.Errorf("invalid GE item %q: missing item name", entry)
+		}
+		if hasMargin {
+			value, err := strconv.ParseInt(strings.TrimSpace(margin), 10, 64)
+			if err != nil || value <= 0 {
+				return nil, fmt.Errorf("invalid GE item %q: the alert margin must be a positive number of coins", entry)
+			}
+			watch.AlertMargin = value
+		}
+		watches = append(watches, watch)
+	}
+	return watches, nil
+}
+
+// GECollector tracks the Grand Exchange prices and flip margins of a list of items
+type GECollector struct {
+	osrs    *Collector
+	watches []GEWatch
+	onAlert func(ctx context.Context, price GEPrice, threshold int64) // See SetAlertHandler
+}
+
+// NewGECollector creates a GE collector for the watched items; names are resolved through the
+// collector's item mapping when prices are collected
+func NewGECollector(osrs *Collector, watches []GEWatch) *GECollector {
+	return &GECollector{
+		osrs:    osrs,
+		watches: watches,
+	}
+}
+
+// SetAlertHandler sets the function called when a watched item's margin rises above its alert
+// margin. It is called again only after the margin has dropped back to the threshold or below.
+// It must be called before collecting.
+func (c *GECollector) SetAlertHandler(handler func(ctx context.Context, price GEPrice, threshold int64)) {
+	c.onAlert = handler
+}
+
+// CollectPrices collects and reports the prices and margins of the watched items, raising alerts
+func (c *GECollector) CollectPrices(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "osrs.collect_ge", attribute.Int("osrs.items", len(c.watches)))
+	defer func() { tracing.End(span, err) }()
+
+	prices, err := c.Prices(ctx)
+	if err != nil {
+		return err
+	}
+	ReportGEPrices(prices)
+
+	for _, price := range prices {
+		if price.alertMargin > 0 {
+			c.checkAlert(ctx, price, price.alertMargin)
+		}
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"items":  len(c.watches),
+		"prices": len(prices),
+	}).Info("Completed OSRS GE price collection")
+	return nil
+}
+
+// Prices returns the latest prices of the watched items, from the cache when fresh. Items that
+// can't be resolved or priced are left out; it fails only when none could be.
+func (c *GECollector) Prices(ctx context.Context) ([]GEPrice, error) {
+	var prices []GEPrice
+	var errs []error
+	for _, watch := range c.watches {
+		item, err := c.osrs.ResolveItem(ctx, watch.Item)
+		if err == nil {
+			var price GEPrice
+			if price, err = c.getPrice(ctx, item); err == nil {
+				price.alertMargin = watch.AlertMargin
+				prices = append(prices, price)
+				continue
+			}
+		}
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"item":  watch.Item,
+			"error": err.Error(),
+		}).Warn("Failed to get GE price, continuing")
+		errs = append(errs, err)
+	}
+
+	if len(prices) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return prices, nil
+}
+
+// getPrice retrieves an item's latest prices, using the cache if available
+func (c *GECollector) getPrice(ctx context.Context, item Item) (GEPrice, error) {
+	cacheKey := fmt.Sprintf("osrs:ge_price:%d", item.ID)
+	data, err := c.osrs.cache.GetOrRefresh(ctx, cacheKey, gePriceTTL, gePriceTTL, func(ctx context.Context) ([]byte, error) {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"item":  item.Name,
+			"cache": "miss",
+		}).Debug("Fetching GE price from API")
+
+		price, err := c.osrs.client.GetGEPrice(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(price)
+	})
+	if err != nil {
+		return GEPrice{}, err
+	}
+
+	var price GEPrice
+	if err := json.Unmarshal(data, &price); err != nil {
+		c.osrs.cache.Delete(ctx, cacheKey)
+		return GEPrice{}, fmt.Errorf("failed to decode cached GE price: %w", err)
+	}
+	return price, nil
+}
+
+// checkAlert raises an alert when an item's margin rises above threshold. Raised alerts are kept
+// in the cache until the margin drops back, so replicas and later collections don't repeat them.
+func (c *GECollector) checkAlert(ctx context.Context, price GEPrice, threshold int64) {
+	if c.onAlert == nil || price.High == 0 || price.Low == 0 {
+		return
+	}
+	cacheKey := fmt.Sprintf("osrs:ge_alert:%d", price.Item.ID)
+	_, alerted := c.osrs.cache.Get(ctx, cacheKey)
+
+	if price.Margin() <= threshold {
+		if alerted {
+			c.osrs.cache.Delete(ctx, cacheKey)
+		}
+		return
+	}
+	if alerted {
+		return
+	}
+	c.osrs.cache.Set(ctx, cacheKey, []byte("1"), geAlertTTL)
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"item":      price.Item.Name,
+		"margin":    price.Margin(),
+		"threshold": threshold,
+	}).Info("OSRS GE margin above alert threshold")
+	c.onAlert(ctx, price, threshold)
+}
+
+// GetGEPrice retrieves an item's latest prices from the wiki
+func (c *Client) GetGEPrice(ctx context.Context, item Item) (price GEPrice, err error) {
+	ctx, span := tracing.Start(ctx, "osrs.ge_price", attribute.Int("osrs.item_id", item.ID))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return GEPrice{}, err
+	}
+
+	url := fmt.Sprintf("%s?id=%d", GELatestPricesURL, item.ID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return GEPrice{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", wikiUserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return GEPrice{}, fmt.Errorf("failed to fetch GE price: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return GEPrice{}, fmt.Errorf("failed to fetch GE price (status: %d)", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return GEPrice{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Prices and times are null for a side that never traded
+	var latest struct {
+		Data map[string]struct {
+			High     *int64 `json:"high"`
+			HighTime *int64 `json:"highTime"`
+			Low      *int64 `json:"low"`
+			LowTime  *int64 `json:"lowTime"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &latest); err != nil {
+		return GEPrice{}, fmt.Errorf("failed to decode GE price: %w", err)
+	}
+	entry, ok := latest.Data[strconv.Itoa(item.ID)]
+	if !ok {
+		return GEPrice{}, fmt.Errorf("no GE price for %s (%d)", item.Name, item.ID)
+	}
+
+	price = GEPrice{Item: item}
+	if entry.High != nil {
+		price.High = *entry.High
+	}
+	if entry.Low != nil {
+		price.Low = *entry.Low
+	}
+	if entry.HighTime != nil {
+		price.HighTime = time.Unix(*entry.HighTime, 0)
+	}
+	if entry.LowTime != nil {
+		price.LowTime = time.Unix(*entry.LowTime, 0)
+	}
+	return price, nil
+}
@@ -0,0 +1,159 @@
+package osrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+)
+
+// ItemMappingURL lists every tradeable item with its ID, from the OSRS wiki's real-time prices API
+const ItemMappingURL = "https://prices.runescape.wiki/api/v1/osrs/mapping"
+
+// wikiUserAgent identifies the exporter to the wiki, which blocks requests with generic user agents
+const wikiUserAgent = "game-stats-exporter - github.com/joshhsoj1902/game-stats-exporter"
+
+// itemMappingTTL is how long the item mapping is cached; new items only come with game updates
+const itemMappingTTL = 24 * time.Hour
+
+// ErrItemNotFound is returned for an item name or ID the mapping doesn't have
+var ErrItemNotFound = errors.New("item not found")
+
+// Item is a tradeable item of the Grand Exchange
+type Item struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Examine  string `json:"examine"`
+	Members  bool   `json:"members"`
+	Limit    int    `json:"limit"` // GE buy limit per 4 hours, 0 if unknown
+	Value    int    `json:"value"` // Store value
+	HighAlch int    `json:"highalch"`
+	LowAlch  int    `json:"lowalch"`
+}
+
+// GetItemMapping retrieves the item mapping from the wiki
+func (c *Client) GetItemMapping(ctx context.Context) (items []Item, err error) {
+	ctx, span := tracing.Start(ctx, "osrs.item_mapping")
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", ItemMappingURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", wikiUserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch item mapping: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch item mapping (status: %d)", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode item mapping: %w", err)
+	}
+	return items, nil
+}
+
+// Items returns the item mapping from the cache, fetching on a miss
+func (c *Collector) Items(ctx context.Context) ([]Item, error) {
+	cacheKey := "osrs:item_mapping"
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, itemMappingTTL, itemMappingTTL, func(ctx context.Context) ([]byte, error) {
+		logger.FromContext(ctx).WithField("cache", "miss").Info("Fetching item mapping from API")
+
+		items, err := c.client.GetItemMapping(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(items)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item mapping: %w", err)
+	}
+
+	var items []Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached item mapping: %w", err)
+	}
+	return items, nil
+}
+
+// ResolveItem finds an item by ID ("20997") or by name, ignoring case ("twisted bow"), so
+// configuration can name items instead of listing IDs
+func (c *Collector) ResolveItem(ctx context.Context, ref string) (Item, error) {
+	items, err := c.Items(ctx)
+	if err != nil {
+		return Item{}, err
+	}
+
+	ref = strings.TrimSpace(ref)
+	id, err := strconv.Atoi(ref)
+	isID := err == nil
+	for _, item := range items {
+		if (isID && item.ID == id) || (!isID && strings.EqualFold(item.Name, ref)) {
+			return item, nil
+		}
+	}
+	return Item{}, fmt.Errorf("%w: %s", ErrItemNotFound, ref)
+}
+
+// SearchItems returns up to limit items whose name contains query, ignoring case: an exact
+// match first, then names starting with query, then the rest, each alphabetically
+func (c *Collector) SearchItems(ctx context.Context, query string, limit int) ([]Item, error) {
+	items, err := c.Items(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	rank := func(name string) int {
+		switch {
+		case name == query:
+			return 0
+		case strings.HasPrefix(name, query):
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	matches := []Item{}
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Name), query) {
+			matches = append(matches, item)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := strings.ToLower(matches[i].Name), strings.ToLower(matches[j].Name)
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		return a < b
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
@@ -1,11 +1,17 @@
 package osrs
 
 import (
+	"crypto/sha256"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// worldCapacity is the number of players a world holds
+const worldCapacity = 2000
+
 var (
 	playerLevelGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "osrs",
@@ -28,6 +34,61 @@ var (
 		Help:      "Player highscores rank",
 	}, []string{"skill", "player", "mode"})
 
+	playerXPTodayGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Name:      "xp_today",
+		Help:      "Experience gained since local midnight; skills without XP today are left out",
+	}, []string{"skill", "player", "mode"})
+
+	playerLastUpdatedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "player",
+		Name:      "last_updated_timestamp_seconds",
+		Help:      "When a player's hiscores were fetched from Jagex (Unix time); the served values are this old",
+	}, []string{"player", "mode"})
+
+	playerSkillsMaxedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "player",
+		Name:      "skills_maxed_total",
+		Help:      "Number of skills at level 99 (Overall not included)",
+	}, []string{"player", "mode"})
+
+	playerSkillsAt99Gauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "player",
+		Name:      "skills_at_99_total",
+		Help:      "Number of skills at level 99 (Overall not included)",
+	}, []string{"player", "mode"})
+
+	playerSkillsAt200mGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "player",
+		Name:      "skills_at_200m_total",
+		Help:      "Number of skills at the 200m XP cap (Overall not included)",
+	}, []string{"player", "mode"})
+
+	geHighPriceGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "ge",
+		Name:      "high_price",
+		Help:      "Latest instant-buy price of a watched Grand Exchange item, in coins",
+	}, []string{"item_id", "item"})
+
+	geLowPriceGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "ge",
+		Name:      "low_price",
+		Help:      "Latest instant-sell price of a watched Grand Exchange item, in coins",
+	}, []string{"item_id", "item"})
+
+	geMarginGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "ge",
+		Name:      "margin",
+		Help:      "Flip margin of a watched Grand Exchange item: high price minus low price minus the GE tax, in coins",
+	}, []string{"item_id", "item"})
+
 	worldPlayersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "osrs",
 		Subsystem: "world",
@@ -35,6 +96,54 @@ var (
 		Help:      "Number of players in a world",
 	}, []string{"id", "location", "isMembers", "type"})
 
+	worldFreeSlotsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "world",
+		Name:      "free_slots",
+		Help:      "Number of players that can still log in to a world (2000 minus its players)",
+	}, []string{"id", "location", "isMembers", "type"})
+
+	worldFullGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "world",
+		Name:      "full",
+		Help:      "Whether a world is full (1) or can be logged in to (0)",
+	}, []string{"id", "location", "isMembers", "type"})
+
+	worldTypeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "world",
+		Name:      "type",
+		Help:      "1 for each type flag of a world (a Members PVP High Risk world has all three); joins osrs_world_players on id",
+	}, []string{"id", "type"})
+
+	worldDecodeTruncatedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "osrs",
+		Subsystem: "world_decode",
+		Name:      "truncated_total",
+		Help:      "World list responses cut short (the 30KB limit or a corrupted world count), decoded up to the cut",
+	})
+
+	worldDecodeRealignedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "osrs",
+		Subsystem: "world_decode",
+		Name:      "realigned_total",
+		Help:      "World list responses whose first world was only found by scanning past misaligned bytes",
+	})
+
+	worldsParsedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Name:      "worlds_parsed",
+		Help:      "Number of worlds decoded from the latest world list response",
+	})
+
+	worldPayloadBytesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "osrs",
+		Subsystem: "world",
+		Name:      "payload_bytes",
+		Help:      "Size of the latest world list response; changes() shows when the upstream starts sending differently sized payloads",
+	})
+
 	minigameRankGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "osrs",
 		Subsystem: "minigame",
@@ -54,14 +163,43 @@ func init() {
 	prometheus.MustRegister(playerLevelGauge)
 	prometheus.MustRegister(playerXPGauge)
 	prometheus.MustRegister(playerRankGauge)
+	prometheus.MustRegister(playerXPTodayGauge)
+	prometheus.MustRegister(playerLastUpdatedGauge)
+	prometheus.MustRegister(playerSkillsMaxedGauge)
+	prometheus.MustRegister(playerSkillsAt99Gauge)
+	prometheus.MustRegister(playerSkillsAt200mGauge)
+	prometheus.MustRegister(geHighPriceGauge)
+	prometheus.MustRegister(geLowPriceGauge)
+	prometheus.MustRegister(geMarginGauge)
 	prometheus.MustRegister(worldPlayersGauge)
+	prometheus.MustRegister(worldFreeSlotsGauge)
+	prometheus.MustRegister(worldFullGauge)
+	prometheus.MustRegister(worldTypeGauge)
+	prometheus.MustRegister(worldDecodeTruncatedCounter)
+	prometheus.MustRegister(worldDecodeRealignedCounter)
+	prometheus.MustRegister(worldsParsedGauge)
+	prometheus.MustRegister(worldPayloadBytesGauge)
 	prometheus.MustRegister(minigameRankGauge)
 	prometheus.MustRegister(minigameScoreGauge)
 }
 
+// reportedWorlds is the checksum of the cached world list the world gauges hold, zero once
+// they're reset, so an unchanged list isn't reported again on every poll
+var reportedWorlds struct {
+	mu  sync.Mutex
+	sum [sha256.Size]byte
+}
+
 // resetWorldMetrics (lowercase) is the actual implementation
 func resetWorldMetrics() {
 	worldPlayersGauge.Reset()
+	worldFreeSlotsGauge.Reset()
+	worldFullGauge.Reset()
+	worldTypeGauge.Reset()
+
+	reportedWorlds.mu.Lock()
+	reportedWorlds.sum = [sha256.Size]byte{}
+	reportedWorlds.mu.Unlock()
 }
 
 // resetPlayerMetrics (lowercase) is the actual implementation
@@ -69,6 +207,11 @@ func resetPlayerMetrics() {
 	playerLevelGauge.Reset()
 	playerXPGauge.Reset()
 	playerRankGauge.Reset()
+	playerXPTodayGauge.Reset()
+	playerLastUpdatedGauge.Reset()
+	playerSkillsMaxedGauge.Reset()
+	playerSkillsAt99Gauge.Reset()
+	playerSkillsAt200mGauge.Reset()
 	minigameRankGauge.Reset()
 	minigameScoreGauge.Reset()
 }
@@ -110,6 +253,51 @@ func reportPlayerStatsWithoutReset(stats []SkillInfo, mode string) {
 			}).Set(rank)
 		}
 	}
+	reportSkillTotals(stats, mode)
+}
+
+// reportXPToday reports the XP gained today per skill; it is reset with the other player metrics
+func reportXPToday(today map[string]int64, rsn string, mode string) {
+	for skill, xp := range today {
+		playerXPTodayGauge.With(prometheus.Labels{
+			"skill":  skill,
+			"player": rsn,
+			"mode":   mode,
+		}).Set(float64(xp))
+	}
+}
+
+// reportLastUpdated reports when a player's hiscores were fetched; it is reset with the other
+// player metrics
+func reportLastUpdated(rsn string, mode string, lastUpdate time.Time) {
+	if lastUpdate.IsZero() {
+		return
+	}
+	playerLastUpdatedGauge.WithLabelValues(rsn, mode).Set(float64(lastUpdate.Unix()))
+}
+
+// reportSkillTotals reports how many of a player's skills are 99 and at 200m XP, sparing
+// dashboards a clause per skill; it is reset with the other player metrics
+func reportSkillTotals(stats []SkillInfo, mode string) {
+	if len(stats) == 0 {
+		return
+	}
+	at99, at200m := 0, 0
+	for _, stat := range stats {
+		if stat.Name == "Overall" {
+			continue
+		}
+		if level, _ := strconv.ParseInt(stat.Level, 10, 64); level >= maxLevel {
+			at99++
+		}
+		if xp, _ := strconv.ParseInt(stat.XP, 10, 64); xp >= maxXP {
+			at200m++
+		}
+	}
+	player := stats[0].Player
+	playerSkillsMaxedGauge.WithLabelValues(player, mode).Set(float64(at99))
+	playerSkillsAt99Gauge.WithLabelValues(player, mode).Set(float64(at99))
+	playerSkillsAt200mGauge.WithLabelValues(player, mode).Set(float64(at200m))
 }
 
 // ReportPlayerStats reports player skill metrics
@@ -145,6 +333,31 @@ func ReportPlayerStats(stats []SkillInfo, mode string) {
 			}).Set(rank)
 		}
 	}
+	reportSkillTotals(stats, mode)
+}
+
+// ReportGEPrices reports the prices and margins of the watched items, replacing the previous
+// ones. Sides that never traded are left out, and so is the margin without both.
+func ReportGEPrices(prices []GEPrice) {
+	geHighPriceGauge.Reset()
+	geLowPriceGauge.Reset()
+	geMarginGauge.Reset()
+
+	for _, price := range prices {
+		labels := prometheus.Labels{
+			"item_id": strconv.Itoa(price.Item.ID),
+			"item":    price.Item.Name,
+		}
+		if price.High > 0 {
+			geHighPriceGauge.With(labels).Set(float64(price.High))
+		}
+		if price.Low > 0 {
+			geLowPriceGauge.With(labels).Set(float64(price.Low))
+		}
+		if price.High > 0 && price.Low > 0 {
+			geMarginGauge.With(labels).Set(float64(price.Margin()))
+		}
+	}
 }
 
 // ResetWorldMetrics resets all world metrics (removes all labels)
@@ -210,6 +423,40 @@ func ReportMinigames(minigames []MinigameInfo, mode string) {
 	}
 }
 
+// reportWorldPayloadSize records the size of a world list response
+func reportWorldPayloadSize(size int) {
+	worldPayloadBytesGauge.Set(float64(size))
+}
+
+// reportWorldDecode records how a world list response decoded; unlike the world metrics it isn't
+// reset, so the anomalies stay visible on every OSRS endpoint
+func reportWorldDecode(decode worldDecode, parsed int) {
+	if decode.truncated {
+		worldDecodeTruncatedCounter.Inc()
+	}
+	if decode.realigned {
+		worldDecodeRealignedCounter.Inc()
+	}
+	worldsParsedGauge.Set(float64(parsed))
+}
+
+// reportWorldDataIfChanged reports worlds unless the world gauges already hold the cached world
+// list with checksum sum, returning whether it reported
+func reportWorldDataIfChanged(sum [sha256.Size]byte, worlds []World) bool {
+	reportedWorlds.mu.Lock()
+	unchanged := reportedWorlds.sum == sum
+	reportedWorlds.mu.Unlock()
+	if unchanged {
+		return false
+	}
+
+	ReportWorldData(worlds)
+	reportedWorlds.mu.Lock()
+	reportedWorlds.sum = sum
+	reportedWorlds.mu.Unlock()
+	return true
+}
+
 // ReportWorldData reports world player count metrics
 func ReportWorldData(worlds []World) {
 	// Reset all world metrics first to avoid stale data from previous requests
@@ -224,17 +471,31 @@ func ReportWorldData(worlds []World) {
 		if playerCount < 0 {
 			playerCount = 0
 		}
-		if playerCount > 2000 {
+		if playerCount > worldCapacity {
 			// Cap at 2000 if somehow we get a value higher than max
-			playerCount = 2000
+			playerCount = worldCapacity
 		}
 
-		worldPlayersGauge.With(prometheus.Labels{
-			"id":         strconv.FormatUint(uint64(world.ID), 10),
-			"location":   string(world.Location),
-			"isMembers":  isMembers,
-			"type":       string(worldType),
-		}).Set(float64(playerCount))
+		id := strconv.FormatUint(uint64(world.ID), 10)
+		labels := prometheus.Labels{
+			"id":        id,
+			"location":  string(world.Location),
+			"isMembers": isMembers,
+			"type":      string(worldType),
+		}
+		worldPlayersGauge.With(labels).Set(float64(playerCount))
+		worldFreeSlotsGauge.With(labels).Set(float64(worldCapacity - playerCount))
+		if playerCount >= worldCapacity {
+			worldFullGauge.With(labels).Set(1)
+		} else {
+			worldFullGauge.With(labels).Set(0)
+		}
+
+		// The type label above is the world's main type only, so every flag is also reported
+		// on its own for queries by any combination
+		for _, t := range world.Types {
+			worldTypeGauge.WithLabelValues(id, string(t)).Set(1)
+		}
 	}
 }
 
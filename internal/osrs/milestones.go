@@ -0,0 +1,160 @@
+package osrs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Milestone kinds
+const (
+	MilestoneLevel99    = "level_99"    // A skill reached level 99
+	MilestoneXP200m     = "xp_200m"     // A skill reached the 200m XP cap
+	MilestoneTotalLevel = "total_level" // The total level passed a threshold
+	MilestoneMaxCape    = "max_cape"    // Every skill is 99
+)
+
+const (
+	maxLevel = 99
+	maxXP    = 200_000_000
+)
+
+// totalLevelThresholds are the total levels reported as milestones
+var totalLevelThresholds = []int64{500, 1000, 1250, 1500, 1750, 2000, 2100, 2200}
+
+// milestonesTTL keeps the milestones a player has already reached for a long break from the game,
+// so coming back doesn't announce them again
+const milestonesTTL = 365 * 24 * time.Hour
+
+// Milestone is an achievement detected between two collections of a player
+type Milestone struct {
+	Player string // Player label
+	Mode   string
+	Kind   string // One of the Milestone* constants
+	Skill  string // Set for MilestoneLevel99 and MilestoneXP200m
+	Value  int64  // The level or XP reached
+}
+
+// String describes the milestone, e.g. "zezima reached 99 Attack"
+func (m Milestone) String() string {
+	var text string
+	switch m.Kind {
+	case MilestoneLevel99:
+		text = fmt.Sprintf("%s reached 99 %s", m.Player, m.Skill)
+	case MilestoneXP200m:
+		text = fmt.Sprintf("%s reached 200m %s XP", m.Player, m.Skill)
+	case MilestoneTotalLevel:
+		text = fmt.Sprintf("%s reached %d total level", m.Player, m.Value)
+	case MilestoneMaxCape:
+		text = fmt.Sprintf("%s maxed every skill", m.Player)
+	default:
+		text = fmt.Sprintf("%s reached %s", m.Player, m.Kind)
+	}
+	if m.Mode != "vanilla" {
+		text += " (" + m.Mode + ")"
+	}
+	return text
+}
+
+// playerMilestones returns the milestones reached in a player's stats, keyed by an ID that is
+// stable across collections
+func playerMilestones(stats []SkillInfo) map[string]Milestone {
+	reached := make(map[string]Milestone)
+	skills, maxed := 0, 0
+	for _, stat := range stats {
+		level, _ := strconv.ParseInt(stat.Level, 10, 64)
+		xp, _ := strconv.ParseInt(stat.XP, 10, 64)
+		if stat.Name == "Overall" {
+			for _, threshold := range totalLevelThresholds {
+				if level >= threshold {
+					reached[fmt.Sprintf("%s:%d", MilestoneTotalLevel, threshold)] = Milestone{Kind: MilestoneTotalLevel, Value: threshold}
+				}
+			}
+			continue
+		}
+
+		skills++
+		if level >= maxLevel {
+			maxed++
+			reached[MilestoneLevel99+":"+stat.Name] = Milestone{Kind: MilestoneLevel99, Skill: stat.Name, Value: level}
+		}
+		if xp >= maxXP {
+			reached[MilestoneXP200m+":"+stat.Name] = Milestone{Kind: MilestoneXP200m, Skill: stat.Name, Value: xp}
+		}
+	}
+	if skills > 0 && maxed == skills {
+		reached[MilestoneMaxCape] = Milestone{Kind: MilestoneMaxCape}
+	}
+	return reached
+}
+
+// SetMilestoneHandler sets the function called once for each milestone a player reaches. It
+// must be called before collecting.
+func (c *Collector) SetMilestoneHandler(handler func(ctx context.Context, milestone Milestone)) {
+	c.onMilestone = handler
+}
+
+// reportMilestones calls the milestone handler for the milestones reached since the last
+// collection. The milestones already reached are kept in the cache, so replicas and restarts
+// don't announce them again; the first collection of a player only records them.
+func (c *Collector) reportMilestones(ctx context.Context, rsn string, mode string, stats []SkillInfo) {
+	if c.onMilestone == nil {
+		return
+	}
+	reached := playerMilestones(stats)
+
+	key := fmt.Sprintf("osrs:milestones:%s:%s", mode, rsn)
+	var known []string
+	data, seen := c.cache.Get(ctx, key)
+	if seen {
+		if err := json.Unmarshal(data, &known); err != nil {
+			seen = false
+		}
+	}
+
+	// Milestones stay reached when a skill drops off the hiscores
+	ids := make(map[string]bool, len(known)+len(reached))
+	for _, id := range known {
+		ids[id] = true
+	}
+	var fresh []string
+	for id := range reached {
+		if !ids[id] {
+			ids[id] = true
+			fresh = append(fresh, id)
+		}
+	}
+	if seen && len(fresh) == 0 {
+		return
+	}
+
+	all := make([]string, 0, len(ids))
+	for id := range ids {
+		all = append(all, id)
+	}
+	sort.Strings(all)
+	if data, err := json.Marshal(all); err == nil {
+		c.cache.Set(ctx, key, data, milestonesTTL)
+	}
+	if !seen {
+		return
+	}
+
+	sort.Strings(fresh)
+	for _, id := range fresh {
+		milestone := reached[id]
+		milestone.Player, milestone.Mode = c.aliases.label(rsn), mode
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"rsn":       rsn,
+			"mode":      mode,
+			"milestone": id,
+		}).Info("OSRS milestone reached")
+		c.onMilestone(ctx, milestone)
+	}
+}
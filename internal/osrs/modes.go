@@ -0,0 +1,85 @@
+package osrs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Hiscores URLs of a table (the m= part, e.g. hiscore_oldschool_tournament)
+const (
+	hiscoresURL     = "https://oldschool.runescape.wiki/cors/m=%s/index_lite.ws"
+	hiscoresHTMLURL = "https://secure.runescape.com/m=%s/hiscorepersonal"
+)
+
+// builtinTables maps the built-in modes to their hiscores table
+var builtinTables = map[string]string{
+	"vanilla":          "hiscore_oldschool",
+	"ironman":          "hiscore_oldschool_ironman",
+	"hardcore_ironman": "hiscore_oldschool_hardcore_ironman",
+	"ultimate_ironman": "hiscore_oldschool_ultimate",
+	"gridmaster":       "hiscore_oldschool_tournament",
+	"deadman":          "hiscore_oldschool_deadman",
+	"seasonal":         "hiscore_oldschool_seasonal",
+}
+
+var (
+	modeNamePattern    = regexp.MustCompile(`^[a-z0-9_-]+$`)
+	tableSuffixPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// modeTables resolves modes to hiscores tables. Tournament modes come from the config file, so
+// the next seasonal hiscores table can be collected without a release.
+type modeTables struct {
+	mu         sync.RWMutex
+	tournament map[string]string // Mode -> table
+}
+
+// table returns a mode's hiscores table; unknown modes read the vanilla hiscores
+func (m *modeTables) table(mode string) string {
+	if table, ok := builtinTables[mode]; ok {
+		return table
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if table, ok := m.tournament[mode]; ok {
+		return table
+	}
+	return builtinTables["vanilla"]
+}
+
+// names returns the built-in modes followed by the tournament modes, sorted
+func (m *modeTables) names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tournament := make([]string, 0, len(m.tournament))
+	for name := range m.tournament {
+		tournament = append(tournament, name)
+	}
+	sort.Strings(tournament)
+	return append(append([]string{}, SupportedModes...), tournament...)
+}
+
+// set replaces the tournament modes, given as mode -> table suffix (the part after
+// hiscore_oldschool_); an invalid mode changes nothing
+func (m *modeTables) set(modes map[string]string) error {
+	tournament := make(map[string]string, len(modes))
+	for name, suffix := range modes {
+		if _, ok := builtinTables[name]; ok || name == "all" {
+			return fmt.Errorf("OSRS mode %q is reserved", name)
+		}
+		if !modeNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid OSRS mode %q (expected lowercase letters, digits, - and _)", name)
+		}
+		if !tableSuffixPattern.MatchString(suffix) {
+			return fmt.Errorf("invalid hiscores table suffix %q for OSRS mode %s", suffix, name)
+		}
+		tournament[name] = "hiscore_oldschool_" + suffix
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tournament = tournament
+	return nil
+}
@@ -0,0 +1,95 @@
+package osrs
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+)
+
+// rateLimitKey is the lease every OSRS request claims for one interval. The lease lives in the
+// cache, so replicas sharing a Redis share the limit.
+const rateLimitKey = "osrs:rate_limit"
+
+// leases is the part of the cache the rate limiter uses
+type leases interface {
+	AcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error)
+}
+
+// rateLimiter spaces out requests to the OSRS endpoints, which rate limit aggressive callers.
+// Each request waits for a local slot, then claims rateLimitKey; if the cache is unreachable
+// only the local limit applies.
+type rateLimiter struct {
+	leases   leases
+	mu       sync.Mutex
+	interval time.Duration // Time between requests, 0 when unlimited
+	next     time.Time     // Start of the next free local slot
+}
+
+// setRate changes the requests allowed per second; 0 disables the limit
+func (l *rateLimiter) setRate(rps float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rps <= 0 {
+		l.interval = 0
+		return
+	}
+	l.interval = time.Duration(float64(time.Second) / rps)
+}
+
+// wait blocks until a request may be made, or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	interval := l.interval
+	if interval == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	slot := time.Now()
+	if l.next.After(slot) {
+		slot = l.next
+	}
+	l.next = slot.Add(interval)
+	l.mu.Unlock()
+
+	if err := sleep(ctx, time.Until(slot)); err != nil {
+		return err
+	}
+	if l.leases == nil {
+		return nil
+	}
+
+	// A fresh owner per request, so a held lease is never extended by another request
+	owner := strconv.FormatUint(rand.Uint64(), 36)
+	for {
+		acquired, err := l.leases.AcquireLease(ctx, rateLimitKey, owner, interval)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Debug("OSRS rate limit lease unavailable, limiting this replica only")
+			return nil
+		}
+		if acquired {
+			return nil
+		}
+		// Another replica holds the slot; it expires within an interval
+		if err := sleep(ctx, max(interval/4, 5*time.Millisecond)); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,48 @@
+package osrs
+
+import (
+	neturl "net/url"
+	"strings"
+	"sync"
+)
+
+// rsnSeparators are the characters Jagex treats as a space in a RuneScape name (the in-game
+// chat shows spaces as non-breaking ones)
+var rsnSeparators = strings.NewReplacer("_", " ", "-", " ", "\u00a0", " ")
+
+// NormalizeRSN returns the form of a RuneScape name used for cache keys and labels. The
+// hiscores ignore case and treat underscores and hyphens as spaces, so B0aty and b0aty, or
+// player_name and player%20name, share one cache entry and one set of series.
+func NormalizeRSN(rsn string) string {
+	if unescaped, err := neturl.PathUnescape(rsn); err == nil {
+		rsn = unescaped
+	}
+	return strings.ToLower(strings.Join(strings.Fields(rsnSeparators.Replace(rsn)), " "))
+}
+
+// rsnAliases maps normalized RSNs to the player label they're reported with
+type rsnAliases struct {
+	mu      sync.RWMutex
+	aliases map[string]string
+}
+
+// label returns the player label of a normalized RSN: its alias, or the RSN itself
+func (a *rsnAliases) label(rsn string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if alias, ok := a.aliases[rsn]; ok {
+		return alias
+	}
+	return rsn
+}
+
+func (a *rsnAliases) set(aliases map[string]string) {
+	normalized := make(map[string]string, len(aliases))
+	for rsn, alias := range aliases {
+		normalized[NormalizeRSN(rsn)] = alias
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.aliases = normalized
+}
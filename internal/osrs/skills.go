@@ -0,0 +1,91 @@
+package osrs
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultSkills are the hiscores skills in the order of the index_lite.ws lines
+var defaultSkills = []string{
+	"Overall",
+	"Attack",
+	"Defence",
+	"Strength",
+	"Hitpoints",
+	"Ranged",
+	"Prayer",
+	"Magic",
+	"Cooking",
+	"Woodcutting",
+	"Fletching",
+	"Fishing",
+	"Firemaking",
+	"Crafting",
+	"Smithing",
+	"Mining",
+	"Herblore",
+	"Agility",
+	"Thieving",
+	"Slayer",
+	"Farming",
+	"Runecrafting",
+	"Hunter",
+	"Construction",
+	"Sailing",
+}
+
+// skillNames is the skill table in use, replaced by SetSkills on config reload
+var skillNames atomic.Pointer[[]string]
+
+// unknownSkills holds the positions of the unknown skills already logged, so each is logged once
+var unknownSkills sync.Map
+
+// Skills returns the hiscores skill names in order
+func Skills() []string {
+	if names := skillNames.Load(); names != nil {
+		return *names
+	}
+	return defaultSkills
+}
+
+// CheckSkills checks a skill table for SetSkills: names must be unique and non-empty
+func CheckSkills(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			return fmt.Errorf("invalid OSRS skill list: empty or duplicate skill %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// SetSkills replaces the skill table (checked by CheckSkills), so a skill Jagex adds can be
+// named without a release; nil restores the built-in table. Names are in hiscores order.
+func SetSkills(names []string) {
+	if len(names) == 0 {
+		skillNames.Store(nil)
+		return
+	}
+	names = append([]string{}, names...)
+	skillNames.Store(&names)
+}
+
+// skillName names the skill on a hiscores line. Lines past the skill table (a skill Jagex
+// added since) are kept as "Skill <n>" rather than dropped or read as minigames.
+func skillName(skills []string, index int) string {
+	if index < len(skills) {
+		return skills[index]
+	}
+	if _, logged := unknownSkills.LoadOrStore(index, true); !logged {
+		logger.Log.WithFields(logrus.Fields{
+			"index":  index,
+			"skills": len(skills),
+		}).Warn("Unknown OSRS skill on the hiscores, reported as Skill <n> (add it to osrs_skills)")
+	}
+	return fmt.Sprintf("Skill %d", index)
+}
@@ -48,6 +48,27 @@ const (
 	WorldTypeUnknown           WorldType = "Unknown"
 )
 
+// worldTypeFlags maps the bits of the world list's type flags to world types; a new world
+// type only needs its bit added here
+var worldTypeFlags = map[uint]WorldType{
+	0:  WorldTypeMembers,
+	2:  WorldTypePVP,
+	5:  WorldTypeBounty,
+	6:  WorldTypePVPArena,
+	7:  WorldTypeSkillTotal,
+	8:  WorldTypeQuestSpeedrunning,
+	10: WorldTypeHighRisk,
+	14: WorldTypeLastManStanding,
+	22: WorldTypeSoulWars,
+	23: WorldTypeBeta,
+	25: WorldTypeNoSaveMode,
+	26: WorldTypeTournament,
+	27: WorldTypeFreshStartWorld,
+	28: WorldTypeMinigame,
+	29: WorldTypeDeadman,
+	30: WorldTypeSeasonal,
+}
+
 type World struct {
 	ID       uint16      `json:"id"`
 	Types    []WorldType `json:"types"`
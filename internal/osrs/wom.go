@@ -0,0 +1,111 @@
+package osrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WOMGroupURL serves a Wise Old Man group with its memberships, by group ID
+const WOMGroupURL = "https://api.wiseoldman.net/v2/groups"
+
+// womGroupTTL is how long a group's member list is cached, so replicas syncing the same group
+// share one request
+const womGroupTTL = 10 * time.Minute
+
+// ErrGroupNotFound is returned for a Wise Old Man group ID that doesn't exist
+var ErrGroupNotFound = errors.New("group not found")
+
+// GetGroupMembers retrieves the RSNs of a Wise Old Man group's members
+func (c *Client) GetGroupMembers(ctx context.Context, groupID int) (rsns []string, err error) {
+	ctx, span := tracing.Start(ctx, "osrs.wom_group", attribute.Int("osrs.wom_group", groupID))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%d", WOMGroupURL, groupID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", wikiUserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch WOM group: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %d", ErrGroupNotFound, groupID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch WOM group (status: %d)", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var group struct {
+		Memberships []struct {
+			Player struct {
+				Username string `json:"username"`
+			} `json:"player"`
+		} `json:"memberships"`
+	}
+	if err := json.Unmarshal(body, &group); err != nil {
+		return nil, fmt.Errorf("failed to decode WOM group: %w", err)
+	}
+
+	rsns = make([]string, 0, len(group.Memberships))
+	for _, membership := range group.Memberships {
+		if rsn := NormalizeRSN(membership.Player.Username); rsn != "" {
+			rsns = append(rsns, rsn)
+		}
+	}
+	sort.Strings(rsns)
+	return rsns, nil
+}
+
+// GroupMembers returns the normalized RSNs of a Wise Old Man group's members, from the cache
+// when fresh
+func (c *Collector) GroupMembers(ctx context.Context, groupID int) ([]string, error) {
+	cacheKey := fmt.Sprintf("osrs:wom_group:%d", groupID)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, womGroupTTL, womGroupTTL, func(ctx context.Context) ([]byte, error) {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"group": groupID,
+			"cache": "miss",
+		}).Debug("Fetching WOM group from API")
+
+		rsns, err := c.client.GetGroupMembers(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(rsns)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WOM group %d: %w", groupID, err)
+	}
+
+	var rsns []string
+	if err := json.Unmarshal(data, &rsns); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached WOM group: %w", err)
+	}
+	return rsns, nil
+}
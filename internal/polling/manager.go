@@ -2,111 +2,492 @@ package polling
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	collectorSteam = "steam"
+	collectorOSRS  = "osrs"
+
+	// kindWorlds is the target kind for the OSRS world data poller
+	kindWorlds = "osrs_worlds"
+
+	// worldDataInterval is how often world data is polled - it changes frequently
+	worldDataInterval = 5 * time.Minute
+
+	// worldsTargetID is the target id used for the world data poller
+	worldsTargetID = "worlds"
+
+	// schedulerTick is how often the scheduler looks for due targets
+	schedulerTick = 1 * time.Second
+
+	// resumeSpread is the window overdue targets are spread across when polling resumes
+	resumeSpread = 1 * time.Minute
+
+	// minLeaseTTL is the shortest lease held on a target
+	minLeaseTTL = 1 * time.Minute
+
+	// defaultMaxBackoff caps the failure backoff when no maximum is configured
+	defaultMaxBackoff = 6 * time.Hour
 )
 
 type SteamCollector interface {
-	Collect(steamId string) error
-	IsActive(steamId string) (bool, error)
+	Collect(ctx context.Context, steamId string) error
+	IsActive(ctx context.Context, steamId string) (bool, error)
+	RateLimited() bool
+}
+
+// LeaseStore provides shared leases so several replicas don't poll the same target
+type LeaseStore interface {
+	AcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, key string, owner string) error
 }
 
 type OSRSCollector interface {
-	CollectPlayerStats(rsn string, mode string) error
-	CollectWorldData() error
-	IsActive(rsn string, mode string) (bool, error)
+	CollectPlayerStats(ctx context.Context, rsn string, mode string) error
+	CollectWorldData(ctx context.Context) error
+	IsActive(ctx context.Context, rsn string, mode string) (bool, error)
 }
 
+// Manager polls registered targets in the background. A single scheduler loop
+// queues targets as they become due and a bounded pool of workers collects them,
+// so hundreds of targets don't each need their own goroutine.
 type Manager struct {
-	steamCollector   SteamCollector
-	osrsCollector    OSRSCollector
-	normalInterval   time.Duration
-	activeInterval   time.Duration
-
-	// Track registered users/players
-	steamUsers       map[string]*userState
-	osrsPlayers      map[string]*playerState
-
-	mu               sync.RWMutex
-	ctx              context.Context
-	cancel           context.CancelFunc
-	wg               sync.WaitGroup
+	steamCollector SteamCollector
+	osrsCollector  OSRSCollector
+	normalInterval time.Duration
+	activeInterval time.Duration
+	workers        int
+	jitter         float64
+	maxBackoff     time.Duration
+	leases         LeaseStore
+	instanceID     string
+	schedules      map[string]Schedule
+	onCollected    func(ctx context.Context, kind string, id string)
+
+	// Registered targets keyed by kind and id
+	targets map[string]*target
+	queue   chan *target
+
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	paused  bool
 }
 
-type userState struct {
-	lastActive bool
+// target is a single polled Steam user, OSRS player, or the world list
+type target struct {
+	kind     string
+	id       string
+	schedule Schedule // Cron schedule replacing the interval, nil for interval polling
+
+	// Guarded by Manager.mu
+	nextRun    time.Time
 	lastPoll   time.Time
-	mu         sync.Mutex
+	lastActive bool
+	playing    bool // Known to be playing right now, e.g. from Discord presence
+	running    bool
+	failures   int  // Consecutive failed polls
+	leased     bool // Whether this instance holds the target's lease
 }
 
-type playerState struct {
-	lastActive bool
-	lastPoll   time.Time
-	mu         sync.Mutex
+// Config configures the polling manager
+type Config struct {
+	NormalInterval time.Duration
+	ActiveInterval time.Duration
+
+	// Workers is the maximum number of concurrent collections
+	Workers int
+
+	// Jitter randomises each interval by up to this fraction (0.1 = +/-10%)
+	// so targets registered together drift apart instead of firing in bursts
+	Jitter float64
+
+	// MaxBackoff caps the exponential backoff applied to targets that keep failing
+	MaxBackoff time.Duration
+
+	// Paused starts the manager with polling paused until Resume is called
+	Paused bool
+
+	// Leases, when set, coordinates replicas sharing a cache: each target is polled
+	// only by the instance holding its lease, identified by InstanceID
+	Leases     LeaseStore
+	InstanceID string
+
+	// Schedules assigns cron schedules to collectors or individual targets (see
+	// ParseSchedules). Scheduled targets run at fixed times instead of intervals.
+	Schedules map[string]Schedule
+
+	// OnCollected, when set, is called after each successful background collection
+	// with the target's kind (steam, osrs or osrs_worlds) and id, e.g. to push its metrics
+	OnCollected func(ctx context.Context, kind string, id string)
 }
 
-func NewManager(steamCollector SteamCollector, osrsCollector OSRSCollector, normalInterval, activeInterval time.Duration) *Manager {
+func NewManager(steamCollector SteamCollector, osrsCollector OSRSCollector, config Config) *Manager {
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jitter := config.Jitter
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	maxBackoff := config.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	setPaused(config.Paused)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Manager{
 		steamCollector: steamCollector,
 		osrsCollector:  osrsCollector,
-		normalInterval: normalInterval,
-		activeInterval: activeInterval,
-		steamUsers:      make(map[string]*userState),
-		osrsPlayers:     make(map[string]*playerState),
-		ctx:             ctx,
-		cancel:          cancel,
+		normalInterval: config.NormalInterval,
+		activeInterval: config.ActiveInterval,
+		workers:        workers,
+		jitter:         jitter,
+		maxBackoff:     maxBackoff,
+		paused:         config.Paused,
+		leases:         config.Leases,
+		instanceID:     config.InstanceID,
+		schedules:      config.Schedules,
+		onCollected:    config.OnCollected,
+		targets:        make(map[string]*target),
+		queue:          make(chan *target, workers),
+		ctx:            ctx,
+		cancel:         cancel,
 	}
 }
 
-// RegisterSteamUser registers a Steam user for background polling
-func (m *Manager) RegisterSteamUser(steamId string) {
+// Start launches the scheduler and worker pool
+func (m *Manager) Start() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.steamUsers[steamId]; !exists {
-		m.steamUsers[steamId] = &userState{
-			lastActive: false,
-			lastPoll:   time.Now(),
-		}
+	if m.started {
+		return
+	}
+	m.started = true
 
-		// Start polling goroutine for this user
+	for i := 0; i < m.workers; i++ {
 		m.wg.Add(1)
-		go m.pollSteamUser(steamId)
+		go m.worker()
 	}
+
+	m.wg.Add(1)
+	go m.schedule()
+
+	logger.Log.WithFields(logrus.Fields{
+		"workers": m.workers,
+		"targets": len(m.targets),
+		"paused":  m.paused,
+	}).Info("Started background polling")
 }
 
-// RegisterOSRSPlayer registers an OSRS player for background polling
-func (m *Manager) RegisterOSRSPlayer(rsn string) {
+// Pause stops dispatching new polls. In-flight polls finish and registrations are kept.
+func (m *Manager) Pause() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.osrsPlayers[rsn]; !exists {
-		m.osrsPlayers[rsn] = &playerState{
-			lastActive: false,
-			lastPoll:   time.Now(),
+	if m.paused {
+		return
+	}
+	m.paused = true
+	setPaused(true)
+
+	logger.Log.Warn("Background polling paused")
+}
+
+// Resume restarts dispatching. Targets that fell due while paused are spread
+// over a short window rather than all firing on the next tick.
+func (m *Manager) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.paused {
+		return
+	}
+	m.paused = false
+	setPaused(false)
+
+	now := time.Now()
+	overdue := 0
+	for key, t := range m.targets {
+		if !t.running && !t.nextRun.IsZero() && !now.Before(t.nextRun) {
+			t.nextRun = now.Add(staggerOffset(key, resumeSpread))
+			overdue++
 		}
+	}
 
-		// Start polling goroutine for this player
-		m.wg.Add(1)
-		go m.pollOSRSPlayer(rsn)
+	logger.Log.WithField("overdue_targets", overdue).Info("Background polling resumed")
+}
+
+// Paused reports whether background polling is paused
+func (m *Manager) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.paused
+}
+
+// TargetCount returns the number of registered polling targets
+func (m *Manager) TargetCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.targets)
+}
+
+// Targets returns the sorted ids of the registered targets of a kind (steam or osrs)
+func (m *Manager) Targets(kind string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []string
+	for _, t := range m.targets {
+		if t.kind == kind {
+			ids = append(ids, t.id)
+		}
 	}
+	sort.Strings(ids)
+	return ids
 }
 
-// pollSteamUser polls a Steam user with adaptive interval
-func (m *Manager) pollSteamUser(steamId string) {
-	defer m.wg.Done()
+// TargetStatus is the polling state of a registered target
+type TargetStatus struct {
+	Kind     string // steam, osrs or osrs_worlds
+	ID       string
+	LastPoll time.Time // Zero until first polled
+	NextRun  time.Time
+	Active   bool // Active at the last poll, so polled at the active interval
+	Running  bool
+	Failures int // Consecutive failed polls
+}
+
+// Status returns the polling state of every registered target, sorted by kind and id
+func (m *Manager) Status() []TargetStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := make([]TargetStatus, 0, len(m.targets))
+	for _, t := range m.targets {
+		status = append(status, TargetStatus{
+			Kind:     t.kind,
+			ID:       t.id,
+			LastPoll: t.lastPoll,
+			NextRun:  t.nextRun,
+			Active:   t.lastActive || t.playing,
+			Running:  t.running,
+			Failures: t.failures,
+		})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Kind != status[j].Kind {
+			return status[i].Kind < status[j].Kind
+		}
+		return status[i].ID < status[j].ID
+	})
+	return status
+}
+
+// RegisterSteamUser registers a Steam user for background polling
+func (m *Manager) RegisterSteamUser(steamId string) {
+	if m.steamCollector == nil {
+		logger.Log.WithField("steam_id", steamId).Warn("Steam collector not initialized - not polling Steam user")
+		return
+	}
+	m.register(collectorSteam, steamId, 0)
+}
 
-	m.mu.RLock()
-	state, exists := m.steamUsers[steamId]
-	m.mu.RUnlock()
+// RegisterOSRSPlayer registers an OSRS player for background polling
+func (m *Manager) RegisterOSRSPlayer(rsn string) {
+	m.register(collectorOSRS, rsn, 0)
+}
+
+// UnregisterSteamUser stops polling a Steam user
+func (m *Manager) UnregisterSteamUser(steamId string) {
+	m.unregister(collectorSteam, steamId)
+}
+
+// UnregisterOSRSPlayer stops polling an OSRS player
+func (m *Manager) UnregisterOSRSPlayer(rsn string) {
+	m.unregister(collectorOSRS, rsn)
+}
+
+// StartWorldDataPolling starts background polling for OSRS world data
+func (m *Manager) StartWorldDataPolling() {
+	m.register(kindWorlds, worldsTargetID, worldDataInterval)
+}
+
+// register adds a target. Its first poll is staggered to a stable offset within
+// interval (0 for the normal interval), so targets registered together are spread
+// across the whole interval.
+func (m *Manager) register(kind string, id string, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if interval == 0 {
+		interval = m.normalInterval
+	}
+
+	key := kind + ":" + id
+	if _, exists := m.targets[key]; exists {
+		return
+	}
+
+	now := time.Now()
+	t := &target{
+		kind:     kind,
+		id:       id,
+		schedule: scheduleFor(m.schedules, kind, id),
+		lastPoll: now,
+	}
+	if t.schedule != nil {
+		t.nextRun = t.schedule.Next(now)
+	} else {
+		t.nextRun = now.Add(staggerOffset(key, interval))
+	}
+	m.targets[key] = t
+
+	logger.Log.WithFields(logrus.Fields{
+		"kind":       kind,
+		"target":     id,
+		"scheduled":  t.schedule != nil,
+		"first_poll": t.nextRun.Format(time.RFC3339),
+	}).Debug("Registered polling target")
+}
+
+// unregister removes a target. A poll already in flight finishes, but the target isn't
+// polled again; its lease is released so it doesn't linger until it expires.
+func (m *Manager) unregister(kind string, id string) {
+	m.mu.Lock()
+	key := kind + ":" + id
+	t, exists := m.targets[key]
+	if exists {
+		delete(m.targets, key)
+	}
+	m.mu.Unlock()
 
 	if !exists {
 		return
 	}
+	deleteTarget(kind, id)
+
+	if t.leased && m.leases != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.leases.ReleaseLease(ctx, leaseKey(t), m.instanceID); err != nil {
+			logger.Log.WithFields(logrus.Fields{
+				"kind":   kind,
+				"target": id,
+				"error":  err.Error(),
+			}).Warn("Failed to release polling lease")
+		}
+	}
+
+	logger.Log.WithFields(logrus.Fields{
+		"kind":   kind,
+		"target": id,
+	}).Debug("Unregistered polling target")
+}
+
+// SetSteamPlaying marks a polled Steam user as playing or not, from a real-time signal such as
+// Discord presence; see setPlaying
+func (m *Manager) SetSteamPlaying(steamId string, playing bool) {
+	m.setPlaying(collectorSteam, steamId, playing)
+}
+
+// SetOSRSPlaying marks a polled OSRS player as playing or not; see setPlaying
+func (m *Manager) SetOSRSPlaying(rsn string, playing bool) {
+	m.setPlaying(collectorOSRS, rsn, playing)
+}
+
+// setPlaying marks a target as playing or not. A playing target is polled at the active
+// interval whatever its activity check says, and one that starts playing is polled right away
+// (unless it's backing off or scheduled). Targets that aren't registered are ignored.
+func (m *Manager) setPlaying(kind string, id string, playing bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, exists := m.targets[kind+":"+id]
+	if !exists || t.playing == playing {
+		return
+	}
+	t.playing = playing
+	if playing && !t.running && t.schedule == nil && t.failures == 0 {
+		t.nextRun = time.Now()
+	}
+
+	logger.Log.WithFields(logrus.Fields{
+		"kind":    kind,
+		"target":  id,
+		"playing": playing,
+	}).Debug("Polling target presence changed")
+}
+
+// SetIntervals changes the normal and active polling intervals. Targets waiting longer
+// than their new interval are brought forward.
+func (m *Manager) SetIntervals(normal time.Duration, active time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.normalInterval = normal
+	m.activeInterval = active
+
+	for _, t := range m.targets {
+		if t.running || t.schedule != nil {
+			continue
+		}
+		if limit := t.lastPoll.Add(m.interval(t)); limit.Before(t.nextRun) {
+			t.nextRun = limit
+		}
+	}
+}
+
+// staggerOffset maps a target key to a stable offset in [0, interval). Hashing
+// spreads targets evenly and keeps each target's slot the same across restarts.
+func staggerOffset(key string, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+// applyJitter randomises an interval by up to +/- the configured jitter fraction
+func (m *Manager) applyJitter(interval time.Duration) time.Duration {
+	if m.jitter == 0 || interval <= 0 {
+		return interval
+	}
+	spread := float64(interval) * m.jitter
+	return interval + time.Duration((rand.Float64()*2-1)*spread)
+}
 
-	ticker := time.NewTicker(m.normalInterval)
+// schedule queues due targets for the workers, oldest first
+func (m *Manager) schedule() {
+	defer m.wg.Done()
+	defer close(m.queue)
+
+	ticker := time.NewTicker(schedulerTick)
 	defer ticker.Stop()
 
 	for {
@@ -114,106 +495,328 @@ func (m *Manager) pollSteamUser(steamId string) {
 		case <-m.ctx.Done():
 			return
 		case <-ticker.C:
-			// Collect data
-			err := m.steamCollector.Collect(steamId)
-			if err != nil {
-				fmt.Printf("Error collecting Steam data for %s: %v\n", steamId, err)
-			}
-
-			// Check if user is active
-			active, err := m.steamCollector.IsActive(steamId)
-			if err != nil {
-				fmt.Printf("Error checking Steam activity for %s: %v\n", steamId, err)
-			} else {
-				state.mu.Lock()
-				state.lastActive = active
-				state.lastPoll = time.Now()
-
-				// Adjust polling interval based on activity
-				if active {
-					ticker.Reset(m.activeInterval)
-				} else {
-					ticker.Reset(m.normalInterval)
-				}
-				state.mu.Unlock()
-			}
-		}
-	}
-}
-
-// pollOSRSPlayer polls an OSRS player with adaptive interval
-func (m *Manager) pollOSRSPlayer(rsn string) {
-	defer m.wg.Done()
+			m.dispatchDue()
+		}
+	}
+}
 
-	m.mu.RLock()
-	state, exists := m.osrsPlayers[rsn]
-	m.mu.RUnlock()
+// dispatchDue hands due targets to idle workers. Targets that don't fit in the
+// queue stay due and are picked up on a later tick, so the worker count is a hard
+// cap on concurrent collections.
+func (m *Manager) dispatchDue() {
+	now := time.Now()
+	steamBlocked := m.steamCollector != nil && m.steamCollector.RateLimited()
 
-	if !exists {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.paused {
 		return
 	}
 
-	ticker := time.NewTicker(m.normalInterval)
-	defer ticker.Stop()
+	due := make([]*target, 0)
+	for _, t := range m.targets {
+		// A zero nextRun is a schedule that never matches again, not a target due forever
+		if t.running || t.nextRun.IsZero() || now.Before(t.nextRun) {
+			continue
+		}
+		// Hold Steam targets back while the rate limiter is in a backoff period
+		if t.kind == collectorSteam && steamBlocked {
+			continue
+		}
+		due = append(due, t)
+	}
 
-	for {
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].nextRun.Before(due[j].nextRun)
+	})
+
+	for _, t := range due {
 		select {
-		case <-m.ctx.Done():
+		case m.queue <- t:
+			t.running = true
+		default:
+			// All workers busy
 			return
-		case <-ticker.C:
-			// Collect data (default to "vanilla" mode for background polling)
-			err := m.osrsCollector.CollectPlayerStats(rsn, "vanilla")
-			if err != nil {
-				fmt.Printf("Error collecting OSRS data for %s: %v\n", rsn, err)
-			}
+		}
+	}
+}
+
+// worker collects queued targets until the scheduler stops
+func (m *Manager) worker() {
+	defer m.wg.Done()
+
+	for t := range m.queue {
+		// Drain without polling once stopping
+		if m.ctx.Err() != nil {
+			continue
+		}
+
+		if !m.acquireLease(t) {
+			// Another replica owns this target - check back after a normal interval
+			m.mu.Lock()
+			t.running = false
+			t.nextRun = m.nextRun(t, time.Now())
+			m.mu.Unlock()
+			continue
+		}
+
+		active, err := m.poll(t)
+		if err == nil && m.onCollected != nil {
+			m.onCollected(m.ctx, t.kind, t.id)
+		}
 
-			// Check if player is active (using "vanilla" mode for background polling)
-			active, err := m.osrsCollector.IsActive(rsn, "vanilla")
-			if err != nil {
-				fmt.Printf("Error checking OSRS activity for %s: %v\n", rsn, err)
-			} else {
-				state.mu.Lock()
-				state.lastActive = active
-				state.lastPoll = time.Now()
+		m.mu.Lock()
+		t.running = false
+		t.lastPoll = time.Now()
+		t.lastActive = active
+		if err != nil {
+			t.failures++
+		} else {
+			t.failures = 0
+		}
+		t.nextRun = m.nextRun(t, t.lastPoll)
+		delay := t.nextRun.Sub(t.lastPoll)
+		failures := t.failures
+		registered := m.targets[t.kind+":"+t.id] == t
+		m.mu.Unlock()
+
+		if !registered {
+			// Unregistered while polling
+			continue
+		}
+		setConsecutiveFailures(t.kind, t.id, failures)
+		if failures > 0 {
+			logger.Log.WithFields(logrus.Fields{
+				"kind":                 t.kind,
+				"target":               t.id,
+				"consecutive_failures": failures,
+				"next_poll_in":         delay.String(),
+			}).Warn("Polling target failed, backing off")
+		}
+	}
+}
+
+// leaseKey is the shared cache key for a target's lease
+func leaseKey(t *target) string {
+	return "polling:lease:" + t.kind + ":" + t.id
+}
 
-				// Adjust polling interval based on activity
-				if active {
-					ticker.Reset(m.activeInterval)
-				} else {
-					ticker.Reset(m.normalInterval)
-				}
-				state.mu.Unlock()
-			}
+// acquireLease claims (or extends) the target's lease when coordination is enabled.
+// The lease outlives the polling interval so the owner keeps it between polls; if
+// the owner dies another replica takes over once it expires. Lease errors fail
+// open - polling twice is better than not polling at all.
+func (m *Manager) acquireLease(t *target) bool {
+	if m.leases == nil {
+		return true
+	}
+
+	m.mu.Lock()
+	now := time.Now()
+	ttl := 2 * m.nextRun(t, now).Sub(now)
+	m.mu.Unlock()
+	if ttl < minLeaseTTL {
+		ttl = minLeaseTTL
+	}
+
+	acquired, err := m.leases.AcquireLease(m.ctx, leaseKey(t), m.instanceID, ttl)
+	if err != nil {
+		logger.Log.WithFields(logrus.Fields{
+			"kind":   t.kind,
+			"target": t.id,
+			"error":  err.Error(),
+		}).Warn("Failed to acquire polling lease, polling anyway")
+		return true
+	}
+
+	m.mu.Lock()
+	wasLeased := t.leased
+	t.leased = acquired
+	m.mu.Unlock()
+
+	if acquired != wasLeased {
+		logger.Log.WithFields(logrus.Fields{
+			"kind":     t.kind,
+			"target":   t.id,
+			"instance": m.instanceID,
+			"owned":    acquired,
+		}).Info("Polling target ownership changed")
+	}
+	return acquired
+}
+
+// releaseLeases gives up every lease held so other replicas can take over immediately
+func (m *Manager) releaseLeases() {
+	if m.leases == nil {
+		return
+	}
+
+	m.mu.Lock()
+	var held []*target
+	for _, t := range m.targets {
+		if t.leased {
+			held = append(held, t)
+			t.leased = false
+		}
+	}
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, t := range held {
+		if err := m.leases.ReleaseLease(ctx, leaseKey(t), m.instanceID); err != nil {
+			logger.Log.WithFields(logrus.Fields{
+				"kind":   t.kind,
+				"target": t.id,
+				"error":  err.Error(),
+			}).Warn("Failed to release polling lease")
 		}
 	}
 }
 
-// StartWorldDataPolling starts background polling for OSRS world data
-func (m *Manager) StartWorldDataPolling() {
-	m.wg.Add(1)
-	go func() {
-		defer m.wg.Done()
-
-		ticker := time.NewTicker(5 * time.Minute) // World data changes frequently
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-m.ctx.Done():
-				return
-			case <-ticker.C:
-				err := m.osrsCollector.CollectWorldData()
-				if err != nil {
-					fmt.Printf("Error collecting OSRS world data: %v\n", err)
-				}
-			}
+// nextRun returns when a target should next be polled after from. Scheduled
+// targets follow their cron schedule; others use their (jittered) interval.
+func (m *Manager) nextRun(t *target, from time.Time) time.Time {
+	if t.schedule != nil {
+		return t.schedule.Next(from)
+	}
+	return from.Add(m.applyJitter(m.interval(t)))
+}
+
+// interval returns how long to wait before polling a target again
+func (m *Manager) interval(t *target) time.Duration {
+	base := m.normalInterval
+	if t.kind == kindWorlds {
+		base = worldDataInterval
+	} else if t.lastActive || t.playing {
+		// Adjust polling interval based on activity
+		base = m.activeInterval
+	}
+
+	return backoff(base, t.failures, m.maxBackoff)
+}
+
+// backoff doubles base for every consecutive failure, capped at max
+// A target that has never failed (or just recovered) polls at base
+func backoff(base time.Duration, failures int, max time.Duration) time.Duration {
+	if failures <= 0 {
+		return base
+	}
+	if base >= max {
+		return max
+	}
+
+	delay := base
+	for i := 0; i < failures; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
 		}
+	}
+	return delay
+}
+
+// poll collects a single target, returning whether it appears active
+// An error means the collection itself failed and the target should back off
+func (m *Manager) poll(t *target) (active bool, err error) {
+	// The span parents the collector's spans, and links the duration sample to them
+	ctx, span := tracing.Start(m.ctx, "polling.collect",
+		attribute.String("polling.collector", t.kind),
+		attribute.String("polling.target", t.id),
+	)
+	start := time.Now()
+	defer func() {
+		observeDuration(ctx, t.kind, time.Since(start))
+		tracing.End(span, err)
 	}()
+
+	switch t.kind {
+	case collectorSteam:
+		return m.pollSteamUser(ctx, t.id)
+	case collectorOSRS:
+		return m.pollOSRSPlayer(ctx, t.id)
+	case kindWorlds:
+		return false, m.pollWorldData(ctx)
+	}
+	return false, nil
 }
 
-// Stop stops all polling
+// pollSteamUser collects a Steam user and checks whether they are active
+func (m *Manager) pollSteamUser(ctx context.Context, steamId string) (bool, error) {
+	collectErr := m.steamCollector.Collect(ctx, steamId)
+	if isPartial(collectErr) {
+		// Some games failed, the rest were reported: worth pushing, not backing off
+		logger.Log.WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    collectErr.Error(),
+		}).Warn("Background poll collected Steam data partially")
+		collectErr = nil
+	} else if collectErr != nil {
+		recordError(collectorSteam)
+		logger.Log.WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    collectErr.Error(),
+		}).Error("Background poll failed to collect Steam data")
+	}
+
+	// Check if user is active
+	active, err := m.steamCollector.IsActive(ctx, steamId)
+	if err != nil {
+		recordError(collectorSteam)
+		logger.Log.WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+		}).Error("Background poll failed to check Steam activity")
+		return false, collectErr
+	}
+	return active, collectErr
+}
+
+// isPartial reports whether a collection error still left metrics worth serving, such as a
+// steam.CollectionError with only some games failed
+func isPartial(err error) bool {
+	var partial interface{ Partial() bool }
+	return errors.As(err, &partial) && partial.Partial()
+}
+
+// pollOSRSPlayer collects an OSRS player and checks whether they are active
+func (m *Manager) pollOSRSPlayer(ctx context.Context, rsn string) (bool, error) {
+	// Collect data (default to "vanilla" mode for background polling)
+	collectErr := m.osrsCollector.CollectPlayerStats(ctx, rsn, "vanilla")
+	if collectErr != nil {
+		recordError(collectorOSRS)
+		logger.Log.WithFields(logrus.Fields{
+			"rsn":   rsn,
+			"error": collectErr.Error(),
+		}).Error("Background poll failed to collect OSRS data")
+	}
+
+	// Check if player is active (using "vanilla" mode for background polling)
+	active, err := m.osrsCollector.IsActive(ctx, rsn, "vanilla")
+	if err != nil {
+		recordError(collectorOSRS)
+		logger.Log.WithFields(logrus.Fields{
+			"rsn":   rsn,
+			"error": err.Error(),
+		}).Error("Background poll failed to check OSRS activity")
+		return false, collectErr
+	}
+	return active, collectErr
+}
+
+// pollWorldData collects OSRS world data
+func (m *Manager) pollWorldData(ctx context.Context) error {
+	err := m.osrsCollector.CollectWorldData(ctx)
+	if err != nil {
+		recordError(collectorOSRS)
+		logger.Log.WithError(err).Error("Background poll failed to collect OSRS world data")
+	}
+	return err
+}
+
+// Stop stops all polling and releases any held leases
 func (m *Manager) Stop() {
 	m.cancel()
 	m.wg.Wait()
+	m.releaseLeases()
 }
-
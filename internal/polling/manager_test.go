@@ -0,0 +1,240 @@
+package polling
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOSRS counts collections, failing them while err is set
+type fakeOSRS struct {
+	mu          sync.Mutex
+	collections map[string]int
+	err         error
+}
+
+func (f *fakeOSRS) CollectPlayerStats(ctx context.Context, rsn string, mode string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.collections == nil {
+		f.collections = make(map[string]int)
+	}
+	f.collections[rsn]++
+	return f.err
+}
+
+func (f *fakeOSRS) CollectWorldData(ctx context.Context) error {
+	return f.CollectPlayerStats(ctx, worldsTargetID, "")
+}
+
+func (f *fakeOSRS) IsActive(ctx context.Context, rsn string, mode string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeOSRS) collected(rsn string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.collections[rsn]
+}
+
+// fakeLeases grants leases while granted is set, or fails with err
+type fakeLeases struct {
+	granted bool
+	err     error
+}
+
+func (f *fakeLeases) AcquireLease(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	return f.granted, f.err
+}
+
+func (f *fakeLeases) ReleaseLease(ctx context.Context, key string, owner string) error {
+	return nil
+}
+
+// neverSchedule is a cron schedule that never matches, as "0 0 30 2 *"
+type neverSchedule struct{}
+
+func (neverSchedule) Next(time.Time) time.Time {
+	return time.Time{}
+}
+
+func newTestManager(t *testing.T, osrs *fakeOSRS, config Config) *Manager {
+	t.Helper()
+	if config.NormalInterval == 0 {
+		config.NormalInterval = 15 * time.Minute
+		config.ActiveInterval = 5 * time.Minute
+	}
+	m := NewManager(nil, osrs, config)
+	t.Cleanup(m.cancel)
+	return m
+}
+
+// runWorker has a single worker poll targets, without the scheduler
+func runWorker(m *Manager, targets ...*target) {
+	m.wg.Add(1)
+	go m.worker()
+	for _, t := range targets {
+		m.mu.Lock()
+		t.running = true
+		m.mu.Unlock()
+		m.queue <- t
+	}
+	close(m.queue)
+	m.wg.Wait()
+}
+
+func TestDispatchDueWorkerLimit(t *testing.T) {
+	m := newTestManager(t, &fakeOSRS{}, Config{Workers: 2})
+	now := time.Now()
+	for i, rsn := range []string{"a", "b", "c", "d"} {
+		m.RegisterOSRSPlayer(rsn)
+		// d isn't due yet, and of the others the longest overdue go first
+		m.targets["osrs:"+rsn].nextRun = now.Add(time.Duration(i-2) * time.Minute)
+	}
+
+	m.dispatchDue()
+	if got := len(m.queue); got != 2 {
+		t.Fatalf("queued %d targets, want 2 (the worker count)", got)
+	}
+	for _, want := range []string{"a", "b"} {
+		if got := (<-m.queue).id; got != want {
+			t.Errorf("queued %s, want %s", got, want)
+		}
+	}
+	// c is due but didn't fit; it's picked up once a worker is free
+	if m.targets["osrs:c"].running {
+		t.Error("c is running though the workers were busy")
+	}
+
+	m.dispatchDue()
+	if got := len(m.queue); got != 1 {
+		t.Fatalf("queued %d targets after the workers freed up, want 1", got)
+	}
+	if got := (<-m.queue).id; got != "c" {
+		t.Errorf("queued %s, want c", got)
+	}
+
+	// Running targets aren't queued again
+	m.dispatchDue()
+	if got := len(m.queue); got != 0 {
+		t.Errorf("queued %d running targets", got)
+	}
+}
+
+func TestDispatchDuePaused(t *testing.T) {
+	m := newTestManager(t, &fakeOSRS{}, Config{Workers: 1, Paused: true})
+	m.RegisterOSRSPlayer("zezima")
+	m.targets["osrs:zezima"].nextRun = time.Now().Add(-time.Minute)
+
+	m.dispatchDue()
+	if got := len(m.queue); got != 0 {
+		t.Errorf("queued %d targets while paused", got)
+	}
+}
+
+func TestDispatchDueNeverMatchingSchedule(t *testing.T) {
+	m := newTestManager(t, &fakeOSRS{}, Config{Workers: 1, Schedules: map[string]Schedule{"osrs": neverSchedule{}}})
+	m.RegisterOSRSPlayer("zezima")
+	if next := m.targets["osrs:zezima"].nextRun; !next.IsZero() {
+		t.Fatalf("next run = %v, want zero", next)
+	}
+
+	// A zero next run is long past, but it's a schedule that never fires
+	m.dispatchDue()
+	if got := len(m.queue); got != 0 {
+		t.Errorf("queued %d targets with a never-matching schedule", got)
+	}
+
+	// Nor does resuming make it due
+	m.Pause()
+	m.Resume()
+	if next := m.targets["osrs:zezima"].nextRun; !next.IsZero() {
+		t.Errorf("next run after resuming = %v, want zero", next)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		base     time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{base: 15 * time.Minute, failures: 0, want: 15 * time.Minute},
+		{base: 15 * time.Minute, failures: 1, want: 30 * time.Minute},
+		{base: 15 * time.Minute, failures: 3, want: 2 * time.Hour},
+		{base: 15 * time.Minute, failures: 5, want: 6 * time.Hour},
+		{base: 15 * time.Minute, failures: 1000, want: 6 * time.Hour},
+		{base: 12 * time.Hour, failures: 1, want: 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.base, tt.failures, 6*time.Hour); got != tt.want {
+			t.Errorf("backoff(%v, %d) = %v, want %v", tt.base, tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerBacksOffFailures(t *testing.T) {
+	osrs := &fakeOSRS{err: errors.New("hiscores unavailable")}
+	m := newTestManager(t, osrs, Config{Workers: 1, MaxBackoff: time.Hour})
+	m.RegisterOSRSPlayer("zezima")
+	zezima := m.targets["osrs:zezima"]
+
+	// 15m doubled per failure: 30m, then the 1h cap
+	for i, want := range []time.Duration{30 * time.Minute, time.Hour, time.Hour} {
+		m.queue = make(chan *target, 1)
+		runWorker(m, zezima)
+		if zezima.failures != i+1 {
+			t.Errorf("failures = %d, want %d", zezima.failures, i+1)
+		}
+		if got := zezima.nextRun.Sub(zezima.lastPoll); got != want {
+			t.Errorf("delay after %d failures = %v, want %v", i+1, got, want)
+		}
+	}
+
+	// A success resets the backoff
+	osrs.err = nil
+	m.queue = make(chan *target, 1)
+	runWorker(m, zezima)
+	if zezima.failures != 0 || zezima.nextRun.Sub(zezima.lastPoll) != 15*time.Minute {
+		t.Errorf("after a success failures = %d and delay = %v, want 0 and 15m", zezima.failures, zezima.nextRun.Sub(zezima.lastPoll))
+	}
+}
+
+func TestWorkerLeases(t *testing.T) {
+	tests := []struct {
+		name   string
+		leases *fakeLeases
+		polled bool
+		leased bool
+	}{
+		{name: "lease held by another instance", leases: &fakeLeases{granted: false}, polled: false},
+		{name: "lease acquired", leases: &fakeLeases{granted: true}, polled: true, leased: true},
+		{name: "lease store failing", leases: &fakeLeases{err: errors.New("redis down")}, polled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			osrs := &fakeOSRS{}
+			m := newTestManager(t, osrs, Config{Workers: 1, Leases: tt.leases, InstanceID: "replica-1"})
+			m.RegisterOSRSPlayer("zezima")
+			zezima := m.targets["osrs:zezima"]
+
+			before := time.Now()
+			runWorker(m, zezima)
+			if got := osrs.collected("zezima") == 1; got != tt.polled {
+				t.Errorf("polled = %v, want %v", got, tt.polled)
+			}
+			if zezima.leased != tt.leased {
+				t.Errorf("leased = %v, want %v", zezima.leased, tt.leased)
+			}
+			if zezima.running {
+				t.Error("target is still marked running")
+			}
+			// A skipped target is checked again after its interval, like a polled one
+			if next := zezima.nextRun.Sub(before); next < 15*time.Minute || next > 16*time.Minute {
+				t.Errorf("next run in %v, want 15m", next)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package polling
+
+import (
+	"context"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pollingErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polling",
+		Name:      "errors_total",
+		Help:      "Number of errors encountered by background polling",
+	}, []string{"collector"})
+
+	targetConsecutiveFailuresGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "polling",
+		Name:      "target_consecutive_failures",
+		Help:      "Number of consecutive failed polls for a target (0 after a success)",
+	}, []string{"collector", "target"})
+
+	pausedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "polling",
+		Name:      "paused",
+		Help:      "Whether background polling is paused (1) or running (0)",
+	})
+
+	collectionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "polling",
+		Name:      "collection_duration_seconds",
+		Help:      "Duration of background collections, including the activity check",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"collector"})
+)
+
+func init() {
+	prometheus.MustRegister(pollingErrorsCounter)
+	prometheus.MustRegister(targetConsecutiveFailuresGauge)
+	prometheus.MustRegister(pausedGauge)
+	prometheus.MustRegister(collectionDuration)
+}
+
+// recordError counts a background polling error for a collector
+func recordError(collector string) {
+	pollingErrorsCounter.WithLabelValues(collector).Inc()
+}
+
+// observeDuration records how long a background collection took, with its trace as exemplar
+func observeDuration(ctx context.Context, collector string, duration time.Duration) {
+	tracing.Observe(ctx, collectionDuration.WithLabelValues(collector), duration.Seconds())
+}
+
+// setConsecutiveFailures records a target's current failure streak
+func setConsecutiveFailures(collector string, target string, failures int) {
+	targetConsecutiveFailuresGauge.WithLabelValues(collector, target).Set(float64(failures))
+}
+
+// deleteTarget drops the series of a target that is no longer polled
+func deleteTarget(collector string, target string) {
+	targetConsecutiveFailuresGauge.DeleteLabelValues(collector, target)
+}
+
+// setPaused records whether polling is paused
+func setPaused(paused bool) {
+	if paused {
+		pausedGauge.Set(1)
+	} else {
+		pausedGauge.Set(0)
+	}
+}
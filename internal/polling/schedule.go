@@ -0,0 +1,58 @@
+package polling
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule computes fixed run times for a target, replacing its polling interval
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// ParseSchedules parses cron schedules from a semicolon-separated list of
+// "<selector>=<cron expression>" entries, e.g.
+//
+//	steam=0 3 * * *;osrs:Zezima=0 */6 * * *;osrs_worlds=*/10 * * * *
+//
+// A selector is either a collector kind ("steam", "osrs", "osrs_worlds"), which
+// applies to every target of that kind, or "<kind>:<target>" for one target.
+// Expressions use the standard 5-field cron syntax, plus descriptors like @daily.
+func ParseSchedules(spec string) (map[string]Schedule, error) {
+	schedules := make(map[string]Schedule)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		selector, expr, found := strings.Cut(entry, "=")
+		selector = strings.TrimSpace(selector)
+		expr = strings.TrimSpace(expr)
+		if !found || selector == "" || expr == "" {
+			return nil, fmt.Errorf("invalid schedule %q: expected <selector>=<cron expression>", entry)
+		}
+
+		schedule, err := cron.ParseStandard(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression for %s: %w", selector, err)
+		}
+		// Expressions such as "0 0 30 2 *" parse but never match, and Next returns the zero time
+		if schedule.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("cron expression for %s never matches: %q", selector, expr)
+		}
+		schedules[selector] = schedule
+	}
+	return schedules, nil
+}
+
+// scheduleFor returns the most specific schedule for a target, or nil to use intervals
+func scheduleFor(schedules map[string]Schedule, kind string, id string) Schedule {
+	if schedule, exists := schedules[kind+":"+id]; exists {
+		return schedule
+	}
+	return schedules[kind]
+}
@@ -0,0 +1,40 @@
+package polling
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedules(t *testing.T) {
+	schedules, err := ParseSchedules("steam=0 3 * * *; osrs:Zezima=@daily ;;osrs_worlds=*/10 * * * *")
+	if err != nil {
+		t.Fatalf("ParseSchedules: %v", err)
+	}
+	if len(schedules) != 3 {
+		t.Errorf("got %d schedules, want 3", len(schedules))
+	}
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	if got, want := schedules["steam"].Next(from), time.Date(2024, 1, 2, 3, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("steam next run = %v, want %v", got, want)
+	}
+
+	// The most specific selector wins
+	if scheduleFor(schedules, "osrs", "Zezima") != schedules["osrs:Zezima"] {
+		t.Error("Zezima doesn't use its own schedule")
+	}
+	if scheduleFor(schedules, "osrs", "Lynx Titan") != nil {
+		t.Error("a player without a schedule got one")
+	}
+
+	for _, spec := range []string{
+		"steam",
+		"=0 3 * * *",
+		"steam=",
+		"steam=0 3 * *",
+		"steam=0 0 30 2 *", // Parses, but February never has a 30th
+	} {
+		if _, err := ParseSchedules(spec); err == nil {
+			t.Errorf("ParseSchedules(%q) succeeded", spec)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package push
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// graphitePaths maps the metrics sent to Graphite to their path, built from label values.
+// Only the headline numbers are sent; Graphite has no labels, so every other series would
+// need its own path scheme.
+var graphitePaths = map[string][]string{
+	"steam_owned_games_playtime_seconds": {"steam", "{steam_id}", "playtime_seconds", "{app_id}"},
+	"osrs_player_xp":                     {"osrs", "{mode}", "{player}", "xp", "{skill}"},
+	"osrs_world_players":                 {"osrs", "worlds", "{id}", "players"},
+}
+
+// GraphiteConfig configures the Graphite/carbon plaintext output
+type GraphiteConfig struct {
+	Addr   string // Carbon plaintext listener, host:port
+	Prefix string // Prepended to every path, e.g. "games"
+}
+
+// GraphiteSink writes playtime, XP and world counts using the carbon plaintext protocol
+type GraphiteSink struct {
+	config GraphiteConfig
+	dialer net.Dialer
+}
+
+func NewGraphiteSink(config GraphiteConfig) *GraphiteSink {
+	return &GraphiteSink{
+		config: config,
+		dialer: net.Dialer{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *GraphiteSink) Name() string {
+	return "graphite"
+}
+
+// Push opens a connection per push; pushes only happen once per target per polling cycle
+func (s *GraphiteSink) Push(ctx context.Context, kind string, id string, families []*dto.MetricFamily) error {
+	lines := graphiteLines(families, s.config.Prefix, time.Now())
+	if len(lines) == 0 {
+		return nil
+	}
+
+	conn, err := s.dialer.DialContext(ctx, "tcp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to carbon: %w", err)
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+
+	w := bufio.NewWriter(conn)
+	for _, line := range lines {
+		if _, err := w.WriteString(line); err != nil {
+			return fmt.Errorf("failed to write to carbon: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write to carbon: %w", err)
+	}
+	return nil
+}
+
+// graphiteLines renders "<path> <value> <timestamp>" lines for the metrics in graphitePaths
+func graphiteLines(families []*dto.MetricFamily, prefix string, at time.Time) []string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	var lines []string
+	for _, smp := range flatten(families) {
+		template, ok := graphitePaths[smp.name]
+		if !ok {
+			continue
+		}
+
+		labels := make(map[string]string, len(smp.labels))
+		for _, label := range smp.labels {
+			labels[label.GetName()] = label.GetValue()
+		}
+
+		nodes := make([]string, 0, len(template)+1)
+		if prefix != "" {
+			nodes = append(nodes, strings.Trim(prefix, "."))
+		}
+		for _, node := range template {
+			if strings.HasPrefix(node, "{") {
+				node = graphiteNode(labels[strings.Trim(node, "{}")])
+			}
+			nodes = append(nodes, node)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %s %s\n",
+			strings.Join(nodes, "."),
+			strconv.FormatFloat(smp.value, 'f', -1, 64),
+			timestamp,
+		))
+	}
+	return lines
+}
+
+// graphiteNode makes a label value safe to use as one node of a Graphite path
+func graphiteNode(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
@@ -0,0 +1,36 @@
+package push
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pushErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "push",
+		Name:      "errors_total",
+		Help:      "Number of failed pushes of collected metrics, by sink",
+	}, []string{"sink"})
+
+	pushLastSuccessGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "push",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful push, by sink",
+	}, []string{"sink"})
+)
+
+func init() {
+	prometheus.MustRegister(pushErrorsCounter)
+	prometheus.MustRegister(pushLastSuccessGauge)
+}
+
+// recordPushError counts a failed push
+func recordPushError(sink string) {
+	pushErrorsCounter.WithLabelValues(sink).Inc()
+}
+
+// recordPushSuccess records the time of a successful push
+func recordPushSuccess(sink string, at time.Time) {
+	pushLastSuccessGauge.WithLabelValues(sink).Set(float64(at.Unix()))
+}
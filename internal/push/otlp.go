@@ -0,0 +1,117 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// OTLPSink pushes metrics over OTLP/HTTP. The endpoint, headers and TLS are read by the
+// exporter from the standard OTEL_EXPORTER_OTLP_* environment variables.
+type OTLPSink struct {
+	exporter *otlpmetrichttp.Exporter
+	resource *resource.Resource
+}
+
+func NewOTLPSink(ctx context.Context, serviceName string) (*OTLPSink, error) {
+	exporter, err := otlpmetrichttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build metric resource: %w", err)
+	}
+
+	return &OTLPSink{
+		exporter: exporter,
+		resource: res,
+	}, nil
+}
+
+func (s *OTLPSink) Name() string {
+	return "otlp"
+}
+
+// Push converts the target's families to OTLP gauges (counters become cumulative sums)
+func (s *OTLPSink) Push(ctx context.Context, kind string, id string, families []*dto.MetricFamily) error {
+	now := time.Now()
+
+	var metrics []metricdata.Metrics
+	for _, mf := range families {
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			points := make([]metricdata.DataPoint[float64], 0, len(mf.GetMetric()))
+			for _, m := range mf.GetMetric() {
+				points = append(points, metricdata.DataPoint[float64]{
+					Attributes: attributeSet(m.GetLabel()),
+					Time:       now,
+					Value:      m.GetCounter().GetValue(),
+				})
+			}
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        mf.GetName(),
+				Description: mf.GetHelp(),
+				Data: metricdata.Sum[float64]{
+					DataPoints:  points,
+					Temporality: metricdata.CumulativeTemporality,
+					IsMonotonic: true,
+				},
+			})
+		default:
+			// Gauges as-is; anything else flattened to gauges like the text format would show it
+			gauges := make(map[string][]metricdata.DataPoint[float64])
+			var order []string
+			for _, smp := range flatten([]*dto.MetricFamily{mf}) {
+				if _, seen := gauges[smp.name]; !seen {
+					order = append(order, smp.name)
+				}
+				gauges[smp.name] = append(gauges[smp.name], metricdata.DataPoint[float64]{
+					Attributes: attributeSet(smp.labels),
+					Time:       now,
+					Value:      smp.value,
+				})
+			}
+			for _, name := range order {
+				metrics = append(metrics, metricdata.Metrics{
+					Name:        name,
+					Description: mf.GetHelp(),
+					Data:        metricdata.Gauge[float64]{DataPoints: gauges[name]},
+				})
+			}
+		}
+	}
+
+	return s.exporter.Export(ctx, &metricdata.ResourceMetrics{
+		Resource: s.resource,
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope:   instrumentation.Scope{Name: "github.com/joshhsoj1902/game-stats-exporter"},
+			Metrics: metrics,
+		}},
+	})
+}
+
+// Close flushes and stops the exporter
+func (s *OTLPSink) Close(ctx context.Context) error {
+	return s.exporter.Shutdown(ctx)
+}
+
+func attributeSet(labels []*dto.LabelPair) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(labels))
+	for _, label := range labels {
+		kvs = append(kvs, attribute.String(label.GetName(), label.GetValue()))
+	}
+	return attribute.NewSet(kvs...)
+}
@@ -0,0 +1,169 @@
+package push
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+// Target kinds, matching the polling manager's
+const (
+	KindSteam  = "steam"
+	KindOSRS   = "osrs"
+	KindWorlds = "osrs_worlds"
+	KindPrices = "steam_prices"
+)
+
+// Sink sends the metrics of one collected target to an external system
+type Sink interface {
+	// Name identifies the sink in logs and metrics
+	Name() string
+	Push(ctx context.Context, kind string, id string, families []*dto.MetricFamily) error
+}
+
+// Pusher forwards each background collection to the configured sinks, for deployments
+// where Prometheus can't scrape the exporter (e.g. a home machine behind NAT)
+type Pusher struct {
+	gatherer prometheus.Gatherer
+	sinks    []Sink
+	rules    relabel.Rules // Applied after a target's series are selected
+}
+
+func NewPusher(gatherer prometheus.Gatherer, sinks ...Sink) *Pusher {
+	return &Pusher{
+		gatherer: gatherer,
+		sinks:    sinks,
+	}
+}
+
+// SetRelabel rewrites the pushed series, like the scrape endpoints
+func (p *Pusher) SetRelabel(rules relabel.Rules) {
+	p.rules = rules
+}
+
+// Enabled reports whether any sinks are configured
+func (p *Pusher) Enabled() bool {
+	return len(p.sinks) > 0
+}
+
+// Push gathers the metrics belonging to a target that was just collected and sends them
+// to every sink. Failures are logged and counted; one sink failing doesn't stop the others.
+func (p *Pusher) Push(ctx context.Context, kind string, id string) {
+	if !p.Enabled() {
+		return
+	}
+
+	all, err := p.gatherer.Gather()
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Failed to gather metrics to push")
+		return
+	}
+	families := TargetFamilies(all, kind, id)
+	if len(families) == 0 {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"kind":   kind,
+			"target": id,
+		}).Debug("No metrics to push for target")
+		return
+	}
+
+	relabeled := p.rules.Apply(families)
+	for _, sink := range p.sinks {
+		pushed := relabeled
+		if _, ok := sink.(*GraphiteSink); ok {
+			// Graphite paths come from graphitePaths and GRAPHITE_PREFIX, which need the
+			// original names and labels
+			pushed = families
+		}
+		if err := sink.Push(ctx, kind, id, pushed); err != nil {
+			recordPushError(sink.Name())
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"sink":   sink.Name(),
+				"kind":   kind,
+				"target": id,
+				"error":  err.Error(),
+			}).Error("Failed to push metrics")
+			continue
+		}
+		recordPushSuccess(sink.Name(), time.Now())
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"sink":   sink.Name(),
+			"kind":   kind,
+			"target": id,
+		}).Debug("Pushed metrics")
+	}
+}
+
+// targetSelector picks one target's series out of the shared registry
+type targetSelector struct {
+	prefixes []string
+	label    string // Label holding the target id, empty if the kind has a single target
+}
+
+var selectors = map[string]targetSelector{
+	KindSteam:  {prefixes: []string{"steam_"}, label: "steam_id"},
+	KindOSRS:   {prefixes: []string{"osrs_player_", "osrs_minigame_", "osrs_xp_today"}, label: "player"},
+	KindWorlds: {prefixes: []string{"osrs_world_"}},
+	KindPrices: {prefixes: []string{"steam_app_"}},
+}
+
+// TargetFamilies returns the metric families (and within them, the series) for a target.
+// Collections reset the shared metrics, so series are filtered by the target's label
+// rather than trusting that the registry only holds this target.
+func TargetFamilies(all []*dto.MetricFamily, kind string, id string) []*dto.MetricFamily {
+	selector, ok := selectors[kind]
+	if !ok {
+		return nil
+	}
+
+	var families []*dto.MetricFamily
+	for _, mf := range all {
+		if !hasAnyPrefix(mf.GetName(), selector.prefixes) {
+			continue
+		}
+		if selector.label == "" {
+			families = append(families, mf)
+			continue
+		}
+
+		var metrics []*dto.Metric
+		for _, m := range mf.GetMetric() {
+			if labelValue(m, selector.label) == id {
+				metrics = append(metrics, m)
+			}
+		}
+		if len(metrics) > 0 {
+			families = append(families, &dto.MetricFamily{
+				Name:   mf.Name,
+				Help:   mf.Help,
+				Type:   mf.Type,
+				Metric: metrics,
+			})
+		}
+	}
+	return families
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, label := range m.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package push
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pgpush "github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// PushgatewayConfig configures pushing to a Prometheus Pushgateway
+type PushgatewayConfig struct {
+	URL      string
+	Job      string
+	Username string // Basic auth, optional
+	Password string
+}
+
+// PushgatewaySink pushes each target to its own Pushgateway group, keyed by
+// collector and target, so targets don't overwrite each other
+type PushgatewaySink struct {
+	config PushgatewayConfig
+}
+
+func NewPushgatewaySink(config PushgatewayConfig) *PushgatewaySink {
+	return &PushgatewaySink{config: config}
+}
+
+func (s *PushgatewaySink) Name() string {
+	return "pushgateway"
+}
+
+// Push replaces the target's group with the freshly collected metrics
+func (s *PushgatewaySink) Push(ctx context.Context, kind string, id string, families []*dto.MetricFamily) error {
+	pusher := pgpush.New(s.config.URL, s.config.Job).
+		Grouping("collector", kind).
+		Grouping("target", id).
+		Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return families, nil
+		}))
+	if s.config.Username != "" {
+		pusher = pusher.BasicAuth(s.config.Username, s.config.Password)
+	}
+	return pusher.PushContext(ctx)
+}
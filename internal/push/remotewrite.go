@@ -0,0 +1,132 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// RemoteWriteConfig configures pushing to a Prometheus remote_write endpoint
+// (Prometheus with --web.enable-remote-write-receiver, Mimir, Thanos, VictoriaMetrics, Grafana Cloud)
+type RemoteWriteConfig struct {
+	URL         string
+	Username    string // Basic auth, optional
+	Password    string
+	BearerToken string // Optional, used instead of basic auth when set
+	Job         string // Value of the job label added to every series
+}
+
+// RemoteWriteSink pushes samples using the remote write 1.0 protocol
+type RemoteWriteSink struct {
+	config     RemoteWriteConfig
+	httpClient *http.Client
+}
+
+func NewRemoteWriteSink(config RemoteWriteConfig) *RemoteWriteSink {
+	return &RemoteWriteSink{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (s *RemoteWriteSink) Name() string {
+	return "remote_write"
+}
+
+// Push sends the target's samples, all stamped with the current time
+func (s *RemoteWriteSink) Push(ctx context.Context, kind string, id string, families []*dto.MetricFamily) error {
+	body := snappy.Encode(nil, encodeWriteRequest(flatten(families), s.config.Job, time.Now()))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "game-stats-exporter")
+	if s.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.BearerToken)
+	} else if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote write request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write failed (status: %d): %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// encodeWriteRequest encodes a prometheus.WriteRequest protobuf message:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+//
+// Hand-encoded so the exporter doesn't need the Prometheus server module for three messages.
+func encodeWriteRequest(samples []sample, job string, at time.Time) []byte {
+	timestamp := at.UnixMilli()
+
+	var buf []byte
+	for _, smp := range samples {
+		// Labels must be sorted by name, including __name__ and job
+		labels := make([][2]string, 0, len(smp.labels)+2)
+		labels = append(labels, [2]string{"__name__", smp.name})
+		for _, label := range smp.labels {
+			if label.GetName() == "job" && job != "" {
+				continue
+			}
+			labels = append(labels, [2]string{label.GetName(), label.GetValue()})
+		}
+		if job != "" {
+			labels = append(labels, [2]string{"job", job})
+		}
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i][0] < labels[j][0]
+		})
+
+		var series []byte
+		for _, label := range labels {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label[0])
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label[1])
+
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, l)
+		}
+
+		var s []byte
+		s = protowire.AppendTag(s, 1, protowire.Fixed64Type)
+		s = protowire.AppendFixed64(s, math.Float64bits(smp.value))
+		s = protowire.AppendTag(s, 2, protowire.VarintType)
+		s = protowire.AppendVarint(s, uint64(timestamp))
+
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, s)
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, series)
+	}
+	return buf
+}
@@ -0,0 +1,210 @@
+package push
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// writtenSeries is a TimeSeries decoded from a WriteRequest
+type writtenSeries struct {
+	labels     [][2]string
+	value      float64
+	timestamps []int64
+}
+
+// fields decodes the fields of a protobuf message, calling fn with each field's number, type
+// and the remaining bytes positioned at its value; fn returns the value's length
+func fields(t *testing.T, b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("malformed tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		n = fn(num, typ, b)
+		if n < 0 {
+			t.Fatalf("malformed field %d: %v", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+}
+
+// decodeWriteRequest decodes a WriteRequest by the field numbers of Prometheus's prompb
+func decodeWriteRequest(t *testing.T, b []byte) []writtenSeries {
+	t.Helper()
+	var written []writtenSeries
+	fields(t, b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if num != 1 || typ != protowire.BytesType {
+			t.Fatalf("unexpected WriteRequest field %d (type %d)", num, typ)
+		}
+		ts, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n
+		}
+		var series writtenSeries
+		fields(t, ts, func(num protowire.Number, typ protowire.Type, b []byte) int {
+			message, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n
+			}
+			switch num {
+			case 1: // Label
+				var label [2]string
+				fields(t, message, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					value, n := protowire.ConsumeString(b)
+					label[num-1] = value
+					return n
+				})
+				series.labels = append(series.labels, label)
+			case 2: // Sample
+				fields(t, message, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					switch {
+					case num == 1 && typ == protowire.Fixed64Type:
+						bits, n := protowire.ConsumeFixed64(b)
+						series.value = math.Float64frombits(bits)
+						return n
+					case num == 2 && typ == protowire.VarintType:
+						timestamp, n := protowire.ConsumeVarint(b)
+						series.timestamps = append(series.timestamps, int64(timestamp))
+						return n
+					}
+					t.Fatalf("unexpected Sample field %d (type %d)", num, typ)
+					return 0
+				})
+			default:
+				t.Fatalf("unexpected TimeSeries field %d", num)
+			}
+			return n
+		})
+		written = append(written, series)
+		return n
+	})
+	return written
+}
+
+func TestRemoteWritePush(t *testing.T) {
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	families := []*dto.MetricFamily{
+		{
+			Name: proto.String("steam_owned_games_playtime_seconds"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{{
+				// A series' own job label is replaced by the configured one
+				Label: []*dto.LabelPair{
+					{Name: proto.String("app_id"), Value: proto.String("440")},
+					{Name: proto.String("job"), Value: proto.String("exporter")},
+					{Name: proto.String("steam_id"), Value: proto.String("76561197960287930")},
+				},
+				Gauge: &dto.Gauge{Value: proto.Float64(3600.5)},
+			}},
+		},
+		{
+			Name: proto.String("exporter_collection_success"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Label:   []*dto.LabelPair{{Name: proto.String("collector"), Value: proto.String("steam")}},
+				Counter: &dto.Counter{Value: proto.Float64(-2)},
+			}},
+		},
+	}
+
+	sink := NewRemoteWriteSink(RemoteWriteConfig{URL: srv.URL, BearerToken: "token", Job: "game-stats"})
+	before := time.Now().UnixMilli()
+	if err := sink.Push(context.Background(), "steam", "76561197960287930", families); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	after := time.Now().UnixMilli()
+
+	for name, want := range map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+		"Authorization":                     "Bearer token",
+	} {
+		if got := header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	decoded, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("body isn't snappy-encoded: %v", err)
+	}
+	written := decodeWriteRequest(t, decoded)
+	if len(written) != 2 {
+		t.Fatalf("got %d series, want 2", len(written))
+	}
+
+	want := []struct {
+		labels [][2]string
+		value  float64
+	}{
+		{
+			labels: [][2]string{{"__name__", "steam_owned_games_playtime_seconds"}, {"app_id", "440"}, {"job", "game-stats"}, {"steam_id", "76561197960287930"}},
+			value:  3600.5,
+		},
+		{
+			labels: [][2]string{{"__name__", "exporter_collection_success"}, {"collector", "steam"}, {"job", "game-stats"}},
+			value:  -2,
+		},
+	}
+	for i, series := range written {
+		if !sort.SliceIsSorted(series.labels, func(a, b int) bool { return series.labels[a][0] < series.labels[b][0] }) {
+			t.Errorf("series %d labels aren't sorted: %v", i, series.labels)
+		}
+		if got, want := formatLabels(series.labels), formatLabels(want[i].labels); got != want {
+			t.Errorf("series %d labels = %s, want %s", i, got, want)
+		}
+		if series.value != want[i].value {
+			t.Errorf("series %d value = %v, want %v", i, series.value, want[i].value)
+		}
+		if len(series.timestamps) != 1 || series.timestamps[0] < before || series.timestamps[0] > after {
+			t.Errorf("series %d timestamps = %v, want one in [%d, %d]", i, series.timestamps, before, after)
+		}
+	}
+}
+
+func TestRemoteWritePushRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "user" || password != "secret" {
+			t.Errorf("basic auth = %q, %q", user, password)
+		}
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	sink := NewRemoteWriteSink(RemoteWriteConfig{URL: srv.URL, Username: "user", Password: "secret"})
+	err := sink.Push(context.Background(), "steam", "76561197960287930", nil)
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "out of order sample") {
+		t.Errorf("Push = %v, want the status and message", err)
+	}
+}
+
+func formatLabels(labels [][2]string) string {
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		pairs[i] = label[0] + "=" + label[1]
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
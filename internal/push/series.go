@@ -0,0 +1,83 @@
+package push
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// sample is one series value flattened out of a metric family, as sinks without
+// Prometheus's typed families (remote write, Graphite) expect
+type sample struct {
+	name   string
+	labels []*dto.LabelPair // Sorted by name
+	value  float64
+}
+
+// flatten expands metric families into individual samples. Histograms and summaries
+// become their _bucket/_sum/_count (or quantile) series, as in the text format.
+func flatten(families []*dto.MetricFamily) []sample {
+	var samples []sample
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			labels := m.GetLabel()
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = append(samples, sample{name: name, labels: labels, value: m.GetCounter().GetValue()})
+			case dto.MetricType_GAUGE:
+				samples = append(samples, sample{name: name, labels: labels, value: m.GetGauge().GetValue()})
+			case dto.MetricType_UNTYPED:
+				samples = append(samples, sample{name: name, labels: labels, value: m.GetUntyped().GetValue()})
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				for _, q := range summary.GetQuantile() {
+					samples = append(samples, sample{
+						name:   name,
+						labels: withLabel(labels, "quantile", formatFloat(q.GetQuantile())),
+						value:  q.GetValue(),
+					})
+				}
+				samples = append(samples,
+					sample{name: name + "_sum", labels: labels, value: summary.GetSampleSum()},
+					sample{name: name + "_count", labels: labels, value: float64(summary.GetSampleCount())},
+				)
+			case dto.MetricType_HISTOGRAM:
+				histogram := m.GetHistogram()
+				for _, b := range histogram.GetBucket() {
+					samples = append(samples, sample{
+						name:   name + "_bucket",
+						labels: withLabel(labels, "le", formatFloat(b.GetUpperBound())),
+						value:  float64(b.GetCumulativeCount()),
+					})
+				}
+				samples = append(samples,
+					sample{name: name + "_bucket", labels: withLabel(labels, "le", "+Inf"), value: float64(histogram.GetSampleCount())},
+					sample{name: name + "_sum", labels: labels, value: histogram.GetSampleSum()},
+					sample{name: name + "_count", labels: labels, value: float64(histogram.GetSampleCount())},
+				)
+			}
+		}
+	}
+	return samples
+}
+
+// withLabel returns a copy of labels with one more label, keeping them sorted
+func withLabel(labels []*dto.LabelPair, name string, value string) []*dto.LabelPair {
+	result := make([]*dto.LabelPair, 0, len(labels)+1)
+	result = append(result, labels...)
+	result = append(result, &dto.LabelPair{Name: &name, Value: &value})
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+	return result
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
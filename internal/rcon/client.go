@@ -0,0 +1,155 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// Minecraft's formatting codes (§ and a character), which Paper adds to its output
+	formattingPattern = regexp.MustCompile(`§.`)
+	// "There are 2 of a max of 20 players online: Steve, Alex", or "There are 2/20 players
+	// online:\nSteve, Alex" before 1.13
+	minecraftListPattern = regexp.MustCompile(`(?s)There are (\d+)(?: of a max of |/)(\d+) players online:(.*)`)
+	// Paper and Spigot: "TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0" (a * marks values capped at 20)
+	minecraftTPSPattern = regexp.MustCompile(`TPS from last 1m, 5m, 15m: \*?([\d.]+)`)
+	// Vanilla's tick query: "Target tick rate: 20.0 per second." and "Average time per tick: 3.2ms"
+	minecraftTickRatePattern = regexp.MustCompile(`Target tick rate: ([\d.]+)`)
+	minecraftMSPTPattern     = regexp.MustCompile(`Average time per tick: ([\d.]+)ms`)
+	// Factorio: "Online players (2):" followed by "  name (online)" lines
+	factorioPlayersPattern = regexp.MustCompile(`Online players \((\d+)\):`)
+)
+
+type Client struct {
+	server Server
+}
+
+// NewClient creates a client of a server's RCON listener
+func NewClient(server Server) *Client {
+	return &Client{server: server}
+}
+
+// Status connects to the server, runs its game's status commands and parses their output
+func (c *Client) Status(ctx context.Context) (status Status, err error) {
+	ctx, span := tracing.Start(ctx, "rcon.query", attribute.String("rcon.server", c.server.Name), attribute.String("rcon.game", c.server.Game))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return Status{}, err
+	}
+
+	conn, err := dial(ctx, c.server.Address, c.server.Password)
+	if err != nil {
+		return Status{}, err
+	}
+	defer conn.Close()
+
+	switch c.server.Game {
+	case GameMinecraft:
+		return minecraftStatus(conn)
+	case GameFactorio:
+		return factorioStatus(conn)
+	case GameValheim:
+		return valheimStatus(conn)
+	default:
+		return Status{}, fmt.Errorf("unsupported game %q", c.server.Game)
+	}
+}
+
+func minecraftStatus(conn *conn) (Status, error) {
+	output, err := conn.command("list")
+	if err != nil {
+		return Status{}, err
+	}
+	match := minecraftListPattern.FindStringSubmatch(formattingPattern.ReplaceAllString(output, ""))
+	if match == nil {
+		return Status{}, fmt.Errorf("unexpected list output %q", output)
+	}
+	status := Status{Up: true}
+	status.Online, _ = strconv.Atoi(match[1])
+	status.MaxPlayers, _ = strconv.Atoi(match[2])
+	for _, name := range strings.FieldsFunc(match[3], func(r rune) bool { return r == ',' || r == '\n' }) {
+		if name = strings.TrimSpace(name); name != "" {
+			status.Players = append(status.Players, name)
+		}
+	}
+
+	// Tick performance is only exposed by Paper/Spigot's tps or by vanilla's tick query, so
+	// each is tried; a server that knows neither just doesn't report it
+	output, err = conn.command("tps")
+	if err != nil {
+		return Status{}, err
+	}
+	if match := minecraftTPSPattern.FindStringSubmatch(formattingPattern.ReplaceAllString(output, "")); match != nil {
+		if tps, err := strconv.ParseFloat(match[1], 64); err == nil {
+			status.TPS = &tps
+		}
+		return status, nil
+	}
+
+	output, err = conn.command("tick query")
+	if err != nil {
+		return Status{}, err
+	}
+	rate := minecraftTickRatePattern.FindStringSubmatch(output)
+	mspt := minecraftMSPTPattern.FindStringSubmatch(output)
+	if rate == nil || mspt == nil {
+		return status, nil
+	}
+	target, err1 := strconv.ParseFloat(rate[1], 64)
+	milliseconds, err2 := strconv.ParseFloat(mspt[1], 64)
+	if err1 != nil || err2 != nil {
+		return status, nil
+	}
+	duration := milliseconds / 1000
+	status.TickDuration = &duration
+	// A tick that takes longer than the target's interval lowers the rate
+	tps := target
+	if milliseconds > 0 && 1000/milliseconds < tps {
+		tps = 1000 / milliseconds
+	}
+	status.TPS = &tps
+	return status, nil
+}
+
+func factorioStatus(conn *conn) (Status, error) {
+	output, err := conn.command("/players online")
+	if err != nil {
+		return Status{}, err
+	}
+	match := factorioPlayersPattern.FindStringSubmatch(output)
+	if match == nil {
+		return Status{}, fmt.Errorf("unexpected players output %q", output)
+	}
+	status := Status{Up: true}
+	status.Online, _ = strconv.Atoi(match[1])
+	for _, line := range strings.Split(output, "\n")[1:] {
+		if name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "(online)")); name != "" {
+			status.Players = append(status.Players, name)
+		}
+	}
+	return status, nil
+}
+
+// valheimStatus runs the RCON mods' players command, which lists a player per line
+func valheimStatus(conn *conn) (Status, error) {
+	output, err := conn.command("players")
+	if err != nil {
+		return Status{}, err
+	}
+	status := Status{Up: true}
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			status.Players = append(status.Players, name)
+		}
+	}
+	status.Online = len(status.Players)
+	return status, nil
+}
@@ -0,0 +1,133 @@
+package rcon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// statusTTL only absorbs back-to-back scrapes: a server's state is served fresh, so a crash
+// shows up on the next scrape rather than after a stale window
+const statusTTL = 30 * time.Second
+
+// Config configures the RCON collector
+type Config struct {
+	Servers []Server
+}
+
+// Collector reports the players and tick performance of dedicated game servers over RCON
+type Collector struct {
+	servers []Server
+	clients map[string]*Client // By server name
+	cache   *cache.Cache
+}
+
+func NewCollector(config Config, cache *cache.Cache) *Collector {
+	clients := make(map[string]*Client, len(config.Servers))
+	for _, server := range config.Servers {
+		clients[server.Name] = NewClient(server)
+	}
+	return &Collector{
+		servers: config.Servers,
+		clients: clients,
+		cache:   cache,
+	}
+}
+
+// ParseServer parses a server given as game://:password@host:port, e.g.
+// minecraft://:secret@mc.example.com:25575 (a password with reserved characters must be
+// URL-encoded)
+func ParseServer(name string, raw string) (Server, error) {
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return Server{}, err
+	}
+	switch u.Scheme {
+	case GameMinecraft, GameFactorio, GameValheim:
+	default:
+		return Server{}, fmt.Errorf("unsupported game %q (expected minecraft, factorio or valheim)", u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return Server{}, fmt.Errorf("expected a host and port")
+	}
+	password := ""
+	if u.User != nil {
+		password, _ = u.User.Password()
+	}
+	return Server{
+		Name:     name,
+		Game:     u.Scheme,
+		Address:  net.JoinHostPort(u.Hostname(), u.Port()),
+		Password: password,
+	}, nil
+}
+
+// Collect reports every configured server. A server that can't be queried is reported down
+// rather than failing the others.
+func (c *Collector) Collect(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "rcon.collect")
+	defer func() { tracing.End(span, err) }()
+
+	up := 0
+	for _, server := range c.servers {
+		status, err := c.Status(ctx, server.Name)
+		if err != nil {
+			return err
+		}
+		if !status.Up {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"server": server.Name,
+				"error":  status.Error,
+			}).Warn("RCON server unavailable")
+		} else {
+			up++
+		}
+		ReportStatus(server, status)
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"servers": len(c.servers),
+		"up":      up,
+	}).Info("Completed RCON metrics collection")
+	return nil
+}
+
+// Status returns a server's status from the cache, querying it on a miss. A failed query is
+// cached as a down status, so an unreachable server isn't redialled on every scrape.
+func (c *Collector) Status(ctx context.Context, name string) (Status, error) {
+	client, ok := c.clients[name]
+	if !ok {
+		return Status{}, fmt.Errorf("unknown RCON server %q", name)
+	}
+
+	cacheKey := fmt.Sprintf("rcon:status:%s", name)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, statusTTL, 0, func(ctx context.Context) ([]byte, error) {
+		status, err := client.Status(ctx)
+		if errors.Is(err, cache.ErrNotCached) || ctx.Err() != nil {
+			return nil, err
+		}
+		if err != nil {
+			status = Status{Error: err.Error()}
+		}
+		return json.Marshal(status)
+	})
+	if err != nil {
+		return Status{}, err
+	}
+
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return Status{}, fmt.Errorf("failed to decode cached status: %w", err)
+	}
+	return status, nil
+}
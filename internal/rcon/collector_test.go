@@ -0,0 +1,101 @@
+package rcon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestCollector(t *testing.T, servers ...Server) *Collector {
+	t.Helper()
+	return NewCollector(Config{Servers: servers}, testserver.NewCache(t))
+}
+
+func TestCollect(t *testing.T) {
+	srv := testserver.New(t)
+	paper := srv.StartRCON(testserver.RCONServer{Game: GameMinecraft, Password: "secret", Players: []string{"Steve", "Alex"}, MaxPlayers: 20, TPS: 19.5})
+	vanilla := srv.StartRCON(testserver.RCONServer{Game: GameMinecraft, Password: "secret", MaxPlayers: 10, MSPT: 80})
+	factorio := srv.StartRCON(testserver.RCONServer{Game: GameFactorio, Password: "secret", Players: []string{"engineer"}})
+	collector := newTestCollector(t,
+		Server{Name: "survival", Game: GameMinecraft, Address: paper, Password: "secret"},
+		Server{Name: "creative", Game: GameMinecraft, Address: vanilla, Password: "secret"},
+		Server{Name: "factory", Game: GameFactorio, Address: factorio, Password: "secret"},
+		Server{Name: "locked", Game: GameFactorio, Address: factorio, Password: "wrong"},
+	)
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(playersOnlineGauge.WithLabelValues("survival", GameMinecraft)); got != 2 {
+		t.Errorf("survival players = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(playersMaxGauge.WithLabelValues("survival", GameMinecraft)); got != 20 {
+		t.Errorf("survival slots = %v, want 20", got)
+	}
+	if got := testutil.ToFloat64(playerOnlineGauge.WithLabelValues("survival", GameMinecraft, "Alex")); got != 1 {
+		t.Errorf("Alex online = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(tpsGauge.WithLabelValues("survival", GameMinecraft)); got != 19.5 {
+		t.Errorf("Paper TPS = %v, want 19.5", got)
+	}
+
+	// Vanilla's tick query gives the tick duration; a tick slower than 50ms lowers the rate
+	if got := testutil.ToFloat64(tickDurationGauge.WithLabelValues("creative", GameMinecraft)); got != 0.08 {
+		t.Errorf("tick duration = %v, want 0.08", got)
+	}
+	if got := testutil.ToFloat64(tpsGauge.WithLabelValues("creative", GameMinecraft)); got != 12.5 {
+		t.Errorf("vanilla TPS = %v, want 12.5", got)
+	}
+
+	if got := testutil.ToFloat64(playerOnlineGauge.WithLabelValues("factory", GameFactorio, "engineer")); got != 1 {
+		t.Errorf("Factorio engineer online = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(tpsGauge); got != 2 {
+		t.Errorf("reported %d TPS series, want 2 (Factorio doesn't expose it)", got)
+	}
+
+	// A rejected password reports the server down without failing the others
+	if got := testutil.ToFloat64(upGauge.WithLabelValues("locked", GameFactorio)); got != 0 {
+		t.Errorf("locked up = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(upGauge.WithLabelValues("factory", GameFactorio)); got != 1 {
+		t.Errorf("factory up = %v, want 1", got)
+	}
+
+	// Statuses are cached between back-to-back scrapes, the failed one included
+	srv.SetRCONPlayers(paper, "Steve")
+	if err := collector.Collect(ctx); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.RCONConnections(factorio); got != 2 {
+		t.Errorf("Factorio connections = %d, want 2 (one per configured server)", got)
+	}
+
+	// A fresh collection drops the players who left
+	if err := collector.Collect(cache.WithPolicy(ctx, cache.PolicyFresh)); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.CollectAndCount(playerOnlineGauge); got != 2 {
+		t.Errorf("reported %d players online, want 2 (Steve and engineer)", got)
+	}
+}
+
+func TestParseServer(t *testing.T) {
+	server, err := ParseServer("survival", "minecraft://:p%40ss@mc.example.com:25575")
+	if err != nil {
+		t.Fatalf("ParseServer: %v", err)
+	}
+	if server.Game != GameMinecraft || server.Address != "mc.example.com:25575" || server.Password != "p@ss" {
+		t.Errorf("ParseServer = %+v", server)
+	}
+
+	for _, raw := range []string{"minecraft://:secret@mc.example.com", "terraria://:secret@host:7777"} {
+		if _, err := ParseServer("bad", raw); err == nil {
+			t.Errorf("ParseServer(%q) succeeded, want an error", raw)
+		}
+	}
+}
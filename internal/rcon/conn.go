@@ -0,0 +1,136 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Packet types of the Source RCON protocol, which Minecraft, Factorio and the Valheim RCON
+// mods implement. A command and an auth response share type 2; the direction tells them apart.
+const (
+	packetResponseValue = 0
+	packetExecCommand   = 2
+	packetAuthResponse  = 2
+	packetAuth          = 3
+)
+
+const (
+	// dialTimeout bounds the whole exchange when the context has no deadline
+	dialTimeout = 10 * time.Second
+	// maxPacketSize guards against reading garbage from something that isn't an RCON server
+	maxPacketSize = 1 << 20
+)
+
+// ErrUnauthorized is returned when a server rejects the RCON password
+var ErrUnauthorized = errors.New("rcon: password rejected")
+
+// conn is an authenticated RCON connection
+type conn struct {
+	conn   net.Conn
+	nextId int32
+}
+
+// dial connects to a server and authenticates with its password
+func dial(ctx context.Context, address string, password string) (*conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	c, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(dialTimeout)
+	}
+	c.SetDeadline(deadline)
+
+	rc := &conn{conn: c}
+	if err := rc.auth(password); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return rc, nil
+}
+
+func (c *conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *conn) auth(password string) error {
+	id, err := c.write(packetAuth, password)
+	if err != nil {
+		return fmt.Errorf("failed to send password: %w", err)
+	}
+	for {
+		// Source servers send an empty response value before the auth response
+		respId, kind, _, err := c.read()
+		if err != nil {
+			return fmt.Errorf("failed to read auth response: %w", err)
+		}
+		if kind != packetAuthResponse {
+			continue
+		}
+		if respId == -1 {
+			return ErrUnauthorized
+		}
+		if respId != id {
+			return fmt.Errorf("auth response for request %d, expected %d", respId, id)
+		}
+		return nil
+	}
+}
+
+// command runs a command and returns its output
+func (c *conn) command(command string) (string, error) {
+	id, err := c.write(packetExecCommand, command)
+	if err != nil {
+		return "", fmt.Errorf("failed to send command: %w", err)
+	}
+	respId, kind, body, err := c.read()
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if kind != packetResponseValue || respId != id {
+		return "", fmt.Errorf("unexpected response (type %d, request %d) to request %d", kind, respId, id)
+	}
+	return body, nil
+}
+
+// write sends a packet and returns its request ID
+func (c *conn) write(kind int32, body string) (int32, error) {
+	c.nextId++
+	id := c.nextId
+
+	// Size (excluding itself), request ID, type, then the body and an empty string, both
+	// null-terminated
+	var packet bytes.Buffer
+	binary.Write(&packet, binary.LittleEndian, int32(4+4+len(body)+2))
+	binary.Write(&packet, binary.LittleEndian, id)
+	binary.Write(&packet, binary.LittleEndian, kind)
+	packet.WriteString(body)
+	packet.Write([]byte{0, 0})
+	_, err := c.conn.Write(packet.Bytes())
+	return id, err
+}
+
+func (c *conn) read() (id int32, kind int32, body string, err error) {
+	var size int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+	if size < 10 || size > maxPacketSize {
+		return 0, 0, "", fmt.Errorf("invalid packet size %d", size)
+	}
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, packet); err != nil {
+		return 0, 0, "", err
+	}
+	id = int32(binary.LittleEndian.Uint32(packet[0:4]))
+	kind = int32(binary.LittleEndian.Uint32(packet[4:8]))
+	return id, kind, string(bytes.TrimRight(packet[8:], "\x00")), nil
+}
@@ -0,0 +1,82 @@
+package rcon
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	upGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rcon",
+		Name:      "up",
+		Help:      "Whether a server answered its RCON status commands (1) or not (0)",
+	}, []string{"server", "game"})
+
+	playersOnlineGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rcon",
+		Name:      "players_online",
+		Help:      "Players online on a server",
+	}, []string{"server", "game"})
+
+	playersMaxGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rcon",
+		Name:      "players_max",
+		Help:      "Player slots of a server; left out for games that don't tell",
+	}, []string{"server", "game"})
+
+	playerOnlineGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rcon",
+		Name:      "player_online",
+		Help:      "1 for each player online on a server; players offline are left out",
+	}, []string{"server", "game", "player"})
+
+	tpsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rcon",
+		Name:      "ticks_per_second",
+		Help:      "A server's tick rate (Minecraft targets 20); left out where the server doesn't expose it",
+	}, []string{"server", "game"})
+
+	tickDurationGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rcon",
+		Name:      "tick_duration_seconds",
+		Help:      "Average time a server takes per tick (in seconds); left out where the server doesn't expose it",
+	}, []string{"server", "game"})
+
+	serverGauges = []*prometheus.GaugeVec{upGauge, playersOnlineGauge, playersMaxGauge, playerOnlineGauge, tpsGauge, tickDurationGauge}
+)
+
+func init() {
+	prometheus.MustRegister(upGauge)
+	prometheus.MustRegister(playersOnlineGauge)
+	prometheus.MustRegister(playersMaxGauge)
+	prometheus.MustRegister(playerOnlineGauge)
+	prometheus.MustRegister(tpsGauge)
+	prometheus.MustRegister(tickDurationGauge)
+}
+
+// ReportStatus replaces the reported state of a server, so players who left drop off; a
+// server that's down only reports rcon_up
+func ReportStatus(server Server, status Status) {
+	for _, gauge := range serverGauges {
+		gauge.DeletePartialMatch(prometheus.Labels{"server": server.Name})
+	}
+
+	labels := prometheus.Labels{"server": server.Name, "game": server.Game}
+	if !status.Up {
+		upGauge.With(labels).Set(0)
+		return
+	}
+	upGauge.With(labels).Set(1)
+	playersOnlineGauge.With(labels).Set(float64(status.Online))
+	if status.MaxPlayers > 0 {
+		playersMaxGauge.With(labels).Set(float64(status.MaxPlayers))
+	}
+	for _, player := range status.Players {
+		playerOnlineGauge.WithLabelValues(server.Name, server.Game, player).Set(1)
+	}
+	if status.TPS != nil {
+		tpsGauge.With(labels).Set(*status.TPS)
+	}
+	if status.TickDuration != nil {
+		tickDurationGauge.With(labels).Set(*status.TickDuration)
+	}
+}
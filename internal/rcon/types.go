@@ -0,0 +1,29 @@
+package rcon
+
+// The games whose status commands are known
+const (
+	GameMinecraft = "minecraft"
+	GameFactorio  = "factorio"
+	GameValheim   = "valheim" // With an RCON mod, e.g. Valheim RCON for BepInEx
+)
+
+// Server is a dedicated server queried over RCON
+type Server struct {
+	Name     string // Label of the server's series
+	Game     string
+	Address  string // host:port of the RCON listener
+	Password string
+}
+
+// Status is what a server reported; the fields its game doesn't expose are left empty
+type Status struct {
+	Up         bool     `json:"up"`
+	Error      string   `json:"error,omitempty"` // Why the server couldn't be queried
+	Online     int      `json:"online"`
+	Players    []string `json:"players"`
+	MaxPlayers int      `json:"max_players,omitempty"`
+
+	// Tick performance: Paper/Spigot's tps command, or vanilla Minecraft's tick query (1.20.3+)
+	TPS          *float64 `json:"tps,omitempty"`
+	TickDuration *float64 `json:"tick_duration_seconds,omitempty"`
+}
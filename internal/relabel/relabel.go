@@ -0,0 +1,202 @@
+// Package relabel rewrites the exported series (namespaces, dropped and static labels) so the
+// exporter fits existing naming conventions without relabel rules in every scrape config.
+package relabel
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+// Rules describe how series are rewritten. The zero value leaves them unchanged.
+type Rules struct {
+	Namespaces   map[string]string // Replacement per namespace, e.g. steam -> games_steam
+	DropLabels   []string          // Labels removed from every series
+	StaticLabels map[string]string // Labels added to every series; a series' own label of the same name wins
+	Prefix       string            // Prepended to every series name after its namespace is replaced, e.g. smiths_
+
+	// Labels only kept on the info metric named for them, e.g. username -> steam_player_info.
+	// The other series of the info metric's namespace lose them and are joined with it instead.
+	InfoLabels map[string]string
+}
+
+// Empty reports whether the rules change nothing
+func (r Rules) Empty() bool {
+	return len(r.Namespaces) == 0 && len(r.DropLabels) == 0 && len(r.StaticLabels) == 0 && r.Prefix == "" && len(r.InfoLabels) == 0
+}
+
+// Apply returns the families rewritten by the rules. The given families aren't modified, as
+// they may be a snapshot served again later.
+func (r Rules) Apply(families []*dto.MetricFamily) []*dto.MetricFamily {
+	if r.Empty() {
+		return families
+	}
+
+	rewritten := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		name := r.rename(mf.GetName())
+		metrics := make([]*dto.Metric, 0, len(mf.Metric))
+		seen := make(map[string]int, len(mf.Metric))
+		conflicting := make(map[string]bool)
+		for _, m := range mf.Metric {
+			labels := r.labels(mf.GetName(), m.Label)
+			// Dropping a label can make series identical. They're served once if their samples
+			// agree, and not at all if they don't, as neither can be told to be the right one.
+			key := labelKey(labels)
+			if i, exists := seen[key]; exists {
+				if !sameSample(metrics[i], m) {
+					conflicting[key] = true
+				}
+				continue
+			}
+			seen[key] = len(metrics)
+			metrics = append(metrics, &dto.Metric{
+				Label:       labels,
+				Gauge:       m.Gauge,
+				Counter:     m.Counter,
+				Untyped:     m.Untyped,
+				Summary:     m.Summary,
+				Histogram:   m.Histogram,
+				TimestampMs: m.TimestampMs,
+			})
+		}
+		if len(conflicting) > 0 {
+			metrics = withoutConflicts(metrics, conflicting)
+			logConflicts(mf.GetName(), len(conflicting))
+		}
+		// A family without series can't be served
+		if len(metrics) == 0 {
+			continue
+		}
+		rewritten = append(rewritten, &dto.MetricFamily{Name: &name, Help: mf.Help, Type: mf.Type, Metric: metrics})
+	}
+	sort.Slice(rewritten, func(i, j int) bool {
+		return rewritten[i].GetName() < rewritten[j].GetName()
+	})
+	return rewritten
+}
+
+// rename replaces a metric's namespace, the part of its name before the first underscore, and
+// adds the prefix
+func (r Rules) rename(name string) string {
+	for namespace, replacement := range r.Namespaces {
+		if strings.HasPrefix(name, namespace+"_") {
+			return r.Prefix + replacement + strings.TrimPrefix(name, namespace)
+		}
+	}
+	return r.Prefix + name
+}
+
+// labels returns the labels of a series of the metric name without the dropped ones and with the
+// static ones, sorted
+func (r Rules) labels(name string, pairs []*dto.LabelPair) []*dto.LabelPair {
+	labels := make([]*dto.LabelPair, 0, len(pairs)+len(r.StaticLabels))
+	present := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		if r.dropped(pair.GetName()) || r.joined(name, pair.GetName()) {
+			continue
+		}
+		labels = append(labels, pair)
+		present[pair.GetName()] = true
+	}
+	for name, value := range r.StaticLabels {
+		if present[name] {
+			continue
+		}
+		name, value := name, value
+		labels = append(labels, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].GetName() < labels[j].GetName()
+	})
+	return labels
+}
+
+func (r Rules) dropped(name string) bool {
+	for _, label := range r.DropLabels {
+		if label == name {
+			return true
+		}
+	}
+	return false
+}
+
+// joined reports whether a label of the metric name is left to its info metric
+func (r Rules) joined(name string, label string) bool {
+	info, ok := r.InfoLabels[label]
+	if !ok || name == info {
+		return false
+	}
+	namespace, _, _ := strings.Cut(info, "_")
+	return strings.HasPrefix(name, namespace+"_")
+}
+
+// sameSample reports whether two series of a family have the same sample
+func sameSample(a *dto.Metric, b *dto.Metric) bool {
+	switch {
+	case a.Gauge != nil:
+		return a.GetGauge().GetValue() == b.GetGauge().GetValue()
+	case a.Counter != nil:
+		return a.GetCounter().GetValue() == b.GetCounter().GetValue()
+	case a.Untyped != nil:
+		return a.GetUntyped().GetValue() == b.GetUntyped().GetValue()
+	default:
+		// Summaries and histograms are compared whole
+		return a.GetSummary().String() == b.GetSummary().String() && a.GetHistogram().String() == b.GetHistogram().String()
+	}
+}
+
+// withoutConflicts returns metrics without the series whose label sets conflict
+func withoutConflicts(metrics []*dto.Metric, conflicting map[string]bool) []*dto.Metric {
+	kept := metrics[:0]
+	for _, m := range metrics {
+		if !conflicting[labelKey(m.Label)] {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// conflictsLogged holds the metrics whose conflicting series were already logged, so each is
+// logged once rather than on every scrape
+var conflictsLogged sync.Map
+
+func logConflicts(name string, series int) {
+	if _, logged := conflictsLogged.LoadOrStore(name, true); logged {
+		return
+	}
+	logger.Log.WithFields(logrus.Fields{
+		"metric": name,
+		"series": series,
+	}).Warn("Series made identical by the dropped labels have different values, not serving them")
+}
+
+func labelKey(labels []*dto.LabelPair) string {
+	var key strings.Builder
+	for _, pair := range labels {
+		key.WriteString(pair.GetName())
+		key.WriteByte(0xff)
+		key.WriteString(pair.GetValue())
+		key.WriteByte(0xff)
+	}
+	return key.String()
+}
+
+// Gatherer wraps a gatherer so everything it gathers is rewritten by rules
+func Gatherer(gatherer prometheus.Gatherer, rules Rules) prometheus.Gatherer {
+	if rules.Empty() {
+		return gatherer
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			return nil, err
+		}
+		return rules.Apply(families), nil
+	})
+}
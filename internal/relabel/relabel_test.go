@@ -0,0 +1,182 @@
+package relabel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gather returns the families of a registry holding gauges set by set
+func gather(t *testing.T, set func(playtime *prometheus.GaugeVec, info *prometheus.GaugeVec)) []*dto.MetricFamily {
+	t.Helper()
+	playtime := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Name:      "playtime_seconds",
+		Help:      "Playtime",
+	}, []string{"app_id", "steam_id", "username"})
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Name:      "player_info",
+		Help:      "Username",
+	}, []string{"steam_id", "username"})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(playtime, info)
+	set(playtime, info)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	return families
+}
+
+// series formats every series of families as name{labels} value, sorted
+func series(families []*dto.MetricFamily) []string {
+	var lines []string
+	for _, mf := range families {
+		for _, m := range mf.Metric {
+			labels := make([]string, 0, len(m.Label))
+			for _, pair := range m.Label {
+				labels = append(labels, fmt.Sprintf("%s=%q", pair.GetName(), pair.GetValue()))
+			}
+			lines = append(lines, fmt.Sprintf("%s{%s} %v", mf.GetName(), strings.Join(labels, ","), m.GetGauge().GetValue()))
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestApply(t *testing.T) {
+	oneUser := func(playtime *prometheus.GaugeVec, info *prometheus.GaugeVec) {
+		playtime.WithLabelValues("440", "1", "robin").Set(300)
+		info.WithLabelValues("1", "robin").Set(1)
+	}
+	// A rename the collector didn't replace leaves series that only differ in the username
+	renamed := func(values ...float64) func(playtime *prometheus.GaugeVec, info *prometheus.GaugeVec) {
+		return func(playtime *prometheus.GaugeVec, info *prometheus.GaugeVec) {
+			playtime.WithLabelValues("440", "1", "robin").Set(values[0])
+			playtime.WithLabelValues("440", "1", "robin_hood").Set(values[1])
+			playtime.WithLabelValues("570", "1", "robin").Set(60)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		rules Rules
+		set   func(playtime *prometheus.GaugeVec, info *prometheus.GaugeVec)
+		want  []string
+	}{
+		{
+			name:  "no rules",
+			rules: Rules{},
+			set:   oneUser,
+			want: []string{
+				`steam_player_info{steam_id="1",username="robin"} 1`,
+				`steam_playtime_seconds{app_id="440",steam_id="1",username="robin"} 300`,
+			},
+		},
+		{
+			name:  "namespace override",
+			rules: Rules{Namespaces: map[string]string{"steam": "games_steam", "osrs": "games_osrs"}},
+			set:   oneUser,
+			want: []string{
+				`games_steam_player_info{steam_id="1",username="robin"} 1`,
+				`games_steam_playtime_seconds{app_id="440",steam_id="1",username="robin"} 300`,
+			},
+		},
+		{
+			name:  "prefix after the namespace override",
+			rules: Rules{Namespaces: map[string]string{"steam": "games_steam"}, Prefix: "smiths_"},
+			set:   oneUser,
+			want: []string{
+				`smiths_games_steam_player_info{steam_id="1",username="robin"} 1`,
+				`smiths_games_steam_playtime_seconds{app_id="440",steam_id="1",username="robin"} 300`,
+			},
+		},
+		{
+			name:  "dropped label",
+			rules: Rules{DropLabels: []string{"username"}},
+			set:   oneUser,
+			want: []string{
+				`steam_player_info{steam_id="1"} 1`,
+				`steam_playtime_seconds{app_id="440",steam_id="1"} 300`,
+			},
+		},
+		{
+			name:  "static labels",
+			rules: Rules{StaticLabels: map[string]string{"env": "home", "username": "static"}},
+			set:   oneUser,
+			want: []string{
+				`steam_player_info{env="home",steam_id="1",username="robin"} 1`,
+				`steam_playtime_seconds{app_id="440",env="home",steam_id="1",username="robin"} 300`,
+			},
+		},
+		{
+			name:  "info labels",
+			rules: Rules{InfoLabels: map[string]string{"username": "steam_player_info"}},
+			set:   oneUser,
+			want: []string{
+				`steam_player_info{steam_id="1",username="robin"} 1`,
+				`steam_playtime_seconds{app_id="440",steam_id="1"} 300`,
+			},
+		},
+		{
+			name:  "collision with the same value",
+			rules: Rules{DropLabels: []string{"username"}},
+			set:   renamed(300, 300),
+			want: []string{
+				`steam_playtime_seconds{app_id="440",steam_id="1"} 300`,
+				`steam_playtime_seconds{app_id="570",steam_id="1"} 60`,
+			},
+		},
+		{
+			// Neither value is known to be current, so neither is served
+			name:  "collision with conflicting values",
+			rules: Rules{DropLabels: []string{"username"}},
+			set:   renamed(300, 420),
+			want: []string{
+				`steam_playtime_seconds{app_id="570",steam_id="1"} 60`,
+			},
+		},
+		{
+			name:  "collision with conflicting values through info labels",
+			rules: Rules{InfoLabels: map[string]string{"username": "steam_player_info"}},
+			set:   renamed(420, 300),
+			want: []string{
+				`steam_playtime_seconds{app_id="570",steam_id="1"} 60`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			families := gather(t, tt.set)
+			before := series(families)
+
+			got := series(tt.rules.Apply(families))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Apply =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			// The gathered families may be served again, so they're left as they were
+			if after := series(families); strings.Join(after, "\n") != strings.Join(before, "\n") {
+				t.Errorf("Apply modified its input:\n%s", strings.Join(after, "\n"))
+			}
+		})
+	}
+}
+
+func TestApplyDropsEmptyFamilies(t *testing.T) {
+	families := gather(t, func(playtime *prometheus.GaugeVec, info *prometheus.GaugeVec) {
+		playtime.WithLabelValues("440", "1", "robin").Set(300)
+		playtime.WithLabelValues("440", "1", "robin_hood").Set(420)
+	})
+
+	// Every series of the family conflicts, which leaves nothing to serve it with
+	rewritten := Rules{DropLabels: []string{"username"}}.Apply(families)
+	if len(rewritten) != 0 {
+		t.Errorf("Apply = %v, want no families", series(rewritten))
+	}
+}
@@ -0,0 +1,289 @@
+// Package schema detects drift in upstream JSON responses: fields the decoded structs expect
+// that are missing, and fields that weren't there before. Both would otherwise decode silently
+// into zero values or nothing, and only show up once dashboards flatline.
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// KindMissing is a field the decoded struct expects that the response left out
+	KindMissing = "missing_field"
+	// KindUnknown is a field that appeared in a response after the endpoint's baseline was taken
+	KindUnknown = "unknown_field"
+
+	keyPrefix = "schema:"
+	// Baselines are re-saved whenever they gain a field; an expired one is taken again
+	baselineTTL = 90 * 24 * time.Hour
+)
+
+var schemaWarningsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "exporter",
+	Name:      "upstream_schema_warnings_total",
+	Help:      "Upstream responses that drifted from their expected schema: missing_field (expected and left out) or unknown_field (new since the baseline)",
+}, []string{"upstream", "endpoint", "kind", "field"})
+
+func init() {
+	prometheus.MustRegister(schemaWarningsCounter)
+}
+
+// Store persists the baselines, so fields aren't learned afresh on every restart
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+}
+
+// Checker compares an upstream's responses with the structs they're decoded into. Fields a
+// struct declares are expected unless tagged `schema:"optional"`. Fields it doesn't declare
+// are learned: the first response of an endpoint is its baseline, and a field first seen
+// after that is reported once and added to the baseline.
+//
+// A nil Checker checks nothing.
+type Checker struct {
+	upstream string
+	store    Store
+
+	mu        sync.Mutex
+	baselines map[string]map[string]bool // Field paths seen per endpoint
+	logged    map[string]bool            // Missing fields already logged, by endpoint and path
+}
+
+// NewChecker creates a Checker for an upstream (e.g. "steam"), keeping baselines in store
+func NewChecker(upstream string, store Store) *Checker {
+	return &Checker{
+		upstream:  upstream,
+		store:     store,
+		baselines: make(map[string]map[string]bool),
+		logged:    make(map[string]bool),
+	}
+}
+
+// Check reports the drift of a response body from an endpoint, decoded successfully into
+// target. Paths name fields from the top of the response, e.g. response.games[].appid.
+func (c *Checker) Check(ctx context.Context, endpoint string, body []byte, target interface{}) {
+	if c == nil {
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return
+	}
+
+	missing := make(map[string]bool)
+	missingFields(value, reflect.TypeOf(target), "", missing)
+	for _, path := range sortedPaths(missing) {
+		c.warn(endpoint, KindMissing, path)
+	}
+
+	present := make(map[string]bool)
+	presentFields(value, "", present)
+	declared := make(map[string]bool)
+	declaredFields(reflect.TypeOf(target), "", declared)
+	for _, path := range c.learn(ctx, endpoint, present, declared) {
+		c.warn(endpoint, KindUnknown, path)
+	}
+}
+
+// learn adds the present fields to the endpoint's baseline, returning the ones new to it that
+// the target doesn't declare either. Nothing is new to a baseline taken from this response.
+func (c *Checker) learn(ctx context.Context, endpoint string, present map[string]bool, declared map[string]bool) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	baseline, ok := c.baselines[endpoint]
+	if !ok {
+		baseline = c.load(ctx, endpoint)
+		c.baselines[endpoint] = baseline
+	}
+	taken := len(baseline) > 0
+
+	var added []string
+	for path := range present {
+		if baseline[path] {
+			continue
+		}
+		baseline[path] = true
+		if taken && !declared[path] {
+			added = append(added, path)
+		}
+	}
+	if !taken || len(added) > 0 {
+		c.save(ctx, endpoint, baseline)
+	}
+	sort.Strings(added)
+	return added
+}
+
+func (c *Checker) load(ctx context.Context, endpoint string) map[string]bool {
+	baseline := make(map[string]bool)
+	if c.store == nil {
+		return baseline
+	}
+	data, exists := c.store.Get(ctx, keyPrefix+c.upstream+":"+endpoint)
+	if !exists {
+		return baseline
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"upstream": c.upstream,
+			"endpoint": endpoint,
+			"error":    err.Error(),
+		}).Warn("Dropping unreadable schema baseline")
+		return baseline
+	}
+	for _, path := range paths {
+		baseline[path] = true
+	}
+	return baseline
+}
+
+func (c *Checker) save(ctx context.Context, endpoint string, baseline map[string]bool) {
+	if c.store == nil {
+		return
+	}
+	data, err := json.Marshal(sortedPaths(baseline))
+	if err != nil {
+		return
+	}
+	c.store.Set(ctx, keyPrefix+c.upstream+":"+endpoint, data, baselineTTL)
+}
+
+// warn counts a drifted field. Missing fields stay missing, so they're only logged the first
+// time; unknown fields are only reported once anyway.
+func (c *Checker) warn(endpoint string, kind string, path string) {
+	schemaWarningsCounter.WithLabelValues(c.upstream, endpoint, kind, path).Inc()
+
+	if kind == KindMissing {
+		c.mu.Lock()
+		logged := c.logged[endpoint+" "+path]
+		c.logged[endpoint+" "+path] = true
+		c.mu.Unlock()
+		if logged {
+			return
+		}
+	}
+	logger.Log.WithFields(logrus.Fields{
+		"upstream": c.upstream,
+		"endpoint": endpoint,
+		"kind":     kind,
+		"field":    path,
+	}).Warn("Upstream response drifted from its expected schema")
+}
+
+// missingFields adds the paths of the fields t declares (and doesn't tag optional) that value
+// leaves out. Fields of array elements are checked in every element.
+func missingFields(value interface{}, t reflect.Type, path string, missing map[string]bool) {
+	t = indirect(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, optional := jsonField(field)
+			if name == "" {
+				continue
+			}
+			fieldValue, present := object[name]
+			if !present {
+				if !optional {
+					missing[join(path, name)] = true
+				}
+				continue
+			}
+			missingFields(fieldValue, field.Type, join(path, name), missing)
+		}
+	case reflect.Slice, reflect.Array:
+		elements, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for _, element := range elements {
+			missingFields(element, t.Elem(), path+"[]", missing)
+		}
+	}
+}
+
+// presentFields adds the paths of every field in value
+func presentFields(value interface{}, path string, present map[string]bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, fieldValue := range value {
+			present[join(path, name)] = true
+			presentFields(fieldValue, join(path, name), present)
+		}
+	case []interface{}:
+		for _, element := range value {
+			presentFields(element, path+"[]", present)
+		}
+	}
+}
+
+// declaredFields adds the paths of every field t declares, optional or not
+func declaredFields(t reflect.Type, path string, declared map[string]bool) {
+	t = indirect(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if name, _ := jsonField(field); name != "" {
+				declared[join(path, name)] = true
+				declaredFields(field.Type, join(path, name), declared)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		declaredFields(t.Elem(), path+"[]", declared)
+	}
+}
+
+// jsonField returns the JSON name of a struct field, empty for fields encoding/json skips
+func jsonField(field reflect.StructField) (name string, optional bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, field.Tag.Get("schema") == "optional"
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func join(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedPaths(paths map[string]bool) []string {
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -2,16 +2,26 @@ package steam
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/debugraw"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/httpcache"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/schema"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -20,40 +30,83 @@ const (
 	AchievementsEndpoint          = "/ISteamUserStats/GetUserStatsForGame/v0002/"
 	GlobalAchievementsEndpoint    = "/ISteamUserStats/GetGlobalAchievementPercentagesForApp/v0002/"
 	PlayerSummariesEndpoint       = "/ISteamUser/GetPlayerSummaries/v0002/"
+	FriendListEndpoint            = "/ISteamUser/GetFriendList/v0001/"
+	PlayerAchievementsEndpoint    = "/ISteamUserStats/GetPlayerAchievements/v0001/"
+	WorkshopFilesEndpoint         = "/IPublishedFileService/GetUserFiles/v1/"
 )
 
+// conditionalEndpoints are fetched with the validators of the previous response (see
+// internal/httpcache), as they rarely change
+var conditionalEndpoints = map[string]bool{
+	GlobalAchievementsEndpoint: true,
+}
+
 type Client struct {
-	apiKey     string
+	keys       *keyPool
 	httpClient *http.Client
-	rateLimit  *RateLimitState
+	responses  httpcache.Store
+	schema     *schema.Checker // Reports responses drifting from the types below
 }
 
-func NewClient(apiKey string, rateLimit *RateLimitState) *Client {
+func newClient(keys *keyPool, responses httpcache.Store, transport http.RoundTripper) *Client {
 	return &Client{
-		apiKey: apiKey,
+		keys:      keys,
+		responses: responses,
+		schema:    schema.NewChecker("steam", responses),
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: transport,
 		},
-		rateLimit: rateLimit,
 	}
 }
 
-func (c *Client) getJSON(url string, params map[string]string, target interface{}) error {
-	// Check rate limiting first
-	if c.rateLimit != nil && c.rateLimit.CheckAndBlock() {
-		return fmt.Errorf("steam API rate limited - backoff period active")
+func (c *Client) getJSON(ctx context.Context, url string, params map[string]string, target interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "steam.api", attribute.String("steam.endpoint", strings.TrimPrefix(url, APIOrigin)))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return err
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	// A 403/429 puts only that key into backoff; retry the request with the next key
+	for attempt := 0; attempt < len(c.keys.keys); attempt++ {
+		key := c.keys.acquire()
+		if key == nil {
+			break
+		}
+		span.SetAttributes(attribute.String("steam.api_key", key.name))
+
+		var rotate bool
+		rotate, err = c.request(ctx, key, url, params, target)
+		if !rotate || attempt+1 == len(c.keys.keys) {
+			return err
+		}
+		logger.Log.WithFields(logrus.Fields{
+			"api_key": key.name,
+			"keys":    len(c.keys.keys),
+		}).Warn("Steam API key rate limited - trying the next key")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
+	}
+	return fmt.Errorf("steam API rate limited - backoff period active")
+}
+
+// request makes one request with key. rotate is true when the key was rate limited and
+// another key may succeed.
+func (c *Client) request(ctx context.Context, key *apiKey, url string, params map[string]string, target interface{}) (rotate bool, err error) {
+	span := trace.SpanFromContext(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	q := req.URL.Query()
 	for k, v := range params {
 		q.Add(k, v)
 	}
-	q.Add("key", c.apiKey)
+	q.Add("key", key.key)
 	q.Add("format", "json")
 	req.URL.RawQuery = q.Encode()
 
@@ -73,83 +126,100 @@ func (c *Client) getJSON(url string, params map[string]string, target interface{
 		"params": strings.Join(debugQuery, "&"),
 	}).Debug("Making Steam API request")
 
-	resp, err := c.httpClient.Do(req)
+	var resp *http.Response
+	if endpoint := strings.TrimPrefix(url, APIOrigin); conditionalEndpoints[endpoint] {
+		// Keyed without the API key, so every key shares the stored response
+		query := make(neturl.Values, len(params))
+		for k, v := range params {
+			query.Set(k, v)
+		}
+		resp, err = httpcache.Do(c.httpClient, c.responses, "steam", "steam:"+endpoint+"?"+query.Encode(), req)
+	} else {
+		resp, err = c.httpClient.Do(req)
+	}
 	if err != nil {
+		// The request URL carries the API key; keep it out of logs and errors shown by /admin/check
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = c.keys.redact(urlErr.URL)
+		}
 		logger.Log.WithError(err).Error("Steam API request failed")
-		return fmt.Errorf("request failed: %w", err)
+		return false, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logger.Log.WithError(err).Error("Failed to read Steam API response body")
-		return fmt.Errorf("failed to read response body: %w", err)
+		return false, fmt.Errorf("failed to read response body: %w", err)
 	}
+	debugraw.Record(ctx, c.keys.redact(req.URL.String()), resp.StatusCode, body)
 
 	logger.Log.WithFields(logrus.Fields{
 		"status_code": resp.StatusCode,
 		"body_length": len(body),
 	}).Debug("Steam API response received")
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", resp.StatusCode),
+		attribute.Int("http.response.body.size", len(body)),
+	)
 
 	switch resp.StatusCode {
 	case http.StatusOK:
 		// Success - reset rate limit tracking
-		if c.rateLimit != nil {
-			c.rateLimit.RecordSuccess()
-		}
+		key.rateLimit.RecordSuccess()
 		logger.Log.Debug("Steam API request successful")
 	case http.StatusTooManyRequests:
 		logger.Log.Error("Steam API rate limit exceeded (429)")
-		if c.rateLimit != nil {
-			c.rateLimit.Record403() // Treat 429 same as 403 for rate limiting
-		}
-		return fmt.Errorf("rate limited by Steam API (429)")
+		key.rateLimit.Record403() // Treat 429 same as 403 for rate limiting
+		return true, fmt.Errorf("rate limited by Steam API (429)")
 	case http.StatusUnauthorized:
 		logger.Log.Error("Steam API unauthorized (401) - check API key")
-		return fmt.Errorf("unauthorized (401) - check your Steam API key")
+		return false, fmt.Errorf("unauthorized (401) - check your Steam API key")
 	case http.StatusForbidden:
 		// 403 can mean rate limiting OR legitimate "no access" (like games with no achievements)
 		// We need to be aggressive and treat it as rate limiting to avoid permanent ban
-		if c.rateLimit != nil {
-			c.rateLimit.Record403()
-		}
+		key.rateLimit.Record403()
 		logger.Log.Error("Steam API forbidden (403) - treating as rate limit, backing off aggressively")
-		return fmt.Errorf("forbidden (403) - Steam API rate limit detected, backing off")
+		return true, fmt.Errorf("forbidden (403) - Steam API rate limit detected, backing off")
 	case http.StatusBadRequest:
 		logger.Log.WithFields(logrus.Fields{
 			"status_code": resp.StatusCode,
 			"body":        string(body),
 		}).Error("Steam API bad request (400)")
-		return fmt.Errorf("bad request (400): %s", string(body))
+		return false, fmt.Errorf("bad request (400): %s", string(body))
 	default:
 		logger.Log.WithFields(logrus.Fields{
 			"status_code": resp.StatusCode,
 			"body":        string(body),
 		}).Error("Unexpected Steam API response")
-		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return false, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Check if the response starts with HTML (common error case)
 	if len(body) > 0 && body[0] == '<' {
 		logger.Log.WithField("body", string(body)).Error("Received HTML instead of JSON from Steam API")
-		return fmt.Errorf("received HTML instead of JSON. Response: %s", string(body))
+		return false, fmt.Errorf("received HTML instead of JSON. Response: %s", string(body))
 	}
 
+	_, decodeSpan := tracing.Start(ctx, "steam.decode")
 	err = json.NewDecoder(bytes.NewReader(body)).Decode(target)
+	tracing.End(decodeSpan, err)
 	if err != nil {
 		bodyPreview := string(body)
 		if len(bodyPreview) > 200 {
 			bodyPreview = bodyPreview[:200] + "..."
 		}
 		logger.Log.WithError(err).WithField("body_preview", bodyPreview).Error("Failed to decode Steam API JSON response")
-		return fmt.Errorf("failed to decode JSON: %w, body: %s", err, string(body))
+		return false, fmt.Errorf("failed to decode JSON: %w, body: %s", err, string(body))
 	}
+	c.schema.Check(ctx, strings.TrimPrefix(url, APIOrigin), body, target)
 
-	return nil
+	return false, nil
 }
 
 // GetOwnedGames retrieves the list of games owned by a Steam user
-func (c *Client) GetOwnedGames(steamId string) (OwnedGamesResponse, error) {
+func (c *Client) GetOwnedGames(ctx context.Context, steamId string) (OwnedGamesResponse, error) {
 	logger.Log.WithField("steam_id", steamId).Info("Fetching owned games from Steam API")
 
 	// Validate Steam ID format (should be numeric)
@@ -167,7 +237,7 @@ func (c *Client) GetOwnedGames(steamId string) (OwnedGamesResponse, error) {
 		return OwnedGamesResponse{}, fmt.Errorf("invalid Steam ID format: '%s' - Steam IDs must be numeric (e.g., 76561198000000000). You may have used a username instead", steamId)
 	}
 
-	if c.apiKey == "" {
+	if len(c.keys.keys) == 0 {
 		logger.Log.Error("Steam API key not configured")
 		return OwnedGamesResponse{}, fmt.Errorf("Steam API key is not configured - set STEAM_KEY environment variable")
 	}
@@ -181,7 +251,7 @@ func (c *Client) GetOwnedGames(steamId string) (OwnedGamesResponse, error) {
 	}
 
 	var httpResp OwnedGamesHttpResponse
-	err := c.getJSON(url, params, &httpResp)
+	err := c.getJSON(ctx, url, params, &httpResp)
 	if err != nil {
 		logger.Log.WithFields(logrus.Fields{
 			"steam_id": steamId,
@@ -199,7 +269,7 @@ func (c *Client) GetOwnedGames(steamId string) (OwnedGamesResponse, error) {
 }
 
 // GetUserStatsForGame retrieves achievement data for a specific game and user
-func (c *Client) GetUserStatsForGame(steamId string, appId uint64) (AchievementResponse, error) {
+func (c *Client) GetUserStatsForGame(ctx context.Context, steamId string, appId uint64) (AchievementResponse, error) {
 	url := APIOrigin + AchievementsEndpoint
 
 	params := map[string]string{
@@ -208,7 +278,7 @@ func (c *Client) GetUserStatsForGame(steamId string, appId uint64) (AchievementR
 	}
 
 	var achievementResp AchievementResponse
-	err := c.getJSON(url, params, &achievementResp)
+	err := c.getJSON(ctx, url, params, &achievementResp)
 	if err != nil {
 		return AchievementResponse{}, err
 	}
@@ -216,8 +286,26 @@ func (c *Client) GetUserStatsForGame(steamId string, appId uint64) (AchievementR
 	return achievementResp, nil
 }
 
+// GetPlayerAchievements retrieves a user's achievements for a game with their unlock times
+func (c *Client) GetPlayerAchievements(ctx context.Context, steamId string, appId uint64) (PlayerAchievementsResponse, error) {
+	url := APIOrigin + PlayerAchievementsEndpoint
+
+	params := map[string]string{
+		"steamid": steamId,
+		"appid":   strconv.FormatUint(appId, 10),
+	}
+
+	var achievementsResp PlayerAchievementsResponse
+	err := c.getJSON(ctx, url, params, &achievementsResp)
+	if err != nil {
+		return PlayerAchievementsResponse{}, err
+	}
+
+	return achievementsResp, nil
+}
+
 // GetGlobalAchievementPercentages retrieves the list of all achievements for a game
-func (c *Client) GetGlobalAchievementPercentages(appId uint64) (GlobalAchievementResponse, error) {
+func (c *Client) GetGlobalAchievementPercentages(ctx context.Context, appId uint64) (GlobalAchievementResponse, error) {
 	url := APIOrigin + GlobalAchievementsEndpoint
 
 	params := map[string]string{
@@ -225,7 +313,7 @@ func (c *Client) GetGlobalAchievementPercentages(appId uint64) (GlobalAchievemen
 	}
 
 	var globalResp GlobalAchievementResponse
-	err := c.getJSON(url, params, &globalResp)
+	err := c.getJSON(ctx, url, params, &globalResp)
 	if err != nil {
 		return GlobalAchievementResponse{}, err
 	}
@@ -234,7 +322,7 @@ func (c *Client) GetGlobalAchievementPercentages(appId uint64) (GlobalAchievemen
 }
 
 // GetPlayerSummaries retrieves player information including username (personaname) from Steam IDs
-func (c *Client) GetPlayerSummaries(steamIds []string) ([]PlayerSummary, error) {
+func (c *Client) GetPlayerSummaries(ctx context.Context, steamIds []string) ([]PlayerSummary, error) {
 	if len(steamIds) == 0 {
 		return nil, fmt.Errorf("steamIds cannot be empty")
 	}
@@ -247,7 +335,7 @@ func (c *Client) GetPlayerSummaries(steamIds []string) ([]PlayerSummary, error)
 	}
 
 	var resp PlayerSummariesResponse
-	err := c.getJSON(url, params, &resp)
+	err := c.getJSON(ctx, url, params, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -255,3 +343,43 @@ func (c *Client) GetPlayerSummaries(steamIds []string) ([]PlayerSummary, error)
 	return resp.Response.Players, nil
 }
 
+// GetFriendList retrieves the Steam IDs of a user's friends. Steam answers 401 when the user's
+// friend list is private.
+func (c *Client) GetFriendList(ctx context.Context, steamId string) ([]string, error) {
+	url := APIOrigin + FriendListEndpoint
+
+	params := map[string]string{
+		"steamid":      steamId,
+		"relationship": "friend",
+	}
+
+	var resp FriendListResponse
+	if err := c.getJSON(ctx, url, params, &resp); err != nil {
+		return nil, err
+	}
+
+	friends := make([]string, 0, len(resp.FriendsList.Friends))
+	for _, friend := range resp.FriendsList.Friends {
+		friends = append(friends, friend.SteamID)
+	}
+	return friends, nil
+}
+
+// GetWorkshopFiles retrieves a page (from 1) of the Workshop items published by a user, across
+// every game
+func (c *Client) GetWorkshopFiles(ctx context.Context, steamId string, page int, perPage int) (WorkshopFilesResponse, error) {
+	url := APIOrigin + WorkshopFilesEndpoint
+
+	params := map[string]string{
+		"steamid":    steamId,
+		"appid":      "0",
+		"page":       strconv.Itoa(page),
+		"numperpage": strconv.Itoa(perPage),
+	}
+
+	var resp WorkshopFilesResponse
+	if err := c.getJSON(ctx, url, params, &resp); err != nil {
+		return WorkshopFilesResponse{}, err
+	}
+	return resp, nil
+}
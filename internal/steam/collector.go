@@ -1,189 +1,449 @@
 package steam
 
 import (
+	"context"
+	"errors"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cardinality"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/daily"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
 )
 
+// Owned games are considered fresh for 30 minutes by default (see SetOwnedGamesTTL)
+// and served stale for as long again while refreshing
+const defaultOwnedGamesTTL = 30 * time.Minute
+
 type Collector struct {
-	client    *Client
-	cache     *cache.Cache
-	rateLimit *RateLimitState
+	client        *Client
+	cache         *cache.Cache
+	rateLimit     *keyPool // Blocked only when every API key is rate limited
+	ownedGamesTTL atomic.Int64 // time.Duration, changed on config reload
+
+	achievementDelay time.Duration // Pause before each user achievements request
+	store            *StoreClient  // Enriches steam_game_info; nil unless Config.GameInfo is set
+	compat           bool          // Add ProtonDB and Deck compatibility to steam_game_info
+	friends          bool          // Export friend counts (steam_friends*)
+	workshop         bool          // Export the user's Workshop items (steam_workshop_*)
+	profile          *StoreClient  // Reads steam_profile_content_count from the community profile; nil unless Config.ProfileContent is set
+	infoMetrics      bool          // Report steam_game_info for every owned game, for the game_name joins
+
+	statsMu   sync.RWMutex
+	statsApps map[uint64]bool // Apps whose stats are exported (stats_apps), changed on config reload
+	daily            *daily.Tracker // Playtime since local midnight
+
+	achievementLimit     int                 // Achievement series reported per game and user, 0 for all
+	achievementBudget    *cardinality.Budget // Caps achievement series across users and games
+	achievementRefreshes int                 // Games whose achievements a collection refreshes from Steam, 0 for all
+
+	collections cache.InFlight // One collection per user at a time
+}
+
+// Config configures the Steam collector
+type Config struct {
+	APIKeys        []string          // Requests rotate between the keys, each with its own rate limit backoff
+	KeyRotation    string            // RotationRoundRobin (default) or RotationFailover
+	Backoff        BackoffPolicy     // Zero fields use DefaultBackoffPolicy's
+	Transport      http.RoundTripper // Upstream transport, e.g. fixture replay; nil for the default
+	GameInfo       bool              // Export steam_game_info with store genres, release year and Metacritic score
+	GameCompat     bool              // Add ProtonDB tier and Steam Deck status to steam_game_info (implies GameInfo)
+	Friends        bool              // Export the user's friend count and how many are online and in game
+	Workshop       bool              // Export the subscribers and favorites of the user's Workshop items
+	ProfileContent bool              // Export the screenshot, review and guide counts of the user's community profile
+	InfoMetrics    bool              // Report steam_game_info for every owned game, even without store metadata (see InfoLabels)
+	DayLocation    *time.Location    // Where steam_playtime_today_seconds resets at midnight; nil for the local time zone
+
+	MaxAchievementsPerGame  int // Achievement series per game and user, 0 for unlimited
+	MaxAchievementSeries    int // Achievement series in total, 0 for unlimited
+	MaxAchievementRefreshes int // Games whose achievements one collection refreshes from Steam, 0 for unlimited
 }
 
-func NewCollector(apiKey string, cache *cache.Cache) *Collector {
-	rateLimit := NewRateLimitState(cache)
-	return &Collector{
-		client:    NewClient(apiKey, rateLimit),
+func NewCollector(config Config, cache *cache.Cache) *Collector {
+	backoff := config.Backoff
+	if backoff.Initial <= 0 {
+		backoff.Initial = DefaultBackoffPolicy.Initial
+	}
+	if backoff.Multiplier < 1 {
+		backoff.Multiplier = DefaultBackoffPolicy.Multiplier
+	}
+	if backoff.Max <= 0 {
+		backoff.Max = DefaultBackoffPolicy.Max
+	}
+
+	keys := newKeyPool(config.APIKeys, config.KeyRotation, backoff, cache)
+	c := &Collector{
+		client:    newClient(keys, cache, config.Transport),
 		cache:     cache,
-		rateLimit: rateLimit,
+		rateLimit: keys,
+
+		achievementDelay: 5 * time.Second,
+		friends:          config.Friends,
+		workshop:         config.Workshop,
+		infoMetrics:      config.InfoMetrics,
+		daily:            daily.NewTracker(cache, config.DayLocation),
+
+		achievementLimit:     config.MaxAchievementsPerGame,
+		achievementBudget:    cardinality.NewBudget("steam", config.MaxAchievementSeries),
+		achievementRefreshes: config.MaxAchievementRefreshes,
+	}
+	if config.GameInfo || config.GameCompat {
+		c.store = NewStoreClient(config.Transport)
+		c.compat = config.GameCompat
+	}
+	if config.ProfileContent {
+		c.profile = NewStoreClient(config.Transport)
 	}
+	c.SetOwnedGamesTTL(defaultOwnedGamesTTL)
+	return c
 }
 
-// Collect collects and reports all Steam metrics for a user
-func (c *Collector) Collect(steamId string) error {
-	logger.Log.WithField("steam_id", steamId).Info("Starting Steam metrics collection")
+// SetOwnedGamesTTL changes how long owned games are cached (0 restores the default);
+// existing entries keep their TTL
+func (c *Collector) SetOwnedGamesTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultOwnedGamesTTL
+	}
+	c.ownedGamesTTL.Store(int64(ttl))
+}
 
-	// Get username (from cache or API)
-	username, err := c.getUsername(steamId)
-	if err != nil {
-		logger.Log.WithFields(logrus.Fields{
-			"steam_id": steamId,
-			"error":    err.Error(),
-		}).Warn("Failed to get username, continuing without username label")
-		username = "" // Fallback to empty string if username lookup fails
-	} else {
-		logger.Log.WithFields(logrus.Fields{
-			"steam_id": steamId,
-			"username": username,
-		}).Debug("Retrieved username for Steam user")
-	}
-
-    // Get owned games (from cache or API)
-    ownedGamesResp, err := c.getOwnedGames(steamId)
-    if err != nil {
-        // If rate limited, attempt to serve from cache instead of failing
-        if strings.Contains(strings.ToLower(err.Error()), "rate limited") {
-            cacheKey := fmt.Sprintf("steam:owned_games:%s", steamId)
-            if cachedData, exists := c.cache.Get(cacheKey); exists {
-                var cachedResp OwnedGamesResponse
-                if uerr := json.Unmarshal(cachedData, &cachedResp); uerr == nil && len(cachedResp.Games) > 0 {
-                    logger.Log.WithFields(logrus.Fields{
-                        "steam_id": steamId,
-                        "game_count": len(cachedResp.Games),
-                    }).Warn("Rate limited: using cached owned games to serve metrics")
-                    ownedGamesResp = cachedResp
-                } else {
-                    logger.Log.WithFields(logrus.Fields{
-                        "steam_id": steamId,
-                        "error":    err.Error(),
-                    }).Error("Rate limited and no cached owned games available")
-                    return fmt.Errorf("failed to get owned games: %w", err)
-                }
-            } else {
-                logger.Log.WithFields(logrus.Fields{
-                    "steam_id": steamId,
-                    "error":    err.Error(),
-                }).Error("Rate limited and owned games cache miss")
-                return fmt.Errorf("failed to get owned games: %w", err)
-            }
-        } else {
-            logger.Log.WithFields(logrus.Fields{
-                "steam_id": steamId,
-                "error":    err.Error(),
-            }).Error("Failed to get owned games")
-            return fmt.Errorf("failed to get owned games: %w", err)
-        }
-    }
+// Collect collects and reports all Steam metrics for a user. A call made while the user is
+// already being collected waits for that collection and returns its result.
+func (c *Collector) Collect(ctx context.Context, steamId string) error {
+	target := steamId
+	if SkipAchievements(ctx) {
+		target += ":without_achievements"
+	}
+	return c.collections.Do(ctx, target, func(ctx context.Context) error {
+		return c.collect(ctx, steamId)
+	})
+}
 
-	logger.Log.WithFields(logrus.Fields{
-		"steam_id":   steamId,
-		"game_count": len(ownedGamesResp.Games),
-	}).Info("Processing owned games")
+// collect collects and reports all Steam metrics for a user
+func (c *Collector) collect(ctx context.Context, steamId string) (err error) {
+	ctx, span := tracing.Start(ctx, "steam.collect", attribute.String("steam.id", steamId))
+	defer func() { tracing.End(span, err) }()
+
+	logger.FromContext(ctx).WithField("steam_id", steamId).Info("Starting Steam metrics collection")
+
+	// What the last collection reported, to fall back on when Steam can't be reached
+	previous := c.loadReported(ctx, steamId)
+
+	// The username and the owned games are independent requests, made concurrently. Without
+	// the owned games no game can be reported, but the metrics that don't depend on them still are.
+	var username string
+	var ownedGamesResp OwnedGamesResponse
+	var fetches errgroup.Group
+	fetches.Go(func() error {
+		username = c.usernameOrEmpty(ctx, steamId)
+		if username == "" && previous != nil {
+			username = previous.Username
+		}
+		return nil
+	})
+	fetches.Go(func() (err error) {
+		ownedGamesResp, err = c.ownedGamesOrCached(ctx, steamId)
+		return err
+	})
+	ownedGamesErr := fetches.Wait()
+
+	// Without a username the last one reported stands
+	if username != "" {
+		ReportPlayerInfo(steamId, username)
+	}
+
+	// Once the owned games have expired from the cache (e.g. after a restart during a backoff),
+	// the games of the last collection are reported rather than none
+	restored := false
+	if ownedGamesErr != nil && previous != nil && canRestore(ownedGamesErr) {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id":   steamId,
+			"game_count": len(previous.Games),
+			"collected":  previous.Collected,
+		}).Warn("Owned games unavailable: reporting the games of the last collection")
+		games := previous.ownedGames()
+		ownedGamesResp = OwnedGamesResponse{GameCount: uint(len(games)), Games: games}
+		ownedGamesErr, restored = nil, true
+		cache.FreshnessFromContext(ctx).Record(previous.Collected)
+	}
+
+	if ownedGamesErr == nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id":   steamId,
+			"game_count": len(ownedGamesResp.Games),
+		}).Info("Processing owned games")
+	}
 
 	// Check if we're rate limited at the start - if so, we'll use cache-only mode
 	isRateLimited := c.rateLimit != nil && c.rateLimit.CheckAndBlock()
 
-	// Report playtime for all games
-	for _, game := range ownedGamesResp.Games {
+	// The optional per-user metrics each make their own requests, so they're collected
+	// concurrently too; each logs and skips its own failures
+	var reports errgroup.Group
+	if ownedGamesErr == nil {
+		// Store metadata comes from the store API, which isn't subject to the Web API's rate limit
+		if c.store != nil {
+			reports.Go(func() error {
+				c.reportGameInfo(ctx, steamId, username, ownedGamesResp.Games)
+				return nil
+			})
+		} else if c.infoMetrics {
+			reportGameNames(ownedGamesResp.Games, steamId, username)
+		}
+
+		c.reportPlaytimeToday(ctx, steamId, username, ownedGamesResp.Games)
+
+		reports.Go(func() error {
+			c.reportGameStats(ctx, steamId, username, ownedGamesResp.Games)
+			return nil
+		})
+	}
+
+	if c.friends {
+		reports.Go(func() error {
+			c.reportFriends(ctx, steamId, username)
+			return nil
+		})
+	}
+
+	if c.workshop {
+		reports.Go(func() error {
+			c.reportWorkshop(ctx, steamId, username)
+			return nil
+		})
+	}
+
+	if c.profile != nil {
+		reports.Go(func() error {
+			c.reportProfileContent(ctx, steamId, username)
+			return nil
+		})
+	}
+	reports.Wait()
+
+	// Series of a previous username or game name are moved over to the current ones before the
+	// games are reported under them
+	var gameNames map[string]string
+	if ownedGamesErr == nil {
+		gameNames = make(map[string]string, len(ownedGamesResp.Games))
+		for _, game := range ownedGamesResp.Games {
+			gameNames[strconv.FormatUint(game.AppId, 10)] = game.Name
+		}
+	}
+	ReportNames(steamId, username, gameNames)
+
+	if ownedGamesErr != nil {
+		return &CollectionError{SteamID: steamId, OwnedGames: ownedGamesErr}
+	}
+
+	// Load every game's cached achievements in one round trip instead of 2 GETs per game
+	preloaded := c.preloadAchievementCache(ctx, steamId, ownedGamesResp.Games)
+
+	// With a refresh budget, the games whose achievements were fetched longest ago get it, and
+	// the rest are refreshed by later collections
+	budget := newRefreshBudget(c.achievementRefreshes)
+	fetched := previous.fetchedTimes()
+	games := ownedGamesResp.Games
+	if budget.limited {
+		games = refreshOrder(games, fetched)
+	}
+
+	// Report playtime for all games. A game whose achievements fail is recorded and skipped,
+	// so one failing game doesn't cost the user's other games. A game whose achievements can't
+	// be reported keeps those of the last collection.
+	skipAchievements := SkipAchievements(ctx)
+	var failed []GameError
+	deferred := 0
+	reported := make([]reportedGame, 0, len(games))
+	for _, game := range games {
 		ReportOwnedGame(game, steamId, username)
+		entry := reportedGame{Game: game, Fetched: fetched[game.AppId]}
 
-		// If rate limited, skip achievement collection entirely (will use cache in collectAchievements if available)
-		if isRateLimited {
-			logger.Log.WithFields(logrus.Fields{
+		switch {
+		case skipAchievements:
+		case isRateLimited:
+			// If rate limited, skip achievement collection entirely (will use cache in collectAchievements if available)
+			logger.FromContext(ctx).WithFields(logrus.Fields{
 				"steam_id": steamId,
 				"game":     game.Name,
 				"app_id":   game.AppId,
 			}).Debug("Rate limited - skipping achievement collection, will use cache if available")
-			// Still try to collect achievements (will use cache only)
-			_ = c.collectAchievements(steamId, game, username)
-			continue
-		}
-
-		// Skip achievement fetching for games with zero playtime
-		if game.PlaytimeForever == 0 {
-			logger.Log.WithFields(logrus.Fields{
+			// Still try to collect achievements (will use cache only); a cache miss is expected
+			// during a backoff rather than a failed game
+			_ = c.collectAchievements(ctx, steamId, game, username, preloaded, budget, &entry)
+			c.restoreAchievements(ctx, previous, &entry, steamId, username)
+		case game.PlaytimeForever == 0:
+			// Skip achievement fetching for games with zero playtime
+			logger.FromContext(ctx).WithFields(logrus.Fields{
 				"steam_id": steamId,
 				"game":     game.Name,
 				"app_id":   game.AppId,
 			}).Debug("Skipping achievements for game with zero playtime")
-			continue
+		default:
+			// Get and report achievements
+			err := c.collectAchievements(ctx, steamId, game, username, preloaded, budget, &entry)
+			if errors.Is(err, errRefreshDeferred) {
+				deferred++
+			} else if err != nil {
+				// On rate limit, we already attempted cache inside collectAchievements; just continue
+				logger.FromContext(ctx).WithFields(logrus.Fields{
+					"steam_id": steamId,
+					"game":     game.Name,
+					"app_id":   game.AppId,
+					"error":    err.Error(),
+				}).Warn("Error collecting achievements for game, continuing")
+				failed = append(failed, GameError{AppID: game.AppId, Name: game.Name, Err: err})
+			}
+			c.restoreAchievements(ctx, previous, &entry, steamId, username)
 		}
+		reported = append(reported, entry)
+	}
 
-		// Get and report achievements
-        err := c.collectAchievements(steamId, game, username)
-		if err != nil {
-            // On rate limit, we already attempted cache inside collectAchievements; just continue
-			logger.Log.WithFields(logrus.Fields{
+	// A collection skipping achievements would drop them from the snapshot, and a restored one
+	// has nothing new to add
+	if !skipAchievements && !restored {
+		c.saveReported(ctx, steamId, reportedSnapshot{Username: username, Games: reported})
+	}
+
+	if budget.limited {
+		ReportAchievementRefreshesDeferred(deferred, steamId, username)
+		if deferred > 0 {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
 				"steam_id": steamId,
-				"game":     game.Name,
-				"app_id":   game.AppId,
-				"error":    err.Error(),
-			}).Warn("Error collecting achievements for game, continuing")
-			continue
+				"deferred": deferred,
+			}).Info("Achievement refresh budget spent, deferring the remaining games to later collections")
 		}
 	}
 
-	logger.Log.WithField("steam_id", steamId).Info("Completed Steam metrics collection")
+	succeeded := len(ownedGamesResp.Games) - len(failed)
+	ReportCollectionPartial(succeeded, len(failed), steamId, username)
+	if len(failed) > 0 {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id":  steamId,
+			"succeeded": succeeded,
+			"failed":    len(failed),
+		}).Warn("Completed Steam metrics collection with failed games")
+		return &CollectionError{SteamID: steamId, Games: failed, Succeeded: succeeded}
+	}
+
+	logger.FromContext(ctx).WithField("steam_id", steamId).Info("Completed Steam metrics collection")
 	return nil
 }
 
-// getOwnedGames retrieves owned games, using cache if available
-func (c *Collector) getOwnedGames(steamId string) (OwnedGamesResponse, error) {
-	// Check cache first
-	cacheKey := fmt.Sprintf("steam:owned_games:%s", steamId)
-	if cachedData, exists := c.cache.Get(cacheKey); exists {
-		var resp OwnedGamesResponse
-		if err := json.Unmarshal(cachedData, &resp); err == nil {
-			logger.Log.WithFields(logrus.Fields{
+// usernameOrEmpty returns the user's name, or an empty username label if it can't be looked up
+func (c *Collector) usernameOrEmpty(ctx context.Context, steamId string) string {
+	username, err := c.getUsername(ctx, steamId)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+		}).Warn("Failed to get username, continuing without username label")
+		return ""
+	}
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"steam_id": steamId,
+		"username": username,
+	}).Debug("Retrieved username for Steam user")
+	return username
+}
+
+// ownedGamesOrCached retrieves the user's owned games, falling back to the cached ones even
+// when expired while rate limited
+func (c *Collector) ownedGamesOrCached(ctx context.Context, steamId string) (OwnedGamesResponse, error) {
+	ownedGamesResp, err := c.getOwnedGames(ctx, steamId)
+	if err == nil {
+		return ownedGamesResp, nil
+	}
+
+	// If rate limited, attempt to serve from cache instead of failing
+	if strings.Contains(strings.ToLower(err.Error()), "rate limited") {
+		cacheKey := fmt.Sprintf("steam:owned_games:%s", steamId)
+		if cachedData, exists := c.cache.Get(ctx, cacheKey); exists {
+			var cachedResp OwnedGamesResponse
+			if uerr := json.Unmarshal(cachedData, &cachedResp); uerr == nil && len(cachedResp.Games) > 0 {
+				logger.FromContext(ctx).WithFields(logrus.Fields{
+					"steam_id":   steamId,
+					"game_count": len(cachedResp.Games),
+				}).Warn("Rate limited: using cached owned games to serve metrics")
+				return cachedResp, nil
+			}
+			logger.FromContext(ctx).WithFields(logrus.Fields{
 				"steam_id": steamId,
-				"cache":    "hit",
-			}).Info("Retrieved owned games from cache")
-			return resp, nil
+				"error":    err.Error(),
+			}).Error("Rate limited and no cached owned games available")
+		} else {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"steam_id": steamId,
+				"error":    err.Error(),
+			}).Error("Rate limited and owned games cache miss")
 		}
-		logger.Log.WithFields(logrus.Fields{
+	} else {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
 			"steam_id": steamId,
-		}).Warn("Cache hit but failed to unmarshal, fetching fresh")
+			"error":    err.Error(),
+		}).Error("Failed to get owned games")
 	}
+	return OwnedGamesResponse{}, err
+}
 
-	logger.Log.WithFields(logrus.Fields{
-		"steam_id": steamId,
-		"cache":    "miss",
-	}).Info("Fetching owned games from API")
+// getOwnedGames retrieves owned games, using cache if available
+// Expired entries are served stale while a background refresh fetches a new copy
+func (c *Collector) getOwnedGames(ctx context.Context, steamId string) (OwnedGamesResponse, error) {
+	cacheKey := fmt.Sprintf("steam:owned_games:%s", steamId)
+	ttl := time.Duration(c.ownedGamesTTL.Load())
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, ttl, ttl, func(ctx context.Context) ([]byte, error) {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"cache":    "miss",
+		}).Info("Fetching owned games from API")
 
-	// Fetch from API
-	resp, err := c.client.GetOwnedGames(steamId)
+		resp, err := c.client.GetOwnedGames(ctx, steamId)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+	})
 	if err != nil {
 		return OwnedGamesResponse{}, err
 	}
 
-	// Cache with default TTL (30 minutes)
-	if data, err := json.Marshal(resp); err == nil {
-		c.cache.Set(cacheKey, data, 30*time.Minute)
-		logger.Log.WithFields(logrus.Fields{
+	var resp OwnedGamesResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
 			"steam_id": steamId,
-			"ttl":      "30m",
-		}).Debug("Cached owned games")
+		}).Warn("Cache hit but failed to unmarshal, fetching fresh")
+		c.cache.Delete(ctx, cacheKey)
+
+		resp, err = c.client.GetOwnedGames(ctx, steamId)
+		if err != nil {
+			return OwnedGamesResponse{}, err
+		}
 	}
 
 	return resp, nil
 }
 
 // getUsername retrieves username for a Steam ID, using cache if available
-func (c *Collector) getUsername(steamId string) (string, error) {
+func (c *Collector) getUsername(ctx context.Context, steamId string) (string, error) {
 	// Check cache first
 	cacheKey := fmt.Sprintf("steam:username:%s", steamId)
-	if cachedData, exists := c.cache.Get(cacheKey); exists {
+	policy := cache.PolicyFromContext(ctx)
+	if cachedData, exists := c.cache.Get(ctx, cacheKey); exists && policy != cache.PolicyFresh {
 		var username string
 		if err := json.Unmarshal(cachedData, &username); err == nil && username != "" {
-			logger.Log.WithFields(logrus.Fields{
+			logger.FromContext(ctx).WithFields(logrus.Fields{
 				"steam_id": steamId,
 				"username": username,
 				"cache":    "hit",
@@ -192,13 +452,13 @@ func (c *Collector) getUsername(steamId string) (string, error) {
 		}
 	}
 
-	logger.Log.WithFields(logrus.Fields{
+	logger.FromContext(ctx).WithFields(logrus.Fields{
 		"steam_id": steamId,
 		"cache":    "miss",
 	}).Debug("Fetching username from API")
 
 	// Fetch from API
-	summaries, err := c.client.GetPlayerSummaries([]string{steamId})
+	summaries, err := c.client.GetPlayerSummaries(ctx, []string{steamId})
 	if err != nil {
 		return "", fmt.Errorf("failed to get player summary: %w", err)
 	}
@@ -215,8 +475,8 @@ func (c *Collector) getUsername(steamId string) (string, error) {
 	// Cache username for 24 hours with jitter (usernames can change but not frequently)
 	if data, err := json.Marshal(username); err == nil {
 		ttl := 24*time.Hour + time.Duration(rand.Intn(120))*time.Minute // 24 hours + 0-2 hours jitter
-		c.cache.Set(cacheKey, data, ttl)
-		logger.Log.WithFields(logrus.Fields{
+		c.cache.Set(ctx, cacheKey, data, ttl)
+		logger.FromContext(ctx).WithFields(logrus.Fields{
 			"steam_id": steamId,
 			"username": username,
 			"ttl":      ttl.String(),
@@ -226,21 +486,89 @@ func (c *Collector) getUsername(steamId string) (string, error) {
 	return username, nil
 }
 
+// globalAchievementsCacheKey is the cache key for a game's global achievement list
+func globalAchievementsCacheKey(appId uint64) string {
+	return fmt.Sprintf("steam:global_achievements:%d", appId)
+}
+
+// userAchievementsCacheKey is the cache key for a user's achievements in a game
+func userAchievementsCacheKey(steamId string, appId uint64) string {
+	return fmt.Sprintf("steam:user_achievements:%s:%d", steamId, appId)
+}
+
+// preloadAchievementCache fetches the cached global and user achievements for every
+// played game with a single MGET, so the per-game loop doesn't issue sequential GETs
+func (c *Collector) preloadAchievementCache(ctx context.Context, steamId string, games []OwnedGame) map[string][]byte {
+	keys := make([]string, 0, 2*len(games))
+	for _, game := range games {
+		if game.PlaytimeForever == 0 {
+			continue
+		}
+		keys = append(keys, globalAchievementsCacheKey(game.AppId), userAchievementsCacheKey(steamId, game.AppId))
+	}
+
+	preloaded := c.cache.MGet(ctx, keys...)
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"steam_id":  steamId,
+		"requested": len(keys),
+		"found":     len(preloaded),
+	}).Debug("Preloaded achievement cache entries")
+
+	// Record misses as nil so lookups know the key was checked and don't fall back to a GET
+	for _, key := range keys {
+		if _, exists := preloaded[key]; !exists {
+			preloaded[key] = nil
+		}
+	}
+
+	return preloaded
+}
+
+// cachedValue looks a key up in the preloaded entries, falling back to the cache
+// for keys that weren't preloaded (preloaded may be nil)
+func (c *Collector) cachedValue(ctx context.Context, preloaded map[string][]byte, key string) ([]byte, bool) {
+	if data, requested := preloaded[key]; requested {
+		return data, data != nil
+	}
+	return c.cache.Get(ctx, key)
+}
+
 // collectAchievements collects achievements for a specific game
-func (c *Collector) collectAchievements(steamId string, game OwnedGame, username string) error {
+// The achievements reported are recorded in reported. A game that needs achievements from Steam
+// takes one refresh from budget, and returns errRefreshDeferred when it is spent.
+func (c *Collector) collectAchievements(ctx context.Context, steamId string, game OwnedGame, username string, preloaded map[string][]byte, budget *refreshBudget, reported *reportedGame) (err error) {
+	ctx, span := tracing.Start(ctx, "steam.achievements", attribute.Int64("steam.app_id", int64(game.AppId)))
+	defer func() { tracing.End(span, err) }()
+
+	// Fetching the global and the user achievements counts as one refresh
+	reserved := false
+	reserve := func() bool {
+		reserved = reserved || budget.take()
+		return reserved
+	}
+
 	// Get global achievements from cache or fetch them
 	var globalAchievements []GlobalAchievement
-	globalCacheKey := fmt.Sprintf("steam:global_achievements:%d", game.AppId)
+	globalCacheKey := globalAchievementsCacheKey(game.AppId)
 	cached := false
-	if cachedData, exists := c.cache.Get(globalCacheKey); exists {
+	if cachedData, exists := c.cachedValue(ctx, preloaded, globalCacheKey); exists {
 		if err := json.Unmarshal(cachedData, &globalAchievements); err == nil && len(globalAchievements) > 0 {
 			cached = true
 		}
 	}
 
+	policy := cache.PolicyFromContext(ctx)
+	if !cached && policy == cache.PolicyCachedOnly {
+		return nil
+	}
+
 		if !cached {
+		if !reserve() {
+			return errRefreshDeferred
+		}
+
 		// Fetch global achievements
-		globalResp, err := c.client.GetGlobalAchievementPercentages(game.AppId)
+		globalResp, err := c.client.GetGlobalAchievementPercentages(ctx, game.AppId)
 		if err != nil {
 			// Check if this is a rate limit error - if so, return early and let the rate limiter handle it
 			if err.Error() == "steam API rate limited - backoff period active" ||
@@ -255,8 +583,8 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
 		if data, err := json.Marshal(globalAchievements); err == nil {
 			// Global achievements change rarely, cache for 7 days with jitter to avoid thundering herd
 			ttl := 7*24*time.Hour + time.Duration(rand.Intn(720))*time.Minute // 7 days + 0-12 hours jitter
-			c.cache.Set(globalCacheKey, data, ttl)
-			logger.Log.WithFields(logrus.Fields{
+			c.cache.Set(ctx, globalCacheKey, data, ttl)
+			logger.FromContext(ctx).WithFields(logrus.Fields{
 				"app_id": game.AppId,
 				"ttl":    ttl.String(),
 			}).Debug("Cached global achievements with jitter")
@@ -269,13 +597,14 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
 	}
 
 	// Check if playtime increased (active player detection)
-	userCacheKey := fmt.Sprintf("steam:user_achievements:%s:%d", steamId, game.AppId)
-	playtimeIncreased := c.hasPlaytimeIncreased(game.AppId, steamId, game.PlaytimeForever)
+	userCacheKey := userAchievementsCacheKey(steamId, game.AppId)
+	playtimeIncreased := c.hasPlaytimeIncreased(ctx, game.AppId, steamId, game.PlaytimeForever, preloaded)
 
 	var userAchievements []Achievement
-	// Try to use cached user achievements if playtime hasn't increased
-	if !playtimeIncreased {
-		if cachedData, exists := c.cache.Get(userCacheKey); exists {
+	// Try to use cached user achievements if playtime hasn't increased. A fresh collection
+	// always refetches them, a cache-only one never does.
+	if (!playtimeIncreased && policy != cache.PolicyFresh) || policy == cache.PolicyCachedOnly {
+		if cachedData, exists := c.cachedValue(ctx, preloaded, userCacheKey); exists {
 			type cacheEntry struct {
 				UserAchievements []Achievement `json:"user_achievements"`
 				Playtime        int           `json:"playtime"`
@@ -287,20 +616,28 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
 		}
 	}
 
+    if userAchievements == nil && policy == cache.PolicyCachedOnly {
+		return nil
+	}
+
     // If we don't have cached user achievements, fetch them
     if userAchievements == nil {
+		if !reserve() {
+			return errRefreshDeferred
+		}
+
 		// Only sleep if we're not rate limited (sleep is to avoid rate limiting, but if we're already rate limited, we won't make the call anyway)
 		if c.rateLimit == nil || !c.rateLimit.CheckAndBlock() {
 			// Add a small delay between achievement requests to avoid rate limiting
-			time.Sleep(5 * time.Second)
+			time.Sleep(c.achievementDelay)
 		}
 
 		// Fetch user achievements
-		achievementResp, err := c.client.GetUserStatsForGame(steamId, game.AppId)
+		achievementResp, err := c.client.GetUserStatsForGame(ctx, steamId, game.AppId)
         if err != nil {
             // If rate limited, try to serve from cache instead of failing
             if strings.Contains(strings.ToLower(err.Error()), "rate limited") {
-                if cachedData, exists := c.cache.Get(userCacheKey); exists {
+                if cachedData, exists := c.cachedValue(ctx, preloaded, userCacheKey); exists {
                     type cacheEntry struct {
                         UserAchievements []Achievement `json:"user_achievements"`
                         Playtime        int           `json:"playtime"`
@@ -308,7 +645,7 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
                     var entry cacheEntry
                     if uerr := json.Unmarshal(cachedData, &entry); uerr == nil && len(entry.UserAchievements) > 0 {
                         userAchievements = entry.UserAchievements
-                        logger.Log.WithFields(logrus.Fields{
+                        logger.FromContext(ctx).WithFields(logrus.Fields{
                             "steam_id": steamId,
                             "app_id":   game.AppId,
                         }).Warn("Rate limited: using cached user achievements to serve metrics")
@@ -318,12 +655,16 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
                 } else {
                     return fmt.Errorf("error fetching user achievements: %w", err)
                 }
+            } else if strings.Contains(err.Error(), "Requested app has no stats") {
+                // A game without stats for the user has no achievements to report, it didn't fail
+                return nil
             } else {
                 return fmt.Errorf("error fetching user achievements: %w", err)
             }
         }
         if userAchievements == nil {
             userAchievements = achievementResp.PlayerStats.Achievements
+            reported.Fetched = time.Now()
         }
 
 		// Cache user achievements with different TTLs based on activity
@@ -340,7 +681,7 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
 			if playtimeIncreased {
 				// Active player: Cache for 2-5 minutes to avoid refetching every scrape while still detecting achievements quickly
 				ttl = 2*time.Minute + time.Duration(rand.Intn(180))*time.Second // 2-5 minutes with jitter
-				logger.Log.WithFields(logrus.Fields{
+				logger.FromContext(ctx).WithFields(logrus.Fields{
 					"app_id":   game.AppId,
 					"steam_id": steamId,
 					"ttl":      ttl.String(),
@@ -349,18 +690,21 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
 			} else {
 				// Inactive player: Cache for 4-6 hours since achievements won't change while not playing
 				ttl = 4*time.Hour + time.Duration(rand.Intn(120))*time.Minute // 4-6 hours with jitter
-				logger.Log.WithFields(logrus.Fields{
+				logger.FromContext(ctx).WithFields(logrus.Fields{
 					"app_id":   game.AppId,
 					"steam_id": steamId,
 					"ttl":      ttl.String(),
 					"reason":   "inactive_player",
 				}).Debug("Cached user achievements for inactive player")
 			}
-			c.cache.Set(userCacheKey, data, ttl)
+			c.cache.Set(ctx, userCacheKey, data, ttl)
 		}
 	}
 
 	// Report achievements
+	reported.Achievements, reported.Global = userAchievements, globalAchievements
+	_, reportSpan := tracing.Start(ctx, "steam.report_metrics")
+	defer reportSpan.End()
 	ReportAchievements(
 		userAchievements,
 		globalAchievements,
@@ -368,6 +712,8 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
 		game.AppId,
 		steamId,
 		username,
+		c.achievementLimit,
+		c.achievementBudget,
 	)
 
 	return nil
@@ -375,9 +721,9 @@ func (c *Collector) collectAchievements(steamId string, game OwnedGame, username
 
 // hasPlaytimeIncreased checks if playtime has increased since last cache
 // Returns true if playtime increased, false if same or cache doesn't exist
-func (c *Collector) hasPlaytimeIncreased(appId uint64, steamId string, currentPlaytime int) bool {
-	userCacheKey := fmt.Sprintf("steam:user_achievements:%s:%d", steamId, appId)
-	if cachedData, exists := c.cache.Get(userCacheKey); exists {
+func (c *Collector) hasPlaytimeIncreased(ctx context.Context, appId uint64, steamId string, currentPlaytime int, preloaded map[string][]byte) bool {
+	userCacheKey := userAchievementsCacheKey(steamId, appId)
+	if cachedData, exists := c.cachedValue(ctx, preloaded, userCacheKey); exists {
 		type cacheEntry struct {
 			UserAchievements []Achievement `json:"user_achievements"`
 			Playtime        int           `json:"playtime"`
@@ -393,26 +739,37 @@ func (c *Collector) hasPlaytimeIncreased(appId uint64, steamId string, currentPl
 
 // shouldInvalidateUserCache checks if cache should be invalidated based on playtime
 // This is kept for backward compatibility with IsActive detection
-func (c *Collector) shouldInvalidateUserCache(appId uint64, steamId string, currentPlaytime int) bool {
-	return c.hasPlaytimeIncreased(appId, steamId, currentPlaytime)
+func (c *Collector) shouldInvalidateUserCache(ctx context.Context, appId uint64, steamId string, currentPlaytime int, preloaded map[string][]byte) bool {
+	return c.hasPlaytimeIncreased(ctx, appId, steamId, currentPlaytime, preloaded)
+}
+
+// ResetRateLimit clears the rate limit backoff of every API key
+func (c *Collector) ResetRateLimit() {
+	c.rateLimit.Reset()
+}
+
+// RateLimited reports whether Steam API calls are currently blocked by the rate limiter
+func (c *Collector) RateLimited() bool {
+	return c.rateLimit != nil && c.rateLimit.Blocked()
 }
 
 // IsActive detects if a user is actively playing by checking playtime increases
-func (c *Collector) IsActive(steamId string) (bool, error) {
+func (c *Collector) IsActive(ctx context.Context, steamId string) (bool, error) {
 	// Get current owned games
-	resp, err := c.client.GetOwnedGames(steamId)
+	resp, err := c.client.GetOwnedGames(ctx, steamId)
 	if err != nil {
 		return false, err
 	}
 
 	// Check cache for last known playtimes
+	preloaded := c.preloadAchievementCache(ctx, steamId, resp.Games)
 	for _, game := range resp.Games {
 		if game.PlaytimeForever == 0 {
 			continue
 		}
 
 		// Check if playtime increased (activity detected)
-		if c.shouldInvalidateUserCache(game.AppId, steamId, game.PlaytimeForever) {
+		if c.shouldInvalidateUserCache(ctx, game.AppId, steamId, game.PlaytimeForever, preloaded) {
 			return true, nil
 		}
 	}
@@ -420,3 +777,91 @@ func (c *Collector) IsActive(steamId string) (bool, error) {
 	return false, nil
 }
 
+
+// Profile returns a user's owned games and username from the cache (fetching on a miss),
+// with the achievements of every game whose achievements have already been collected.
+// Achievements are never fetched here; that is left to Collect.
+func (c *Collector) Profile(ctx context.Context, steamId string) (Profile, error) {
+	ownedGames, err := c.getOwnedGames(ctx, steamId)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to get owned games: %w", err)
+	}
+
+	// Username is optional, as it is for metrics
+	username, err := c.getUsername(ctx, steamId)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+		}).Warn("Failed to get username, continuing without it")
+	}
+
+	type cacheEntry struct {
+		UserAchievements []Achievement `json:"user_achievements"`
+		Playtime        int           `json:"playtime"`
+	}
+
+	preloaded := c.preloadAchievementCache(ctx, steamId, ownedGames.Games)
+	games := make([]GameProfile, 0, len(ownedGames.Games))
+	for _, game := range ownedGames.Games {
+		profile := GameProfile{
+			AppId:           game.AppId,
+			Name:            game.Name,
+			PlaytimeForever: game.PlaytimeForever,
+		}
+		// Achievements are only collected for played games, which are the ones preloaded
+		if game.PlaytimeForever == 0 {
+			games = append(games, profile)
+			continue
+		}
+		if cachedData, exists := c.cachedValue(ctx, preloaded, userAchievementsCacheKey(steamId, game.AppId)); exists {
+			var entry cacheEntry
+			if err := json.Unmarshal(cachedData, &entry); err == nil {
+				profile.Achievements = entry.UserAchievements
+			}
+		}
+		games = append(games, profile)
+	}
+
+	return Profile{
+		SteamID:   steamId,
+		Username:  username,
+		GameCount: ownedGames.GameCount,
+		Games:     games,
+	}, nil
+}
+
+// reportPlaytimeToday reports how long each game was played since local midnight
+func (c *Collector) reportPlaytimeToday(ctx context.Context, steamId string, username string, games []OwnedGame) {
+	playtime := make(map[string]int64, len(games))
+	for _, game := range games {
+		playtime[strconv.FormatUint(game.AppId, 10)] = int64(game.PlaytimeForever)
+	}
+	today := c.daily.Today(ctx, fmt.Sprintf("steam:playtime_today:%s", steamId), playtime)
+	ReportPlaytimeToday(today, games, steamId, username)
+}
+
+// OwnedGames returns a user's owned games from the cache, fetching on a miss
+func (c *Collector) OwnedGames(ctx context.Context, steamId string) (OwnedGamesResponse, error) {
+	return c.getOwnedGames(ctx, steamId)
+}
+
+// checkSteamID is a long-standing public profile used to validate the API key
+const checkSteamID = "76561197960287930"
+
+// CheckAPIKey makes a single uncached API call with each key to confirm it is accepted
+func (c *Collector) CheckAPIKey(ctx context.Context) error {
+	for i, key := range c.client.keys.keys {
+		client := &Client{keys: c.client.keys.only(i), httpClient: c.client.httpClient, responses: c.client.responses, schema: c.client.schema}
+		if _, err := client.GetPlayerSummaries(ctx, []string{checkSteamID}); err != nil {
+			return fmt.Errorf("steam API key %s check failed (an invalid key is reported by Steam as 403): %w", key.name, err)
+		}
+	}
+	return nil
+}
+
+// DebugOwnedGames fetches a user's owned games uncached for /debug/raw, without caching or
+// reporting them
+func (c *Collector) DebugOwnedGames(ctx context.Context, steamId string) (OwnedGamesResponse, error) {
+	return c.client.GetOwnedGames(ctx, steamId)
+}
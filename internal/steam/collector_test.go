@@ -0,0 +1,908 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cardinality"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/relabel"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const (
+	testSteamID  = "76561197960287930"
+	otherSteamID = "76561197960287931"
+)
+
+// newTestCollector returns a collector with a fresh file cache that sends every request to srv
+func newTestCollector(t *testing.T, srv *testserver.Server, keys ...string) *Collector {
+	t.Helper()
+	collector := NewCollector(Config{APIKeys: keys, Transport: srv.Transport()}, testserver.NewCache(t))
+	collector.achievementDelay = 0
+	return collector
+}
+
+func newTestServer(t *testing.T) *testserver.Server {
+	srv := testserver.New(t)
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{
+		Name: "gabe",
+		Games: []testserver.Game{
+			{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 120, Achievements: map[string]bool{"TF_PLAY_GAME": true, "TF_WIN_GAME": false}},
+			{AppID: 570, Name: "Dota 2", PlaytimeMinutes: 30},
+			{AppID: 620, Name: "Portal 2"},
+		},
+	})
+	srv.AddSteamUser(otherSteamID, testserver.SteamUser{
+		Name:  "robin",
+		Games: []testserver.Game{{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 5}},
+	})
+	return srv
+}
+
+func TestCollect(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	profile, err := collector.Profile(ctx, testSteamID)
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if profile.Username != "gabe" {
+		t.Errorf("username = %q, want gabe", profile.Username)
+	}
+	if len(profile.Games) != 3 {
+		t.Fatalf("got %d games, want 3", len(profile.Games))
+	}
+	tf2 := profile.Games[0]
+	if tf2.PlaytimeForever != 120 {
+		t.Errorf("playtime = %d, want 120", tf2.PlaytimeForever)
+	}
+	achieved := map[string]int{}
+	for _, achievement := range tf2.Achievements {
+		achieved[achievement.Name] = achievement.Achieved
+	}
+	if len(achieved) != 2 || achieved["TF_PLAY_GAME"] != 1 || achieved["TF_WIN_GAME"] != 0 {
+		t.Errorf("achievements = %v, want TF_PLAY_GAME achieved and TF_WIN_GAME not", achieved)
+	}
+	if got := testutil.ToFloat64(completionRatioGauge.WithLabelValues("440", "Team Fortress 2", testSteamID, "gabe")); got != 0.5 {
+		t.Errorf("completion ratio = %v, want 0.5", got)
+	}
+	if got := testutil.ToFloat64(achievementGlobalPercentGauge.WithLabelValues("440", "TF_WIN_GAME")); got != 50 {
+		t.Errorf("global percent = %v, want 50", got)
+	}
+	if got := testutil.ToFloat64(rarestAchievementGauge.WithLabelValues("440", "Team Fortress 2", testSteamID, "gabe")); got != 50 {
+		t.Errorf("rarest unlocked achievement = %v, want 50", got)
+	}
+
+	// Unplayed games and games without achievements are skipped
+	if got := srv.Requests("/ISteamUserStats/GetUserStatsForGame/v0002/"); got != 1 {
+		t.Errorf("user stats requests = %d, want 1 (the played game with achievements)", got)
+	}
+}
+
+func TestCollectRateLimitedFallsBackToCache(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	srv.SetSteamStatus(http.StatusTooManyRequests)
+
+	// A user with nothing cached can't be collected, and the 429 starts the backoff
+	if err := collector.Collect(ctx, otherSteamID); err == nil {
+		t.Fatal("Collect of an uncached user succeeded while rate limited")
+	}
+	if !collector.RateLimited() {
+		t.Fatal("RateLimited() = false after a 429")
+	}
+
+	// A cached user is still served, without calling Steam during the backoff
+	requests := srv.SteamRequests()
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect of a cached user while rate limited: %v", err)
+	}
+	if got := srv.SteamRequests(); got != requests {
+		t.Errorf("made %d Steam requests during the backoff, want 0", got-requests)
+	}
+
+	collector.ResetRateLimit()
+	srv.SetSteamStatus(0)
+	if err := collector.Collect(ctx, otherSteamID); err != nil {
+		t.Fatalf("Collect after the backoff was reset: %v", err)
+	}
+}
+
+func TestCollectCachePolicy(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	cachedOnly := cache.WithPolicy(context.Background(), cache.PolicyCachedOnly)
+
+	// Nothing is cached yet, and cache-only collections never call Steam
+	if err := collector.Collect(cachedOnly, testSteamID); !errors.Is(err, cache.ErrNotCached) {
+		t.Fatalf("cache-only Collect of an uncached user = %v, want ErrNotCached", err)
+	}
+	if got := srv.SteamRequests(); got != 0 {
+		t.Fatalf("cache-only Collect made %d Steam requests, want 0", got)
+	}
+
+	// Skipping achievements fetches the library only
+	if err := collector.Collect(WithoutAchievements(context.Background()), testSteamID); err != nil {
+		t.Fatalf("Collect without achievements: %v", err)
+	}
+	if got := srv.Requests("/ISteamUserStats/GetUserStatsForGame/v0002/"); got != 0 {
+		t.Errorf("user stats requests without achievements = %d, want 0", got)
+	}
+
+	// Now the library is cached
+	requests := srv.SteamRequests()
+	if err := collector.Collect(cachedOnly, testSteamID); err != nil {
+		t.Fatalf("cache-only Collect of a cached user: %v", err)
+	}
+	if got := srv.SteamRequests(); got != requests {
+		t.Errorf("cache-only Collect made %d Steam requests, want 0", got-requests)
+	}
+
+	// A fresh collection refetches the library even though it's cached
+	ownedGames := srv.Requests("/IPlayerService/GetOwnedGames/v0001/")
+	if err := collector.Collect(cache.WithPolicy(context.Background(), cache.PolicyFresh), testSteamID); err != nil {
+		t.Fatalf("fresh Collect: %v", err)
+	}
+	if got := srv.Requests("/IPlayerService/GetOwnedGames/v0001/"); got != ownedGames+1 {
+		t.Errorf("owned games requests = %d, want %d", got, ownedGames+1)
+	}
+}
+
+func TestCollectRestoresLastReported(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	// The owned games and achievements expire from the cache and the gauges start empty, as
+	// after a restart during a backoff
+	collector.cache.Delete(ctx, fmt.Sprintf("steam:owned_games:%s", testSteamID))
+	collector.cache.Delete(ctx, userAchievementsCacheKey(testSteamID, 440))
+	ownedGamePlaytimeGauge.Reset()
+	achievementGauge.Reset()
+
+	cachedOnly := cache.WithPolicy(ctx, cache.PolicyCachedOnly)
+	if err := collector.Collect(cachedOnly, testSteamID); err != nil {
+		t.Fatalf("cache-only Collect with a reported snapshot: %v", err)
+	}
+	if got := testutil.ToFloat64(ownedGamePlaytimeGauge.WithLabelValues("440", "Team Fortress 2", testSteamID, "gabe")); got != 7200 {
+		t.Errorf("Team Fortress 2 playtime = %v, want 7200", got)
+	}
+	if got := testutil.ToFloat64(achievementGauge.WithLabelValues("440", "Team Fortress 2", "TF_PLAY_GAME", testSteamID, "gabe", "true")); got != 1 {
+		t.Errorf("TF_PLAY_GAME = %v, want 1", got)
+	}
+
+	// A user never collected still has nothing to report
+	if err := collector.Collect(cachedOnly, otherSteamID); !errors.Is(err, cache.ErrNotCached) {
+		t.Errorf("cache-only Collect of an uncollected user = %v, want ErrNotCached", err)
+	}
+}
+
+func TestCollectPlaytimeCounter(t *testing.T) {
+	const steamID = "76561197960287940"
+	srv := testserver.New(t)
+	srv.AddSteamUser(steamID, testserver.SteamUser{
+		Name:  "robin",
+		Games: []testserver.Game{{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 5}},
+	})
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+	// The playtime played between the collections is also reported as played today
+	t.Cleanup(func() { playtimeTodayGauge.DeletePartialMatch(prometheus.Labels{"steam_id": steamID}) })
+
+	if err := collector.Collect(ctx, steamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(ownedGamePlaytimeCounter.WithLabelValues("440", steamID)); got != 300 {
+		t.Errorf("playtime counter = %v, want 300", got)
+	}
+	if got := testutil.ToFloat64(playerInfoGauge.WithLabelValues(steamID, "robin")); got != 1 {
+		t.Errorf("player info = %v, want 1", got)
+	}
+
+	// The user renames themselves and plays on: the counter keeps its series and only the info
+	// metric changes
+	srv.AddSteamUser(steamID, testserver.SteamUser{
+		Name:  "robin_hood",
+		Games: []testserver.Game{{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 7}},
+	})
+	collector.cache.Delete(ctx, fmt.Sprintf("steam:owned_games:%s", steamID))
+	collector.cache.Delete(ctx, fmt.Sprintf("steam:username:%s", steamID))
+	if err := collector.Collect(ctx, steamID); err != nil {
+		t.Fatalf("Collect after the rename: %v", err)
+	}
+	if got := testutil.ToFloat64(ownedGamePlaytimeCounter.WithLabelValues("440", steamID)); got != 420 {
+		t.Errorf("playtime counter = %v, want 420", got)
+	}
+	if got := testutil.ToFloat64(playerInfoGauge.WithLabelValues(steamID, "robin_hood")); got != 1 {
+		t.Errorf("player info after the rename = %v, want 1", got)
+	}
+	if playerInfoGauge.DeleteLabelValues(steamID, "robin") {
+		t.Error("player info of the old username is still reported")
+	}
+
+	// Playtime going down doesn't reset the counter
+	addPlaytime("440", steamID, 60)
+	if got := testutil.ToFloat64(ownedGamePlaytimeCounter.WithLabelValues("440", steamID)); got != 420 {
+		t.Errorf("playtime counter after a lower playtime = %v, want 420", got)
+	}
+}
+
+func TestCollectRenameWithInfoLabels(t *testing.T) {
+	const steamID = "76561197960287941"
+	srv := testserver.New(t)
+	srv.AddSteamUser(steamID, testserver.SteamUser{
+		Name: "robin",
+		Games: []testserver.Game{
+			{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 5, Achievements: map[string]bool{"TF_PLAY_GAME": true}},
+			{AppID: 570, Name: "Dota 2", PlaytimeMinutes: 1},
+		},
+	})
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+	t.Cleanup(func() {
+		for _, vec := range append(renamableGauges, playerInfoGauge) {
+			vec.DeletePartialMatch(prometheus.Labels{"steam_id": steamID})
+		}
+	})
+
+	if err := collector.Collect(ctx, steamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	// The user and a game are renamed, and the user plays on
+	srv.AddSteamUser(steamID, testserver.SteamUser{
+		Name: "robin_hood",
+		Games: []testserver.Game{
+			{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 7, Achievements: map[string]bool{"TF_PLAY_GAME": true}},
+			{AppID: 570, Name: "Dota 2: Reborn", PlaytimeMinutes: 1},
+		},
+	})
+	collector.cache.Delete(ctx, fmt.Sprintf("steam:owned_games:%s", steamID))
+	collector.cache.Delete(ctx, fmt.Sprintf("steam:username:%s", steamID))
+	if err := collector.Collect(ctx, steamID); err != nil {
+		t.Fatalf("Collect after the rename: %v", err)
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	// With the renamed labels dropped, the old and new series would be identical: only the
+	// current ones are left to serve
+	for _, rules := range []relabel.Rules{{InfoLabels: InfoLabels}, {DropLabels: []string{"username", "game_name"}}} {
+		playtime := map[string][]float64{}
+		achievements := 0
+		for _, mf := range rules.Apply(families) {
+			for _, m := range mf.Metric {
+				labels := map[string]string{}
+				for _, pair := range m.Label {
+					labels[pair.GetName()] = pair.GetValue()
+				}
+				if labels["steam_id"] != steamID {
+					continue
+				}
+				switch mf.GetName() {
+				case "steam_owned_games_playtime_seconds":
+					playtime[labels["app_id"]] = append(playtime[labels["app_id"]], m.GetGauge().GetValue())
+				case "steam_achievements_achieved":
+					achievements++
+				}
+			}
+		}
+		if got := playtime["440"]; len(got) != 1 || got[0] != 420 {
+			t.Errorf("served Team Fortress 2 playtime = %v, want [420]", got)
+		}
+		if got := playtime["570"]; len(got) != 1 || got[0] != 60 {
+			t.Errorf("served Dota 2 playtime = %v, want [60]", got)
+		}
+		if achievements != 1 {
+			t.Errorf("served %d achievement series, want 1", achievements)
+		}
+	}
+
+	// Without the relabel rules, only the current names are reported
+	if got := testutil.ToFloat64(ownedGamePlaytimeGauge.WithLabelValues("570", "Dota 2: Reborn", steamID, "robin_hood")); got != 60 {
+		t.Errorf("renamed game playtime = %v, want 60", got)
+	}
+	if ownedGamePlaytimeGauge.DeleteLabelValues("570", "Dota 2", steamID, "robin") {
+		t.Error("playtime of the old names is still reported")
+	}
+	if achievementGauge.DeleteLabelValues("440", "Team Fortress 2", "TF_PLAY_GAME", steamID, "robin", "true") {
+		t.Error("achievement of the old username is still reported")
+	}
+}
+
+func TestReportNamesKeepsUnreportedSeries(t *testing.T) {
+	const steamID = "76561197960287942"
+	t.Cleanup(func() { completionRatioGauge.DeletePartialMatch(prometheus.Labels{"steam_id": steamID}) })
+
+	completionRatioGauge.WithLabelValues("440", "Team Fortress 2", steamID, "robin").Set(0.5)
+	completionRatioGauge.WithLabelValues("570", "Dota 2", steamID, "robin").Set(0.25)
+	completionRatioGauge.WithLabelValues("570", "Dota 2", steamID, "robin_hood").Set(0.75)
+	ReportNames(steamID, "robin_hood", nil)
+
+	// A series not reported again is carried over to the new name, and one that was keeps its
+	// new value
+	if got := testutil.ToFloat64(completionRatioGauge.WithLabelValues("440", "Team Fortress 2", steamID, "robin_hood")); got != 0.5 {
+		t.Errorf("carried over completion ratio = %v, want 0.5", got)
+	}
+	if got := testutil.ToFloat64(completionRatioGauge.WithLabelValues("570", "Dota 2", steamID, "robin_hood")); got != 0.75 {
+		t.Errorf("reported completion ratio = %v, want 0.75", got)
+	}
+	for _, appID := range []string{"440", "570"} {
+		if completionRatioGauge.DeletePartialMatch(prometheus.Labels{"app_id": appID, "steam_id": steamID, "username": "robin"}) > 0 {
+			t.Errorf("completion ratio of app %s under the old username is still reported", appID)
+		}
+	}
+}
+
+func TestCollectSharesRunningCollection(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	held, release := srv.HoldSteam()
+	defer release()
+	results := make(chan error, 2)
+	go func() { results <- collector.Collect(ctx, testSteamID) }()
+	// The username and the owned games are requested concurrently; both are held before the
+	// second collection starts, so neither is taken for the second collection's
+	<-held
+	<-held
+
+	// A second collection of the user waits for the running one instead of calling Steam
+	go func() { results <- collector.Collect(ctx, testSteamID) }()
+	select {
+	case path := <-held:
+		t.Errorf("second Collect requested %s", path)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	for range 2 {
+		if err := <-results; err != nil {
+			t.Errorf("Collect: %v", err)
+		}
+	}
+	if got := srv.Requests("/IPlayerService/GetOwnedGames/v0001/"); got != 1 {
+		t.Errorf("owned games requests = %d, want 1", got)
+	}
+}
+
+func TestCollectAchievementBudget(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	collector.achievementLimit = 1
+	achievementGauge.Reset()
+
+	if err := collector.Collect(context.Background(), testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	// One of Team Fortress 2's two achievements is exported, the ratio still covers both
+	if got := testutil.CollectAndCount(achievementGauge); got != 1 {
+		t.Errorf("achievement series = %d, want 1", got)
+	}
+	if got := testutil.ToFloat64(completionRatioGauge.WithLabelValues("440", "Team Fortress 2", testSteamID, "gabe")); got != 0.5 {
+		t.Errorf("completion ratio = %v, want 0.5", got)
+	}
+
+	// An exhausted total budget keeps the series it has and refuses new ones
+	collector.achievementLimit = 0
+	collector.achievementBudget = cardinality.NewBudget("steam", 1)
+	achievementGauge.Reset()
+	if err := collector.Collect(context.Background(), testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.CollectAndCount(achievementGauge); got != 1 {
+		t.Errorf("achievement series with a total budget of 1 = %d, want 1", got)
+	}
+}
+
+func TestCollectRefreshBudget(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	collector.achievementRefreshes = 1
+	ctx := context.Background()
+
+	// Team Fortress 2 and Dota 2 are both due a refresh; only the first gets the budget
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/ISteamUserStats/GetGlobalAchievementPercentagesForApp/v0002/"); got != 1 {
+		t.Errorf("global achievements requests = %d, want 1", got)
+	}
+	if got := testutil.ToFloat64(achievementRefreshesDeferredGauge.WithLabelValues(testSteamID, "gabe")); got != 1 {
+		t.Errorf("deferred refreshes = %v, want 1", got)
+	}
+
+	// The next collection refreshes the game left over, Team Fortress 2's achievements still
+	// being cached
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/ISteamUserStats/GetGlobalAchievementPercentagesForApp/v0002/"); got != 2 {
+		t.Errorf("global achievements requests = %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(achievementRefreshesDeferredGauge.WithLabelValues(testSteamID, "gabe")); got != 0 {
+		t.Errorf("deferred refreshes = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(achievementGauge.WithLabelValues("440", "Team Fortress 2", "TF_PLAY_GAME", testSteamID, "gabe", "true")); got != 1 {
+		t.Errorf("TF_PLAY_GAME = %v, want 1", got)
+	}
+}
+
+func TestCollectPartial(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{
+		Name: "gabe",
+		Games: []testserver.Game{
+			{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 120, Achievements: map[string]bool{"TF_PLAY_GAME": true}},
+			{AppID: 570, Name: "Dota 2", PlaytimeMinutes: 30, Achievements: map[string]bool{"DOTA_WIN": true}, StatsStatus: http.StatusInternalServerError},
+			{AppID: 620, Name: "Portal 2"},
+		},
+	})
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	// A failing game is reported without its achievements, the others in full
+	err := collector.Collect(ctx, testSteamID)
+	if !IsPartial(err) {
+		t.Fatalf("Collect = %v, want a partial collection", err)
+	}
+	var collectionErr *CollectionError
+	if !errors.As(err, &collectionErr) || len(collectionErr.Games) != 1 || collectionErr.Games[0].AppID != 570 || collectionErr.Succeeded != 2 {
+		t.Errorf("got %+v, want Dota 2 failed and 2 games succeeded", collectionErr)
+	}
+	if got := testutil.ToFloat64(collectionPartialGauge.WithLabelValues(testSteamID, "gabe", "failed")); got != 1 {
+		t.Errorf("failed games = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(collectionPartialGauge.WithLabelValues(testSteamID, "gabe", "succeeded")); got != 2 {
+		t.Errorf("succeeded games = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(ownedGamePlaytimeGauge.WithLabelValues("570", "Dota 2", testSteamID, "gabe")); got != 1800 {
+		t.Errorf("Dota 2 playtime = %v, want 1800", got)
+	}
+	if got := testutil.ToFloat64(achievementGauge.WithLabelValues("440", "Team Fortress 2", "TF_PLAY_GAME", testSteamID, "gabe", "true")); got != 1 {
+		t.Errorf("TF_PLAY_GAME = %v, want 1", got)
+	}
+
+	// Without the owned games the collection fails
+	srv.SetSteamStatus(http.StatusInternalServerError)
+	err = collector.Collect(ctx, otherSteamID)
+	if err == nil || IsPartial(err) {
+		t.Errorf("Collect without owned games = %v, want a failed collection", err)
+	}
+}
+
+func TestCollectFriends(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{
+		Name:    "gabe",
+		Friends: []string{otherSteamID, "76561197960287932", "76561197960287933"},
+	})
+	srv.AddSteamUser(otherSteamID, testserver.SteamUser{Name: "robin", Online: true, InGame: 440, PrivateFriends: true})
+	srv.AddSteamUser("76561197960287932", testserver.SteamUser{Name: "sam", Online: true})
+	srv.AddSteamUser("76561197960287933", testserver.SteamUser{Name: "alex"})
+
+	collector := newTestCollector(t, srv, "key")
+	collector.friends = true
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(friendsGauge.WithLabelValues(testSteamID, "gabe")); got != 3 {
+		t.Errorf("friends = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(friendsOnlineGauge.WithLabelValues(testSteamID, "gabe")); got != 2 {
+		t.Errorf("friends online = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(friendsInGameGauge.WithLabelValues(testSteamID, "gabe")); got != 1 {
+		t.Errorf("friends in game = %v, want 1", got)
+	}
+
+	// A private friend list leaves the friend metrics out without failing the collection
+	if err := collector.Collect(ctx, otherSteamID); err != nil {
+		t.Fatalf("Collect of a user with a private friend list: %v", err)
+	}
+	if got := testutil.CollectAndCount(friendsGauge); got != 1 {
+		t.Errorf("friend series = %d, want 1 (only the public friend list)", got)
+	}
+}
+
+func TestDiscoverFriends(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{
+		Name:    "gabe",
+		Friends: []string{"76561197960287933", otherSteamID, "76561197960287932"},
+	})
+	srv.AddSteamUser(otherSteamID, testserver.SteamUser{Name: "robin", PrivateFriends: true})
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	friends, err := collector.DiscoverFriends(ctx, testSteamID, FriendFilter{Deny: []string{"76561197960287932"}})
+	if err != nil {
+		t.Fatalf("DiscoverFriends: %v", err)
+	}
+	if got, want := fmt.Sprint(friends), fmt.Sprint([]string{otherSteamID, "76561197960287933"}); got != want {
+		t.Errorf("got friends %s, want %s", got, want)
+	}
+
+	// An allow list keeps only the friends on it; the friend list is served from the cache
+	friends, err = collector.DiscoverFriends(ctx, testSteamID, FriendFilter{Allow: []string{otherSteamID, "76561197960287999"}})
+	if err != nil {
+		t.Fatalf("DiscoverFriends: %v", err)
+	}
+	if got, want := fmt.Sprint(friends), fmt.Sprint([]string{otherSteamID}); got != want {
+		t.Errorf("got friends %s, want %s", got, want)
+	}
+	if got := srv.Requests("/ISteamUser/GetFriendList/v0001/"); got != 1 {
+		t.Errorf("made %d friend list requests, want 1", got)
+	}
+
+	if _, err := collector.DiscoverFriends(ctx, otherSteamID, FriendFilter{}); err == nil {
+		t.Error("DiscoverFriends of a private friend list succeeded")
+	}
+}
+
+func TestCollectWorkshop(t *testing.T) {
+	srv := testserver.New(t)
+	var items []testserver.WorkshopItem
+	for i := 0; i < 101; i++ {
+		items = append(items, testserver.WorkshopItem{ID: uint64(1000 + i), AppID: 440, Title: fmt.Sprintf("Hat %d", i), Subscriptions: i, Favorited: 2 * i, LifetimeSubscriptions: 10 * i})
+	}
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{Name: "gabe", Workshop: items})
+
+	collector := newTestCollector(t, srv, "key")
+	collector.workshop = true
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.CollectAndCount(workshopSubscribersGauge); got != 101 {
+		t.Errorf("workshop series = %d, want 101 (both pages)", got)
+	}
+	if got := testutil.ToFloat64(workshopLifetimeSubscriptionsGauge.WithLabelValues("440", "1100", "Hat 100", testSteamID, "gabe")); got != 1000 {
+		t.Errorf("lifetime subscriptions = %v, want 1000", got)
+	}
+
+	// Items are cached, and a deleted item disappears once the cache is refreshed
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{Name: "gabe", Workshop: items[:1]})
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/IPublishedFileService/GetUserFiles/v1/"); got != 2 {
+		t.Errorf("workshop requests = %d, want 2 (one collection of two pages)", got)
+	}
+	if err := collector.Collect(cache.WithPolicy(ctx, cache.PolicyFresh), testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.CollectAndCount(workshopSubscribersGauge); got != 1 {
+		t.Errorf("workshop series after deleting items = %d, want 1", got)
+	}
+}
+
+func TestCollectProfileContent(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{
+		Name:          "gabe",
+		ProfileCounts: map[string]int{"Screenshots": 1234, "Reviews": 7, "Badges": 40},
+	})
+	srv.AddSteamUser(otherSteamID, testserver.SteamUser{Name: "robin"})
+
+	collector := newTestCollector(t, srv, "key")
+	collector.profile = NewStoreClient(srv.Transport())
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(profileContentGauge.WithLabelValues("screenshots", testSteamID, "gabe")); got != 1234 {
+		t.Errorf("screenshots = %v, want 1234", got)
+	}
+	if got := testutil.ToFloat64(profileContentGauge.WithLabelValues("reviews", testSteamID, "gabe")); got != 7 {
+		t.Errorf("reviews = %v, want 7", got)
+	}
+	// Content types the profile doesn't list have none
+	if got := testutil.ToFloat64(profileContentGauge.WithLabelValues("guides", testSteamID, "gabe")); got != 0 {
+		t.Errorf("guides = %v, want 0", got)
+	}
+
+	// A private profile lists no counts, so none are reported for it
+	if err := collector.Collect(ctx, otherSteamID); err != nil {
+		t.Fatalf("Collect of a private profile: %v", err)
+	}
+	if got := testutil.CollectAndCount(profileContentGauge); got != len(profileContentTypes) {
+		t.Errorf("profile content series = %d, want %d (only the public profile)", got, len(profileContentTypes))
+	}
+}
+
+func TestCollectGameStats(t *testing.T) {
+	srv := testserver.New(t)
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{
+		Name: "gabe",
+		Games: []testserver.Game{
+			{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 120, Stats: map[string]float64{"Scout.accum.iPointsScored": 1500, "Soldier.max.iDamageDealt": 980}},
+			{AppID: 730, Name: "Counter-Strike 2", PlaytimeMinutes: 60, Stats: map[string]float64{"total_kills": 42}},
+		},
+	})
+	collector := newTestCollector(t, srv, "key")
+	collector.SetStatsApps([]uint64{440})
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(gameStatGauge.WithLabelValues("440", "Team Fortress 2", "Scout.accum.iPointsScored", testSteamID, "gabe")); got != 1500 {
+		t.Errorf("Scout points = %v, want 1500", got)
+	}
+	// Only the listed app's stats are fetched
+	if got := srv.Requests("/ISteamUserStats/GetUserStatsForGame/v0002/"); got != 1 {
+		t.Errorf("user stats requests = %d, want 1", got)
+	}
+
+	// Without new playtime the cached stats are reported
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/ISteamUserStats/GetUserStatsForGame/v0002/"); got != 1 {
+		t.Errorf("user stats requests after a second collection = %d, want 1", got)
+	}
+}
+
+func TestAchievementUnlocks(t *testing.T) {
+	srv := testserver.New(t)
+	game := testserver.Game{
+		AppID:           570,
+		Name:            "Dota 2",
+		PlaytimeMinutes: 60,
+		Achievements:    map[string]bool{"FIRST_BLOOD": true, "RAMPAGE": true, "DIVINE": false},
+		UnlockTimes:     map[string]int64{"FIRST_BLOOD": 1500000000, "RAMPAGE": 1700000000},
+	}
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{Name: "gabe", Games: []testserver.Game{game}})
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	unlocks, err := collector.AchievementUnlocks(ctx, testSteamID, 570)
+	if err != nil {
+		t.Fatalf("AchievementUnlocks: %v", err)
+	}
+	if len(unlocks) != 2 || unlocks[0].APIName != "FIRST_BLOOD" || unlocks[1].APIName != "RAMPAGE" {
+		t.Errorf("unlocks = %+v, want FIRST_BLOOD then RAMPAGE", unlocks)
+	}
+
+	// Unlock times are only fetched again once the collected achievements change
+	if _, err := collector.AchievementUnlocks(ctx, testSteamID, 570); err != nil {
+		t.Fatalf("AchievementUnlocks: %v", err)
+	}
+	if got := srv.Requests("/ISteamUserStats/GetPlayerAchievements/v0001/"); got != 1 {
+		t.Errorf("player achievements requests = %d, want 1", got)
+	}
+
+	game.PlaytimeMinutes = 90
+	game.Achievements = map[string]bool{"FIRST_BLOOD": true, "RAMPAGE": true, "DIVINE": true}
+	game.UnlockTimes = map[string]int64{"FIRST_BLOOD": 1500000000, "RAMPAGE": 1700000000, "DIVINE": 1750000000}
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{Name: "gabe", Games: []testserver.Game{game}})
+	if err := collector.Collect(cache.WithPolicy(ctx, cache.PolicyFresh), testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	unlocks, err = collector.AchievementUnlocks(ctx, testSteamID, 570)
+	if err != nil {
+		t.Fatalf("AchievementUnlocks: %v", err)
+	}
+	if len(unlocks) != 3 || unlocks[2].APIName != "DIVINE" || unlocks[2].UnlockTime != 1750000000 {
+		t.Errorf("unlocks after a new unlock = %+v, want DIVINE last", unlocks)
+	}
+}
+
+func TestCollectRotatesPastRejectedKey(t *testing.T) {
+	srv := newTestServer(t)
+	srv.RejectKey("bad", http.StatusForbidden)
+	collector := newTestCollector(t, srv, "bad", "good")
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	profile, err := collector.Profile(ctx, testSteamID)
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if profile.Username != "gabe" || len(profile.Games) != 3 {
+		t.Errorf("profile = %+v, want gabe's 3 games", profile)
+	}
+	if collector.RateLimited() {
+		t.Error("RateLimited() = true with one key still accepted")
+	}
+}
+
+func TestCollectGameInfo(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetAppInfo(440, testserver.AppInfo{Genres: []string{"Action", "Free to Play"}, ReleaseDate: "10 Oct, 2007", Metacritic: 92})
+	srv.SetAppInfo(570, testserver.AppInfo{Genres: []string{"Strategy"}, ReleaseDate: "Jul 9, 2013", Metacritic: 90})
+	collector := newTestCollector(t, srv, "key")
+	collector.store = NewStoreClient(srv.Transport())
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	data, ok := collector.cache.Get(ctx, gameInfoCacheKey(440))
+	if !ok {
+		t.Fatal("game info wasn't cached")
+	}
+	var info GameInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatalf("failed to decode cached game info: %v", err)
+	}
+	if len(info.Genres) != 2 || info.Genres[0] != "Action" || info.ReleaseYear != 2007 || info.MetacriticScore != 92 {
+		t.Errorf("game info = %+v, want Action and Free to Play, 2007, 92", info)
+	}
+
+	// Every game is looked up once, including the one the store doesn't list
+	if got := srv.Requests("/api/appdetails"); got != 3 {
+		t.Errorf("store requests = %d, want 3", got)
+	}
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := srv.Requests("/api/appdetails"); got != 3 {
+		t.Errorf("store requests after a second collection = %d, want 3 (cached)", got)
+	}
+}
+
+func TestCollectInfoMetrics(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	collector.infoMetrics = true
+	ctx := context.Background()
+	gameInfoGauge.Reset()
+	t.Cleanup(gameInfoGauge.Reset)
+
+	// Without store metadata every game is still reported, for its name
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.CollectAndCount(gameInfoGauge); got != 3 {
+		t.Errorf("game info series = %d, want 3", got)
+	}
+	if got := testutil.ToFloat64(gameInfoGauge.WithLabelValues("440", "Team Fortress 2", testSteamID, "gabe", "", "", "", "", "", "")); got != 1 {
+		t.Errorf("game info without metadata = %v, want 1", got)
+	}
+
+	// Once the metadata is fetched it replaces the game's series
+	srv.SetAppInfo(440, testserver.AppInfo{Genres: []string{"Action"}, ReleaseDate: "10 Oct, 2007", Metacritic: 92})
+	collector.store = NewStoreClient(srv.Transport())
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect with store metadata: %v", err)
+	}
+	if got := testutil.CollectAndCount(gameInfoGauge); got != 3 {
+		t.Errorf("game info series with store metadata = %d, want 3", got)
+	}
+	if got := testutil.ToFloat64(gameInfoGauge.WithLabelValues("440", "Team Fortress 2", testSteamID, "gabe", "Action", "Action", "2007", "92", "", "")); got != 1 {
+		t.Errorf("game info with metadata = %v, want 1", got)
+	}
+}
+
+func TestCollectGameCompat(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetAppInfo(440, testserver.AppInfo{Genres: []string{"Action"}, ProtonDBTier: "platinum", DeckCategory: 3})
+	srv.SetAppInfo(570, testserver.AppInfo{Genres: []string{"Strategy"}, DeckCategory: 1})
+	collector := newTestCollector(t, srv, "key")
+	collector.store = NewStoreClient(srv.Transport())
+	collector.compat = true
+	ctx := context.Background()
+
+	if err := collector.Collect(ctx, testSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	want := map[uint64]GameCompat{
+		440: {AppId: 440, ProtonDBTier: "platinum", DeckStatus: "verified"},
+		570: {AppId: 570, DeckStatus: "unsupported"},
+		620: {AppId: 620, DeckStatus: "unknown"},
+	}
+	for appId, want := range want {
+		data, ok := collector.cache.Get(ctx, gameCompatCacheKey(appId))
+		if !ok {
+			t.Errorf("compatibility of app %d wasn't cached", appId)
+			continue
+		}
+		var got GameCompat
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("failed to decode cached compatibility: %v", err)
+		}
+		if got != want {
+			t.Errorf("compatibility of app %d = %+v, want %+v", appId, got, want)
+		}
+	}
+}
+
+func TestCollectPlaytimeToday(t *testing.T) {
+	srv := newTestServer(t)
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	// The first collection of the day is the baseline
+	if err := collector.Collect(ctx, otherSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.CollectAndCount(playtimeTodayGauge); got != 0 {
+		t.Errorf("got %d playtime today series before any play, want 0", got)
+	}
+
+	srv.AddSteamUser(otherSteamID, testserver.SteamUser{
+		Name:  "robin",
+		Games: []testserver.Game{{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 35}},
+	})
+	collector.cache.Delete(ctx, "steam:owned_games:"+otherSteamID)
+	if err := collector.Collect(ctx, otherSteamID); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(playtimeTodayGauge.WithLabelValues("440", "Team Fortress 2", otherSteamID, "robin")); got != 30*60 {
+		t.Errorf("playtime today = %v, want %v", got, 30*60)
+	}
+}
+
+func TestSchemaDrift(t *testing.T) {
+	srv := testserver.New(t)
+	game := testserver.Game{AppID: 440, Name: "Team Fortress 2", PlaytimeMinutes: 120}
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{Name: "gabe", Games: []testserver.Game{game}})
+	collector := newTestCollector(t, srv, "key")
+	ctx := context.Background()
+
+	ownedGames := func() {
+		t.Helper()
+		if _, err := collector.DebugOwnedGames(ctx, testSteamID); err != nil {
+			t.Fatalf("DebugOwnedGames: %v", err)
+		}
+	}
+	// The first response is the baseline
+	ownedGames()
+
+	// A field new since the baseline is reported once; a missing one on every response. Optional
+	// fields the baseline happened to lack (playtime_2weeks) are declared, so they aren't new.
+	game.Fields = map[string]interface{}{"playtime_2weeks": 30, "playtime_vr_forever": 10, "name": nil}
+	srv.AddSteamUser(testSteamID, testserver.SteamUser{Name: "gabe", Games: []testserver.Game{game}})
+	ownedGames()
+	ownedGames()
+
+	expected := `
+# HELP exporter_upstream_schema_warnings_total Upstream responses that drifted from their expected schema: missing_field (expected and left out) or unknown_field (new since the baseline)
+# TYPE exporter_upstream_schema_warnings_total counter
+exporter_upstream_schema_warnings_total{endpoint="/IPlayerService/GetOwnedGames/v0001/",field="response.games[].name",kind="missing_field",upstream="steam"} 2
+exporter_upstream_schema_warnings_total{endpoint="/IPlayerService/GetOwnedGames/v0001/",field="response.games[].playtime_vr_forever",kind="unknown_field",upstream="steam"} 1
+`
+	if err := testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(expected), "exporter_upstream_schema_warnings_total"); err != nil {
+		t.Error(err)
+	}
+}
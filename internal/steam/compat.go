@@ -0,0 +1,96 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// ProtonDBSummaryURL serves an app's ProtonDB rating summary ({app_id}.json); 404 when it has no reports
+	ProtonDBSummaryURL = "https://www.protondb.com/api/v1/reports/summaries/"
+
+	// DeckCompatibilityURL is the store's Steam Deck compatibility report
+	DeckCompatibilityURL = "https://store.steampowered.com/saleaction/ajaxgetdeckappcompatibilityreport"
+)
+
+// deckCategories maps the store's resolved Deck compatibility category to its name
+var deckCategories = map[int]string{
+	1: "unsupported",
+	2: "playable",
+	3: "verified",
+}
+
+// GetAppCompat retrieves an app's ProtonDB tier and Steam Deck compatibility
+func (c *StoreClient) GetAppCompat(ctx context.Context, appId uint64) (GameCompat, error) {
+	compat := GameCompat{AppId: appId}
+
+	var summary struct {
+		Tier string `json:"tier"`
+	}
+	found, err := c.getCompatJSON(ctx, "protondb", ProtonDBSummaryURL+strconv.FormatUint(appId, 10)+".json", appId, &summary)
+	if err != nil {
+		return GameCompat{}, fmt.Errorf("failed to get ProtonDB summary of app %d: %w", appId, err)
+	}
+	if found {
+		compat.ProtonDBTier = summary.Tier
+	}
+
+	var report struct {
+		Results struct {
+			ResolvedCategory int `json:"resolved_category"`
+		} `json:"results"`
+	}
+	if _, err := c.getCompatJSON(ctx, "deck", DeckCompatibilityURL+"?nAppID="+strconv.FormatUint(appId, 10), appId, &report); err != nil {
+		return GameCompat{}, fmt.Errorf("failed to get Steam Deck compatibility of app %d: %w", appId, err)
+	}
+	compat.DeckStatus = deckCategories[report.Results.ResolvedCategory]
+	if compat.DeckStatus == "" {
+		compat.DeckStatus = "unknown"
+	}
+	return compat, nil
+}
+
+// getCompatJSON decodes a compatibility response into target; found is false on a 404
+func (c *StoreClient) getCompatJSON(ctx context.Context, source string, url string, appId uint64, target interface{}) (found bool, err error) {
+	ctx, span := tracing.Start(ctx, "steam.compat", attribute.String("steam.compat_source", source), attribute.Int64("steam.app_id", int64(appId)))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return true, nil
+}
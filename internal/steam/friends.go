@@ -0,0 +1,135 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Friend lists change rarely
+	friendListTTL = time.Hour
+	// Presence changes by the minute, so it's only cached to absorb back-to-back scrapes
+	friendPresenceTTL = time.Minute
+	// GetPlayerSummaries accepts up to 100 Steam IDs per request
+	playerSummariesBatch = 100
+)
+
+// friendPresence counts a user's friends by presence
+type friendPresence struct {
+	Friends int `json:"friends"`
+	Online  int `json:"online"`
+	InGame  int `json:"in_game"`
+}
+
+// FriendFilter selects which friends friend discovery registers for polling
+type FriendFilter struct {
+	Allow []string // Only these Steam IDs, when set
+	Deny  []string // Never these Steam IDs
+}
+
+// DiscoverFriends returns the Steam IDs of the user's friends that pass the filter, sorted.
+// The friend list is cached for friendListTTL and shared with the friend metrics.
+func (c *Collector) DiscoverFriends(ctx context.Context, steamId string, filter FriendFilter) ([]string, error) {
+	friends, err := c.friendList(ctx, steamId)
+	if err != nil {
+		return nil, err
+	}
+
+	discovered := make([]string, 0, len(friends))
+	for _, friend := range friends {
+		if len(filter.Allow) > 0 && !slices.Contains(filter.Allow, friend) {
+			continue
+		}
+		if slices.Contains(filter.Deny, friend) {
+			continue
+		}
+		discovered = append(discovered, friend)
+	}
+	sort.Strings(discovered)
+	return discovered, nil
+}
+
+// reportFriends reports how many of the user's friends are online and in game. A private
+// friend list is logged and skipped rather than failing the collection.
+func (c *Collector) reportFriends(ctx context.Context, steamId string, username string) {
+	presence, err := c.friendPresence(ctx, steamId)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+		}).Warn("Failed to get friends, skipping friend metrics (is the friend list private?)")
+		return
+	}
+	ReportFriends(presence.Friends, presence.Online, presence.InGame, steamId, username)
+}
+
+// friendPresence returns the user's friend counts, from the cache or the API
+func (c *Collector) friendPresence(ctx context.Context, steamId string) (friendPresence, error) {
+	cacheKey := fmt.Sprintf("steam:friend_presence:%s", steamId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, friendPresenceTTL, friendPresenceTTL, func(ctx context.Context) ([]byte, error) {
+		friends, err := c.friendList(ctx, steamId)
+		if err != nil {
+			return nil, err
+		}
+
+		presence := friendPresence{Friends: len(friends)}
+		for start := 0; start < len(friends); start += playerSummariesBatch {
+			end := start + playerSummariesBatch
+			if end > len(friends) {
+				end = len(friends)
+			}
+			summaries, err := c.client.GetPlayerSummaries(ctx, friends[start:end])
+			if err != nil {
+				return nil, fmt.Errorf("failed to get friend summaries: %w", err)
+			}
+			for _, summary := range summaries {
+				if summary.PersonaState != 0 {
+					presence.Online++
+				}
+				if summary.GameID != "" {
+					presence.InGame++
+				}
+			}
+		}
+		return json.Marshal(presence)
+	})
+	if err != nil {
+		return friendPresence{}, err
+	}
+
+	var presence friendPresence
+	if err := json.Unmarshal(data, &presence); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return friendPresence{}, fmt.Errorf("failed to decode cached friend presence: %w", err)
+	}
+	return presence, nil
+}
+
+// friendList returns the Steam IDs of the user's friends, from the cache or the API
+func (c *Collector) friendList(ctx context.Context, steamId string) ([]string, error) {
+	cacheKey := fmt.Sprintf("steam:friends:%s", steamId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, friendListTTL, friendListTTL, func(ctx context.Context) ([]byte, error) {
+		friends, err := c.client.GetFriendList(ctx, steamId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get friend list: %w", err)
+		}
+		return json.Marshal(friends)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var friends []string
+	if err := json.Unmarshal(data, &friends); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached friend list: %w", err)
+	}
+	return friends, nil
+}
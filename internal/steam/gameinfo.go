@@ -0,0 +1,144 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Store metadata barely changes, so it is cached for 4 weeks (plus up to a week of jitter)
+	gameInfoTTL = 4 * 7 * 24 * time.Hour
+
+	// ProtonDB tiers and Deck status change with game and Proton updates, so they are cached
+	// for a week (plus up to a day of jitter)
+	gameCompatTTL = 7 * 24 * time.Hour
+
+	// gameInfoFetchesPerCollection caps the games looked up per collection, so a large library
+	// is enriched over several collections instead of exhausting the store's per-IP rate limit
+	gameInfoFetchesPerCollection = 20
+)
+
+// gameInfoCacheKey is the cache key for an app's store metadata
+func gameInfoCacheKey(appId uint64) string {
+	return fmt.Sprintf("steam:game_info:%d", appId)
+}
+
+// gameCompatCacheKey is the cache key for an app's ProtonDB and Deck compatibility
+func gameCompatCacheKey(appId uint64) string {
+	return fmt.Sprintf("steam:game_compat:%d", appId)
+}
+
+// reportGameInfo reports steam_game_info for the user's games. Cached metadata is loaded in
+// one round trip; missing metadata is fetched for the most played games first, up to
+// gameInfoFetchesPerCollection, and the rest is left for the next collections.
+func (c *Collector) reportGameInfo(ctx context.Context, steamId string, username string, games []OwnedGame) {
+	keys := make([]string, 0, 2*len(games))
+	for _, game := range games {
+		keys = append(keys, gameInfoCacheKey(game.AppId))
+		if c.compat {
+			keys = append(keys, gameCompatCacheKey(game.AppId))
+		}
+	}
+	cached := c.cache.MGet(ctx, keys...)
+
+	var missing []OwnedGame
+	for _, game := range games {
+		var info GameInfo
+		var compat GameCompat
+		infoCached := cachedGameData(cached, gameInfoCacheKey(game.AppId), &info)
+		compatCached := !c.compat || cachedGameData(cached, gameCompatCacheKey(game.AppId), &compat)
+		if !infoCached || !compatCached {
+			missing = append(missing, game)
+			continue
+		}
+		ReportGameInfo(info, compat, game.Name, steamId, username)
+	}
+
+	sort.SliceStable(missing, func(i, j int) bool {
+		return missing[i].PlaytimeForever > missing[j].PlaytimeForever
+	})
+	fetched := 0
+	for i, game := range missing {
+		// Cache-only collections report what's cached and leave the rest for the next one
+		if i == gameInfoFetchesPerCollection || cache.PolicyFromContext(ctx) == cache.PolicyCachedOnly {
+			break
+		}
+
+		info, compat, err := c.fetchGameInfo(ctx, game.AppId, cached)
+		if err != nil {
+			// Most likely a rate limit, which the remaining requests would hit too
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"steam_id": steamId,
+				"app_id":   game.AppId,
+				"error":    err.Error(),
+			}).Warn("Failed to get game info, retrying next collection")
+			break
+		}
+		ReportGameInfo(info, compat, game.Name, steamId, username)
+		fetched++
+	}
+	if c.infoMetrics {
+		reportGameNames(missing[fetched:], steamId, username)
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"steam_id": steamId,
+		"cached":   len(games) - len(missing),
+		"fetched":  fetched,
+		"pending":  len(missing) - fetched,
+	}).Debug("Reported game info")
+}
+
+// reportGameNames reports games without their store metadata, so with InfoMetrics each game's
+// name can be joined on before its metadata is fetched
+func reportGameNames(games []OwnedGame, steamId string, username string) {
+	for _, game := range games {
+		ReportGameInfo(GameInfo{AppId: game.AppId}, GameCompat{}, game.Name, steamId, username)
+	}
+}
+
+// fetchGameInfo fetches and caches the parts of an app's metadata missing from cached
+func (c *Collector) fetchGameInfo(ctx context.Context, appId uint64, cached map[string][]byte) (GameInfo, GameCompat, error) {
+	var info GameInfo
+	if !cachedGameData(cached, gameInfoCacheKey(appId), &info) {
+		var err error
+		if info, err = c.store.GetAppInfo(ctx, appId); err != nil {
+			return GameInfo{}, GameCompat{}, err
+		}
+		ttl := gameInfoTTL + time.Duration(rand.Intn(7*24))*time.Hour // 4 weeks + 0-7 days jitter
+		c.cacheGameData(ctx, gameInfoCacheKey(appId), info, ttl)
+	}
+
+	var compat GameCompat
+	if c.compat {
+		if !cachedGameData(cached, gameCompatCacheKey(appId), &compat) {
+			var err error
+			if compat, err = c.store.GetAppCompat(ctx, appId); err != nil {
+				return GameInfo{}, GameCompat{}, err
+			}
+			ttl := gameCompatTTL + time.Duration(rand.Intn(24))*time.Hour // 1 week + 0-24 hours jitter
+			c.cacheGameData(ctx, gameCompatCacheKey(appId), compat, ttl)
+		}
+	}
+	return info, compat, nil
+}
+
+// cachedGameData decodes a preloaded cache entry into target, reporting whether there was one
+func cachedGameData(cached map[string][]byte, key string, target interface{}) bool {
+	data, exists := cached[key]
+	return exists && json.Unmarshal(data, target) == nil
+}
+
+func (c *Collector) cacheGameData(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if data, err := json.Marshal(value); err == nil {
+		c.cache.Set(ctx, key, data, ttl)
+	}
+}
@@ -0,0 +1,116 @@
+package steam
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+)
+
+// Key rotation strategies
+const (
+	RotationRoundRobin = "round-robin" // Spread requests evenly across the keys
+	RotationFailover   = "failover"    // Use the first key until it is rate limited
+)
+
+// apiKey is one configured Steam API key with its own rate limit state, so a 403 on one
+// key doesn't stop requests made with the others
+type apiKey struct {
+	key       string
+	name      string // "1", "2"... in configuration order; logged instead of the key
+	rateLimit *RateLimitState
+}
+
+// keyPool rotates requests between the configured keys. It is blocked (CheckAndBlock,
+// Blocked) only when every key is in its backoff period.
+type keyPool struct {
+	keys     []*apiKey
+	rotation string
+	next     atomic.Uint64
+}
+
+func newKeyPool(keys []string, rotation string, policy BackoffPolicy, cache *cache.Cache) *keyPool {
+	pool := &keyPool{rotation: rotation}
+	for i, key := range keys {
+		// The first key keeps the original cache key so its backoff survives upgrades
+		cacheKey := rateLimitCacheKey
+		if i > 0 {
+			fingerprint := sha256.Sum256([]byte(key))
+			cacheKey = rateLimitCacheKey + ":" + hex.EncodeToString(fingerprint[:4])
+		}
+		name := fmt.Sprintf("%d", i+1)
+		pool.keys = append(pool.keys, &apiKey{
+			key:       key,
+			name:      name,
+			rateLimit: newRateLimitState(cache, cacheKey, name, policy),
+		})
+	}
+	return pool
+}
+
+// acquire returns the key to use for the next request, skipping keys in their backoff
+// period, or nil when every key is blocked
+func (p *keyPool) acquire() *apiKey {
+	if len(p.keys) == 0 {
+		return nil
+	}
+
+	start := uint64(0)
+	if p.rotation != RotationFailover {
+		start = p.next.Add(1) - 1
+	}
+	for i := 0; i < len(p.keys); i++ {
+		key := p.keys[(start+uint64(i))%uint64(len(p.keys))]
+		// Blocked first, so skipping a key doesn't log on every request
+		if !key.rateLimit.Blocked() && !key.rateLimit.CheckAndBlock() {
+			return key
+		}
+	}
+	return nil
+}
+
+// CheckAndBlock returns true if every key is in its backoff period
+func (p *keyPool) CheckAndBlock() bool {
+	for _, key := range p.keys {
+		if !key.rateLimit.CheckAndBlock() {
+			return false
+		}
+	}
+	return true
+}
+
+// Blocked reports whether every key is in its backoff period, without logging or clearing
+// expired state
+func (p *keyPool) Blocked() bool {
+	for _, key := range p.keys {
+		if !key.rateLimit.Blocked() {
+			return false
+		}
+	}
+	return true
+}
+
+// Reset clears the backoff of every key
+func (p *keyPool) Reset() {
+	for _, key := range p.keys {
+		key.rateLimit.Reset()
+	}
+}
+
+// only returns a pool holding just the i-th key, used to check each key separately
+func (p *keyPool) only(i int) *keyPool {
+	return &keyPool{keys: p.keys[i : i+1], rotation: RotationFailover}
+}
+
+// redact replaces every configured key in s
+func (p *keyPool) redact(s string) string {
+	for _, key := range p.keys {
+		if key.key != "" {
+			s = strings.ReplaceAll(s, key.key, "[HIDDEN]")
+		}
+	}
+	return s
+}
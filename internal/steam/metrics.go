@@ -1,9 +1,14 @@
 package steam
 
 import (
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cardinality"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 var (
@@ -14,17 +19,194 @@ var (
 		Help:       "Amount of time an owned game has been played (in seconds)",
 	}, []string{"app_id", "game_name", "steam_id", "username"})
 
+	// Keyed by IDs only, so increase() isn't split across series when a game or user is renamed
+	ownedGamePlaytimeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "steam",
+		Subsystem: "owned_games",
+		Name:      "playtime_seconds_total",
+		Help:      "Amount of time an owned game has been played (in seconds); join steam_player_info on steam_id for the username",
+	}, []string{"app_id", "steam_id"})
+
+	playerInfoGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Name:      "player_info",
+		Help:      "Current username of a Steam user (always 1)",
+	}, []string{"steam_id", "username"})
+
+	playtimeTodayGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Name:      "playtime_today_seconds",
+		Help:      "Amount of time a game has been played since local midnight (in seconds); games not played today are left out",
+	}, []string{"app_id", "game_name", "steam_id", "username"})
+
 	achievementGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "steam",
 		Subsystem: "achievements",
 		Name:      "achieved",
 		Help:      "Whether an achievement has been achieved (1) or not (0)",
 	}, []string{"app_id", "game_name", "achievement_name", "steam_id", "username", "achieved"})
+
+	achievementGlobalPercentGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "achievement",
+		Name:      "global_percent",
+		Help:      "Percentage of all players of a game who have unlocked an achievement",
+	}, []string{"app_id", "achievement_name"})
+
+	rarestAchievementGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "game",
+		Name:      "rarest_achievement_percent",
+		Help:      "Global unlock percentage of the rarest achievement the user has unlocked in a game",
+	}, []string{"app_id", "game_name", "steam_id", "username"})
+
+	completionRatioGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "game",
+		Name:      "completion_ratio",
+		Help:      "Share of a game's achievements the user has unlocked (0 to 1)",
+	}, []string{"app_id", "game_name", "steam_id", "username"})
+
+	gameStatGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "game",
+		Name:      "stat",
+		Help:      "Value of a game-defined stat of the user, for the apps listed in stats_apps",
+	}, []string{"app_id", "game_name", "stat", "steam_id", "username"})
+
+	friendsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Name:      "friends",
+		Help:      "Number of friends of the user",
+	}, []string{"steam_id", "username"})
+
+	friendsOnlineGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "friends",
+		Name:      "online",
+		Help:      "Number of the user's friends currently online (friends with private profiles count as offline)",
+	}, []string{"steam_id", "username"})
+
+	friendsInGameGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "friends",
+		Name:      "in_game",
+		Help:      "Number of the user's friends currently playing a game",
+	}, []string{"steam_id", "username"})
+
+	workshopSubscribersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "workshop",
+		Name:      "subscribers",
+		Help:      "Current subscribers of a Workshop item published by the user",
+	}, []string{"app_id", "item_id", "title", "steam_id", "username"})
+
+	workshopFavoritesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "workshop",
+		Name:      "favorites",
+		Help:      "Current favorites of a Workshop item published by the user",
+	}, []string{"app_id", "item_id", "title", "steam_id", "username"})
+
+	workshopLifetimeSubscriptionsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "workshop",
+		Name:      "lifetime_subscriptions",
+		Help:      "Unique users who ever subscribed to a Workshop item published by the user (Steam's closest figure to lifetime downloads)",
+	}, []string{"app_id", "item_id", "title", "steam_id", "username"})
+
+	profileContentGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "profile",
+		Name:      "content_count",
+		Help:      "Content the user published on their community profile, by type (screenshots, reviews, guides, ...)",
+	}, []string{"type", "steam_id", "username"})
+
+	gameInfoGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "game",
+		Name:      "info",
+		Help:      "Store metadata of an owned game, always 1; join on app_id to group playtime by genre or year",
+	}, []string{"app_id", "game_name", "steam_id", "username", "genre", "genres", "release_year", "metacritic_score", "protondb_tier", "deck_status"})
+
+	collectionPartialGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Name:      "collection_partial",
+		Help:      "Games of the user's last collection reported in full (result=succeeded) or without their achievements (result=failed)",
+	}, []string{"steam_id", "username", "result"})
+
+	achievementRefreshesDeferredGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Name:      "achievement_refreshes_deferred",
+		Help:      "Games of the user's last collection due an achievement refresh that was left to a later collection by STEAM_MAX_ACHIEVEMENT_REFRESHES",
+	}, []string{"steam_id", "username"})
+
+	appPriceGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "app",
+		Name:      "price_cents",
+		Help:      "Current store price of an app, after any discount (in the currency's smallest unit)",
+	}, []string{"app_id", "currency"})
+
+	appDiscountGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "steam",
+		Subsystem: "app",
+		Name:      "discount_percent",
+		Help:      "Current store discount of an app (0 when not on sale)",
+	}, []string{"app_id", "currency"})
 )
 
+// InfoLabels are the labels that change without the series' identity changing (a rename),
+// mapped to the info metric that carries them. With STEAM_INFO_METRICS they're only served on
+// their info metric, and other series are joined with it on steam_id or app_id and steam_id.
+var InfoLabels = map[string]string{
+	"username":  "steam_player_info",
+	"game_name": "steam_game_info",
+}
+
+// renamableGauges are the gauges labelled with a username or game name, which a rename supersedes.
+// steam_player_info is left out, as ReportPlayerInfo replaces it itself.
+var renamableGauges = []*prometheus.GaugeVec{
+	ownedGamePlaytimeGauge,
+	playtimeTodayGauge,
+	achievementGauge,
+	rarestAchievementGauge,
+	completionRatioGauge,
+	gameStatGauge,
+	friendsGauge,
+	friendsOnlineGauge,
+	friendsInGameGauge,
+	workshopSubscribersGauge,
+	workshopFavoritesGauge,
+	workshopLifetimeSubscriptionsGauge,
+	profileContentGauge,
+	gameInfoGauge,
+	collectionPartialGauge,
+	achievementRefreshesDeferredGauge,
+}
+
 func init() {
 	prometheus.MustRegister(ownedGamePlaytimeGauge)
+	prometheus.MustRegister(ownedGamePlaytimeCounter)
+	prometheus.MustRegister(playerInfoGauge)
+	prometheus.MustRegister(playtimeTodayGauge)
 	prometheus.MustRegister(achievementGauge)
+	prometheus.MustRegister(achievementGlobalPercentGauge)
+	prometheus.MustRegister(rarestAchievementGauge)
+	prometheus.MustRegister(completionRatioGauge)
+	prometheus.MustRegister(gameStatGauge)
+	prometheus.MustRegister(friendsGauge)
+	prometheus.MustRegister(friendsOnlineGauge)
+	prometheus.MustRegister(friendsInGameGauge)
+	prometheus.MustRegister(workshopSubscribersGauge)
+	prometheus.MustRegister(workshopFavoritesGauge)
+	prometheus.MustRegister(workshopLifetimeSubscriptionsGauge)
+	prometheus.MustRegister(profileContentGauge)
+	prometheus.MustRegister(gameInfoGauge)
+	prometheus.MustRegister(collectionPartialGauge)
+	prometheus.MustRegister(achievementRefreshesDeferredGauge)
+	prometheus.MustRegister(appPriceGauge)
+	prometheus.MustRegister(appDiscountGauge)
 }
 
 // ReportOwnedGame reports playtime metrics for a game
@@ -37,10 +219,147 @@ func ReportOwnedGame(game OwnedGame, userId string, username string) {
 		"steam_id":  userId,
 		"username":  username,
 	}).Set(playtimeSeconds)
+	addPlaytime(strconv.FormatUint(game.AppId, 10), userId, playtimeSeconds)
+}
+
+// playtimeTotals is the playtime each playtime counter was last brought up to, by app and
+// Steam ID. Steam reports a running total, so the counters are advanced by the difference.
+var playtimeTotals = struct {
+	sync.Mutex
+	seconds map[[2]string]float64
+}{seconds: make(map[[2]string]float64)}
+
+// addPlaytime brings the game's playtime counter up to seconds. A counter first reported after
+// a restart starts at the full playtime, which Prometheus takes for the value it already had.
+// Playtime that went down (a restored snapshot) is ignored rather than read as a counter reset,
+// which increase() would count as all of it having been played again.
+func addPlaytime(appId string, userId string, seconds float64) {
+	playtimeTotals.Lock()
+	defer playtimeTotals.Unlock()
+
+	key := [2]string{appId, userId}
+	last, seen := playtimeTotals.seconds[key]
+	if seen && seconds <= last {
+		return
+	}
+	ownedGamePlaytimeCounter.WithLabelValues(appId, userId).Add(seconds - last)
+	playtimeTotals.seconds[key] = seconds
 }
 
-// ReportAchievements reports achievement metrics for a game
-func ReportAchievements(userAchievements []Achievement, globalAchievements []GlobalAchievement, gameName string, appId uint64, userId string, username string) {
+// ReportPlayerInfo reports the user's current username, replacing the one reported before so a
+// renamed user keeps a single series
+func ReportPlayerInfo(userId string, username string) {
+	playerInfoGauge.DeletePartialMatch(prometheus.Labels{"steam_id": userId})
+	playerInfoGauge.WithLabelValues(userId, username).Set(1)
+}
+
+// ReportNames moves the user's series labelled with a superseded username or game name over to
+// the current names (gameNames maps app IDs to them, nil when unknown), so a rename leaves one
+// series per game rather than the old one alongside the new. Otherwise the two would only differ
+// in the renamed label, and dropping it (METRIC_DROP_LABELS, STEAM_INFO_METRICS) would collapse
+// them. A series already reported under the current names keeps its value, and one that wasn't
+// (e.g. the achievements of a game skipped by this collection) keeps the old series' value.
+func ReportNames(userId string, username string, gameNames map[string]string) {
+	for _, vec := range renamableGauges {
+		renameSeries(vec, userId, username, gameNames)
+	}
+}
+
+func renameSeries(vec *prometheus.GaugeVec, userId string, username string, gameNames map[string]string) {
+	type series struct {
+		labels prometheus.Labels
+		value  float64
+	}
+	var user []series
+	reported := make(map[string]bool)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+	for metric := range ch {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil {
+			continue
+		}
+		labels := make(prometheus.Labels, len(m.Label))
+		for _, pair := range m.Label {
+			labels[pair.GetName()] = pair.GetValue()
+		}
+		if labels["steam_id"] != userId {
+			continue
+		}
+		user = append(user, series{labels: labels, value: m.GetGauge().GetValue()})
+		reported[seriesKey(labels)] = true
+	}
+
+	for _, s := range user {
+		current := make(prometheus.Labels, len(s.labels))
+		for name, value := range s.labels {
+			current[name] = value
+		}
+		if _, ok := current["username"]; ok && username != "" {
+			current["username"] = username
+		}
+		if name, ok := gameNames[current["app_id"]]; ok {
+			if _, labelled := current["game_name"]; labelled {
+				current["game_name"] = name
+			}
+		}
+
+		key := seriesKey(current)
+		if key == seriesKey(s.labels) {
+			continue
+		}
+		vec.Delete(s.labels)
+		if !reported[key] {
+			vec.With(current).Set(s.value)
+			reported[key] = true
+		}
+	}
+}
+
+// seriesKey identifies a series by its labels
+func seriesKey(labels prometheus.Labels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var key strings.Builder
+	for _, name := range names {
+		key.WriteString(name)
+		key.WriteByte(0xff)
+		key.WriteString(labels[name])
+		key.WriteByte(0xff)
+	}
+	return key.String()
+}
+
+// ReportPlaytimeToday reports the user's playtime since midnight (in minutes, by app ID),
+// replacing what was reported for them before so yesterday's games drop out
+func ReportPlaytimeToday(today map[string]int64, games []OwnedGame, userId string, username string) {
+	playtimeTodayGauge.DeletePartialMatch(prometheus.Labels{"steam_id": userId})
+	for _, game := range games {
+		appId := strconv.FormatUint(game.AppId, 10)
+		minutes, played := today[appId]
+		if !played {
+			continue
+		}
+		playtimeTodayGauge.With(prometheus.Labels{
+			"app_id":    appId,
+			"game_name": game.Name,
+			"steam_id":  userId,
+			"username":  username,
+		}).Set(float64(60 * minutes))
+	}
+}
+
+// ReportAchievements reports achievement metrics for a game. Only the first limit achievements
+// (all when 0) get per-achievement series, and only while budget allows; the completion ratio and
+// rarest achievement still cover every achievement.
+func ReportAchievements(userAchievements []Achievement, globalAchievements []GlobalAchievement, gameName string, appId uint64, userId string, username string, limit int, budget *cardinality.Budget) {
 	// Create a map of user achievements for quick lookup
 	userAchievementMap := make(map[string]int)
 	for _, achievement := range userAchievements {
@@ -48,12 +367,34 @@ func ReportAchievements(userAchievements []Achievement, globalAchievements []Glo
 	}
 
 	// Report all achievements, using 0 for unearned ones
-	for _, globalAchievement := range globalAchievements {
+	unlocked := 0
+	rarest := -1.0
+	for i, globalAchievement := range globalAchievements {
 		achieved := 0
 		if earned, exists := userAchievementMap[globalAchievement.Name]; exists {
 			achieved = earned
 		}
 
+		percent, err := strconv.ParseFloat(globalAchievement.Percent, 64)
+		if achieved == 1 {
+			unlocked++
+			if err == nil && (rarest < 0 || percent < rarest) {
+				rarest = percent
+			}
+		}
+
+		// Achievements past the per-game limit are counted as dropped below
+		if (limit > 0 && i >= limit) || !budget.Allow("steam_achievements_achieved", strconv.FormatUint(appId, 10), globalAchievement.Name, userId) {
+			continue
+		}
+
+		if err == nil {
+			achievementGlobalPercentGauge.With(prometheus.Labels{
+				"app_id":           strconv.FormatUint(appId, 10),
+				"achievement_name": globalAchievement.Name,
+			}).Set(percent)
+		}
+
 		// Create a more meaningful achieved label
 		achievedLabel := "false"
 		if achieved == 1 {
@@ -69,5 +410,156 @@ func ReportAchievements(userAchievements []Achievement, globalAchievements []Glo
 			"achieved":         achievedLabel,
 		}).Set(float64(achieved))
 	}
+
+	if limit > 0 && len(globalAchievements) > limit {
+		cardinality.Dropped("steam", "steam_achievements_achieved", len(globalAchievements)-limit)
+	}
+
+	if len(globalAchievements) > 0 {
+		completionRatioGauge.With(prometheus.Labels{
+			"game_name": gameName,
+			"app_id":    strconv.FormatUint(appId, 10),
+			"steam_id":  userId,
+			"username":  username,
+		}).Set(float64(unlocked) / float64(len(globalAchievements)))
+	}
+	if rarest >= 0 {
+		rarestAchievementGauge.With(prometheus.Labels{
+			"game_name": gameName,
+			"app_id":    strconv.FormatUint(appId, 10),
+			"steam_id":  userId,
+			"username":  username,
+		}).Set(rarest)
+	}
 }
 
+
+// ReportGameStats reports the user's stats in a game
+func ReportGameStats(stats []GameStat, gameName string, appId uint64, userId string, username string) {
+	for _, stat := range stats {
+		gameStatGauge.With(prometheus.Labels{
+			"app_id":    strconv.FormatUint(appId, 10),
+			"game_name": gameName,
+			"stat":      stat.Name,
+			"steam_id":  userId,
+			"username":  username,
+		}).Set(stat.Value)
+	}
+}
+
+// ReportFriends reports how many friends the user has and how many are online and in game
+func ReportFriends(friends int, online int, inGame int, userId string, username string) {
+	labels := prometheus.Labels{"steam_id": userId, "username": username}
+	friendsGauge.With(labels).Set(float64(friends))
+	friendsOnlineGauge.With(labels).Set(float64(online))
+	friendsInGameGauge.With(labels).Set(float64(inGame))
+}
+
+// ReportCollectionPartial reports how many of the user's games were collected in full and how
+// many failed, replacing the user's previous series
+func ReportCollectionPartial(succeeded int, failed int, userId string, username string) {
+	collectionPartialGauge.DeletePartialMatch(prometheus.Labels{"steam_id": userId})
+	labels := prometheus.Labels{"steam_id": userId, "username": username}
+	labels["result"] = "succeeded"
+	collectionPartialGauge.With(labels).Set(float64(succeeded))
+	labels["result"] = "failed"
+	collectionPartialGauge.With(labels).Set(float64(failed))
+}
+
+// ReportAchievementRefreshesDeferred reports how many of the user's games were due an achievement
+// refresh the collection's budget left to a later one
+func ReportAchievementRefreshesDeferred(deferred int, userId string, username string) {
+	achievementRefreshesDeferredGauge.DeletePartialMatch(prometheus.Labels{"steam_id": userId})
+	achievementRefreshesDeferredGauge.WithLabelValues(userId, username).Set(float64(deferred))
+}
+
+// ReportWorkshopItems reports the user's Workshop items, replacing the user's previous series
+// so deleted items disappear
+func ReportWorkshopItems(items []WorkshopItem, userId string, username string) {
+	user := prometheus.Labels{"steam_id": userId}
+	workshopSubscribersGauge.DeletePartialMatch(user)
+	workshopFavoritesGauge.DeletePartialMatch(user)
+	workshopLifetimeSubscriptionsGauge.DeletePartialMatch(user)
+
+	for _, item := range items {
+		labels := prometheus.Labels{
+			"app_id":   strconv.FormatUint(item.ConsumerAppID, 10),
+			"item_id":  item.PublishedFileID,
+			"title":    item.Title,
+			"steam_id": userId,
+			"username": username,
+		}
+		workshopSubscribersGauge.With(labels).Set(float64(item.Subscriptions))
+		workshopFavoritesGauge.With(labels).Set(float64(item.Favorited))
+		workshopLifetimeSubscriptionsGauge.With(labels).Set(float64(item.LifetimeSubscriptions))
+	}
+}
+
+// ReportProfileContent reports the content counts of the user's profile by type, replacing the
+// user's previous series. Types the profile doesn't list have none, so they're reported as 0 as
+// long as the profile listed any (a private profile lists none and is left out).
+func ReportProfileContent(counts map[string]int, userId string, username string) {
+	profileContentGauge.DeletePartialMatch(prometheus.Labels{"steam_id": userId})
+	if len(counts) == 0 {
+		return
+	}
+	for _, contentType := range profileContentTypes {
+		profileContentGauge.With(prometheus.Labels{
+			"type":     contentType,
+			"steam_id": userId,
+			"username": username,
+		}).Set(float64(counts[contentType]))
+	}
+}
+
+// ReportGameInfo reports the store metadata and compatibility of an owned game, replacing the
+// game's previous series for the user. genre is the first (main) genre and genres all of them,
+// comma-separated; unknown values are empty.
+func ReportGameInfo(info GameInfo, compat GameCompat, gameName string, userId string, username string) {
+	genre := ""
+	if len(info.Genres) > 0 {
+		genre = info.Genres[0]
+	}
+	releaseYear := ""
+	if info.ReleaseYear > 0 {
+		releaseYear = strconv.Itoa(info.ReleaseYear)
+	}
+	metacriticScore := ""
+	if info.MetacriticScore > 0 {
+		metacriticScore = strconv.Itoa(info.MetacriticScore)
+	}
+
+	appId := strconv.FormatUint(info.AppId, 10)
+	gameInfoGauge.DeletePartialMatch(prometheus.Labels{"app_id": appId, "steam_id": userId})
+	gameInfoGauge.With(prometheus.Labels{
+		"app_id":           appId,
+		"game_name":        gameName,
+		"steam_id":         userId,
+		"username":         username,
+		"genre":            genre,
+		"genres":           strings.Join(info.Genres, ","),
+		"release_year":     releaseYear,
+		"metacritic_score": metacriticScore,
+		"protondb_tier":    compat.ProtonDBTier,
+		"deck_status":      compat.DeckStatus,
+	}).Set(1)
+}
+
+// ReportPrices reports store price metrics, replacing those of the previous collection.
+// Free apps and apps not sold in a region have no price to report.
+func ReportPrices(prices []AppPrice) {
+	appPriceGauge.Reset()
+	appDiscountGauge.Reset()
+
+	for _, price := range prices {
+		if !price.Available || price.Free {
+			continue
+		}
+		labels := prometheus.Labels{
+			"app_id":   strconv.FormatUint(price.AppId, 10),
+			"currency": price.Currency,
+		}
+		appPriceGauge.With(labels).Set(float64(price.FinalCents))
+		appDiscountGauge.With(labels).Set(float64(price.DiscountPercent))
+	}
+}
@@ -0,0 +1,17 @@
+package steam
+
+import "context"
+
+type skipAchievementsKey struct{}
+
+// WithoutAchievements returns a context whose collections report playtime and game info but
+// skip achievements, the most expensive part of a collection
+func WithoutAchievements(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipAchievementsKey{}, true)
+}
+
+// SkipAchievements reports whether the context's collections skip achievements
+func SkipAchievements(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipAchievementsKey{}).(bool)
+	return skip
+}
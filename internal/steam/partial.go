@@ -0,0 +1,75 @@
+package steam
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxGameErrorsShown caps how many failed games CollectionError.Error lists
+const maxGameErrorsShown = 3
+
+// GameError is a game whose achievements couldn't be collected
+type GameError struct {
+	AppID uint64
+	Name  string
+	Err   error
+}
+
+func (e GameError) Error() string {
+	return fmt.Sprintf("%s (%d): %v", e.Name, e.AppID, e.Err)
+}
+
+func (e GameError) Unwrap() error {
+	return e.Err
+}
+
+// CollectionError is returned by Collect when part of a user's collection failed; everything
+// that could be collected was still reported. Without the owned games no game was reported and
+// the collection failed; otherwise it is partial, the failed games missing their achievements.
+type CollectionError struct {
+	SteamID    string
+	OwnedGames error       // Why the owned games couldn't be fetched, nil if they were
+	Games      []GameError // Games whose achievements couldn't be collected
+	Succeeded  int         // Games reported in full
+}
+
+func (e *CollectionError) Error() string {
+	if e.OwnedGames != nil {
+		return fmt.Sprintf("failed to get owned games: %v", e.OwnedGames)
+	}
+
+	shown := make([]string, 0, maxGameErrorsShown)
+	for _, game := range e.Games[:min(len(e.Games), maxGameErrorsShown)] {
+		shown = append(shown, game.Error())
+	}
+	message := fmt.Sprintf("failed to collect %d of %d games: %s", len(e.Games), len(e.Games)+e.Succeeded, strings.Join(shown, "; "))
+	if more := len(e.Games) - len(shown); more > 0 {
+		message += fmt.Sprintf(" (and %d more)", more)
+	}
+	return message
+}
+
+// Unwrap returns the underlying errors, so errors.Is sees through to e.g. a rate limit
+func (e *CollectionError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Games)+1)
+	if e.OwnedGames != nil {
+		errs = append(errs, e.OwnedGames)
+	}
+	for _, game := range e.Games {
+		errs = append(errs, game)
+	}
+	return errs
+}
+
+// Partial reports whether the user's games were reported, only some of them without their
+// achievements
+func (e *CollectionError) Partial() bool {
+	return e.OwnedGames == nil
+}
+
+// IsPartial reports whether err is from a partial collection, whose metrics are worth serving
+func IsPartial(err error) bool {
+	var collectionErr *CollectionError
+	return errors.As(err, &collectionErr) && collectionErr.Partial()
+}
@@ -0,0 +1,128 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Prices are cached per region for an hour (plus jitter), so a sale shows up within the hour
+// without spending the store's per-IP rate limit, and served stale for as long again
+const priceTTL = time.Hour
+
+// DefaultPriceRegion is used when no region is configured
+const DefaultPriceRegion = "us"
+
+// PriceConfig configures the store price collector
+type PriceConfig struct {
+	AppIDs    []uint64
+	Regions   []string          // Store country codes, e.g. "us", "gb"; DefaultPriceRegion when empty
+	Transport http.RoundTripper // Upstream transport, e.g. fixture replay; nil for the default
+}
+
+// PriceCollector tracks the store price and discount of a configured list of apps.
+// It needs no API key, so it runs whether or not STEAM_KEY is set.
+type PriceCollector struct {
+	client  *StoreClient
+	cache   *cache.Cache
+	appIDs  []uint64
+	regions []string
+}
+
+func NewPriceCollector(config PriceConfig, cache *cache.Cache) *PriceCollector {
+	regions := config.Regions
+	if len(regions) == 0 {
+		regions = []string{DefaultPriceRegion}
+	}
+	return &PriceCollector{
+		client:  NewStoreClient(config.Transport),
+		cache:   cache,
+		appIDs:  config.AppIDs,
+		regions: regions,
+	}
+}
+
+// CollectPrices collects and reports the price of every configured app in every region
+func (c *PriceCollector) CollectPrices(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "steam.collect_prices", attribute.Int("steam.apps", len(c.appIDs)))
+	defer func() { tracing.End(span, err) }()
+
+	prices, err := c.Prices(ctx)
+	if err != nil {
+		return err
+	}
+	ReportPrices(prices)
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"apps":    len(c.appIDs),
+		"regions": len(c.regions),
+		"prices":  len(prices),
+	}).Info("Completed Steam price collection")
+	return nil
+}
+
+// Prices returns the price of every configured app in every region from the cache, fetching
+// on a miss. Apps whose price can't be fetched are left out; it fails only when none could be.
+func (c *PriceCollector) Prices(ctx context.Context) ([]AppPrice, error) {
+	var prices []AppPrice
+	var errs []error
+	for _, region := range c.regions {
+		for _, appId := range c.appIDs {
+			price, err := c.getPrice(ctx, appId, region)
+			if err != nil {
+				logger.FromContext(ctx).WithFields(logrus.Fields{
+					"app_id": appId,
+					"region": region,
+					"error":  err.Error(),
+				}).Warn("Failed to get app price, continuing")
+				errs = append(errs, err)
+				continue
+			}
+			prices = append(prices, price)
+		}
+	}
+
+	if len(prices) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return prices, nil
+}
+
+// getPrice retrieves an app's price in a region, using the cache if available
+func (c *PriceCollector) getPrice(ctx context.Context, appId uint64, region string) (AppPrice, error) {
+	cacheKey := fmt.Sprintf("steam:app_price:%s:%d", region, appId)
+	ttl := priceTTL + time.Duration(rand.Intn(10))*time.Minute // 1 hour + 0-10 minutes jitter
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, ttl, ttl, func(ctx context.Context) ([]byte, error) {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"app_id": appId,
+			"region": region,
+			"cache":  "miss",
+		}).Debug("Fetching app price from the Steam store")
+
+		price, err := c.client.GetAppPrice(ctx, appId, region)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(price)
+	})
+	if err != nil {
+		return AppPrice{}, err
+	}
+
+	var price AppPrice
+	if err := json.Unmarshal(data, &price); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return AppPrice{}, fmt.Errorf("failed to decode cached app price: %w", err)
+	}
+	return price, nil
+}
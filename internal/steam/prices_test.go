@@ -0,0 +1,68 @@
+package steam
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/testserver"
+)
+
+func newTestPriceCollector(t *testing.T, srv *testserver.Server, appIDs []uint64, regions ...string) *PriceCollector {
+	t.Helper()
+	return NewPriceCollector(PriceConfig{AppIDs: appIDs, Regions: regions, Transport: srv.Transport()}, testserver.NewCache(t))
+}
+
+func TestPrices(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetPrice(1145360, "us", &testserver.Price{Currency: "USD", Initial: 2499, Final: 1249, DiscountPercent: 50})
+	srv.SetPrice(1145360, "gb", &testserver.Price{Currency: "GBP", Initial: 1999, Final: 1999})
+	srv.SetPrice(440, "us", nil)
+	collector := newTestPriceCollector(t, srv, []uint64{1145360, 440, 999999}, "us", "gb")
+	ctx := context.Background()
+
+	prices, err := collector.Prices(ctx)
+	if err != nil {
+		t.Fatalf("Prices: %v", err)
+	}
+	byRegion := map[string]map[uint64]AppPrice{}
+	for _, price := range prices {
+		if byRegion[price.Region] == nil {
+			byRegion[price.Region] = map[uint64]AppPrice{}
+		}
+		byRegion[price.Region][price.AppId] = price
+	}
+
+	if got := byRegion["us"][1145360]; got.Currency != "USD" || got.FinalCents != 1249 || got.DiscountPercent != 50 {
+		t.Errorf("us price = %+v, want USD 1249 at 50%% off", got)
+	}
+	if got := byRegion["gb"][1145360]; got.Currency != "GBP" || got.FinalCents != 1999 || got.DiscountPercent != 0 {
+		t.Errorf("gb price = %+v, want GBP 1999", got)
+	}
+	if got := byRegion["us"][440]; !got.Available || !got.Free {
+		t.Errorf("free app = %+v, want available and free", got)
+	}
+	if got := byRegion["us"][999999]; got.Available {
+		t.Errorf("unsold app = %+v, want unavailable", got)
+	}
+
+	// Cached per region, so a second collection doesn't call the store
+	requests := srv.Requests("/api/appdetails")
+	if err := collector.CollectPrices(ctx); err != nil {
+		t.Fatalf("CollectPrices: %v", err)
+	}
+	if got := srv.Requests("/api/appdetails"); got != requests {
+		t.Errorf("made %d store requests with a warm cache, want 0", got-requests)
+	}
+}
+
+func TestPricesRateLimited(t *testing.T) {
+	srv := testserver.New(t)
+	srv.SetPrice(1145360, "us", &testserver.Price{Currency: "USD", Initial: 2499, Final: 2499})
+	srv.SetSteamStatus(http.StatusTooManyRequests)
+	collector := newTestPriceCollector(t, srv, []uint64{1145360})
+
+	if err := collector.CollectPrices(context.Background()); err == nil {
+		t.Fatal("CollectPrices succeeded while the store was rate limiting")
+	}
+}
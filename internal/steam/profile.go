@@ -0,0 +1,127 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// CommunityProfileURL is a user's community profile page ({steam_id}/). The Web API has no
+	// counts of screenshots, reviews or guides, so they're read from the page.
+	CommunityProfileURL = "https://steamcommunity.com/profiles/"
+
+	// Content counts change slowly, and the community site is rate limited per IP
+	profileContentTTL = 6 * time.Hour
+)
+
+// profileContentTypes maps the (English) labels of the profile's count links to the type
+// label of steam_profile_content_count
+var profileContentTypes = map[string]string{
+	"Screenshots":    "screenshots",
+	"Videos":         "videos",
+	"Artwork":        "artwork",
+	"Reviews":        "reviews",
+	"Guides":         "guides",
+	"Workshop Items": "workshop_items",
+}
+
+// profileCountPattern matches a count link of the profile page:
+// <span class="count_link_label">Screenshots</span>&nbsp;<span class="profile_count_link_total">1,234</span>
+var profileCountPattern = regexp.MustCompile(`(?s)<span class="count_link_label">([^<]+)</span>.*?<span class="profile_count_link_total">\s*([\d,]+)\s*</span>`)
+
+// GetProfileContentCounts retrieves the content counts (by type) shown on a user's community
+// profile. A private profile shows none, so it returns an empty map.
+func (c *StoreClient) GetProfileContentCounts(ctx context.Context, steamId string) (counts map[string]int, err error) {
+	ctx, span := tracing.Start(ctx, "steam.community_profile", attribute.String("steam.id", steamId))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", CommunityProfileURL+steamId+"/?l=english", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("rate limited by the Steam community site (%d)", resp.StatusCode)
+	default:
+		return nil, fmt.Errorf("unexpected status code %d from the Steam community site", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	counts = make(map[string]int)
+	for _, match := range profileCountPattern.FindAllStringSubmatch(string(body), -1) {
+		contentType, ok := profileContentTypes[strings.TrimSpace(match[1])]
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(strings.ReplaceAll(match[2], ",", ""))
+		if err != nil {
+			continue
+		}
+		counts[contentType] = count
+	}
+	return counts, nil
+}
+
+// reportProfileContent reports the content counts of the user's community profile. A failure
+// is logged and skipped rather than failing the collection.
+func (c *Collector) reportProfileContent(ctx context.Context, steamId string, username string) {
+	counts, err := c.profileContent(ctx, steamId)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+		}).Warn("Failed to get profile content counts, skipping them")
+		return
+	}
+	ReportProfileContent(counts, steamId, username)
+}
+
+// profileContent returns the user's profile content counts, from the cache or the profile page
+func (c *Collector) profileContent(ctx context.Context, steamId string) (map[string]int, error) {
+	cacheKey := fmt.Sprintf("steam:profile_content:%s", steamId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, profileContentTTL, profileContentTTL, func(ctx context.Context) ([]byte, error) {
+		counts, err := c.profile.GetProfileContentCounts(ctx, steamId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get profile page: %w", err)
+		}
+		return json.Marshal(counts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var counts map[string]int
+	if err := json.Unmarshal(data, &counts); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached profile content counts: %w", err)
+	}
+	return counts, nil
+}
@@ -1,6 +1,7 @@
 package steam
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
 	"time"
@@ -18,19 +19,47 @@ type RateLimitState struct {
 	BackoffHours   int           `json:"backoff_hours"` // Current backoff duration in hours
 	mu             sync.RWMutex  `json:"-"`
 	cache          *cache.Cache  `json:"-"`
+	cacheKey       string        `json:"-"`
+	key            string        `json:"-"` // Which API key this state belongs to, for logs
+	policy         BackoffPolicy `json:"-"`
 }
 
-const (
-	rateLimitCacheKey = "steam:rate_limit_state"
-	initialBackoff    = 1 * time.Hour   // Start with 1 hour backoff
-	maxBackoff        = 24 * time.Hour  // Max 24 hours backoff
-	backoffMultiplier = 2               // Double each time
-)
+const rateLimitCacheKey = "steam:rate_limit_state"
+
+// BackoffPolicy is the exponential backoff applied after consecutive 403/429 responses:
+// Initial, then multiplied by Multiplier for every further one, capped at Max
+type BackoffPolicy struct {
+	Initial    time.Duration
+	Multiplier float64
+	Max        time.Duration
+}
+
+// DefaultBackoffPolicy backs off aggressively (1h, 2h, 4h... up to 24h) to avoid a permanent ban
+var DefaultBackoffPolicy = BackoffPolicy{
+	Initial:    1 * time.Hour,
+	Multiplier: 2,
+	Max:        24 * time.Hour,
+}
+
+// duration returns the backoff after the given number of consecutive 403s
+func (p BackoffPolicy) duration(consecutive403 int) time.Duration {
+	backoff := p.Initial
+	for i := 0; i < consecutive403-1 && backoff < p.Max; i++ {
+		backoff = time.Duration(float64(backoff) * p.Multiplier)
+	}
+	if backoff > p.Max {
+		backoff = p.Max
+	}
+	return backoff
+}
 
-// NewRateLimitState creates a new rate limiter
-func NewRateLimitState(cache *cache.Cache) *RateLimitState {
+// newRateLimitState creates the rate limiter of one API key, persisted under cacheKey
+func newRateLimitState(cache *cache.Cache, cacheKey string, key string, policy BackoffPolicy) *RateLimitState {
 	rl := &RateLimitState{
 		cache:        cache,
+		cacheKey:     cacheKey,
+		key:          key,
+		policy:       policy,
 		BackoffHours: 1, // Start at 1 hour
 	}
 
@@ -52,6 +81,7 @@ func (rl *RateLimitState) CheckAndBlock() bool {
 	if time.Now().Before(rl.BlockedUntil) {
 		remaining := time.Until(rl.BlockedUntil)
 		logger.Log.WithFields(logrus.Fields{
+			"api_key":           rl.key,
 			"blocked_until":     rl.BlockedUntil,
 			"remaining_seconds": int(remaining.Seconds()),
 			"backoff_hours":     rl.BackoffHours,
@@ -65,10 +95,19 @@ func (rl *RateLimitState) CheckAndBlock() bool {
 	rl.BackoffHours = 1 // Reset to initial backoff
 	rl.saveState()
 
-	logger.Log.Info("Steam API rate limit backoff period expired - resuming API calls")
+	logger.Log.WithField("api_key", rl.key).Info("Steam API rate limit backoff period expired - resuming API calls")
 	return false
 }
 
+// Blocked reports whether a backoff period is currently active, without logging or
+// clearing expired state. Used by schedulers to hold back work while rate limited.
+func (rl *RateLimitState) Blocked() bool {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	return rl.IsRateLimited && time.Now().Before(rl.BlockedUntil)
+}
+
 // Record403 records a 403 response and applies exponential backoff
 func (rl *RateLimitState) Record403() {
 	rl.mu.Lock()
@@ -76,24 +115,18 @@ func (rl *RateLimitState) Record403() {
 
 	rl.Consecutive403++
 	
-	// Calculate exponential backoff: 1 hour, 2 hours, 4 hours, 8 hours, 16 hours, 24 hours (max)
-	backoffDuration := initialBackoff
-	for i := 0; i < rl.Consecutive403-1 && backoffDuration < maxBackoff; i++ {
-		backoffDuration *= backoffMultiplier
-		if backoffDuration > maxBackoff {
-			backoffDuration = maxBackoff
-			break
-		}
-	}
+	// Calculate exponential backoff: by default 1 hour, 2 hours, 4 hours, 8 hours, 16 hours, 24 hours (max)
+	backoffDuration := rl.policy.duration(rl.Consecutive403)
 
 	rl.IsRateLimited = true
 	rl.BlockedUntil = time.Now().Add(backoffDuration)
 	rl.BackoffHours = int(backoffDuration.Hours())
 
 	logger.Log.WithFields(logrus.Fields{
+		"api_key":         rl.key,
 		"consecutive_403": rl.Consecutive403,
 		"blocked_until":   rl.BlockedUntil,
-		"backoff_hours":   rl.BackoffHours,
+		"backoff":         backoffDuration.String(),
 	}).Error("Steam API rate limit detected (403) - applying aggressive backoff")
 
 	rl.saveState()
@@ -113,8 +146,22 @@ func (rl *RateLimitState) RecordSuccess() {
 	}
 }
 
+// Reset clears the backoff, for when the operator knows the 403s were spurious
+func (rl *RateLimitState) Reset() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.IsRateLimited = false
+	rl.BlockedUntil = time.Time{}
+	rl.Consecutive403 = 0
+	rl.BackoffHours = 1
+	rl.saveState()
+
+	logger.Log.WithField("api_key", rl.key).Warn("Steam API rate limit state reset manually")
+}
+
 func (rl *RateLimitState) loadState() {
-	if cachedData, exists := rl.cache.Get(rateLimitCacheKey); exists {
+	if cachedData, exists := rl.cache.Get(context.Background(), rl.cacheKey); exists {
 		var state struct {
 			IsRateLimited  bool      `json:"is_rate_limited"`
 			BlockedUntil   time.Time `json:"blocked_until"`
@@ -130,6 +177,7 @@ func (rl *RateLimitState) loadState() {
 			rl.mu.Unlock()
 
 			logger.Log.WithFields(logrus.Fields{
+				"api_key":         rl.key,
 				"is_rate_limited": rl.IsRateLimited,
 				"blocked_until":    rl.BlockedUntil,
 				"consecutive_403":  rl.Consecutive403,
@@ -158,7 +206,7 @@ func (rl *RateLimitState) saveState() {
 			remaining := time.Until(rl.BlockedUntil)
 			ttl = remaining + 1*time.Hour // Cache until backoff expires + 1 hour safety
 		}
-		rl.cache.Set(rateLimitCacheKey, data, ttl)
+		rl.cache.Set(context.Background(), rl.cacheKey, data, ttl)
 	}
 }
 
@@ -0,0 +1,57 @@
+package steam
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// errRefreshDeferred is returned by collectAchievements for a game whose achievements are due
+// a refresh from Steam once the collection's refresh budget is spent
+var errRefreshDeferred = errors.New("achievement refresh deferred to a later collection")
+
+// refreshBudget caps how many games one collection refreshes achievements for from Steam, so a
+// huge library is refreshed over several collections instead of in one long burst
+type refreshBudget struct {
+	remaining int
+	limited   bool
+}
+
+// newRefreshBudget returns a budget of limit refreshes, unlimited for 0
+func newRefreshBudget(limit int) *refreshBudget {
+	return &refreshBudget{remaining: limit, limited: limit > 0}
+}
+
+// take reserves a refresh, reporting false once the budget is spent
+func (b *refreshBudget) take() bool {
+	if !b.limited {
+		return true
+	}
+	if b.remaining == 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// fetchedTimes returns when each game's achievements were last fetched from Steam, by app ID
+func (s *reportedSnapshot) fetchedTimes() map[uint64]time.Time {
+	fetched := make(map[uint64]time.Time)
+	if s == nil {
+		return fetched
+	}
+	for _, game := range s.Games {
+		fetched[game.Game.AppId] = game.Fetched
+	}
+	return fetched
+}
+
+// refreshOrder returns the games with those whose achievements were fetched longest ago first
+// (never fetched before all), so they're the ones a limited budget refreshes
+func refreshOrder(games []OwnedGame, fetched map[uint64]time.Time) []OwnedGame {
+	ordered := slices.Clone(games)
+	slices.SortStableFunc(ordered, func(a, b OwnedGame) int {
+		return fetched[a.AppId].Compare(fetched[b.AppId])
+	})
+	return ordered
+}
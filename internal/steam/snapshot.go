@@ -0,0 +1,109 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// reportedSnapshotTTL keeps what a user's last collection reported for longer than a Steam
+// backoff lasts (STEAM_BACKOFF_MAX defaults to a day), while the owned games and achievements
+// caches expire within hours
+const reportedSnapshotTTL = 7 * 24 * time.Hour
+
+// reportedGame is a game as last reported, with the achievements it was reported with
+type reportedGame struct {
+	Game         OwnedGame           `json:"game"`
+	Achievements []Achievement       `json:"achievements,omitempty"`
+	Global       []GlobalAchievement `json:"global,omitempty"` // nil when no achievements were reported
+	Fetched      time.Time           `json:"fetched"`          // When the achievements were last fetched from Steam
+}
+
+// reportedSnapshot is what a user's last collection reported. It lets a collection that can't
+// reach Steam (rate limited, or cache-only) report the user's games and achievements again,
+// so a restart during a backoff doesn't leave the gauges empty.
+type reportedSnapshot struct {
+	Username  string         `json:"username"`
+	Games     []reportedGame `json:"games"`
+	Collected time.Time      `json:"collected"`
+}
+
+func reportedSnapshotKey(steamId string) string {
+	return fmt.Sprintf("steam:reported:%s", steamId)
+}
+
+// loadReported returns the user's last reported snapshot, nil if there is none
+func (c *Collector) loadReported(ctx context.Context, steamId string) *reportedSnapshot {
+	data, exists := c.cache.Get(ctx, reportedSnapshotKey(steamId))
+	if !exists {
+		return nil
+	}
+	var snapshot reportedSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		c.cache.Delete(ctx, reportedSnapshotKey(steamId))
+		return nil
+	}
+	return &snapshot
+}
+
+// saveReported stores what a collection reported
+func (c *Collector) saveReported(ctx context.Context, steamId string, snapshot reportedSnapshot) {
+	snapshot.Collected = time.Now()
+	if data, err := json.Marshal(snapshot); err == nil {
+		c.cache.Set(ctx, reportedSnapshotKey(steamId), data, reportedSnapshotTTL)
+	}
+}
+
+// game returns the game as last reported
+func (s *reportedSnapshot) game(appId uint64) (reportedGame, bool) {
+	if s == nil {
+		return reportedGame{}, false
+	}
+	for _, game := range s.Games {
+		if game.Game.AppId == appId {
+			return game, true
+		}
+	}
+	return reportedGame{}, false
+}
+
+// ownedGames returns the games as last reported
+func (s *reportedSnapshot) ownedGames() []OwnedGame {
+	games := make([]OwnedGame, 0, len(s.Games))
+	for _, game := range s.Games {
+		games = append(games, game.Game)
+	}
+	return games
+}
+
+// canRestore reports whether a failure to get the owned games is one the snapshot covers: a
+// backoff, or a cache-only collection of a user whose owned games expired
+func canRestore(err error) bool {
+	return errors.Is(err, cache.ErrNotCached) || strings.Contains(strings.ToLower(err.Error()), "rate limited")
+}
+
+// restoreAchievements reports a game's achievements from the previous snapshot when this
+// collection didn't report any, and keeps them in the new snapshot
+func (c *Collector) restoreAchievements(ctx context.Context, previous *reportedSnapshot, entry *reportedGame, steamId string, username string) {
+	if entry.Global != nil {
+		return
+	}
+	last, ok := previous.game(entry.Game.AppId)
+	if !ok || last.Global == nil {
+		return
+	}
+	entry.Achievements, entry.Global = last.Achievements, last.Global
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"steam_id": steamId,
+		"app_id":   entry.Game.AppId,
+	}).Debug("Reporting achievements of the last collection")
+	ReportAchievements(entry.Achievements, entry.Global, entry.Game.Name, entry.Game.AppId, steamId, username, c.achievementLimit, c.achievementBudget)
+}
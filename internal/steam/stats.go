@@ -0,0 +1,94 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// statsCacheEntry is a user's stats in a game, with the playtime they were fetched at so
+// they're only refetched after the user played
+type statsCacheEntry struct {
+	Stats    []GameStat `json:"stats"`
+	Playtime int        `json:"playtime"`
+}
+
+func userStatsCacheKey(steamId string, appId uint64) string {
+	return fmt.Sprintf("steam:user_stats:%s:%d", steamId, appId)
+}
+
+// SetStatsApps sets the apps whose game-defined stats are exported as steam_game_stat
+// (the stats_apps section of CONFIG_FILE)
+func (c *Collector) SetStatsApps(appIds []uint64) {
+	apps := make(map[uint64]bool, len(appIds))
+	for _, appId := range appIds {
+		apps[appId] = true
+	}
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	c.statsApps = apps
+}
+
+func (c *Collector) statsApp(appId uint64) bool {
+	c.statsMu.RLock()
+	defer c.statsMu.RUnlock()
+	return c.statsApps[appId]
+}
+
+// reportGameStats reports the stats of the user's games listed in stats_apps. A game that
+// fails is logged and skipped.
+func (c *Collector) reportGameStats(ctx context.Context, steamId string, username string, games []OwnedGame) {
+	for _, game := range games {
+		if !c.statsApp(game.AppId) || game.PlaytimeForever == 0 {
+			continue
+		}
+		stats, err := c.gameStats(ctx, steamId, game)
+		if err != nil {
+			logger.FromContext(ctx).WithFields(logrus.Fields{
+				"steam_id": steamId,
+				"app_id":   game.AppId,
+				"error":    err.Error(),
+			}).Warn("Failed to get game stats, continuing")
+			continue
+		}
+		ReportGameStats(stats, game.Name, game.AppId, steamId, username)
+	}
+}
+
+// gameStats returns the user's stats in a game, fetching them again only once the user has
+// played since they were cached
+func (c *Collector) gameStats(ctx context.Context, steamId string, game OwnedGame) ([]GameStat, error) {
+	cacheKey := userStatsCacheKey(steamId, game.AppId)
+	policy := cache.PolicyFromContext(ctx)
+
+	var entry statsCacheEntry
+	cached := false
+	if data, exists := c.cache.Get(ctx, cacheKey); exists {
+		cached = json.Unmarshal(data, &entry) == nil
+	}
+	rateLimited := c.rateLimit != nil && c.rateLimit.CheckAndBlock()
+	if cached && (policy == cache.PolicyCachedOnly || rateLimited || (entry.Playtime >= game.PlaytimeForever && policy != cache.PolicyFresh)) {
+		return entry.Stats, nil
+	}
+	if policy == cache.PolicyCachedOnly {
+		return nil, cache.ErrNotCached
+	}
+
+	resp, err := c.client.GetUserStatsForGame(ctx, steamId, game.AppId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching user stats: %w", err)
+	}
+
+	entry = statsCacheEntry{Stats: resp.PlayerStats.Stats, Playtime: game.PlaytimeForever}
+	if data, err := json.Marshal(entry); err == nil {
+		ttl := 24*time.Hour + time.Duration(rand.Intn(120))*time.Minute // 24 hours + 0-2 hours jitter
+		c.cache.Set(ctx, cacheKey, data, ttl)
+	}
+	return entry.Stats, nil
+}
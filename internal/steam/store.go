@@ -0,0 +1,166 @@
+package steam
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// StoreAppDetailsURL is the Steam store's app details API. It needs no API key, but is rate
+// limited per IP (roughly 200 requests per 5 minutes), so its responses are cached for long.
+const StoreAppDetailsURL = "https://store.steampowered.com/api/appdetails"
+
+// StoreClient calls the Steam store API
+type StoreClient struct {
+	httpClient *http.Client
+}
+
+// NewStoreClient creates a store client; transport is the upstream transport (e.g. fixture
+// replay), nil for the default
+func NewStoreClient(transport http.RoundTripper) *StoreClient {
+	return &StoreClient{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// appDetails fetches an app's store details as seen from a region (a country code such as
+// "us"), limited to filters. available is false when the store doesn't sell the app there.
+func (c *StoreClient) appDetails(ctx context.Context, appId uint64, region string, filters string, target interface{}) (available bool, err error) {
+	ctx, span := tracing.Start(ctx, "steam.store", attribute.Int64("steam.app_id", int64(appId)), attribute.String("steam.region", region))
+	defer func() { tracing.End(span, err) }()
+
+	if err = cache.CheckUpstream(ctx); err != nil {
+		return false, err
+	}
+
+	appIdStr := strconv.FormatUint(appId, 10)
+	query := neturl.Values{}
+	query.Set("appids", appIdStr)
+	query.Set("cc", region)
+	query.Set("filters", filters)
+	query.Set("l", "english")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", StoreAppDetailsURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests, http.StatusForbidden:
+		return false, fmt.Errorf("rate limited by the Steam store (%d)", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status code %d from the Steam store", resp.StatusCode)
+	}
+
+	// {"440": {"success": true, "data": {...}}}; data is [] when none of the filtered fields apply
+	var details map[string]struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &details); err != nil {
+		return false, fmt.Errorf("failed to decode app details: %w", err)
+	}
+	entry, ok := details[appIdStr]
+	if !ok || !entry.Success {
+		return false, nil
+	}
+	if len(entry.Data) == 0 || bytes.HasPrefix(bytes.TrimSpace(entry.Data), []byte("[")) {
+		return true, nil
+	}
+	if err := json.Unmarshal(entry.Data, target); err != nil {
+		return false, fmt.Errorf("failed to decode app details: %w", err)
+	}
+	return true, nil
+}
+
+// GetAppPrice retrieves an app's current price in a region
+func (c *StoreClient) GetAppPrice(ctx context.Context, appId uint64, region string) (AppPrice, error) {
+	var data struct {
+		PriceOverview *struct {
+			Currency        string `json:"currency"`
+			Initial         int64  `json:"initial"`
+			Final           int64  `json:"final"`
+			DiscountPercent int    `json:"discount_percent"`
+		} `json:"price_overview"`
+	}
+	available, err := c.appDetails(ctx, appId, region, "price_overview", &data)
+	if err != nil {
+		return AppPrice{}, fmt.Errorf("failed to get price of app %d in %s: %w", appId, region, err)
+	}
+
+	price := AppPrice{AppId: appId, Region: region, Available: available}
+	switch {
+	case !available:
+		logger.Log.WithFields(logrus.Fields{
+			"app_id": appId,
+			"region": region,
+		}).Debug("App is not sold in the region")
+	case data.PriceOverview == nil:
+		price.Free = true
+	default:
+		price.Currency = data.PriceOverview.Currency
+		price.InitialCents = data.PriceOverview.Initial
+		price.FinalCents = data.PriceOverview.Final
+		price.DiscountPercent = data.PriceOverview.DiscountPercent
+	}
+	return price, nil
+}
+
+// yearPattern finds the year in a store release date, whose format varies ("10 Oct, 2007",
+// "Oct 10, 2007", "Q4 2024")
+var yearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// GetAppInfo retrieves an app's genres, release year and Metacritic score. Apps the store
+// doesn't list (delisted, or tools and servers) return empty metadata.
+func (c *StoreClient) GetAppInfo(ctx context.Context, appId uint64) (GameInfo, error) {
+	var data struct {
+		Genres []struct {
+			Description string `json:"description"`
+		} `json:"genres"`
+		ReleaseDate struct {
+			Date string `json:"date"`
+		} `json:"release_date"`
+		Metacritic struct {
+			Score int `json:"score"`
+		} `json:"metacritic"`
+	}
+	if _, err := c.appDetails(ctx, appId, DefaultPriceRegion, "genres,release_date,metacritic", &data); err != nil {
+		return GameInfo{}, fmt.Errorf("failed to get details of app %d: %w", appId, err)
+	}
+
+	info := GameInfo{AppId: appId, MetacriticScore: data.Metacritic.Score}
+	for _, genre := range data.Genres {
+		info.Genres = append(info.Genres, genre.Description)
+	}
+	if year := yearPattern.FindString(data.ReleaseDate.Date); year != "" {
+		info.ReleaseYear, _ = strconv.Atoi(year)
+	}
+	return info, nil
+}
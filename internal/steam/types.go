@@ -5,16 +5,41 @@ type Achievement struct {
 	Achieved int    `json:"achieved"`
 }
 
+// PlayerStats leaves out achievements and stats for games that have none. Fields Steam may
+// leave out are tagged schema:"optional" (see internal/schema).
 type PlayerStats struct {
 	SteamID      string        `json:"steamID"`
 	GameName     string        `json:"gameName"`
-	Achievements []Achievement `json:"achievements"`
+	Achievements []Achievement `json:"achievements" schema:"optional"`
+	Stats        []GameStat    `json:"stats" schema:"optional"`
+}
+
+// GameStat is one of the game-defined counters of a user's stats (e.g. TF2's kills per class)
+type GameStat struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
 }
 
 type AchievementResponse struct {
 	PlayerStats PlayerStats `json:"playerstats"`
 }
 
+// AchievementUnlock is an achievement as returned by GetPlayerAchievements, which (unlike
+// GetUserStatsForGame) includes when it was unlocked
+type AchievementUnlock struct {
+	APIName    string `json:"apiname"`
+	Achieved   int    `json:"achieved"`
+	UnlockTime int64  `json:"unlocktime"` // Unix seconds, 0 when locked
+}
+
+type PlayerAchievementsResponse struct {
+	PlayerStats struct {
+		SteamID      string              `json:"steamID"`
+		GameName     string              `json:"gameName"`
+		Achievements []AchievementUnlock `json:"achievements" schema:"optional"`
+	} `json:"playerstats"`
+}
+
 type GlobalAchievement struct {
 	Name    string `json:"name"`
 	Percent string `json:"percent"`
@@ -26,21 +51,43 @@ type GlobalAchievementResponse struct {
 	} `json:"achievementpercentages"`
 }
 
+// OwnedGame is a game of GetOwnedGames. Steam leaves out the fields tagged schema:"optional"
+// depending on the game and on how recently it was played; they're declared so the schema
+// checker doesn't take them for new fields.
 type OwnedGame struct {
 	AppId           uint64 `json:"appid"`
 	Name            string `json:"name"`
 	PlaytimeForever int    `json:"playtime_forever"` // This is in minutes
+
+	Playtime2Weeks           int    `json:"playtime_2weeks,omitempty" schema:"optional"` // Minutes, left out when not played in the last two weeks
+	PlaytimeWindowsForever   int    `json:"playtime_windows_forever,omitempty" schema:"optional"`
+	PlaytimeMacForever       int    `json:"playtime_mac_forever,omitempty" schema:"optional"`
+	PlaytimeLinuxForever     int    `json:"playtime_linux_forever,omitempty" schema:"optional"`
+	PlaytimeDeckForever      int    `json:"playtime_deck_forever,omitempty" schema:"optional"`
+	PlaytimeDisconnected     int    `json:"playtime_disconnected,omitempty" schema:"optional"`
+	RTimeLastPlayed          int64  `json:"rtime_last_played,omitempty" schema:"optional"` // Unix seconds, left out when never played
+	ImgIconURL               string `json:"img_icon_url,omitempty" schema:"optional"`
+	HasCommunityVisibleStats bool   `json:"has_community_visible_stats,omitempty" schema:"optional"`
+	HasLeaderboards          bool   `json:"has_leaderboards,omitempty" schema:"optional"`
+	HasWorkshop              bool   `json:"has_workshop,omitempty" schema:"optional"`
+	HasMarket                bool   `json:"has_market,omitempty" schema:"optional"`
+	HasDLC                   bool   `json:"has_dlc,omitempty" schema:"optional"`
+	ContentDescriptorIDs     []int  `json:"content_descriptorids,omitempty" schema:"optional"`
+	SortAs                   string `json:"sort_as,omitempty" schema:"optional"`
 }
 
+// OwnedGamesResponse is empty for private profiles
 type OwnedGamesResponse struct {
-	GameCount uint        `json:"game_count"`
-	Games     []OwnedGame `json:"games"`
+	GameCount uint        `json:"game_count" schema:"optional"`
+	Games     []OwnedGame `json:"games" schema:"optional"`
 }
 
 type OwnedGamesHttpResponse struct {
 	Response OwnedGamesResponse `json:"response"`
 }
 
+// PlayerSummary is a player of GetPlayerSummaries. Private profiles, and players not in game,
+// leave out the fields tagged schema:"optional".
 type PlayerSummary struct {
 	SteamID      string `json:"steamid"`
 	PersonaName  string `json:"personaname"`
@@ -48,6 +95,25 @@ type PlayerSummary struct {
 	Avatar       string `json:"avatar"`
 	AvatarMedium string `json:"avatarmedium"`
 	AvatarFull   string `json:"avatarfull"`
+	PersonaState int    `json:"personastate"`              // 0 when offline (or the profile is private)
+	GameID       string `json:"gameid" schema:"optional"` // App being played, empty when not in game
+
+	AvatarHash               string `json:"avatarhash,omitempty" schema:"optional"`
+	CommunityVisibilityState int    `json:"communityvisibilitystate,omitempty" schema:"optional"` // 3 when public
+	ProfileState             int    `json:"profilestate,omitempty" schema:"optional"`             // 1 once the profile is set up
+	CommentPermission        int    `json:"commentpermission,omitempty" schema:"optional"`
+	LastLogoff               int64  `json:"lastlogoff,omitempty" schema:"optional"`
+	PersonaStateFlags        int    `json:"personastateflags,omitempty" schema:"optional"`
+	RealName                 string `json:"realname,omitempty" schema:"optional"`
+	PrimaryClanID            string `json:"primaryclanid,omitempty" schema:"optional"`
+	TimeCreated              int64  `json:"timecreated,omitempty" schema:"optional"`
+	GameExtraInfo            string `json:"gameextrainfo,omitempty" schema:"optional"` // Name of the app being played
+	GameServerIP             string `json:"gameserverip,omitempty" schema:"optional"`
+	GameServerSteamID        string `json:"gameserversteamid,omitempty" schema:"optional"`
+	LobbySteamID             string `json:"lobbysteamid,omitempty" schema:"optional"`
+	LocCountryCode           string `json:"loccountrycode,omitempty" schema:"optional"`
+	LocStateCode             string `json:"locstatecode,omitempty" schema:"optional"`
+	LocCityID                int    `json:"loccityid,omitempty" schema:"optional"`
 }
 
 type PlayerSummariesResponse struct {
@@ -56,3 +122,75 @@ type PlayerSummariesResponse struct {
 	} `json:"response"`
 }
 
+type FriendListResponse struct {
+	FriendsList struct {
+		Friends []Friend `json:"friends"`
+	} `json:"friendslist"`
+}
+
+type Friend struct {
+	SteamID      string `json:"steamid"`
+	Relationship string `json:"relationship"`
+	FriendSince  int64  `json:"friend_since"`
+}
+
+// WorkshopFilesResponse is a page of IPublishedFileService's GetUserFiles
+type WorkshopFilesResponse struct {
+	Response struct {
+		Total                int            `json:"total"`
+		PublishedFileDetails []WorkshopItem `json:"publishedfiledetails" schema:"optional"` // Left out when there are none
+	} `json:"response"`
+}
+
+// WorkshopItem is a Workshop item published by the user
+type WorkshopItem struct {
+	PublishedFileID       string `json:"publishedfileid"`
+	Title                 string `json:"title"`
+	ConsumerAppID         uint64 `json:"consumer_appid"` // The game the item is for
+	Subscriptions         int64  `json:"subscriptions"`
+	Favorited             int64  `json:"favorited"`
+	LifetimeSubscriptions int64  `json:"lifetime_subscriptions"`
+}
+
+
+// Profile is a user's library as served by the JSON API
+type Profile struct {
+	SteamID   string        `json:"steam_id"`
+	Username  string        `json:"username,omitempty"`
+	GameCount uint          `json:"game_count"`
+	Games     []GameProfile `json:"games"`
+}
+
+type GameProfile struct {
+	AppId           uint64        `json:"appid"`
+	Name            string        `json:"name"`
+	PlaytimeForever int           `json:"playtime_forever"` // This is in minutes
+	Achievements    []Achievement `json:"achievements,omitempty"` // Only once collected into the cache
+}
+
+// AppPrice is an app's store price in a region, in the currency's smallest unit (cents)
+type AppPrice struct {
+	AppId           uint64 `json:"appid"`
+	Region          string `json:"region"`
+	Available       bool   `json:"available"` // False when the store doesn't sell the app in the region
+	Free            bool   `json:"free"`
+	Currency        string `json:"currency,omitempty"`
+	InitialCents    int64  `json:"initial_cents"`
+	FinalCents      int64  `json:"final_cents"`
+	DiscountPercent int    `json:"discount_percent"`
+}
+
+// GameInfo is an app's store metadata, exported as the labels of steam_game_info
+type GameInfo struct {
+	AppId           uint64   `json:"appid"`
+	Genres          []string `json:"genres,omitempty"`
+	ReleaseYear     int      `json:"release_year,omitempty"`
+	MetacriticScore int      `json:"metacritic_score,omitempty"`
+}
+
+// GameCompat is an app's Linux and Steam Deck compatibility, exported as labels of steam_game_info
+type GameCompat struct {
+	AppId        uint64 `json:"appid"`
+	ProtonDBTier string `json:"protondb_tier,omitempty"` // platinum, gold, silver, bronze, borked, pending; empty without reports
+	DeckStatus   string `json:"deck_status"`             // verified, playable, unsupported or unknown
+}
@@ -0,0 +1,74 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+)
+
+// unlocksCacheEntry is a user's unlocked achievements in a game, with how many were unlocked
+// in the cached user achievements when they were fetched, so they're only refetched once that
+// count grows
+type unlocksCacheEntry struct {
+	Unlocks  []AchievementUnlock `json:"unlocks"`
+	Unlocked int                 `json:"unlocked"`
+}
+
+func achievementUnlocksCacheKey(steamId string, appId uint64) string {
+	return fmt.Sprintf("steam:achievement_unlocks:%s:%d", steamId, appId)
+}
+
+// AchievementUnlocks returns the achievements a user unlocked in a game, oldest first. Unlock
+// times aren't part of the achievements Collect fetches, so they're fetched here (once per
+// change in the cached achievements) for the history store's timeline.
+func (c *Collector) AchievementUnlocks(ctx context.Context, steamId string, appId uint64) ([]AchievementUnlock, error) {
+	unlocked := 0
+	if data, exists := c.cache.Get(ctx, userAchievementsCacheKey(steamId, appId)); exists {
+		var entry struct {
+			UserAchievements []Achievement `json:"user_achievements"`
+		}
+		if err := json.Unmarshal(data, &entry); err == nil {
+			for _, achievement := range entry.UserAchievements {
+				if achievement.Achieved == 1 {
+					unlocked++
+				}
+			}
+		}
+	}
+
+	cacheKey := achievementUnlocksCacheKey(steamId, appId)
+	policy := cache.PolicyFromContext(ctx)
+	if data, exists := c.cache.Get(ctx, cacheKey); exists && policy != cache.PolicyFresh {
+		var entry unlocksCacheEntry
+		if err := json.Unmarshal(data, &entry); err == nil && entry.Unlocked == unlocked {
+			return entry.Unlocks, nil
+		}
+	}
+
+	resp, err := c.client.GetPlayerAchievements(ctx, steamId, appId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching achievement unlock times: %w", err)
+	}
+
+	unlocks := make([]AchievementUnlock, 0, len(resp.PlayerStats.Achievements))
+	for _, achievement := range resp.PlayerStats.Achievements {
+		if achievement.Achieved == 1 {
+			unlocks = append(unlocks, achievement)
+		}
+	}
+	sort.SliceStable(unlocks, func(i, j int) bool {
+		return unlocks[i].UnlockTime < unlocks[j].UnlockTime
+	})
+
+	entry := unlocksCacheEntry{Unlocks: unlocks, Unlocked: unlocked}
+	if data, err := json.Marshal(entry); err == nil {
+		ttl := 7*24*time.Hour + time.Duration(rand.Intn(24))*time.Hour // 7 days + 0-24 hours jitter
+		c.cache.Set(ctx, cacheKey, data, ttl)
+	}
+	return unlocks, nil
+}
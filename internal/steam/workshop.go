@@ -0,0 +1,61 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Subscriber counts move slowly enough that an hour old count is fine
+	workshopTTL = time.Hour
+	// GetUserFiles returns up to 100 items per page
+	workshopPageSize = 100
+)
+
+// reportWorkshop reports the subscribers and favorites of the user's Workshop items. A failure
+// is logged and skipped rather than failing the collection.
+func (c *Collector) reportWorkshop(ctx context.Context, steamId string, username string) {
+	items, err := c.workshopItems(ctx, steamId)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"steam_id": steamId,
+			"error":    err.Error(),
+		}).Warn("Failed to get Workshop items, skipping Workshop metrics")
+		return
+	}
+	ReportWorkshopItems(items, steamId, username)
+}
+
+// workshopItems returns the Workshop items published by the user, from the cache or the API
+func (c *Collector) workshopItems(ctx context.Context, steamId string) ([]WorkshopItem, error) {
+	cacheKey := fmt.Sprintf("steam:workshop:%s", steamId)
+	data, err := c.cache.GetOrRefresh(ctx, cacheKey, workshopTTL, workshopTTL, func(ctx context.Context) ([]byte, error) {
+		var items []WorkshopItem
+		for page := 1; ; page++ {
+			resp, err := c.client.GetWorkshopFiles(ctx, steamId, page, workshopPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get Workshop items: %w", err)
+			}
+			items = append(items, resp.Response.PublishedFileDetails...)
+			if len(resp.Response.PublishedFileDetails) < workshopPageSize || len(items) >= resp.Response.Total {
+				break
+			}
+		}
+		return json.Marshal(items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []WorkshopItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		c.cache.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to decode cached Workshop items: %w", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,1559 @@
+// Package testserver emulates the Steam Web API and store and the OSRS hiscores and world list for
+// end-to-end tests of the collectors, including their failure modes: Steam 429/403 rate
+// limiting and the OSRS world list's truncated responses. Transport routes a client's
+// requests to the server whatever their host, so collectors run unmodified against it.
+package testserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"golang.org/x/net/websocket"
+)
+
+// SteamUser is a Steam profile and library. A user with PrivateFriends answers the friend list
+// request with a 401, as Steam does.
+type SteamUser struct {
+	Name           string
+	Games          []Game
+	Online         bool
+	InGame         uint64 // App being played, 0 when not in game
+	Friends        []string
+	PrivateFriends bool
+	Workshop       []WorkshopItem
+	ProfileCounts  map[string]int // Count links of the community profile by label (e.g. Screenshots); none on a private profile
+}
+
+// WorkshopItem is a Workshop item published by a user
+type WorkshopItem struct {
+	ID                    uint64
+	AppID                 uint64
+	Title                 string
+	Subscriptions         int
+	Favorited             int
+	LifetimeSubscriptions int
+}
+
+// Game is an owned game. Achievements maps each of the game's achievements to whether the user
+// unlocked it and Stats holds its game-defined stats; games without either answer the user stats
+// request with a 400, as Steam does. UnlockTimes holds the unix times achievements were unlocked
+// at (defaultUnlockTime when missing).
+type Game struct {
+	AppID           uint64
+	Name            string
+	PlaytimeMinutes int
+	Achievements    map[string]bool
+	Stats           map[string]float64
+	UnlockTimes     map[string]int64
+	StatsStatus     int                    // Status the user stats requests fail with (e.g. 500), 0 for none
+	Fields          map[string]interface{} // Overrides fields of the game's owned games entry; a nil value leaves the field out
+}
+
+// defaultUnlockTime is the unlock time of achievements missing from a game's UnlockTimes
+const defaultUnlockTime = 1600000000
+
+// EpicAccount is an Epic Games account, authenticated by its access token
+type EpicAccount struct {
+	Token string
+	Games []EpicGame
+}
+
+// EpicGame is a title in an Epic account's library; titles with playtime are also in its playtime
+type EpicGame struct {
+	AppName         string
+	Title           string
+	PlaytimeSeconds int64
+}
+
+// epicLibraryPageSize is the number of library items per page, small so tests follow the cursor
+const epicLibraryPageSize = 2
+
+// NintendoAccount is a Parental Controls account, logged in to with its session token
+type NintendoAccount struct {
+	SessionToken string
+	Devices      []NintendoDevice
+}
+
+// NintendoDevice is a console linked to a Parental Controls account
+type NintendoDevice struct {
+	ID        string
+	Label     string
+	Today     []NintendoPlay // Served as the summary of the current (local) day
+	Yesterday []NintendoPlay
+}
+
+// NintendoPlay is a player's play of a title on a day
+type NintendoPlay struct {
+	Player        string
+	ApplicationID string
+	Title         string
+	Seconds       int64
+}
+
+// nintendoAccessToken is the access token every session token is exchanged for
+const nintendoAccessToken = "nintendo-access-token"
+
+// D3Profile is a Diablo III career profile
+type D3Profile struct {
+	ParagonLevel int
+	MonsterKills int64
+	EliteKills   int64
+	Heroes       []D3Hero
+}
+
+type D3Hero struct {
+	ID       int64
+	Name     string
+	Class    string // Class slug, e.g. demon-hunter
+	Level    int
+	Hardcore bool
+}
+
+// SC2Profile is a StarCraft II profile; race maps are keyed by terran, zerg and protoss
+type SC2Profile struct {
+	Name        string
+	SwarmLevels map[string]int
+	Wins        map[string]int
+	Games       int
+}
+
+// ClashPlayer is a Clash of Clans or Clash Royale player; Game is clans or royale
+type ClashPlayer struct {
+	Game              string
+	Name              string
+	ExpLevel          int
+	Trophies          int
+	BestTrophies      int
+	Donations         int
+	DonationsReceived int
+	TownHallLevel     int // Clash of Clans
+	WarStars          int
+	ArenaID           int // Clash Royale
+	ArenaName         string
+}
+
+// BattleMetricsServer is a game server tracked by BattleMetrics; Game is its game ID (rust, dayz, ...)
+type BattleMetricsServer struct {
+	Name       string
+	Game       string
+	Players    int
+	MaxPlayers int
+	Rank       int
+	Status     string // online, offline or dead
+	Outages    []BattleMetricsOutage
+}
+
+// BattleMetricsOutage is a period a server was down; a zero Stop is an ongoing outage
+type BattleMetricsOutage struct {
+	Start time.Time
+	Stop  time.Time
+}
+
+// RCONServer is a dedicated server's RCON listener; Game is minecraft, factorio or valheim.
+// A Minecraft server answers tps when TPS is set (Paper) and tick query when MSPT is.
+type RCONServer struct {
+	Game       string
+	Password   string
+	Players    []string
+	MaxPlayers int
+	TPS        float64
+	MSPT       float64
+}
+
+// discordGatewayPath is where the emulated Discord gateway accepts connections
+const discordGatewayPath = "/discord/gateway"
+
+// bnetAccessToken is the access token issued for the Battle.net client credentials
+const bnetAccessToken = "bnet-access-token"
+
+// OSRSPlayer is a hiscores entry. Skills are in hiscores order; minigames with a -1 rank are
+// left off the personal hiscores page, as on the real one.
+type OSRSPlayer struct {
+	Skills    []Skill
+	Minigames []Minigame
+}
+
+type Skill struct {
+	Rank  int
+	Level int
+	XP    int64
+}
+
+type Minigame struct {
+	Name  string
+	Rank  int
+	Score int
+}
+
+// World is an entry of the binary world list
+type World struct {
+	ID       uint16
+	Flags    int32
+	Address  string
+	Activity string
+	Location int8
+	Players  int16
+}
+
+// GEItem is an entry of the wiki's Grand Exchange item mapping
+type GEItem struct {
+	ID       int
+	Name     string
+	Members  bool
+	Limit    int
+	HighAlch int
+	High     int64 // Latest prices, 0 for a side that never traded
+	Low      int64
+}
+
+// Price is an app's store price in a region
+type Price struct {
+	Currency        string
+	Initial         int64 // In cents
+	Final           int64
+	DiscountPercent int
+}
+
+// AppInfo is an app's store metadata and compatibility
+type AppInfo struct {
+	Genres       []string
+	ReleaseDate  string // As the store formats it, e.g. "10 Oct, 2007"
+	Metacritic   int
+	ProtonDBTier string // Empty for an app without ProtonDB reports (a 404)
+	DeckCategory int    // 0 unknown, 1 unsupported, 2 playable, 3 verified
+}
+
+// Server is the emulated upstream
+type Server struct {
+	server *httptest.Server
+
+	mu           sync.Mutex
+	steamUsers   map[string]SteamUser
+	steamStatus  int               // Status every Steam request fails with, 0 for none
+	steamHold    chan struct{}     // Steam API requests wait until it's closed, nil for none
+	steamHeld    chan string       // Receives the path of each request held by steamHold
+	keyStatus    map[string]int    // Status requests with a given API key fail with
+	prices       map[string]*Price // By region and app ID; nil for free apps
+	apps         map[uint64]AppInfo
+	osrsPlayers  map[string]OSRSPlayer
+	hiscoresFail []int                          // Statuses the next hiscores CSV requests fail with, in order
+	epicAccounts map[string]EpicAccount         // By account ID
+	nintendo     map[string]NintendoAccount     // By account ID
+	bnetClient   [2]string                      // Battle.net client ID and secret
+	d3Profiles   map[string]D3Profile           // By BattleTag (Name-1234)
+	sc2Profiles  map[string]SC2Profile          // By region-realm-profile
+	clashTokens  map[string]string              // API token by game
+	clash        map[string]ClashPlayer         // By game and tag (#ABC)
+	bmServers    map[string]BattleMetricsServer // By server ID
+	discordGames map[string][]string            // Games being played by Discord user ID
+	discordConns []*websocket.Conn              // Identified gateway connections
+	rcon         map[string]RCONServer          // By address
+	listeners    []net.Listener                 // RCON servers, closed with the server
+	worlds       []World
+	geItems      []GEItem
+	womGroups    map[int][]string // Member RSNs by Wise Old Man group ID
+	worldsLimit  int              // Truncate the world list to this many bytes, 0 for no limit
+	worldsCount  int16            // World count written in the header, 0 for len(worlds)
+	requests     map[string]int   // By path
+}
+
+// New starts a server that is closed when the test finishes
+func New(t testing.TB) *Server {
+	s := &Server{
+		steamUsers:   make(map[string]SteamUser),
+		keyStatus:    make(map[string]int),
+		prices:       make(map[string]*Price),
+		apps:         make(map[uint64]AppInfo),
+		osrsPlayers:  make(map[string]OSRSPlayer),
+		epicAccounts: make(map[string]EpicAccount),
+		nintendo:     make(map[string]NintendoAccount),
+		d3Profiles:   make(map[string]D3Profile),
+		sc2Profiles:  make(map[string]SC2Profile),
+		clashTokens:  make(map[string]string),
+		clash:        make(map[string]ClashPlayer),
+		bmServers:    make(map[string]BattleMetricsServer),
+		discordGames: make(map[string][]string),
+		requests:     make(map[string]int),
+		rcon:         make(map[string]RCONServer),
+		womGroups:    make(map[int][]string),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(func() {
+		s.server.Close()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, listener := range s.listeners {
+			listener.Close()
+		}
+	})
+	return s
+}
+
+// NewCache returns an empty file-backed cache that is closed when the test finishes, for the
+// collectors under test
+func NewCache(t testing.TB) *cache.Cache {
+	t.Helper()
+	c, err := cache.New(cache.Options{Backend: cache.BackendFile, FilePath: filepath.Join(t.TempDir(), "cache.db")})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// Transport sends every request to the server, keeping its path and query. The original host
+// is sent as X-Forwarded-Host, for upstreams that share paths.
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.server.URL)
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-Forwarded-Host", req.URL.Host)
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		req.Host = target.Host
+		return s.server.Client().Transport.RoundTrip(req)
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// AddSteamUser adds or replaces a Steam user
+func (s *Server) AddSteamUser(steamID string, user SteamUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steamUsers[steamID] = user
+}
+
+// AddEpicAccount adds or replaces an Epic Games account
+func (s *Server) AddEpicAccount(accountID string, account EpicAccount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epicAccounts[accountID] = account
+}
+
+// AddNintendoAccount adds or replaces a Nintendo Parental Controls account
+func (s *Server) AddNintendoAccount(accountID string, account NintendoAccount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nintendo[accountID] = account
+}
+
+// SetBattleNetClient sets the Battle.net API client credentials that are issued access tokens
+func (s *Server) SetBattleNetClient(clientID string, clientSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bnetClient = [2]string{clientID, clientSecret}
+}
+
+// AddD3Profile adds or replaces a Diablo III profile
+func (s *Server) AddD3Profile(battleTag string, profile D3Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.d3Profiles[battleTag] = profile
+}
+
+// AddSC2Profile adds or replaces a StarCraft II profile (region-realm-profile, e.g. 1-1-123456)
+func (s *Server) AddSC2Profile(id string, profile SC2Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sc2Profiles[id] = profile
+}
+
+// SetClashToken sets the API token a Clash game (clans or royale) accepts
+func (s *Server) SetClashToken(game string, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clashTokens[game] = token
+}
+
+// AddClashPlayer adds or replaces a Clash player by tag (#ABC)
+func (s *Server) AddClashPlayer(tag string, player ClashPlayer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clash[player.Game+":"+tag] = player
+}
+
+// AddBattleMetricsServer adds or replaces a BattleMetrics server
+func (s *Server) AddBattleMetricsServer(id string, server BattleMetricsServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bmServers[id] = server
+}
+
+// StartRCON starts an RCON listener for a dedicated server and returns its address
+func (s *Server) StartRCON(server RCONServer) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("testserver: failed to listen for RCON: %v", err))
+	}
+	address := listener.Addr().String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcon[address] = server
+	s.listeners = append(s.listeners, listener)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveRCON(conn, address)
+		}
+	}()
+	return address
+}
+
+// SetRCONPlayers replaces the players online on an RCON server
+func (s *Server) SetRCONPlayers(address string, players ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server := s.rcon[address]
+	server.Players = players
+	s.rcon[address] = server
+}
+
+// RCONConnections returns how many connections an RCON server accepted
+func (s *Server) RCONConnections(address string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests["rcon:"+address]
+}
+
+// DiscordGatewayURL returns the URL of the emulated Discord gateway
+func (s *Server) DiscordGatewayURL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http") + discordGatewayPath
+}
+
+// SetDiscordPresence sets the games a Discord user is playing (none for online, not playing)
+// and sends the presence to the connected gateway sessions
+func (s *Server) SetDiscordPresence(userID string, games ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discordGames[userID] = games
+	for _, conn := range s.discordConns {
+		websocket.JSON.Send(conn, map[string]interface{}{"op": 0, "s": 2, "t": "PRESENCE_UPDATE", "d": discordPresence(userID, games)})
+	}
+}
+
+// SetSteamStatus makes every Steam request (API and store) fail with status (e.g. 429 or 403);
+// 0 restores service
+func (s *Server) SetSteamStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steamStatus = status
+}
+
+// HoldSteam makes Steam API requests wait until release is called, e.g. to have a collection
+// still running when another starts. held receives the path of each request as it starts
+// waiting. Calling release again does nothing.
+func (s *Server) HoldSteam() (held <-chan string, release func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hold := make(chan struct{})
+	s.steamHold = hold
+	s.steamHeld = make(chan string, 100)
+	var once sync.Once
+	return s.steamHeld, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.steamHold == hold {
+				s.steamHold = nil
+			}
+			close(hold)
+		})
+	}
+}
+
+// RejectKey makes requests with the given API key fail with status
+func (s *Server) RejectKey(key string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keyStatus[key] = status
+}
+
+// SetPrice sets an app's store price in a region (a country code); nil makes it free. Apps
+// without a price aren't sold in the region.
+func (s *Server) SetPrice(appID uint64, region string, price *Price) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[priceKey(appID, region)] = price
+}
+
+// SetAppInfo sets an app's store metadata
+func (s *Server) SetAppInfo(appID uint64, info AppInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apps[appID] = info
+}
+
+func priceKey(appID uint64, region string) string {
+	return region + ":" + strconv.FormatUint(appID, 10)
+}
+
+// AddOSRSPlayer adds or replaces a hiscores entry (in every game mode)
+func (s *Server) AddOSRSPlayer(rsn string, player OSRSPlayer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.osrsPlayers[strings.ToLower(rsn)] = player
+}
+
+// AddGEItem adds an item to the Grand Exchange item mapping
+func (s *Server) AddGEItem(item GEItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.geItems = append(s.geItems, item)
+}
+
+// SetGEPrice changes the latest prices of a Grand Exchange item
+func (s *Server) SetGEPrice(id int, high int64, low int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.geItems {
+		if s.geItems[i].ID == id {
+			s.geItems[i].High, s.geItems[i].Low = high, low
+		}
+	}
+}
+
+// SetWOMGroup sets the members of a Wise Old Man group
+func (s *Server) SetWOMGroup(id int, rsns ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.womGroups[id] = rsns
+}
+
+// FailHiscores makes the next hiscores CSV requests fail, one status each (e.g. 503, 503)
+func (s *Server) FailHiscores(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hiscoresFail = append(s.hiscoresFail, statuses...)
+}
+
+// SetWorlds sets the world list
+func (s *Server) SetWorlds(worlds ...World) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.worlds = worlds
+}
+
+// TruncateWorlds cuts the world list response to limit bytes, as the real server does at 30KB
+func (s *Server) TruncateWorlds(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.worldsLimit = limit
+}
+
+// SetWorldCount overrides the world count in the world list header, emulating a corrupted header
+func (s *Server) SetWorldCount(count int16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.worldsCount = count
+}
+
+// Requests returns how many requests were made to an upstream path, e.g.
+// "/IPlayerService/GetOwnedGames/v0001/"
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// SteamRequests returns how many Steam API requests were made
+func (s *Server) SteamRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for path, count := range s.requests {
+		if strings.HasPrefix(path, "/I") {
+			total += count
+		}
+	}
+	return total
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	// The gateway connection is long lived, so it's served without holding the lock
+	if r.URL.Path == discordGatewayPath {
+		websocket.Handler(s.serveDiscordGateway).ServeHTTP(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/I") {
+		s.waitSteamHold(r.URL.Path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.URL.Path]++
+
+	query := r.URL.Query()
+	switch path := r.URL.Path; {
+	case strings.HasPrefix(path, "/I"):
+		s.serveSteam(w, r.URL.Path, query)
+	case path == "/api/appdetails":
+		s.serveAppDetails(w, query)
+	case strings.HasPrefix(path, "/api/v1/reports/summaries/"):
+		s.serveProtonDB(w, strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/reports/summaries/"), ".json"))
+	case path == "/saleaction/ajaxgetdeckappcompatibilityreport":
+		s.serveDeckCompatibility(w, query.Get("nAppID"))
+	case strings.HasPrefix(path, "/profiles/"):
+		s.serveProfilePage(w, strings.Trim(strings.TrimPrefix(path, "/profiles/"), "/"))
+	case strings.HasPrefix(path, "/library/api/public/"):
+		s.serveEpic(w, r)
+	case path == "/connect/1.0.0/api/token":
+		s.serveNintendoToken(w, r)
+	case strings.HasPrefix(path, "/v1/users/"), strings.HasPrefix(path, "/v1/devices/"):
+		s.serveNintendo(w, r)
+	case path == "/token":
+		s.serveBattleNetToken(w, r)
+	case strings.HasPrefix(path, "/d3/"), strings.HasPrefix(path, "/sc2/"):
+		s.serveBattleNet(w, r)
+	case strings.HasPrefix(path, "/v1/players/"):
+		s.serveClash(w, r)
+	case strings.HasPrefix(path, "/servers/"):
+		s.serveBattleMetrics(w, r)
+	case strings.HasSuffix(path, "/index_lite.ws"):
+		s.serveHiscores(w, query.Get("player"))
+	case strings.HasSuffix(path, "/hiscorepersonal"):
+		s.serveHiscoresPage(w, query.Get("user1"))
+	case strings.HasSuffix(path, "/slr.ws"):
+		s.serveWorlds(w)
+	case path == "/api/v1/osrs/mapping":
+		s.serveItemMapping(w)
+	case path == "/api/v1/osrs/latest":
+		s.serveGEPrices(w, query.Get("id"))
+	case strings.HasPrefix(path, "/v2/groups/"):
+		s.serveWOMGroup(w, strings.TrimPrefix(path, "/v2/groups/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// waitSteamHold blocks a Steam API request while HoldSteam is in effect
+func (s *Server) waitSteamHold(path string) {
+	s.mu.Lock()
+	hold, held := s.steamHold, s.steamHeld
+	s.mu.Unlock()
+	if hold == nil {
+		return
+	}
+
+	held <- path
+	<-hold
+}
+
+func (s *Server) serveSteam(w http.ResponseWriter, path string, query url.Values) {
+	key := query.Get("key")
+	switch {
+	case key == "":
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	case s.keyStatus[key] != 0:
+		http.Error(w, http.StatusText(s.keyStatus[key]), s.keyStatus[key])
+		return
+	case s.steamStatus != 0:
+		http.Error(w, http.StatusText(s.steamStatus), s.steamStatus)
+		return
+	}
+
+	switch path {
+	case "/IPlayerService/GetOwnedGames/v0001/":
+		user := s.steamUsers[query.Get("steamid")]
+		games := make([]map[string]interface{}, 0, len(user.Games))
+		for _, game := range user.Games {
+			entry := map[string]interface{}{
+				"appid":            game.AppID,
+				"name":             game.Name,
+				"playtime_forever": game.PlaytimeMinutes,
+			}
+			for field, value := range game.Fields {
+				if value == nil {
+					delete(entry, field)
+				} else {
+					entry[field] = value
+				}
+			}
+			games = append(games, entry)
+		}
+		writeJSON(w, map[string]interface{}{
+			"response": map[string]interface{}{"game_count": len(games), "games": games},
+		})
+	case "/ISteamUser/GetPlayerSummaries/v0002/":
+		players := []map[string]interface{}{}
+		for _, steamID := range strings.Split(query.Get("steamids"), ",") {
+			if user, ok := s.steamUsers[steamID]; ok {
+				player := map[string]interface{}{
+					"steamid":      steamID,
+					"personaname":  user.Name,
+					"profileurl":   "https://steamcommunity.com/profiles/" + steamID + "/",
+					"avatar":       "https://avatars.steamstatic.com/" + steamID + ".jpg",
+					"avatarmedium": "https://avatars.steamstatic.com/" + steamID + "_medium.jpg",
+					"avatarfull":   "https://avatars.steamstatic.com/" + steamID + "_full.jpg",
+					"personastate": 0,
+				}
+				if user.Online {
+					player["personastate"] = 1
+				}
+				if user.InGame != 0 {
+					player["gameid"] = strconv.FormatUint(user.InGame, 10)
+				}
+				players = append(players, player)
+			}
+		}
+		writeJSON(w, map[string]interface{}{"response": map[string]interface{}{"players": players}})
+	case "/ISteamUser/GetFriendList/v0001/":
+		user := s.steamUsers[query.Get("steamid")]
+		if user.PrivateFriends {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		friends := []map[string]interface{}{}
+		for _, steamID := range user.Friends {
+			friends = append(friends, map[string]interface{}{"steamid": steamID, "relationship": "friend", "friend_since": 1500000000})
+		}
+		writeJSON(w, map[string]interface{}{"friendslist": map[string]interface{}{"friends": friends}})
+	case "/IPublishedFileService/GetUserFiles/v1/":
+		user := s.steamUsers[query.Get("steamid")]
+		page, _ := strconv.Atoi(query.Get("page"))
+		perPage, _ := strconv.Atoi(query.Get("numperpage"))
+		if page < 1 {
+			page = 1
+		}
+		if perPage < 1 {
+			perPage = 50
+		}
+		files := []map[string]interface{}{}
+		for i := (page - 1) * perPage; i < page*perPage && i < len(user.Workshop); i++ {
+			item := user.Workshop[i]
+			files = append(files, map[string]interface{}{
+				"publishedfileid":        strconv.FormatUint(item.ID, 10),
+				"title":                  item.Title,
+				"consumer_appid":         item.AppID,
+				"subscriptions":          item.Subscriptions,
+				"favorited":              item.Favorited,
+				"lifetime_subscriptions": item.LifetimeSubscriptions,
+			})
+		}
+		writeJSON(w, map[string]interface{}{
+			"response": map[string]interface{}{"total": len(user.Workshop), "publishedfiledetails": files},
+		})
+	case "/ISteamUserStats/GetPlayerAchievements/v0001/":
+		appID, _ := strconv.ParseUint(query.Get("appid"), 10, 64)
+		game, ok := s.game(query.Get("steamid"), appID)
+		if ok && game.StatsStatus != 0 {
+			http.Error(w, http.StatusText(game.StatsStatus), game.StatsStatus)
+			return
+		}
+		if !ok || len(game.Achievements) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]interface{}{
+				"playerstats": map[string]interface{}{"error": "Requested app has no stats", "success": false},
+			})
+			return
+		}
+		achievements := []map[string]interface{}{}
+		for _, name := range sortedNames(game.Achievements) {
+			achieved, unlockTime := 0, int64(0)
+			if game.Achievements[name] {
+				achieved, unlockTime = 1, defaultUnlockTime
+				if t, ok := game.UnlockTimes[name]; ok {
+					unlockTime = t
+				}
+			}
+			achievements = append(achievements, map[string]interface{}{"apiname": name, "achieved": achieved, "unlocktime": unlockTime})
+		}
+		writeJSON(w, map[string]interface{}{
+			"playerstats": map[string]interface{}{
+				"steamID":      query.Get("steamid"),
+				"gameName":     game.Name,
+				"achievements": achievements,
+				"success":      true,
+			},
+		})
+	case "/ISteamUserStats/GetUserStatsForGame/v0002/":
+		appID, _ := strconv.ParseUint(query.Get("appid"), 10, 64)
+		game, ok := s.game(query.Get("steamid"), appID)
+		if ok && game.StatsStatus != 0 {
+			http.Error(w, http.StatusText(game.StatsStatus), game.StatsStatus)
+			return
+		}
+		if !ok || (len(game.Achievements) == 0 && len(game.Stats) == 0) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]interface{}{
+				"playerstats": map[string]interface{}{"error": "Requested app has no stats", "success": false},
+			})
+			return
+		}
+		achievements := []map[string]interface{}{}
+		for _, name := range sortedNames(game.Achievements) {
+			achieved := 0
+			if game.Achievements[name] {
+				achieved = 1
+			}
+			achievements = append(achievements, map[string]interface{}{"apiname": name, "name": name, "achieved": achieved})
+		}
+		stats := []map[string]interface{}{}
+		for _, name := range sortedStatNames(game.Stats) {
+			stats = append(stats, map[string]interface{}{"name": name, "value": game.Stats[name]})
+		}
+		writeJSON(w, map[string]interface{}{
+			"playerstats": map[string]interface{}{
+				"steamID":      query.Get("steamid"),
+				"gameName":     game.Name,
+				"achievements": achievements,
+				"stats":        stats,
+				"success":      true,
+			},
+		})
+	case "/ISteamUserStats/GetGlobalAchievementPercentagesForApp/v0002/":
+		appID, _ := strconv.ParseUint(query.Get("gameid"), 10, 64)
+		names := map[string]bool{}
+		for _, user := range s.steamUsers {
+			for _, game := range user.Games {
+				if game.AppID == appID {
+					for name := range game.Achievements {
+						names[name] = true
+					}
+				}
+			}
+		}
+		achievements := []map[string]string{}
+		for _, name := range sortedNames(names) {
+			achievements = append(achievements, map[string]string{"name": name, "percent": "50.0"})
+		}
+		writeJSON(w, map[string]interface{}{
+			"achievementpercentages": map[string]interface{}{"achievements": achievements},
+		})
+	default:
+		http.NotFound(w, nil)
+	}
+}
+
+func (s *Server) serveAppDetails(w http.ResponseWriter, query url.Values) {
+	if s.steamStatus != 0 {
+		http.Error(w, http.StatusText(s.steamStatus), s.steamStatus)
+		return
+	}
+
+	details := map[string]interface{}{}
+	for _, appID := range strings.Split(query.Get("appids"), ",") {
+		id, _ := strconv.ParseUint(appID, 10, 64)
+		if query.Get("filters") != "price_overview" {
+			info, listed := s.apps[id]
+			if !listed {
+				details[appID] = map[string]interface{}{"success": false}
+				continue
+			}
+			genres := []map[string]string{}
+			for i, genre := range info.Genres {
+				genres = append(genres, map[string]string{"id": strconv.Itoa(i + 1), "description": genre})
+			}
+			data := map[string]interface{}{
+				"genres":       genres,
+				"release_date": map[string]interface{}{"coming_soon": false, "date": info.ReleaseDate},
+			}
+			if info.Metacritic > 0 {
+				data["metacritic"] = map[string]interface{}{"score": info.Metacritic}
+			}
+			details[appID] = map[string]interface{}{"success": true, "data": data}
+			continue
+		}
+
+		price, sold := s.prices[priceKey(id, query.Get("cc"))]
+		switch {
+		case !sold:
+			details[appID] = map[string]interface{}{"success": false}
+		case price == nil:
+			// The store answers an empty array when none of the filtered fields apply
+			details[appID] = map[string]interface{}{"success": true, "data": []interface{}{}}
+		default:
+			details[appID] = map[string]interface{}{"success": true, "data": map[string]interface{}{
+				"price_overview": map[string]interface{}{
+					"currency":         price.Currency,
+					"initial":          price.Initial,
+					"final":            price.Final,
+					"discount_percent": price.DiscountPercent,
+				},
+			}}
+		}
+	}
+	writeJSON(w, details)
+}
+
+func (s *Server) serveProtonDB(w http.ResponseWriter, appID string) {
+	id, _ := strconv.ParseUint(appID, 10, 64)
+	info := s.apps[id]
+	if info.ProtonDBTier == "" {
+		http.NotFound(w, nil)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"tier": info.ProtonDBTier, "bestReportedTier": info.ProtonDBTier, "total": 10})
+}
+
+func (s *Server) serveDeckCompatibility(w http.ResponseWriter, appID string) {
+	id, _ := strconv.ParseUint(appID, 10, 64)
+	writeJSON(w, map[string]interface{}{
+		"success": 1,
+		"results": map[string]interface{}{"appid": id, "resolved_category": s.apps[id].DeckCategory},
+	})
+}
+
+// game finds a user's game
+func (s *Server) game(steamID string, appID uint64) (Game, bool) {
+	for _, game := range s.steamUsers[steamID].Games {
+		if game.AppID == appID {
+			return game, true
+		}
+	}
+	return Game{}, false
+}
+
+// serveProfilePage serves a community profile page with the user's count links, laid out as
+// on the real page
+func (s *Server) serveProfilePage(w http.ResponseWriter, steamID string) {
+	user, ok := s.steamUsers[steamID]
+	if !ok {
+		http.Error(w, "The specified profile could not be found.", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html><body><div class=\"profile_header\">%s</div>\n", user.Name)
+	if len(user.ProfileCounts) == 0 {
+		fmt.Fprint(w, "<div class=\"profile_private_info\">This profile is private.</div>\n")
+	}
+	for _, label := range sortedCountLabels(user.ProfileCounts) {
+		fmt.Fprintf(w, "<div class=\"profile_count_link ellipsis\">\n\t<a href=\"https://steamcommunity.com/profiles/%s/\">\n"+
+			"\t\t<span class=\"count_link_label\">%s</span>&nbsp;\n\t\t<span class=\"profile_count_link_total\">\n\t\t\t%s\n\t\t</span>\n\t</a>\n</div>\n",
+			steamID, label, thousands(user.ProfileCounts[label]))
+	}
+	fmt.Fprint(w, "</body></html>")
+}
+
+// serveEpic serves Epic's library service. Requests must carry the account's token; a
+// playtime request for another account is refused, as Epic does.
+func (s *Server) serveEpic(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")
+	accountID := ""
+	for id, account := range s.epicAccounts {
+		if account.Token == token {
+			accountID = id
+		}
+	}
+	if accountID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]interface{}{"errorCode": "errors.com.epicgames.common.authentication.token_verification_failed"})
+		return
+	}
+	account := s.epicAccounts[accountID]
+
+	switch path := r.URL.Path; {
+	case path == "/library/api/public/playtime/account/"+accountID+"/all":
+		playtime := []map[string]interface{}{}
+		for _, game := range account.Games {
+			if game.PlaytimeSeconds > 0 {
+				playtime = append(playtime, map[string]interface{}{"accountId": accountID, "artifactId": game.AppName, "totalTime": game.PlaytimeSeconds})
+			}
+		}
+		writeJSON(w, playtime)
+	case strings.HasPrefix(path, "/library/api/public/playtime/account/"):
+		w.WriteHeader(http.StatusForbidden)
+		writeJSON(w, map[string]interface{}{"errorCode": "errors.com.epicgames.common.missing_permission"})
+	case path == "/library/api/public/items":
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		records := []map[string]interface{}{}
+		for i := start; i < start+epicLibraryPageSize && i < len(account.Games); i++ {
+			game := account.Games[i]
+			records = append(records, map[string]interface{}{"appName": game.AppName, "namespace": "ns", "catalogItemId": game.AppName, "sandboxName": game.Title})
+		}
+		metadata := map[string]interface{}{}
+		if start+epicLibraryPageSize < len(account.Games) {
+			metadata["nextCursor"] = strconv.Itoa(start + epicLibraryPageSize)
+		}
+		writeJSON(w, map[string]interface{}{"responseMetadata": metadata, "records": records})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveNintendoToken exchanges a session token for an access token and an ID token naming
+// the account; an unknown session token gets a 400 (invalid_grant), as from Nintendo
+func (s *Server) serveNintendoToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		SessionToken string `json:"session_token"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	for id, account := range s.nintendo {
+		if account.SessionToken == body.SessionToken {
+			claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + id + `"}`))
+			writeJSON(w, map[string]interface{}{
+				"access_token": nintendoAccessToken,
+				"id_token":     "e30." + claims + ".signature",
+				"expires_in":   900,
+			})
+			return
+		}
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	writeJSON(w, map[string]interface{}{"error": "invalid_grant"})
+}
+
+// serveNintendo serves the Parental Controls API: an account's devices and their daily summaries
+func (s *Server) serveNintendo(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+nintendoAccessToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := r.URL.Path
+	for id, account := range s.nintendo {
+		if path == "/v1/users/"+id+"/devices" {
+			items := []map[string]interface{}{}
+			for _, device := range account.Devices {
+				items = append(items, map[string]interface{}{"deviceId": device.ID, "label": device.Label})
+			}
+			writeJSON(w, map[string]interface{}{"items": items})
+			return
+		}
+		for _, device := range account.Devices {
+			if path == "/v1/devices/"+device.ID+"/daily_summaries" {
+				now := time.Now()
+				writeJSON(w, map[string]interface{}{"items": []map[string]interface{}{
+					nintendoSummary(device.ID, now, device.Today),
+					nintendoSummary(device.ID, now.AddDate(0, 0, -1), device.Yesterday),
+				}})
+				return
+			}
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// nintendoSummary builds a day's summary from its plays
+func nintendoSummary(deviceID string, day time.Time, plays []NintendoPlay) map[string]interface{} {
+	var total int64
+	apps := []map[string]interface{}{}
+	seen := make(map[string]bool)
+	players := []map[string]interface{}{}
+	byPlayer := make(map[string]int)
+	for _, play := range plays {
+		total += play.Seconds
+		if !seen[play.ApplicationID] {
+			seen[play.ApplicationID] = true
+			apps = append(apps, map[string]interface{}{"applicationId": play.ApplicationID, "title": play.Title})
+		}
+		i, ok := byPlayer[play.Player]
+		if !ok {
+			i = len(players)
+			byPlayer[play.Player] = i
+			players = append(players, map[string]interface{}{
+				"profile":     map[string]interface{}{"playerId": "player-" + play.Player, "nickname": play.Player},
+				"playingTime": int64(0),
+				"playedGames": []map[string]interface{}{},
+			})
+		}
+		players[i]["playingTime"] = players[i]["playingTime"].(int64) + play.Seconds
+		players[i]["playedGames"] = append(players[i]["playedGames"].([]map[string]interface{}),
+			map[string]interface{}{"applicationId": play.ApplicationID, "playingTime": play.Seconds})
+	}
+	return map[string]interface{}{
+		"deviceId":    deviceID,
+		"date":        day.Format("2006-01-02"),
+		"playingTime": total,
+		"playedApps":  apps,
+		"players":     players,
+	}
+}
+
+// serveBattleNetToken issues an access token to the configured client credentials
+func (s *Server) serveBattleNetToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || r.FormValue("grant_type") != "client_credentials" || [2]string{clientID, clientSecret} != s.bnetClient {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]interface{}{"error": "invalid_client"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{"access_token": bnetAccessToken, "token_type": "bearer", "expires_in": 86399})
+}
+
+// serveBattleNet serves the Diablo III and StarCraft II profile APIs
+func (s *Server) serveBattleNet(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+bnetAccessToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch path := r.URL.Path; {
+	case strings.HasPrefix(path, "/d3/profile/"):
+		profile, ok := s.d3Profiles[strings.Trim(strings.TrimPrefix(path, "/d3/profile/"), "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, map[string]interface{}{"code": "NOTFOUND"})
+			return
+		}
+		heroes := []map[string]interface{}{}
+		timePlayed := map[string]float64{}
+		for _, hero := range profile.Heroes {
+			heroes = append(heroes, map[string]interface{}{"id": hero.ID, "name": hero.Name, "classSlug": hero.Class, "level": hero.Level, "hardcore": hero.Hardcore})
+			timePlayed[hero.Class] = 1
+		}
+		writeJSON(w, map[string]interface{}{
+			"paragonLevel": profile.ParagonLevel,
+			"heroes":       heroes,
+			"kills":        map[string]interface{}{"monsters": profile.MonsterKills, "elites": profile.EliteKills},
+			"timePlayed":   timePlayed,
+		})
+	case strings.HasPrefix(path, "/sc2/profile/"):
+		profile, ok := s.sc2Profiles[strings.ReplaceAll(strings.TrimPrefix(path, "/sc2/profile/"), "/", "-")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		total := 0
+		swarmLevels := map[string]interface{}{}
+		for race, level := range profile.SwarmLevels {
+			total += level
+			swarmLevels[race] = map[string]interface{}{"level": level}
+		}
+		writeJSON(w, map[string]interface{}{
+			"summary": map[string]interface{}{"displayName": profile.Name, "totalSwarmLevel": total},
+			"career": map[string]interface{}{
+				"terranWins":       profile.Wins["terran"],
+				"zergWins":         profile.Wins["zerg"],
+				"protossWins":      profile.Wins["protoss"],
+				"totalCareerGames": profile.Games,
+			},
+			"swarmLevels": swarmLevels,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveClash serves the player endpoint of the Clash of Clans and Clash Royale APIs, told apart
+// by host. A rejected token gets a 403, as from Supercell.
+func (s *Server) serveClash(w http.ResponseWriter, r *http.Request) {
+	game := "clans"
+	if strings.Contains(r.Header.Get("X-Forwarded-Host"), "clashroyale") {
+		game = "royale"
+	}
+	if token := s.clashTokens[game]; token == "" || r.Header.Get("Authorization") != "Bearer "+token {
+		w.WriteHeader(http.StatusForbidden)
+		writeJSON(w, map[string]interface{}{"reason": "accessDenied"})
+		return
+	}
+
+	tag := strings.TrimPrefix(r.URL.Path, "/v1/players/")
+	player, ok := s.clash[game+":"+tag]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, map[string]interface{}{"reason": "notFound"})
+		return
+	}
+	resp := map[string]interface{}{
+		"tag":               tag,
+		"name":              player.Name,
+		"expLevel":          player.ExpLevel,
+		"trophies":          player.Trophies,
+		"bestTrophies":      player.BestTrophies,
+		"donations":         player.Donations,
+		"donationsReceived": player.DonationsReceived,
+	}
+	if game == "clans" {
+		resp["townHallLevel"] = player.TownHallLevel
+		resp["warStars"] = player.WarStars
+	} else {
+		resp["arena"] = map[string]interface{}{"id": player.ArenaID, "name": player.ArenaName}
+	}
+	writeJSON(w, resp)
+}
+
+// serveBattleMetrics serves a server and its outages as JSON:API documents; an unknown server
+// gets a 404
+func (s *Server) serveBattleMetrics(w http.ResponseWriter, r *http.Request) {
+	id, relationship, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/servers/"), "/")
+	server, ok := s.bmServers[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, map[string]interface{}{"errors": []map[string]interface{}{{"status": "404", "title": "Unknown Server"}}})
+		return
+	}
+
+	switch relationship {
+	case "":
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"type": "server",
+			"id":   id,
+			"attributes": map[string]interface{}{
+				"id":         id,
+				"name":       server.Name,
+				"players":    server.Players,
+				"maxPlayers": server.MaxPlayers,
+				"rank":       server.Rank,
+				"status":     server.Status,
+			},
+			"relationships": map[string]interface{}{
+				"game": map[string]interface{}{"data": map[string]interface{}{"type": "game", "id": server.Game}},
+			},
+		}})
+	case "relationships/outages":
+		outages := []map[string]interface{}{}
+		for i, outage := range server.Outages {
+			attributes := map[string]interface{}{"start": outage.Start.UTC().Format(time.RFC3339), "stop": nil}
+			if !outage.Stop.IsZero() {
+				attributes["stop"] = outage.Stop.UTC().Format(time.RFC3339)
+			}
+			outages = append(outages, map[string]interface{}{
+				"type":       "serverOutage",
+				"id":         strconv.Itoa(i + 1),
+				"attributes": attributes,
+			})
+		}
+		writeJSON(w, map[string]interface{}{"data": outages})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveRCON serves an RCON connection: a wrong password is answered with request ID -1, and
+// commands the server's game doesn't know get the text of an unknown command
+func (s *Server) serveRCON(conn net.Conn, address string) {
+	defer conn.Close()
+	s.mu.Lock()
+	s.requests["rcon:"+address]++
+	s.mu.Unlock()
+
+	read := func() (int32, int32, string, error) {
+		var size int32
+		if err := binary.Read(conn, binary.LittleEndian, &size); err != nil {
+			return 0, 0, "", err
+		}
+		packet := make([]byte, size)
+		if _, err := io.ReadFull(conn, packet); err != nil {
+			return 0, 0, "", err
+		}
+		body := strings.TrimRight(string(packet[8:]), "\x00")
+		return int32(binary.LittleEndian.Uint32(packet[0:4])), int32(binary.LittleEndian.Uint32(packet[4:8])), body, nil
+	}
+	write := func(id int32, kind int32, body string) {
+		var packet bytes.Buffer
+		binary.Write(&packet, binary.LittleEndian, int32(10+len(body)))
+		binary.Write(&packet, binary.LittleEndian, id)
+		binary.Write(&packet, binary.LittleEndian, kind)
+		packet.WriteString(body + "\x00\x00")
+		conn.Write(packet.Bytes())
+	}
+
+	authenticated := false
+	for {
+		id, kind, body, err := read()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		server := s.rcon[address]
+		s.mu.Unlock()
+
+		if kind == 3 {
+			// Like Source servers, an empty response value comes before the auth response
+			write(id, 0, "")
+			if body != server.Password {
+				write(-1, 2, "")
+				return
+			}
+			authenticated = true
+			write(id, 2, "")
+			continue
+		}
+		if !authenticated {
+			return
+		}
+		write(id, 0, rconResponse(server, body))
+	}
+}
+
+// rconResponse is a game's output for a command
+func rconResponse(server RCONServer, command string) string {
+	switch {
+	case server.Game == "minecraft" && command == "list":
+		return fmt.Sprintf("There are %d of a max of %d players online: %s", len(server.Players), server.MaxPlayers, strings.Join(server.Players, ", "))
+	case server.Game == "minecraft" && command == "tps" && server.TPS > 0:
+		return fmt.Sprintf("§6TPS from last 1m, 5m, 15m: §a%.1f, §a%.1f, §a%.1f", server.TPS, server.TPS, server.TPS)
+	case server.Game == "minecraft" && command == "tick query" && server.MSPT > 0:
+		return fmt.Sprintf("The game is running normally\nTarget tick rate: 20.0 per second.\nAverage time per tick: %.1fms (Target: 50.0ms)", server.MSPT)
+	case server.Game == "factorio" && command == "/players online":
+		lines := []string{fmt.Sprintf("Online players (%d):", len(server.Players))}
+		for _, player := range server.Players {
+			lines = append(lines, "  "+player+" (online)")
+		}
+		return strings.Join(lines, "\n")
+	case server.Game == "valheim" && command == "players":
+		return strings.Join(server.Players, "\n")
+	default:
+		return "Unknown or incomplete command, see below for error"
+	}
+}
+
+// serveDiscordGateway emulates a Discord gateway session: hello, then READY and a guild with the
+// current presences once identified; heartbeats are acknowledged
+func (s *Server) serveDiscordGateway(conn *websocket.Conn) {
+	websocket.JSON.Send(conn, map[string]interface{}{"op": 10, "d": map[string]interface{}{"heartbeat_interval": 41250}})
+
+	var identify struct {
+		Op int `json:"op"`
+	}
+	if err := websocket.JSON.Receive(conn, &identify); err != nil || identify.Op != 2 {
+		return
+	}
+
+	s.mu.Lock()
+	presences := []map[string]interface{}{}
+	for userID, games := range s.discordGames {
+		presences = append(presences, discordPresence(userID, games))
+	}
+	websocket.JSON.Send(conn, map[string]interface{}{"op": 0, "s": 1, "t": "READY", "d": map[string]interface{}{"v": 10}})
+	websocket.JSON.Send(conn, map[string]interface{}{"op": 0, "s": 1, "t": "GUILD_CREATE", "d": map[string]interface{}{"id": "1", "presences": presences}})
+	s.discordConns = append(s.discordConns, conn)
+	s.mu.Unlock()
+
+	for {
+		var p struct {
+			Op int `json:"op"`
+		}
+		if err := websocket.JSON.Receive(conn, &p); err != nil {
+			break
+		}
+		if p.Op == 1 {
+			s.mu.Lock()
+			websocket.JSON.Send(conn, map[string]interface{}{"op": 11})
+			s.mu.Unlock()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, c := range s.discordConns {
+		if c == conn {
+			s.discordConns = append(s.discordConns[:i], s.discordConns[i+1:]...)
+			break
+		}
+	}
+}
+
+// discordPresence builds a user's presence, playing the given games
+func discordPresence(userID string, games []string) map[string]interface{} {
+	activities := []map[string]interface{}{}
+	for _, game := range games {
+		activities = append(activities, map[string]interface{}{"name": game, "type": 0})
+	}
+	return map[string]interface{}{"user": map[string]interface{}{"id": userID}, "status": "online", "activities": activities}
+}
+
+func (s *Server) serveHiscores(w http.ResponseWriter, rsn string) {
+	if len(s.hiscoresFail) > 0 {
+		status := s.hiscoresFail[0]
+		s.hiscoresFail = s.hiscoresFail[1:]
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	player, ok := s.osrsPlayers[strings.ToLower(rsn)]
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	var body strings.Builder
+	for _, skill := range player.Skills {
+		fmt.Fprintf(&body, "%d,%d,%d\n", skill.Rank, skill.Level, skill.XP)
+	}
+	for _, minigame := range player.Minigames {
+		fmt.Fprintf(&body, "%d,%d\n", minigame.Rank, minigame.Score)
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, body.String())
+}
+
+func (s *Server) serveHiscoresPage(w http.ResponseWriter, rsn string) {
+	player, ok := s.osrsPlayers[strings.ToLower(rsn)]
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, "<html><body><table>\n")
+	for i, minigame := range player.Minigames {
+		if minigame.Rank == -1 {
+			continue
+		}
+		fmt.Fprintf(w, "<tr><td><a href=\"overall?table=%d&category_type=1\">%s</a></td></tr>\n", i, minigame.Name)
+	}
+	fmt.Fprint(w, "</table></body></html>\n")
+}
+
+func (s *Server) serveItemMapping(w http.ResponseWriter) {
+	items := make([]map[string]interface{}, 0, len(s.geItems))
+	for _, item := range s.geItems {
+		items = append(items, map[string]interface{}{
+			"id":       item.ID,
+			"name":     item.Name,
+			"examine":  "An item.",
+			"members":  item.Members,
+			"limit":    item.Limit,
+			"value":    item.HighAlch * 5 / 3,
+			"highalch": item.HighAlch,
+			"lowalch":  item.HighAlch * 2 / 3,
+			"icon":     item.Name + ".png",
+		})
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) serveGEPrices(w http.ResponseWriter, id string) {
+	data := make(map[string]interface{})
+	for _, item := range s.geItems {
+		if id != "" && id != strconv.Itoa(item.ID) {
+			continue
+		}
+		price := map[string]interface{}{"high": nil, "highTime": nil, "low": nil, "lowTime": nil}
+		if item.High > 0 {
+			price["high"], price["highTime"] = item.High, 1700000000
+		}
+		if item.Low > 0 {
+			price["low"], price["lowTime"] = item.Low, 1700000000
+		}
+		data[strconv.Itoa(item.ID)] = price
+	}
+	writeJSON(w, map[string]interface{}{"data": data})
+}
+
+func (s *Server) serveWOMGroup(w http.ResponseWriter, id string) {
+	groupID, _ := strconv.Atoi(id)
+	rsns, ok := s.womGroups[groupID]
+	if !ok {
+		http.Error(w, `{"message":"Group not found."}`, http.StatusNotFound)
+		return
+	}
+	memberships := make([]map[string]interface{}, 0, len(rsns))
+	for i, rsn := range rsns {
+		memberships = append(memberships, map[string]interface{}{
+			"playerId": i + 1,
+			"groupId":  groupID,
+			"role":     "member",
+			"player": map[string]interface{}{
+				"id":          i + 1,
+				"username":    strings.ToLower(rsn),
+				"displayName": rsn,
+				"type":        "regular",
+			},
+		})
+	}
+	writeJSON(w, map[string]interface{}{
+		"id":          groupID,
+		"name":        "Test clan",
+		"memberCount": len(rsns),
+		"memberships": memberships,
+	})
+}
+
+func (s *Server) serveWorlds(w http.ResponseWriter) {
+	body := EncodeWorlds(s.worlds, s.worldsCount)
+	if s.worldsLimit > 0 && len(body) > s.worldsLimit {
+		body = body[:s.worldsLimit]
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(body)
+}
+
+// EncodeWorlds encodes a world list in the slr.ws format: the remaining length and world count,
+// then each world's ID, type flags, address, activity, location and player count. count
+// overrides the world count in the header; 0 uses len(worlds).
+func EncodeWorlds(worlds []World, count int16) []byte {
+	if count == 0 {
+		count = int16(len(worlds))
+	}
+
+	var entries bytes.Buffer
+	for _, world := range worlds {
+		binary.Write(&entries, binary.LittleEndian, world.ID)
+		binary.Write(&entries, binary.LittleEndian, world.Flags)
+		entries.WriteString(world.Address)
+		entries.WriteByte(0)
+		entries.WriteString(world.Activity)
+		entries.WriteByte(0)
+		binary.Write(&entries, binary.LittleEndian, world.Location)
+		binary.Write(&entries, binary.LittleEndian, world.Players)
+	}
+
+	var body bytes.Buffer
+	binary.Write(&body, binary.LittleEndian, int32(2+entries.Len()))
+	binary.Write(&body, binary.LittleEndian, count)
+	body.Write(entries.Bytes())
+	return body.Bytes()
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedStatNames(stats map[string]float64) []string {
+	sorted := make([]string, 0, len(stats))
+	for name := range stats {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedCountLabels(counts map[string]int) []string {
+	sorted := make([]string, 0, len(counts))
+	for label := range counts {
+		sorted = append(sorted, label)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// thousands formats n with comma separators, as the profile page does (1,234)
+func thousands(n int) string {
+	digits := strconv.Itoa(n)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
@@ -2,70 +2,383 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joshhsoj1902/game-stats-exporter/internal/api"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/battlemetrics"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/battlenet"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/check"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/clash"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/discord"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/epic"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/family"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/fixtures"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/history"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/nintendo"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/notify"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/polling"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/push"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/rcon"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/relabel"
 	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	// One-shot mode: collect once and write a textfile instead of running the server
+	if len(os.Args) > 1 && (os.Args[1] == "collect" || os.Args[1] == "--once") {
+		os.Exit(runCollect(os.Args[2:]))
+	}
+	// Self-test: check every dependency and exit
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+	flags := flag.NewFlagSet("game-stats-exporter", flag.ExitOnError)
+	validateOnly := flags.Bool("validate-config", false, "Check the configuration, print every invalid value and exit (1 if any)")
+	addConfigFlags(flags)
+	flags.Parse(os.Args[1:])
+	applyLogFlags()
+	if *validateOnly {
+		os.Exit(runValidateConfig())
+	}
+
 	// Initialize logger first
 	logger.Log.Info("Starting game-stats-exporter")
 
-	// Load configuration from environment variables
+	// Load configuration from flags and environment variables
 	config := loadConfig()
 
 	logger.Log.WithFields(logrus.Fields{
 		"port":               config.Port,
+		"cache_backend":      config.CacheBackend,
 		"redis_addr":         config.RedisAddr,
+		"redis_tls":          config.RedisTLS,
 		"poll_interval":      config.PollIntervalNormal,
 		"poll_interval_active": config.PollIntervalActive,
-		"steam_key_set":      config.SteamKey != "",
+		"poll_workers":       config.PollWorkers,
+		"poll_coordination":  config.PollCoordination,
+		"poll_targets":       len(config.PollSteamIDs) + len(config.PollOSRSPlayers),
+		"push_remote_write":  config.PushRemoteWriteURL != "",
+		"push_otlp":          config.PushOTLP,
+		"push_pushgateway":   config.PushgatewayURL != "",
+		"push_graphite":      config.GraphiteAddr != "",
+		"history_driver":     config.HistoryDriver,
+		"config_file":        config.ConfigFile,
+		"steam_keys":         len(config.SteamKeys),
+		"steam_price_apps":   len(config.SteamPriceAppIDs),
+		"steam_discover_friends": config.SteamDiscoverFriendsOf != "",
+		"osrs_ge_items":      len(config.OSRSGEItems),
+		"osrs_wom_group":     config.OSRSWOMGroupID,
+		"epic_accounts":      len(config.EpicTokens),
+		"nintendo_enabled":   config.NintendoSessionToken != "",
+		"bnet_enabled":       config.BattleNetClientID != "",
+		"clash_enabled":      config.ClashOfClansToken != "" || config.ClashRoyaleToken != "",
+		"battlemetrics_servers": len(config.BattleMetricsServers),
+		"rcon_servers":       len(config.RCONServers),
+		"discord_enabled":    config.DiscordBotToken != "",
+		"notify_enabled":     config.NotifyWebhookURL != "",
+		"auth_enabled":       config.AuthBearerToken != "" || config.AuthUsername != "",
 	}).Info("Configuration loaded")
 
-	// Initialize Redis cache
-	redisCache := cache.New(config.RedisAddr, config.RedisPassword, config.RedisDB)
+	// Initialize tracing (a no-op unless TRACING_ENABLED is set)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Enabled:     config.TracingEnabled,
+		ServiceName: config.TracingServiceName,
+		SampleRatio: config.TracingSampleRatio,
+	})
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Failed to initialize tracing")
+	}
+
+	// Initialize cache (Redis by default, or an embedded file for single-node deployments)
+	redisCache, err := newCache(config)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Failed to initialize cache")
+	}
 	defer redisCache.Close()
 
 	// Initialize collectors
 	var steamCollector *steam.Collector
-	if config.SteamKey != "" {
-		steamCollector = steam.NewCollector(config.SteamKey, redisCache)
+	if len(config.SteamKeys) > 0 {
+		steamCollector = steam.NewCollector(steamConfig(config), redisCache)
 	}
 
-	osrsCollector := osrs.NewCollector(redisCache)
+	osrsCollector := newOSRSCollector(config, redisCache)
+
+	// Dependency checks, served at /admin/check and optionally run before starting
+	checker := newChecker(redisCache, steamCollector, osrsCollector)
+	if config.StartupCheck {
+		results := checker.Run(context.Background())
+		for _, result := range results {
+			logger.Log.WithFields(logrus.Fields{
+				"check":    result.Name,
+				"ok":       result.OK,
+				"error":    result.Error,
+				"duration": result.Duration,
+			}).Info("Startup check")
+		}
+		if !check.Passed(results) {
+			logger.Log.Fatal("Startup checks failed")
+		}
+	}
+
+	// Push mode: forward each background collection for environments Prometheus can't scrape
+	var sinks []push.Sink
+	if config.PushRemoteWriteURL != "" {
+		sinks = append(sinks, push.NewRemoteWriteSink(push.RemoteWriteConfig{
+			URL:         config.PushRemoteWriteURL,
+			Username:    config.PushRemoteWriteUsername,
+			Password:    config.PushRemoteWritePassword,
+			BearerToken: config.PushRemoteWriteBearerToken,
+			Job:         config.PushJob,
+		}))
+	}
+	if config.PushgatewayURL != "" {
+		sinks = append(sinks, push.NewPushgatewaySink(push.PushgatewayConfig{
+			URL:      config.PushgatewayURL,
+			Job:      config.PushJob,
+			Username: config.PushgatewayUsername,
+			Password: config.PushgatewayPassword,
+		}))
+	}
+	if config.GraphiteAddr != "" {
+		sinks = append(sinks, push.NewGraphiteSink(push.GraphiteConfig{
+			Addr:   config.GraphiteAddr,
+			Prefix: config.GraphitePrefix,
+		}))
+	}
+	var otlpSink *push.OTLPSink
+	if config.PushOTLP {
+		otlpSink, err = push.NewOTLPSink(context.Background(), config.TracingServiceName)
+		if err != nil {
+			logger.Log.WithError(err).Fatal("Failed to initialize OTLP metrics push")
+		}
+		sinks = append(sinks, otlpSink)
+	}
+	pusher := push.NewPusher(prometheus.DefaultGatherer, sinks...)
+	pusher.SetRelabel(relabelRules(config))
+
+	// Optional history store, snapshotting each background collection
+	var historyStore *history.Store
+	var recorder *history.Recorder
+	if config.HistoryDriver != "" {
+		historyStore, err = history.Open(context.Background(), config.HistoryDriver, config.HistoryDSN)
+		if err != nil {
+			logger.Log.WithError(err).Fatal("Failed to initialize history store")
+		}
+		defer historyStore.Close()
+
+		var steamSource history.SteamSource
+		if steamCollector != nil {
+			steamSource = steamCollector
+		}
+		recorder = history.NewRecorder(historyStore, steamSource, osrsCollector)
+	}
+
+	var onCollected func(ctx context.Context, kind string, id string)
+	if pusher.Enabled() || recorder != nil {
+		onCollected = func(ctx context.Context, kind string, id string) {
+			if kind == push.KindOSRS {
+				// OSRS series are labelled with the player label rather than the polled RSN
+				pusher.Push(ctx, kind, osrsCollector.PlayerLabel(id))
+			} else {
+				pusher.Push(ctx, kind, id)
+			}
+			if recorder != nil {
+				recorder.Record(ctx, kind, id)
+			}
+		}
+	}
+
+	// Initialize polling manager for background polling of configured targets
+	// On-demand collection via the HTTP endpoints works independently of it
+	var steamPoller polling.SteamCollector
+	if steamCollector != nil {
+		steamPoller = steamCollector
+	}
+	var leases polling.LeaseStore
+	if config.PollCoordination {
+		leases = redisCache
+	}
+	pollingManager := polling.NewManager(steamPoller, osrsCollector, polling.Config{
+		NormalInterval: config.PollIntervalNormal,
+		ActiveInterval: config.PollIntervalActive,
+		Workers:        config.PollWorkers,
+		Jitter:         config.PollJitter,
+		MaxBackoff:     config.PollMaxBackoff,
+		Paused:         config.PollPaused,
+		Leases:         leases,
+		InstanceID:     config.PollInstanceID,
+		Schedules:      config.PollSchedules,
+		OnCollected:    onCollected,
+	})
 
-	// Initialize polling manager (optional - for background polling if needed)
-	// Note: Currently collection is on-demand via HTTP endpoints
-	// The polling manager can be used for background polling if desired
-	var pollingManager *polling.Manager
+	// Register the polled targets and apply CONFIG_FILE; reapplied on SIGHUP and POST /admin/reload
+	var familySteam family.SteamSource
 	if steamCollector != nil {
-		pollingManager = polling.NewManager(
-			steamCollector,
-			osrsCollector,
-			config.PollIntervalNormal,
-			config.PollIntervalActive,
-		)
-		// Start background polling for world data
-		pollingManager.StartWorldDataPolling()
+		familySteam = steamCollector
 	}
+	families := family.NewAggregator(familySteam, osrsCollector)
+	users := newUserDirectory()
+	tenants := newTenantDirectory()
+
+	// Optional Discord bot watching the presence of the users with a discord_id; a user who
+	// starts playing has their polled accounts polled right away and at the active interval
+	var discordWatcher *discord.Watcher
+	if config.DiscordBotToken != "" {
+		discordWatcher = discord.NewWatcher(discord.Config{
+			Token: config.DiscordBotToken,
+			OnPlaying: func(user string, playing bool) {
+				accounts, ok := users.User(user)
+				if !ok {
+					return
+				}
+				if accounts.SteamID != "" {
+					pollingManager.SetSteamPlaying(accounts.SteamID, playing)
+				}
+				for _, rsn := range accounts.OSRSPlayers {
+					pollingManager.SetOSRSPlaying(rsn, playing)
+				}
+			},
+		})
+	}
+
+	reloader := newConfigReloader(config, pollingManager, steamCollector, osrsCollector, families, users, tenants, discordWatcher)
+	if err := reloader.Reload(context.Background()); err != nil {
+		logger.Log.WithError(err).Fatal("Failed to load config file")
+	}
+	if discordWatcher != nil {
+		discordWatcher.Start()
+		defer discordWatcher.Stop()
+	}
+
+	// Targets discovered outside the configuration, synced until shutdown
+	discoveryCtx, stopDiscovery := context.WithCancel(context.Background())
+	defer stopDiscovery()
+	if steamCollector != nil && config.SteamDiscoverFriendsOf != "" {
+		go runDiscovery(discoveryCtx, "steam_friends", config.SteamDiscoverInterval, func(ctx context.Context) error {
+			friends, err := steamCollector.DiscoverFriends(ctx, config.SteamDiscoverFriendsOf, config.SteamDiscoverFilter)
+			if err != nil {
+				return err
+			}
+			reloader.SetDiscoveredSteamIDs(ctx, friends)
+			return nil
+		})
+	}
+	if config.OSRSWOMGroupID != 0 {
+		go runDiscovery(discoveryCtx, "osrs_wom_group", config.OSRSWOMSyncInterval, func(ctx context.Context) error {
+			rsns, err := osrsCollector.GroupMembers(ctx, config.OSRSWOMGroupID)
+			if err != nil {
+				return err
+			}
+			reloader.SetDiscoveredOSRSPlayers(ctx, rsns)
+			return nil
+		})
+	}
+	// Start background polling for world data
+	pollingManager.StartWorldDataPolling()
+	pollingManager.Start()
 
 	// Initialize handlers with polling manager
-	handlers := api.NewHandlers(steamCollector, osrsCollector)
+	handlerOptions := api.HandlerOptions{
+		ScrapeTimeout: config.ScrapeTimeout,
+		Families:      families,
+		Users:         users,
+		Targets:       pollingManager,
+		Relabel:       relabelRules(config),
+
+		SampleTimestamps: config.MetricTimestamps,
+		Exemplars:        config.TracingEnabled,
+	}
+	if historyStore != nil {
+		handlerOptions.History = historyStore
+	}
+	if prices := priceCollector(config, redisCache); prices != nil {
+		handlerOptions.Prices = prices
+	}
+	if ge := geCollector(config, osrsCollector); ge != nil {
+		handlerOptions.GE = ge
+	}
+	if len(config.EpicTokens) > 0 {
+		handlerOptions.Epic = epic.NewCollector(epic.Config{
+			Tokens:    config.EpicTokens,
+			Transport: config.UpstreamTransport,
+		}, redisCache)
+	}
+	if config.NintendoSessionToken != "" {
+		handlerOptions.Nintendo = nintendo.NewCollector(nintendo.Config{
+			SessionToken: config.NintendoSessionToken,
+			Transport:    config.UpstreamTransport,
+			DayLocation:  config.DayLocation,
+		}, redisCache)
+	}
+	if config.BattleNetClientID != "" {
+		handlerOptions.BattleNet = battlenet.NewCollector(battlenet.Config{
+			ClientID:     config.BattleNetClientID,
+			ClientSecret: config.BattleNetClientSecret,
+			Region:       config.BattleNetRegion,
+			Transport:    config.UpstreamTransport,
+		}, redisCache)
+	}
+	if config.ClashOfClansToken != "" || config.ClashRoyaleToken != "" {
+		handlerOptions.Clash = clash.NewCollector(clash.Config{
+			ClansToken:  config.ClashOfClansToken,
+			RoyaleToken: config.ClashRoyaleToken,
+			Transport:   config.UpstreamTransport,
+		}, redisCache)
+	}
+	if len(config.BattleMetricsServers) > 0 {
+		handlerOptions.BattleMetrics = battlemetrics.NewCollector(battlemetrics.Config{
+			Token:     config.BattleMetricsToken,
+			ServerIDs: config.BattleMetricsServers,
+			Transport: config.UpstreamTransport,
+		}, redisCache)
+	}
+	if len(config.RCONServers) > 0 {
+		handlerOptions.RCON = rcon.NewCollector(rcon.Config{
+			Servers: config.RCONServers,
+		}, redisCache)
+	}
+	if discordWatcher != nil {
+		handlerOptions.Discord = discordWatcher
+	}
+	handlers := api.NewHandlers(steamCollector, osrsCollector, handlerOptions)
+
+	var rateLimitAdmin api.RateLimitAdmin
+	if steamCollector != nil {
+		rateLimitAdmin = steamCollector
+	}
+	adminHandlers := api.NewAdminHandlers(redisCache, pollingManager, checker, reloader, rateLimitAdmin)
 
 	// Create router
-	router := api.NewRouter(handlers)
+	router := api.NewRouter(handlers, adminHandlers, api.RouterConfig{
+		Auth: api.AuthConfig{
+			BearerToken: config.AuthBearerToken,
+			Username:    config.AuthUsername,
+			Password:    config.AuthPassword,
+			Tenants:     tenants,
+		},
+		Limits: api.LimitConfig{
+			PerIPRate:     config.RateLimitPerIP,
+			PerIPBurst:    config.RateLimitBurst,
+			MaxConcurrent: config.MaxConcurrentCollections,
+		},
+	})
 
 	// Create HTTP server
 	server := &http.Server{
@@ -81,63 +394,583 @@ func main() {
 		}
 	}()
 
+	// Reload the config file on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logger.Log.Info("SIGHUP received, reloading configuration")
+			if err := reloader.Reload(context.Background()); err != nil {
+				logger.Log.WithError(err).Error("Failed to reload configuration, keeping the current one")
+			}
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Log.Info("Shutting down server...")
+	logger.Log.WithField("timeout", config.ShutdownTimeout).Info("Shutting down server...")
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
 
-	// Stop polling manager if it exists
-	if pollingManager != nil {
-		logger.Log.Info("Stopping polling manager")
-		pollingManager.Stop()
+	// Stop starting collections and cancel the running ones: background polls and discovery,
+	// and on-demand collections still running after their scrape timed out
+	logger.Log.Info("Stopping polling manager")
+	stopDiscovery()
+	pollingManager.Stop()
+	if err := handlers.Drain(ctx); err != nil {
+		logger.Log.WithError(err).Warn("Collections still running at the shutdown timeout")
 	}
 
-	// Shutdown HTTP server with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+	// Finish the requests being answered; collection endpoints now answer 503
 	if err := server.Shutdown(ctx); err != nil {
-		logger.Log.WithError(err).Fatal("Server forced to shutdown")
+		logger.Log.WithError(err).Warn("Server forced to shutdown")
+	}
+
+	// Let background cache refreshes write what they fetched before the cache is closed
+	if err := redisCache.Drain(ctx); err != nil {
+		logger.Log.WithError(err).Warn("Cache refreshes still running at the shutdown timeout")
+	}
+
+	if otlpSink != nil {
+		if err := otlpSink.Close(ctx); err != nil {
+			logger.Log.WithError(err).Warn("Failed to flush OTLP metrics")
+		}
+	}
+
+	// Flush any buffered spans
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Log.WithError(err).Warn("Failed to flush traces")
 	}
 
 	logger.Log.Info("Server exited")
 }
 
 type Config struct {
-	SteamKey          string
+	SteamKeys         []string
+	SteamKeyRotation  string
+	SteamBackoff      steam.BackoffPolicy
+	SteamGameInfo     bool
+	SteamGameCompat   bool
+	SteamFriends      bool
+	SteamWorkshop     bool
+	SteamProfileContent bool
+	SteamInfoMetrics  bool // Leave username and game_name to steam_player_info and steam_game_info
+	SteamMaxAchievementsPerGame  int // Cardinality budget of steam_achievements_achieved, 0 for unlimited
+	SteamMaxAchievementSeries    int
+	SteamMaxAchievementRefreshes int // Games whose achievements one collection refreshes, 0 for unlimited
+	SteamPriceAppIDs  []uint64
+	SteamDiscoverFriendsOf string // Steam ID whose friends are polled, empty for none
+	SteamDiscoverFilter    steam.FriendFilter
+	SteamDiscoverInterval  time.Duration
+	SteamPriceRegions []string
+	OSRSRateLimit     float64 // OSRS requests per second across replicas, 0 for unlimited
+	OSRSGEItems       []osrs.GEWatch // Grand Exchange items served at /metrics/osrs/ge
+	OSRSWOMGroupID    int // Wise Old Man group whose members are polled, 0 for none
+	OSRSWOMSyncInterval time.Duration
+	EpicTokens        map[string]string // Access token per Epic account ID
+	NintendoSessionToken string // Session token of the Parental Controls app
+	BattleNetClientID     string
+	BattleNetClientSecret string
+	BattleNetRegion       string // us, eu, kr or tw
+	ClashOfClansToken string
+	ClashRoyaleToken  string
+	BattleMetricsServers []string // BattleMetrics server IDs
+	BattleMetricsToken   string
+	RCONServers       []rcon.Server
+	DiscordBotToken   string
+	NotifyWebhookURL  string // Where one-shot events such as OSRS milestones are posted
+	DayLocation       *time.Location // Where the *_today metrics reset at midnight
+	MetricNamespaces   map[string]string // Replacements of the steam and osrs namespaces
+	MetricDropLabels   []string
+	MetricStaticLabels map[string]string
+	MetricTimestamps   bool // Stamp samples with the time their cached data was fetched
+	UpstreamTransport http.RoundTripper // Pooled transport (UPSTREAM_*), wrapped for fixture record/replay when UPSTREAM_FIXTURES is set
+	CacheBackend      string
+	CacheFilePath     string
 	RedisAddr         string
+	RedisUsername     string
 	RedisPassword     string
 	RedisDB           int
+	RedisTLS           bool
+	RedisTLSSkipVerify bool
+	RedisTLSCAFile     string
+	RedisOpTimeout     time.Duration
+	RedisCompress      bool
 	PollIntervalNormal time.Duration
 	PollIntervalActive time.Duration
+	PollWorkers        int
+	PollJitter         float64
+	PollMaxBackoff     time.Duration
+	PollPaused         bool
+	PollCoordination   bool
+	PollInstanceID     string
+	PollSchedules      map[string]polling.Schedule
+	PollSteamIDs       []string
+	PollOSRSPlayers    []string
+	AuthBearerToken    string
+	AuthUsername       string
+	AuthPassword       string
+	RateLimitPerIP     float64
+	RateLimitBurst     int
+	MaxConcurrentCollections int
+	ScrapeTimeout      time.Duration
+	ShutdownTimeout    time.Duration // How long shutdown waits for in-flight work before exiting
+	TracingEnabled     bool
+	TracingServiceName string
+	TracingSampleRatio float64
+	PushRemoteWriteURL string
+	PushRemoteWriteUsername    string
+	PushRemoteWritePassword    string
+	PushRemoteWriteBearerToken string
+	PushOTLP           bool
+	PushgatewayURL      string
+	PushgatewayUsername string
+	PushgatewayPassword string
+	GraphiteAddr        string
+	GraphitePrefix      string
+	PushJob            string
+	HistoryDriver      string
+	HistoryDSN         string
+	StartupCheck       bool
+	ConfigFile         string
+	ConfigStrict       bool // Refuse to start with malformed values instead of using their defaults
 	Port               int
 }
 
+// steamConfig builds the Steam collector configuration
+func steamConfig(config Config) steam.Config {
+	return steam.Config{
+		APIKeys:        config.SteamKeys,
+		KeyRotation:    config.SteamKeyRotation,
+		Backoff:        config.SteamBackoff,
+		Transport:      config.UpstreamTransport,
+		GameInfo:       config.SteamGameInfo,
+		GameCompat:     config.SteamGameCompat,
+		Friends:        config.SteamFriends,
+		Workshop:       config.SteamWorkshop,
+		ProfileContent: config.SteamProfileContent,
+		InfoMetrics:    config.SteamInfoMetrics,
+		DayLocation:    config.DayLocation,
+
+		MaxAchievementsPerGame:  config.SteamMaxAchievementsPerGame,
+		MaxAchievementSeries:    config.SteamMaxAchievementSeries,
+		MaxAchievementRefreshes: config.SteamMaxAchievementRefreshes,
+	}
+}
+
+// metricNamePattern matches valid label names and metric name prefixes
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// relabelRules builds the rewrite applied to every served and pushed series
+func relabelRules(config Config) relabel.Rules {
+	rules := relabel.Rules{
+		Namespaces:   config.MetricNamespaces,
+		DropLabels:   config.MetricDropLabels,
+		StaticLabels: config.MetricStaticLabels,
+	}
+	if config.SteamInfoMetrics {
+		rules.InfoLabels = steam.InfoLabels
+	}
+	return rules
+}
+
+// newOSRSCollector builds the OSRS collector
+func newOSRSCollector(config Config, cache *cache.Cache) *osrs.Collector {
+	collector := osrs.NewCollector(cache, config.UpstreamTransport)
+	collector.SetDayLocation(config.DayLocation)
+	collector.SetRateLimit(config.OSRSRateLimit)
+	if notifier := notify.NewNotifier(config.NotifyWebhookURL); notifier != nil {
+		collector.SetMilestoneHandler(func(ctx context.Context, milestone osrs.Milestone) {
+			notifier.Notify(notify.Event{
+				Kind:    "osrs_milestone",
+				Message: milestone.String(),
+				Labels: map[string]string{
+					"player":    milestone.Player,
+					"mode":      milestone.Mode,
+					"milestone": milestone.Kind,
+					"skill":     milestone.Skill,
+				},
+			})
+		})
+	}
+	return collector
+}
+
+// priceCollector builds the store price collector, nil when no apps are tracked
+func priceCollector(config Config, cache *cache.Cache) *steam.PriceCollector {
+	if len(config.SteamPriceAppIDs) == 0 {
+		return nil
+	}
+	return steam.NewPriceCollector(steam.PriceConfig{
+		AppIDs:    config.SteamPriceAppIDs,
+		Regions:   config.SteamPriceRegions,
+		Transport: config.UpstreamTransport,
+	}, cache)
+}
+
+// geCollector builds the Grand Exchange price collector, nil when no items are watched
+func geCollector(config Config, osrsCollector *osrs.Collector) *osrs.GECollector {
+	if len(config.OSRSGEItems) == 0 {
+		return nil
+	}
+	collector := osrs.NewGECollector(osrsCollector, config.OSRSGEItems)
+	if notifier := notify.NewNotifier(config.NotifyWebhookURL); notifier != nil {
+		collector.SetAlertHandler(func(ctx context.Context, price osrs.GEPrice, threshold int64) {
+			notifier.Notify(notify.Event{
+				Kind:    "osrs_ge_margin",
+				Message: fmt.Sprintf("%s margin is %d coins (buy %d, sell %d), above %d", price.Item.Name, price.Margin(), price.Low, price.High, threshold),
+				Labels: map[string]string{
+					"item_id": strconv.Itoa(price.Item.ID),
+					"item":    price.Item.Name,
+				},
+			})
+		})
+	}
+	return collector
+}
+
+// newCache creates the cache configured by CACHE_BACKEND and the REDIS_* settings
+func newCache(config Config) (*cache.Cache, error) {
+	return cache.New(cache.Options{
+		Backend:       config.CacheBackend,
+		FilePath:      config.CacheFilePath,
+		Addr:          config.RedisAddr,
+		Username:      config.RedisUsername,
+		Password:      config.RedisPassword,
+		DB:            config.RedisDB,
+		TLS:           config.RedisTLS,
+		TLSSkipVerify: config.RedisTLSSkipVerify,
+		TLSCAFile:     config.RedisTLSCAFile,
+		OpTimeout:     config.RedisOpTimeout,
+		Compress:      config.RedisCompress,
+	})
+}
+
+// loadConfig parses the configuration, exiting when it is invalid
 func loadConfig() Config {
+	config, problems := parseConfig()
+	problems.report(config.ConfigStrict)
+	return config
+}
+
+// parseConfig parses the configuration from flags and environment variables. Settings that
+// can't be used are returned as problems; malformed numbers and durations fall back to their
+// default.
+func parseConfig() (Config, *configProblems) {
 	config := Config{}
+	problems := &configProblems{}
+
+	// Steam API keys: one, or several separated by commas (or newlines, for STEAM_KEY_FILE)
+	config.SteamKeys = strings.FieldsFunc(configValue("STEAM_KEY"), func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' ' || r == '\r'
+	})
+	config.SteamKeyRotation = getEnv("STEAM_KEY_ROTATION", steam.RotationRoundRobin)
+	if config.SteamKeyRotation != steam.RotationRoundRobin && config.SteamKeyRotation != steam.RotationFailover {
+		problems.invalidf("STEAM_KEY_ROTATION=%q: expected round-robin or failover", config.SteamKeyRotation)
+	}
+
+	// Backoff after a 403/429 from Steam: initial, multiplied for each consecutive one, up to max
+	config.SteamBackoff = steam.DefaultBackoffPolicy
+	backoffInitialStr := getEnv("STEAM_BACKOFF_INITIAL", "1h")
+	if initial, err := time.ParseDuration(backoffInitialStr); err == nil && initial > 0 {
+		config.SteamBackoff.Initial = initial
+	} else {
+		problems.defaultedValue("STEAM_BACKOFF_INITIAL", backoffInitialStr, "a positive duration")
+	}
+	backoffMultiplierStr := getEnv("STEAM_BACKOFF_MULTIPLIER", "2")
+	if multiplier, err := strconv.ParseFloat(backoffMultiplierStr, 64); err == nil && multiplier >= 1 {
+		config.SteamBackoff.Multiplier = multiplier
+	} else {
+		problems.defaultedValue("STEAM_BACKOFF_MULTIPLIER", backoffMultiplierStr, "a number of at least 1")
+	}
+	backoffMaxStr := getEnv("STEAM_BACKOFF_MAX", "24h")
+	if max, err := time.ParseDuration(backoffMaxStr); err == nil && max > 0 {
+		config.SteamBackoff.Max = max
+	} else {
+		problems.defaultedValue("STEAM_BACKOFF_MAX", backoffMaxStr, "a positive duration")
+	}
+
+	// Enrich owned games with store metadata (steam_game_info)
+	config.SteamGameInfo = getEnvBool(problems, "STEAM_GAME_INFO", false)
+	// ... with ProtonDB tiers and Steam Deck status, for Linux and Deck users
+	config.SteamGameCompat = getEnvBool(problems, "STEAM_GAME_COMPAT", false)
+
+	// Friend counts and presence (steam_friends*), one or more extra requests per collection
+	config.SteamFriends = getEnvBool(problems, "STEAM_FRIENDS", false)
+
+	// Subscribers and favorites of each user's Workshop items (steam_workshop_*)
+	config.SteamWorkshop = getEnvBool(problems, "STEAM_WORKSHOP", false)
+	// Screenshot, review and guide counts from each user's community profile page
+	config.SteamProfileContent = getEnvBool(problems, "STEAM_PROFILE_CONTENT", false)
+
+	// Usernames and game names only on info metrics, so renames don't churn every series
+	config.SteamInfoMetrics = getEnvBool(problems, "STEAM_INFO_METRICS", false)
+
+	// Cardinality budget of the per-achievement series; series beyond it are dropped and
+	// counted in exporter_series_dropped_total
+	perGameStr := getEnv("STEAM_MAX_ACHIEVEMENTS_PER_GAME", "0")
+	if limit, err := strconv.Atoi(perGameStr); err == nil && limit >= 0 {
+		config.SteamMaxAchievementsPerGame = limit
+	} else {
+		problems.defaultedValue("STEAM_MAX_ACHIEVEMENTS_PER_GAME", perGameStr, "a non-negative integer")
+	}
+	config.SteamMaxAchievementSeries = 100000 // Default
+	seriesStr := getEnv("STEAM_MAX_ACHIEVEMENT_SERIES", "100000")
+	if limit, err := strconv.Atoi(seriesStr); err == nil && limit >= 0 {
+		config.SteamMaxAchievementSeries = limit
+	} else {
+		problems.defaultedValue("STEAM_MAX_ACHIEVEMENT_SERIES", seriesStr, "a non-negative integer")
+	}
+
+	// Spread the achievement refreshes of large libraries over several collections
+	refreshesStr := getEnv("STEAM_MAX_ACHIEVEMENT_REFRESHES", "0")
+	if limit, err := strconv.Atoi(refreshesStr); err == nil && limit >= 0 {
+		config.SteamMaxAchievementRefreshes = limit
+	} else {
+		problems.defaultedValue("STEAM_MAX_ACHIEVEMENT_REFRESHES", refreshesStr, "a non-negative integer")
+	}
+
+	// Store prices tracked for sale alerts, per region (store country code)
+	for _, appIdStr := range getEnvList("STEAM_PRICE_APP_IDS") {
+		appId, err := strconv.ParseUint(appIdStr, 10, 64)
+		if err != nil {
+			problems.invalidf("STEAM_PRICE_APP_IDS: app ID %q is not numeric", appIdStr)
+			continue
+		}
+		config.SteamPriceAppIDs = append(config.SteamPriceAppIDs, appId)
+	}
+	config.SteamPriceRegions = getEnvList("STEAM_PRICE_REGIONS")
+
+	// Friends of a Steam account registered for polling, synced periodically
+	config.SteamDiscoverFriendsOf = getEnv("STEAM_DISCOVER_FRIENDS_OF", "")
+	config.SteamDiscoverFilter = steam.FriendFilter{
+		Allow: getEnvList("STEAM_DISCOVER_ALLOW"),
+		Deny:  getEnvList("STEAM_DISCOVER_DENY"),
+	}
+	steamDiscoverStr := getEnv("STEAM_DISCOVER_INTERVAL", "1h")
+	if interval, err := time.ParseDuration(steamDiscoverStr); err == nil && interval > 0 {
+		config.SteamDiscoverInterval = interval
+	} else {
+		config.SteamDiscoverInterval = time.Hour // Default
+		problems.defaultedValue("STEAM_DISCOVER_INTERVAL", steamDiscoverStr, "a positive duration")
+	}
+
+	// Grand Exchange items to track, by name or ID, each optionally =<margin> to alert above
+	if watches, err := osrs.ParseGEWatches(getEnvList("OSRS_GE_ITEMS")); err == nil {
+		config.OSRSGEItems = watches
+	} else {
+		problems.invalidf("OSRS_GE_ITEMS: %v", err)
+	}
+
+	// Wise Old Man group whose members are registered for polling, synced periodically
+	if groupStr := getEnv("OSRS_WOM_GROUP_ID", ""); groupStr != "" {
+		groupID, err := strconv.Atoi(groupStr)
+		if err != nil || groupID <= 0 {
+			problems.invalidf("OSRS_WOM_GROUP_ID=%q: group IDs must be positive numbers", groupStr)
+		}
+		config.OSRSWOMGroupID = groupID
+	}
+	womSyncStr := getEnv("OSRS_WOM_SYNC_INTERVAL", "1h")
+	if interval, err := time.ParseDuration(womSyncStr); err == nil && interval > 0 {
+		config.OSRSWOMSyncInterval = interval
+	} else {
+		config.OSRSWOMSyncInterval = time.Hour // Default
+		problems.defaultedValue("OSRS_WOM_SYNC_INTERVAL", womSyncStr, "a positive duration")
+	}
+
+	// Requests per second to the OSRS endpoints, shared by replicas using the same Redis
+	osrsRateStr := getEnv("OSRS_RATE_LIMIT", "5")
+	if rate, err := strconv.ParseFloat(osrsRateStr, 64); err == nil && rate >= 0 {
+		config.OSRSRateLimit = rate
+	} else {
+		config.OSRSRateLimit = 5 // Default
+		problems.defaultedValue("OSRS_RATE_LIMIT", osrsRateStr, "a non-negative number of requests per second")
+	}
+
+	// Epic Games accounts, as account_id=access_token pairs; Epic only serves an account's
+	// playtime to its own token
+	for _, pair := range getEnvList("EPIC_ACCOUNTS") {
+		accountId, token, ok := strings.Cut(pair, "=")
+		accountId, token = strings.TrimSpace(accountId), strings.TrimSpace(token)
+		if !ok || accountId == "" || token == "" {
+			problems.invalidf("EPIC_ACCOUNTS: expected account_id=access_token pairs")
+			continue
+		}
+		if config.EpicTokens == nil {
+			config.EpicTokens = make(map[string]string)
+		}
+		config.EpicTokens[accountId] = token
+	}
+
+	// Session token of the Nintendo Switch Parental Controls app, exchanged for access tokens
+	config.NintendoSessionToken = configValue("NINTENDO_SESSION_TOKEN")
+
+	// Blizzard API client (https://develop.battle.net) for the Diablo III and StarCraft II profile APIs
+	config.BattleNetClientID = configValue("BNET_CLIENT_ID")
+	config.BattleNetClientSecret = configValue("BNET_CLIENT_SECRET")
+	config.BattleNetRegion = strings.ToLower(getEnv("BNET_REGION", "us"))
+	if config.BattleNetClientID != "" && config.BattleNetClientSecret == "" {
+		problems.invalidf("BNET_CLIENT_SECRET is required with BNET_CLIENT_ID")
+	}
+	switch config.BattleNetRegion {
+	case "us", "eu", "kr", "tw":
+	default:
+		problems.invalidf("BNET_REGION=%q: expected us, eu, kr or tw", config.BattleNetRegion)
+	}
+
+	// Supercell API tokens, one per game; each is bound to the IPs given when creating it
+	config.ClashOfClansToken = configValue("CLASH_OF_CLANS_TOKEN")
+	config.ClashRoyaleToken = configValue("CLASH_ROYALE_TOKEN")
+
+	// BattleMetrics servers to report, by the ID in their battlemetrics.com URL; the API
+	// token is optional and only raises the rate limit
+	config.BattleMetricsServers = getEnvList("BATTLEMETRICS_SERVERS")
+	config.BattleMetricsToken = configValue("BATTLEMETRICS_TOKEN")
+
+	// Dedicated servers queried over RCON, as name=game://:password@host:port pairs
+	for _, pair := range getEnvList("RCON_SERVERS") {
+		name, raw, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			problems.invalidf("RCON_SERVERS: expected name=game://:password@host:port pairs")
+			continue
+		}
+		server, err := rcon.ParseServer(name, strings.TrimSpace(raw))
+		if err != nil {
+			problems.invalidf("RCON_SERVERS: server %s: %v", name, err)
+			continue
+		}
+		config.RCONServers = append(config.RCONServers, server)
+	}
+
+	// Discord bot token; the bot needs the presence intent and membership of the users' server
+	config.DiscordBotToken = configValue("DISCORD_BOT_TOKEN")
 
-	// Steam API key
-	config.SteamKey = os.Getenv("STEAM_KEY")
+	// Webhook receiving one-shot events (OSRS milestones); Discord and Slack webhooks work as is
+	config.NotifyWebhookURL = configValue("NOTIFY_WEBHOOK_URL")
+
+	// Time zone whose midnight resets steam_playtime_today_seconds and osrs_xp_today
+	// (an IANA name such as Europe/London; the local time zone, TZ, by default)
+	if name := configValue("DAY_TIMEZONE"); name != "" {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			problems.invalidf("DAY_TIMEZONE=%q: %v", name, err)
+		}
+		config.DayLocation = location
+	}
+
+	// Connection pool shared by the upstream clients, sized for concurrent collections
+	transportConfig := upstreamTransportConfig{HTTP2: getEnvBool(problems, "UPSTREAM_HTTP2", true)}
+	idleConnsStr := getEnv("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", "16")
+	if idleConns, err := strconv.Atoi(idleConnsStr); err == nil && idleConns > 0 {
+		transportConfig.MaxIdleConnsPerHost = idleConns
+	} else {
+		transportConfig.MaxIdleConnsPerHost = 16 // Default
+		problems.defaultedValue("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", idleConnsStr, "a positive integer")
+	}
+	idleTimeoutStr := getEnv("UPSTREAM_IDLE_CONN_TIMEOUT", "90s")
+	if timeout, err := time.ParseDuration(idleTimeoutStr); err == nil && timeout > 0 {
+		transportConfig.IdleConnTimeout = timeout
+	} else {
+		transportConfig.IdleConnTimeout = 90 * time.Second // Default
+		problems.defaultedValue("UPSTREAM_IDLE_CONN_TIMEOUT", idleTimeoutStr, "a positive duration")
+	}
+
+	// DNS and IPv6 workarounds: UPSTREAM_FORCE_IPV4 is true (every upstream) or hostnames
+	if force, err := strconv.ParseBool(configValue("UPSTREAM_FORCE_IPV4")); err == nil {
+		transportConfig.ForceIPv4 = force
+	} else {
+		for _, host := range getEnvList("UPSTREAM_FORCE_IPV4") {
+			if transportConfig.IPv4Hosts == nil {
+				transportConfig.IPv4Hosts = make(map[string]bool)
+			}
+			transportConfig.IPv4Hosts[strings.ToLower(host)] = true
+		}
+	}
+	if server := configValue("UPSTREAM_DNS_SERVER"); server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			problems.invalidf("UPSTREAM_DNS_SERVER=%q: expected host or host:port", configValue("UPSTREAM_DNS_SERVER"))
+		} else {
+			transportConfig.DNSServer = server
+		}
+	}
+	for _, pair := range getEnvList("UPSTREAM_HOSTS") {
+		host, address, ok := strings.Cut(pair, "=")
+		host, address = strings.TrimSpace(host), strings.TrimSpace(address)
+		if !ok || host == "" || address == "" {
+			problems.invalidf("UPSTREAM_HOSTS: %q is not a hostname=address pair", pair)
+			continue
+		}
+		if transportConfig.HostOverrides == nil {
+			transportConfig.HostOverrides = make(map[string]string)
+		}
+		transportConfig.HostOverrides[strings.ToLower(host)] = address
+	}
+	config.UpstreamTransport = newUpstreamTransport(transportConfig)
+
+	// Record upstream responses to a directory and replay them, for offline development
+	if dir := configValue("UPSTREAM_FIXTURES"); dir != "" {
+		transport, err := fixtures.NewTransport(dir, getEnv("UPSTREAM_FIXTURES_MODE", fixtures.ModeAuto), config.UpstreamTransport)
+		if err != nil {
+			problems.invalidf("UPSTREAM_FIXTURES: %v", err)
+		} else {
+			config.UpstreamTransport = transport
+			logger.Log.WithField("dir", dir).Warn("Upstream fixtures enabled - responses are recorded and replayed from disk")
+		}
+	}
+
+	// Cache backend: "redis" (default) or "file" for an embedded BoltDB cache without Redis
+	config.CacheBackend = getEnv("CACHE_BACKEND", "redis")
+	config.CacheFilePath = getEnv("CACHE_FILE_PATH", "data/cache.db")
 
 	// Redis configuration
 	config.RedisAddr = getEnv("REDIS_ADDR", "localhost:6379")
-	config.RedisPassword = os.Getenv("REDIS_PASSWORD")
+	config.RedisUsername = configValue("REDIS_USERNAME")
+	config.RedisPassword = configValue("REDIS_PASSWORD")
 
-	redisDBStr := os.Getenv("REDIS_DB")
+	redisDBStr := configValue("REDIS_DB")
 	if redisDBStr != "" {
-		if db, err := strconv.Atoi(redisDBStr); err == nil {
+		if db, err := strconv.Atoi(redisDBStr); err == nil && db >= 0 {
 			config.RedisDB = db
+		} else {
+			problems.defaultedValue("REDIS_DB", redisDBStr, "a non-negative database number")
 		}
 	}
 
+	// Redis TLS (required by most managed Redis services)
+	config.RedisTLS = getEnvBool(problems, "REDIS_TLS", false)
+	config.RedisTLSSkipVerify = getEnvBool(problems, "REDIS_TLS_SKIP_VERIFY", false)
+	config.RedisTLSCAFile = configValue("REDIS_TLS_CA_FILE")
+
+	// Per-operation Redis timeout - a hung Redis degrades to cache misses instead of stalling scrapes
+	opTimeoutStr := getEnv("REDIS_OP_TIMEOUT", "500ms")
+	if timeout, err := time.ParseDuration(opTimeoutStr); err != nil {
+		config.RedisOpTimeout = 500 * time.Millisecond // Default
+		problems.defaultedValue("REDIS_OP_TIMEOUT", opTimeoutStr, "a duration")
+	} else if timeout <= 0 {
+		// A zero timeout would fail every cache operation, so don't start with one
+		config.RedisOpTimeout = 500 * time.Millisecond
+		problems.invalidf("REDIS_OP_TIMEOUT=%q: the timeout must be a positive duration", opTimeoutStr)
+	} else {
+		config.RedisOpTimeout = timeout
+	}
+
+	// Compress large cached values (owned games, achievements) to save Redis memory
+	config.RedisCompress = getEnvBool(problems, "REDIS_COMPRESS", true)
+
 	// Polling intervals
 	pollNormalStr := getEnv("POLL_INTERVAL_NORMAL", "15m")
 	if interval, err := time.ParseDuration(pollNormalStr); err == nil {
 		config.PollIntervalNormal = interval
 	} else {
 		config.PollIntervalNormal = 15 * time.Minute // Default
+		problems.defaultedValue("POLL_INTERVAL_NORMAL", pollNormalStr, "a duration")
 	}
 
 	pollActiveStr := getEnv("POLL_INTERVAL_ACTIVE", "5m")
@@ -145,23 +978,233 @@ func loadConfig() Config {
 		config.PollIntervalActive = interval
 	} else {
 		config.PollIntervalActive = 5 * time.Minute // Default
+		problems.defaultedValue("POLL_INTERVAL_ACTIVE", pollActiveStr, "a duration")
 	}
 
+	// Background polling worker pool size and targets
+	workersStr := getEnv("POLL_WORKERS", "4")
+	if workers, err := strconv.Atoi(workersStr); err == nil && workers > 0 {
+		config.PollWorkers = workers
+	} else {
+		config.PollWorkers = 4 // Default
+		problems.defaultedValue("POLL_WORKERS", workersStr, "a positive integer")
+	}
+	jitterStr := getEnv("POLL_JITTER", "0.1")
+	if jitter, err := strconv.ParseFloat(jitterStr, 64); err == nil && jitter >= 0 && jitter <= 1 {
+		config.PollJitter = jitter
+	} else {
+		config.PollJitter = 0.1 // Default
+		problems.defaultedValue("POLL_JITTER", jitterStr, "a fraction between 0 and 1")
+	}
+	maxBackoffStr := getEnv("POLL_MAX_BACKOFF", "6h")
+	if maxBackoff, err := time.ParseDuration(maxBackoffStr); err == nil {
+		config.PollMaxBackoff = maxBackoff
+	} else {
+		config.PollMaxBackoff = 6 * time.Hour // Default
+		problems.defaultedValue("POLL_MAX_BACKOFF", maxBackoffStr, "a duration")
+	}
+	config.PollPaused = getEnvBool(problems, "POLL_PAUSED", false)
+	// Coordinate polling across replicas sharing the same Redis
+	config.PollCoordination = getEnvBool(problems, "POLL_COORDINATION", false)
+	config.PollInstanceID = getEnv("POLL_INSTANCE_ID", defaultInstanceID())
+	// Cron schedules for collections that should run at fixed times
+	if schedules, err := polling.ParseSchedules(configValue("POLL_SCHEDULES")); err == nil {
+		config.PollSchedules = schedules
+	} else {
+		problems.invalidf("POLL_SCHEDULES: %v", err)
+	}
+	config.PollSteamIDs = getEnvList("POLL_STEAM_IDS")
+	config.PollOSRSPlayers = getEnvList("POLL_OSRS_PLAYERS")
+
+	// Optional authentication for the metrics and admin endpoints
+	config.AuthBearerToken = configValue("AUTH_BEARER_TOKEN")
+	config.AuthUsername = configValue("AUTH_USERNAME")
+	config.AuthPassword = configValue("AUTH_PASSWORD")
+	if (config.AuthUsername == "") != (config.AuthPassword == "") {
+		problems.invalidf("AUTH_USERNAME and AUTH_PASSWORD must be set together")
+	}
+
+	// Limits on on-demand collection so clients can't trigger upstream fetch storms
+	rateStr := getEnv("RATE_LIMIT_PER_IP", "0")
+	if rate, err := strconv.ParseFloat(rateStr, 64); err == nil && rate >= 0 {
+		config.RateLimitPerIP = rate
+	} else {
+		problems.defaultedValue("RATE_LIMIT_PER_IP", rateStr, "a non-negative number of requests per second")
+	}
+	burstStr := getEnv("RATE_LIMIT_BURST", "10")
+	if burst, err := strconv.Atoi(burstStr); err == nil && burst > 0 {
+		config.RateLimitBurst = burst
+	} else {
+		config.RateLimitBurst = 10 // Default
+		problems.defaultedValue("RATE_LIMIT_BURST", burstStr, "a positive integer")
+	}
+	maxConcurrentStr := getEnv("MAX_CONCURRENT_COLLECTIONS", "10")
+	if maxConcurrent, err := strconv.Atoi(maxConcurrentStr); err == nil && maxConcurrent >= 0 {
+		config.MaxConcurrentCollections = maxConcurrent
+	} else {
+		config.MaxConcurrentCollections = 10 // Default
+		problems.defaultedValue("MAX_CONCURRENT_COLLECTIONS", maxConcurrentStr, "a non-negative integer")
+	}
+
+	// Scrapes serve partial metrics rather than failing once this passes
+	scrapeTimeoutStr := getEnv("SCRAPE_TIMEOUT", "30s")
+	if timeout, err := time.ParseDuration(scrapeTimeoutStr); err == nil && timeout >= 0 {
+		config.ScrapeTimeout = timeout
+	} else {
+		config.ScrapeTimeout = 30 * time.Second // Default
+		problems.defaultedValue("SCRAPE_TIMEOUT", scrapeTimeoutStr, "a non-negative duration")
+	}
+
+	// Shutdown cancels in-flight collections and waits this long for them and the cache writes
+	shutdownTimeoutStr := getEnv("SHUTDOWN_TIMEOUT", "10s")
+	if timeout, err := time.ParseDuration(shutdownTimeoutStr); err == nil && timeout > 0 {
+		config.ShutdownTimeout = timeout
+	} else {
+		config.ShutdownTimeout = 10 * time.Second // Default
+		problems.defaultedValue("SHUTDOWN_TIMEOUT", shutdownTimeoutStr, "a positive duration")
+	}
+
+	// OpenTelemetry tracing; the OTLP endpoint comes from the standard OTEL_EXPORTER_OTLP_* variables
+	config.TracingEnabled = getEnvBool(problems, "TRACING_ENABLED", false)
+	config.TracingServiceName = getEnv("OTEL_SERVICE_NAME", "game-stats-exporter")
+	sampleRatioStr := getEnv("TRACING_SAMPLE_RATIO", "1")
+	if ratio, err := strconv.ParseFloat(sampleRatioStr, 64); err == nil && ratio >= 0 && ratio <= 1 {
+		config.TracingSampleRatio = ratio
+	} else {
+		config.TracingSampleRatio = 1 // Default
+		problems.defaultedValue("TRACING_SAMPLE_RATIO", sampleRatioStr, "a fraction between 0 and 1")
+	}
+
+	// Push mode for deployments Prometheus can't scrape; targets come from POLL_STEAM_IDS/POLL_OSRS_PLAYERS
+	config.PushRemoteWriteURL = configValue("PUSH_REMOTE_WRITE_URL")
+	config.PushRemoteWriteUsername = configValue("PUSH_REMOTE_WRITE_USERNAME")
+	config.PushRemoteWritePassword = configValue("PUSH_REMOTE_WRITE_PASSWORD")
+	config.PushRemoteWriteBearerToken = configValue("PUSH_REMOTE_WRITE_BEARER_TOKEN")
+	config.PushOTLP = getEnvBool(problems, "PUSH_OTLP", false)
+	config.PushgatewayURL = configValue("PUSHGATEWAY_URL")
+	config.PushgatewayUsername = configValue("PUSHGATEWAY_USERNAME")
+	config.PushgatewayPassword = configValue("PUSHGATEWAY_PASSWORD")
+	config.GraphiteAddr = configValue("GRAPHITE_ADDR")
+	config.GraphitePrefix = getEnv("GRAPHITE_PREFIX", "games")
+	config.PushJob = getEnv("PUSH_JOB", "game-stats-exporter")
+
+	// Rewrite the exported series to fit existing naming conventions
+	for _, namespace := range []string{"steam", "osrs"} {
+		replacement := configValue("METRIC_NAMESPACE_" + strings.ToUpper(namespace))
+		if replacement == "" || replacement == namespace {
+			continue
+		}
+		if !metricNamePattern.MatchString(replacement) {
+			problems.invalidf("METRIC_NAMESPACE_%s=%q: expected a metric name prefix such as games_%s", strings.ToUpper(namespace), replacement, namespace)
+			continue
+		}
+		if config.MetricNamespaces == nil {
+			config.MetricNamespaces = make(map[string]string)
+		}
+		config.MetricNamespaces[namespace] = replacement
+	}
+	config.MetricDropLabels = getEnvList("METRIC_DROP_LABELS")
+	for _, pair := range getEnvList("METRIC_STATIC_LABELS") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !metricNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			problems.invalidf("METRIC_STATIC_LABELS: %q is not a name=value pair", pair)
+			continue
+		}
+		if config.MetricStaticLabels == nil {
+			config.MetricStaticLabels = make(map[string]string)
+		}
+		config.MetricStaticLabels[name] = strings.TrimSpace(value)
+	}
+
+	// Cached data can be minutes old; optionally say so in the samples' timestamps
+	config.MetricTimestamps = getEnvBool(problems, "METRIC_TIMESTAMPS", false)
+
+	// History store: "sqlite" or "postgres"; empty disables history
+	config.HistoryDriver = configValue("HISTORY_DRIVER")
+	config.HistoryDSN = getEnv("HISTORY_DSN", "data/history.db")
+
+	// Check dependencies (cache, Steam API key, OSRS endpoints) before starting
+	config.StartupCheck = getEnvBool(problems, "STARTUP_CHECK", false)
+
+	// Optional YAML file with the settings that can be reloaded without a restart
+	config.ConfigFile = configValue("CONFIG_FILE")
+
 	// Port
 	portStr := getEnv("PORT", "8000")
-	if port, err := strconv.Atoi(portStr); err == nil {
+	if port, err := strconv.Atoi(portStr); err == nil && port > 0 && port <= 65535 {
 		config.Port = port
 	} else {
 		config.Port = 8000 // Default
+		problems.defaultedValue("PORT", portStr, "a port number")
 	}
 
-	return config
+	// The logger is configured at init (and by applyLogFlags) and falls back to the defaults
+	// for invalid values, so they're checked here to be reported like the other settings
+	if level := configValue("LOG_LEVEL"); level != "" {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			problems.defaultedValue("LOG_LEVEL", level, "panic, fatal, error, warn, info, debug or trace")
+		}
+	}
+	if format := configValue("LOG_FORMAT"); format != "" && !strings.EqualFold(format, "text") && !strings.EqualFold(format, "json") {
+		problems.defaultedValue("LOG_FORMAT", format, "text or json")
+	}
+	getEnvBool(problems, "LOG_CALLER", false)
+
+	// Reject the malformed values above instead of starting with their defaults. A malformed
+	// CONFIG_STRICT is rejected too, as falling back would quietly turn strict mode off.
+	strictStr := configValue("CONFIG_STRICT")
+	if strict, err := strconv.ParseBool(strictStr); err == nil {
+		config.ConfigStrict = strict
+	} else if strictStr != "" {
+		problems.invalidf("CONFIG_STRICT=%q: expected true or false", strictStr)
+	}
+
+	return config, problems
 }
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := configValue(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
+
+// defaultInstanceID identifies this replica for polling leases: hostname plus pid,
+// which is unique per container/pod
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// getEnvList parses a comma-separated environment variable, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(configValue(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvBool parses a boolean setting, recording a malformed one in problems (when not nil)
+// before falling back to the default
+func getEnvBool(problems *configProblems, key string, defaultValue bool) bool {
+	value := configValue(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		if problems != nil {
+			problems.defaultedValue(key, value, "true or false")
+		}
+		return defaultValue
+	}
+	return b
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/api"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/discord"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/family"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/polling"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v2"
+)
+
+// FileConfig is the optional YAML file named by CONFIG_FILE. It holds the settings that can
+// change without a restart; unset fields fall back to the environment (or the defaults).
+type FileConfig struct {
+	LogLevel string `yaml:"log_level"`
+	Poll     struct {
+		IntervalNormal time.Duration `yaml:"interval_normal"`
+		IntervalActive time.Duration `yaml:"interval_active"`
+		SteamIDs       []string      `yaml:"steam_ids"`
+		OSRSPlayers    []string      `yaml:"osrs_players"`
+	} `yaml:"poll"`
+	Cache struct {
+		SteamOwnedGamesTTL time.Duration `yaml:"steam_owned_games_ttl"`
+		OSRSPlayerStatsTTL time.Duration `yaml:"osrs_player_stats_ttl"`
+		OSRSWorldDataTTL   time.Duration `yaml:"osrs_world_data_ttl"`
+	} `yaml:"cache"`
+	// Families group accounts whose playtime and XP are summed, served at /metrics/family/{name}
+	Families map[string]struct {
+		SteamIDs    []string `yaml:"steam_ids"`
+		OSRSPlayers []string `yaml:"osrs_players"`
+	} `yaml:"families"`
+	// Users map a person to their accounts, served with a user label at /metrics/user/{name}
+	Users map[string]struct {
+		SteamID     string   `yaml:"steam_id"`
+		OSRSPlayers []string `yaml:"osrs_players"`
+		DiscordID   string   `yaml:"discord_id"` // Watched for "now playing" when DISCORD_BOT_TOKEN is set
+	} `yaml:"users"`
+	// Tenants share the exporter with their own bearer token, reaching only their targets'
+	// endpoints and served only their targets' series, with their prefix on every name
+	Tenants map[string]struct {
+		Token       string   `yaml:"token"`
+		Prefix      string   `yaml:"prefix"`
+		SteamIDs    []string `yaml:"steam_ids"`
+		OSRSPlayers []string `yaml:"osrs_players"`
+	} `yaml:"tenants"`
+	// OSRSTournamentModes add hiscores modes, by name, given as the table suffix after
+	// hiscore_oldschool_ (e.g. tournament for gridmaster's hiscore_oldschool_tournament)
+	OSRSTournamentModes map[string]string `yaml:"osrs_tournament_modes"`
+	// OSRSSkills replace the hiscores skill names, in hiscores order, for a skill added since
+	// the release (unset keeps the built-in list)
+	OSRSSkills []string `yaml:"osrs_skills"`
+	// OSRSAliases map an RSN to the player label it's reported with (RSNs are otherwise
+	// labelled lowercase, with underscores and hyphens as spaces)
+	OSRSAliases map[string]string `yaml:"osrs_aliases"`
+	// StatsApps are the Steam apps whose game-defined stats are exported as steam_game_stat
+	StatsApps []uint64 `yaml:"stats_apps"`
+}
+
+// loadConfigFile reads the config file; unknown keys are rejected so typos don't go unnoticed
+func loadConfigFile(path string) (FileConfig, error) {
+	var fileConfig FileConfig
+	if path == "" {
+		return fileConfig, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileConfig, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, &fileConfig); err != nil {
+		return fileConfig, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return fileConfig, nil
+}
+
+// configReloader applies the config file on startup, SIGHUP and POST /admin/reload.
+// Polled targets are the union of POLL_STEAM_IDS/POLL_OSRS_PLAYERS, the file's lists and the
+// discovered targets (Steam friends, OSRS_WOM_GROUP_ID's members); targets no longer listed stop
+// being polled.
+type configReloader struct {
+	path     string
+	config   Config // From the environment
+	logLevel logrus.Level
+
+	polling  *polling.Manager
+	steam    *steam.Collector // nil when Steam isn't configured
+	osrs     *osrs.Collector
+	families *family.Aggregator
+	users    *userDirectory
+	tenants  *tenantDirectory
+	discord  *discord.Watcher // nil when DISCORD_BOT_TOKEN isn't set
+
+	mu          sync.Mutex
+	steamIDs    map[string]bool // Currently registered
+	osrsPlayers map[string]bool
+
+	// The file's lists as last applied and the discovered targets, so either can change alone
+	fileSteamIDs          []string
+	fileOSRSPlayers       []string
+	discoveredSteamIDs    []string
+	discoveredOSRSPlayers []string
+}
+
+func newConfigReloader(config Config, pollingManager *polling.Manager, steamCollector *steam.Collector, osrsCollector *osrs.Collector, families *family.Aggregator, users *userDirectory, tenants *tenantDirectory, discordWatcher *discord.Watcher) *configReloader {
+	return &configReloader{
+		path:        config.ConfigFile,
+		config:      config,
+		logLevel:    logger.Log.GetLevel(),
+		polling:     pollingManager,
+		steam:       steamCollector,
+		osrs:        osrsCollector,
+		families:    families,
+		users:       users,
+		tenants:     tenants,
+		discord:     discordWatcher,
+		steamIDs:    make(map[string]bool),
+		osrsPlayers: make(map[string]bool),
+	}
+}
+
+// Reload re-reads the config file and applies it. An invalid file changes nothing.
+func (r *configReloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fileConfig, err := loadConfigFile(r.path)
+	if err != nil {
+		return err
+	}
+
+	logLevel := r.logLevel
+	if fileConfig.LogLevel != "" {
+		if logLevel, err = logrus.ParseLevel(fileConfig.LogLevel); err != nil {
+			return fmt.Errorf("invalid log_level %q: %w", fileConfig.LogLevel, err)
+		}
+	}
+	if err := osrs.CheckSkills(fileConfig.OSRSSkills); err != nil {
+		return err
+	}
+	tenants, err := fileTenants(fileConfig)
+	if err != nil {
+		return err
+	}
+	if err := r.osrs.SetTournamentModes(fileConfig.OSRSTournamentModes); err != nil {
+		return err
+	}
+	osrs.SetSkills(fileConfig.OSRSSkills)
+	logger.Log.SetLevel(logLevel)
+
+	normal := r.config.PollIntervalNormal
+	if fileConfig.Poll.IntervalNormal > 0 {
+		normal = fileConfig.Poll.IntervalNormal
+	}
+	active := r.config.PollIntervalActive
+	if fileConfig.Poll.IntervalActive > 0 {
+		active = fileConfig.Poll.IntervalActive
+	}
+	r.polling.SetIntervals(normal, active)
+
+	if r.steam != nil {
+		r.steam.SetOwnedGamesTTL(fileConfig.Cache.SteamOwnedGamesTTL)
+		r.steam.SetStatsApps(fileConfig.StatsApps)
+	}
+	r.osrs.SetTTLs(fileConfig.Cache.OSRSPlayerStatsTTL, fileConfig.Cache.OSRSWorldDataTTL)
+	r.osrs.SetAliases(fileConfig.OSRSAliases)
+
+	families := make([]family.Family, 0, len(fileConfig.Families))
+	for name, members := range fileConfig.Families {
+		families = append(families, family.Family{Name: name, SteamIDs: members.SteamIDs, OSRSPlayers: members.OSRSPlayers})
+	}
+	r.families.SetFamilies(families)
+
+	users := make(map[string]api.UserAccounts, len(fileConfig.Users))
+	discordUsers := make(map[string]string)
+	for name, accounts := range fileConfig.Users {
+		users[name] = api.UserAccounts{SteamID: accounts.SteamID, OSRSPlayers: accounts.OSRSPlayers}
+		if accounts.DiscordID != "" {
+			discordUsers[accounts.DiscordID] = name
+		}
+	}
+	r.users.set(users)
+	if r.discord != nil {
+		r.discord.SetUsers(discordUsers)
+	}
+
+	r.tenants.set(tenants)
+
+	// Tenants' targets are polled, so their scrapes are served from a warm cache
+	r.fileSteamIDs, r.fileOSRSPlayers = slices.Clone(fileConfig.Poll.SteamIDs), slices.Clone(fileConfig.Poll.OSRSPlayers)
+	for _, tenant := range fileConfig.Tenants {
+		r.fileSteamIDs = append(r.fileSteamIDs, tenant.SteamIDs...)
+		r.fileOSRSPlayers = append(r.fileOSRSPlayers, tenant.OSRSPlayers...)
+	}
+	steamAdded, steamRemoved := r.syncSteamTargets()
+	osrsAdded, osrsRemoved := r.syncOSRSTargets()
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"config_file":          r.path,
+		"log_level":            logLevel.String(),
+		"poll_interval":        normal,
+		"poll_interval_active": active,
+		"targets_added":        steamAdded + osrsAdded,
+		"targets_removed":      steamRemoved + osrsRemoved,
+		"families":             len(families),
+		"users":                len(users),
+		"discord_users":        len(discordUsers),
+		"tenants":              len(tenants),
+		"stats_apps":           len(fileConfig.StatsApps),
+		"tournament_modes":     len(fileConfig.OSRSTournamentModes),
+	}).Info("Configuration applied")
+	return nil
+}
+
+// SetDiscoveredSteamIDs and SetDiscoveredOSRSPlayers replace the discovered targets, polled
+// along with the configured ones; targets no longer discovered or configured stop being polled
+func (r *configReloader) SetDiscoveredSteamIDs(ctx context.Context, steamIDs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.discoveredSteamIDs = steamIDs
+	added, removed := r.syncSteamTargets()
+	logDiscoveredTargets(ctx, "steam", len(steamIDs), added, removed)
+}
+
+func (r *configReloader) SetDiscoveredOSRSPlayers(ctx context.Context, rsns []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.discoveredOSRSPlayers = rsns
+	added, removed := r.syncOSRSTargets()
+	logDiscoveredTargets(ctx, "osrs", len(rsns), added, removed)
+}
+
+func logDiscoveredTargets(ctx context.Context, kind string, discovered int, added int, removed int) {
+	if added == 0 && removed == 0 {
+		return
+	}
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"kind":            kind,
+		"discovered":      discovered,
+		"targets_added":   added,
+		"targets_removed": removed,
+	}).Info("Discovered targets synced")
+}
+
+// syncSteamTargets and syncOSRSTargets register and unregister targets to match the lists;
+// r.mu must be held
+func (r *configReloader) syncSteamTargets() (int, int) {
+	return syncTargets(r.steamIDs, r.polling.RegisterSteamUser, r.polling.UnregisterSteamUser,
+		r.config.PollSteamIDs, r.fileSteamIDs, r.discoveredSteamIDs)
+}
+
+func (r *configReloader) syncOSRSTargets() (int, int) {
+	return syncTargets(r.osrsPlayers, r.polling.RegisterOSRSPlayer, r.polling.UnregisterOSRSPlayer,
+		r.config.PollOSRSPlayers, r.fileOSRSPlayers, r.discoveredOSRSPlayers)
+}
+
+// syncTargets registers the targets in the lists that aren't registered yet, and unregisters
+// the ones no longer listed
+func syncTargets(registered map[string]bool, register func(string), unregister func(string), lists ...[]string) (int, int) {
+	wanted := make(map[string]bool)
+	for _, list := range lists {
+		for _, id := range list {
+			wanted[id] = true
+		}
+	}
+
+	added, removed := 0, 0
+	for id := range wanted {
+		if !registered[id] {
+			register(id)
+			registered[id] = true
+			added++
+		}
+	}
+	for id := range registered {
+		if !wanted[id] {
+			unregister(id)
+			delete(registered, id)
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// userDirectory serves the config file's users to /metrics/user/{name}
+type userDirectory struct {
+	mu    sync.RWMutex
+	users map[string]api.UserAccounts
+}
+
+func newUserDirectory() *userDirectory {
+	return &userDirectory{users: make(map[string]api.UserAccounts)}
+}
+
+func (d *userDirectory) User(name string) (api.UserAccounts, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	accounts, ok := d.users[name]
+	return accounts, ok
+}
+
+func (d *userDirectory) set(users map[string]api.UserAccounts) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.users = users
+}
+
+// fileTenants returns the config file's tenants, checking that each has its own token and a
+// prefix usable in metric names
+func fileTenants(fileConfig FileConfig) ([]api.Tenant, error) {
+	tenants := make([]api.Tenant, 0, len(fileConfig.Tenants))
+	tokens := make(map[string]string, len(fileConfig.Tenants))
+	for name, tenant := range fileConfig.Tenants {
+		if tenant.Token == "" {
+			return nil, fmt.Errorf("tenant %q: token is required", name)
+		}
+		if other, ok := tokens[tenant.Token]; ok {
+			return nil, fmt.Errorf("tenants %q and %q have the same token", other, name)
+		}
+		tokens[tenant.Token] = name
+		if tenant.Prefix != "" && (!metricNamePattern.MatchString(tenant.Prefix) || !strings.HasSuffix(tenant.Prefix, "_")) {
+			return nil, fmt.Errorf("tenant %q: invalid prefix %q, expected letters, digits and underscores ending in _", name, tenant.Prefix)
+		}
+
+		players := make([]string, 0, len(tenant.OSRSPlayers))
+		for _, rsn := range tenant.OSRSPlayers {
+			players = append(players, osrs.NormalizeRSN(rsn))
+		}
+		tenants = append(tenants, api.Tenant{
+			Name:        name,
+			Token:       tenant.Token,
+			Prefix:      tenant.Prefix,
+			SteamIDs:    tenant.SteamIDs,
+			OSRSPlayers: players,
+		})
+	}
+	return tenants, nil
+}
+
+// tenantDirectory serves the config file's tenants to the auth middleware
+type tenantDirectory struct {
+	mu      sync.RWMutex
+	tenants []api.Tenant
+}
+
+func newTenantDirectory() *tenantDirectory {
+	return &tenantDirectory{}
+}
+
+func (d *tenantDirectory) Tenants() []api.Tenant {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.tenants
+}
+
+func (d *tenantDirectory) set(tenants []api.Tenant) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tenants = tenants
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+)
+
+// secretsFile holds the settings of SECRETS_FILE, read on first use
+var secretsFile struct {
+	once   sync.Once
+	values map[string]string
+}
+
+// secretsFileValue returns a setting from SECRETS_FILE, a file of KEY=value lines such as one
+// rendered by Vault Agent or decrypted by `sops -d`
+func secretsFileValue(key string) (string, bool) {
+	secretsFile.once.Do(func() {
+		path, ok := flagValues["SECRETS_FILE"]
+		if !ok {
+			path = os.Getenv("SECRETS_FILE")
+		}
+		if path == "" {
+			return
+		}
+		values, err := readSecretsFile(path)
+		if err != nil {
+			// Starting without the secrets would fail later in less obvious ways
+			logger.Log.WithError(err).Fatal("Invalid configuration")
+		}
+		secretsFile.values = values
+	})
+	value, ok := secretsFile.values[key]
+	return value, ok
+}
+
+// readSecretsFile parses a dotenv-style file: KEY=value lines, optionally prefixed by export and
+// with the value in matching quotes; blank lines and # comments are skipped. Unknown keys are
+// rejected so typos don't go unnoticed.
+func readSecretsFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SECRETS_FILE: %w", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("SECRETS_FILE %s line %d: expected KEY=value", path, line)
+		}
+		if key == "SECRETS_FILE" || !slices.Contains(configVars, key) {
+			return nil, fmt.Errorf("SECRETS_FILE %s line %d: unknown setting %s", path, line, key)
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read SECRETS_FILE: %w", err)
+	}
+	return values, nil
+}
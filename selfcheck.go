@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/cache"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/check"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/osrs"
+	"github.com/joshhsoj1902/game-stats-exporter/internal/steam"
+)
+
+// checkTimeout bounds each dependency check
+const checkTimeout = 15 * time.Second
+
+// newChecker builds the dependency checks for the configured deployment
+// (the Steam check only runs when STEAM_KEY is set)
+func newChecker(cacheStore *cache.Cache, steamCollector *steam.Collector, osrsCollector *osrs.Collector) *check.Checker {
+	checks := []check.Check{
+		{Name: "cache", Run: cacheStore.Ping},
+	}
+	if steamCollector != nil {
+		checks = append(checks, check.Check{Name: "steam_api_key", Run: steamCollector.CheckAPIKey})
+	}
+	checks = append(checks,
+		check.Check{Name: "osrs_hiscores", Run: osrsCollector.CheckHiscores},
+		check.Check{Name: "osrs_world_list", Run: osrsCollector.CheckWorldList},
+	)
+	return check.NewChecker(checkTimeout, checks...)
+}
+
+// runCheck implements the `check` subcommand: validate every dependency, print pass/fail
+// per dependency and return the process exit code (1 if any check failed)
+func runCheck(args []string) int {
+	// Keep stdout for the results
+	logger.Log.SetOutput(os.Stderr)
+	parseConfigFlags("check", args)
+
+	config := loadConfig()
+
+	cacheStore, err := newCache(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL  cache: %v\n", err)
+		return 1
+	}
+	defer cacheStore.Close()
+
+	var steamCollector *steam.Collector
+	if len(config.SteamKeys) > 0 {
+		steamCollector = steam.NewCollector(steamConfig(config), cacheStore)
+	} else {
+		fmt.Println("SKIP  steam_api_key: STEAM_KEY not set")
+	}
+	osrsCollector := newOSRSCollector(config, cacheStore)
+
+	results := newChecker(cacheStore, steamCollector, osrsCollector).Run(context.Background())
+	printCheckResults(results)
+
+	if !check.Passed(results) {
+		return 1
+	}
+	return 0
+}
+
+func printCheckResults(results []check.Result) {
+	for _, result := range results {
+		if result.OK {
+			fmt.Printf("PASS  %s (%s)\n", result.Name, result.Duration)
+			continue
+		}
+		fmt.Printf("FAIL  %s (%s): %s\n", result.Name, result.Duration, result.Error)
+	}
+}
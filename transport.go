@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// upstreamTransportConfig tunes the connection pool shared by the upstream API clients
+type upstreamTransportConfig struct {
+	MaxIdleConnsPerHost int           // Idle connections kept per upstream host
+	IdleConnTimeout     time.Duration // How long an idle connection is kept
+	HTTP2               bool          // Negotiate HTTP/2 with upstreams that offer it
+
+	// For home networks with broken IPv6 or DNS
+	ForceIPv4     bool              // Dial every upstream over IPv4
+	IPv4Hosts     map[string]bool   // Hostnames dialled over IPv4 only, when not ForceIPv4
+	DNSServer     string            // host:port of the DNS server resolving upstream hostnames; the system resolver when empty
+	HostOverrides map[string]string // Address (IP or hostname) dialled instead of an upstream hostname
+}
+
+// newUpstreamTransport builds the transport of every upstream client. http.DefaultTransport
+// keeps two idle connections per host, so polling workers and scrapes collecting concurrently
+// from the Steam API or the hiscores keep closing connections and dialling (and TLS
+// handshaking) new ones.
+func newUpstreamTransport(config upstreamTransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if config.DNSServer != "" {
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, config.DNSServer)
+			},
+		}
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(config.HTTP2)
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           config.dial(dialer),
+		Protocols:             protocols,
+		MaxIdleConns:          0, // Bounded per host instead
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// dial applies the host overrides and IPv4 restrictions before dialling. TLS still verifies the
+// certificate of the requested hostname, so an override must serve the same certificate.
+func (config upstreamTransportConfig) dial(dialer *net.Dialer) func(ctx context.Context, network string, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		host = strings.ToLower(host)
+		if network == "tcp" && (config.ForceIPv4 || config.IPv4Hosts[host]) {
+			network = "tcp4"
+		}
+		if override, ok := config.HostOverrides[host]; ok {
+			addr = net.JoinHostPort(override, port)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
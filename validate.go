@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+)
+
+// configProblems collects the invalid settings found while parsing the configuration, so they're
+// reported together instead of one per restart
+type configProblems struct {
+	invalid   []string // Settings the exporter can't run with
+	defaulted []string // Malformed settings replaced by their default, rejected in strict mode
+}
+
+// invalidf records a setting the exporter can't run with
+func (p *configProblems) invalidf(format string, args ...any) {
+	p.invalid = append(p.invalid, fmt.Sprintf(format, args...))
+}
+
+// defaultedValue records a malformed setting that was replaced by its default
+func (p *configProblems) defaultedValue(key string, value string, expected string) {
+	p.defaulted = append(p.defaulted, fmt.Sprintf("%s=%q: expected %s", key, value, expected))
+}
+
+// report logs the problems and exits when the exporter can't run: on any invalid setting, or
+// on a defaulted one in strict mode
+func (p *configProblems) report(strict bool) {
+	fatal := len(p.invalid) > 0 || (strict && len(p.defaulted) > 0)
+	for _, problem := range p.invalid {
+		logger.Log.WithField("problem", problem).Error("Invalid configuration")
+	}
+	for _, problem := range p.defaulted {
+		entry := logger.Log.WithField("problem", problem)
+		if strict {
+			entry.Error("Invalid configuration")
+		} else {
+			entry.Warn("Invalid configuration value, using the default (CONFIG_STRICT=true rejects it)")
+		}
+	}
+	if fatal {
+		logger.Log.WithField("invalid", len(p.invalid)+len(p.defaulted)).Fatal("Refusing to start with an invalid configuration")
+	}
+}
+
+// runValidateConfig implements --validate-config: print every invalid setting of the
+// environment, flags and CONFIG_FILE, as strict mode would reject them, and return the process
+// exit code (1 if any), e.g. to check deployment manifests in CI
+func runValidateConfig() int {
+	// Keep stdout for the results
+	logger.Log.SetOutput(os.Stderr)
+
+	config, problems := parseConfig()
+	if fileConfig, err := loadConfigFile(config.ConfigFile); err != nil {
+		problems.invalidf("CONFIG_FILE=%q: %v", config.ConfigFile, err)
+	} else if _, err := fileTenants(fileConfig); err != nil {
+		problems.invalidf("CONFIG_FILE=%q: %v", config.ConfigFile, err)
+	}
+
+	for _, problem := range append(problems.invalid, problems.defaulted...) {
+		fmt.Printf("INVALID  %s\n", problem)
+	}
+	if len(problems.invalid) > 0 || len(problems.defaulted) > 0 {
+		return 1
+	}
+	fmt.Println("VALID  configuration")
+	return 0
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/joshhsoj1902/game-stats-exporter/internal/logger"
+)
+
+func TestParseConfigProblems(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		invalid   []string
+		defaulted []string
+	}{
+		{name: "defaults"},
+		{name: "valid values", env: map[string]string{"REDIS_TLS": "true", "TRACING_ENABLED": "1", "LOG_LEVEL": "debug", "LOG_FORMAT": "JSON", "CONFIG_STRICT": "false"}},
+		{
+			name:      "malformed booleans",
+			env:       map[string]string{"REDIS_TLS": "yes", "TRACING_ENABLED": "on", "LOG_CALLER": "maybe"},
+			defaulted: []string{`REDIS_TLS="yes": expected true or false`, `TRACING_ENABLED="on": expected true or false`, `LOG_CALLER="maybe": expected true or false`},
+		},
+		{
+			name:      "logging",
+			env:       map[string]string{"LOG_LEVEL": "bogus", "LOG_FORMAT": "xml"},
+			defaulted: []string{`LOG_LEVEL="bogus": expected panic, fatal, error, warn, info, debug or trace`, `LOG_FORMAT="xml": expected text or json`},
+		},
+		{
+			name:      "durations",
+			env:       map[string]string{"REDIS_OP_TIMEOUT": "soon", "POLL_INTERVAL_NORMAL": "15"},
+			defaulted: []string{`REDIS_OP_TIMEOUT="soon": expected a duration`, `POLL_INTERVAL_NORMAL="15": expected a duration`},
+		},
+		{
+			name:    "unusable values",
+			env:     map[string]string{"REDIS_OP_TIMEOUT": "0s", "BNET_REGION": "mars"},
+			invalid: []string{`BNET_REGION="mars": expected us, eu, kr or tw`, `REDIS_OP_TIMEOUT="0s": the timeout must be a positive duration`},
+		},
+		{
+			// Falling back to the default would turn strict mode off
+			name:    "malformed strict mode",
+			env:     map[string]string{"CONFIG_STRICT": "yes"},
+			invalid: []string{`CONFIG_STRICT="yes": expected true or false`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, problems := parseConfig()
+			if got, want := strings.Join(problems.invalid, "\n"), strings.Join(tt.invalid, "\n"); got != want {
+				t.Errorf("invalid =\n%s\nwant\n%s", got, want)
+			}
+			if got, want := strings.Join(problems.defaulted, "\n"), strings.Join(tt.defaulted, "\n"); got != want {
+				t.Errorf("defaulted =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseConfigDefaultsMalformedValues(t *testing.T) {
+	t.Setenv("REDIS_TLS", "yes")
+	t.Setenv("REDIS_COMPRESS", "off")
+	t.Setenv("CONFIG_STRICT", "1")
+
+	config, _ := parseConfig()
+	if config.RedisTLS || !config.RedisCompress {
+		t.Errorf("REDIS_TLS, REDIS_COMPRESS = %v, %v, want their defaults false, true", config.RedisTLS, config.RedisCompress)
+	}
+	if !config.ConfigStrict {
+		t.Error("CONFIG_STRICT=1 didn't enable strict mode")
+	}
+}
+
+func TestConfigProblemsReport(t *testing.T) {
+	exitFunc := logger.Log.ExitFunc
+	output := logger.Log.Out
+	t.Cleanup(func() {
+		logger.Log.ExitFunc = exitFunc
+		logger.Log.SetOutput(output)
+	})
+	logger.Log.SetOutput(io.Discard)
+
+	tests := []struct {
+		name     string
+		problems configProblems
+		strict   bool
+		fatal    bool
+	}{
+		{name: "no problems", strict: true},
+		{name: "defaulted", problems: configProblems{defaulted: []string{`REDIS_TLS="yes": expected true or false`}}},
+		{name: "defaulted in strict mode", problems: configProblems{defaulted: []string{`REDIS_TLS="yes": expected true or false`}}, strict: true, fatal: true},
+		{name: "invalid", problems: configProblems{invalid: []string{`BNET_REGION="mars": expected us, eu, kr or tw`}}, fatal: true},
+		{name: "invalid in strict mode", problems: configProblems{invalid: []string{`BNET_REGION="mars": expected us, eu, kr or tw`}}, strict: true, fatal: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exited := false
+			logger.Log.ExitFunc = func(int) { exited = true }
+
+			tt.problems.report(tt.strict)
+			if exited != tt.fatal {
+				t.Errorf("exited = %v, want %v", exited, tt.fatal)
+			}
+		})
+	}
+}
+
+// validateConfig runs --validate-config, returning its exit code and output
+func validateConfig(t *testing.T) (int, string) {
+	t.Helper()
+	output := logger.Log.Out
+	t.Cleanup(func() { logger.Log.SetOutput(output) })
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	code := runValidateConfig()
+	os.Stdout = stdout
+	w.Close()
+
+	printed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read the output: %v", err)
+	}
+	return code, string(printed)
+}
+
+func TestRunValidateConfig(t *testing.T) {
+	if code, printed := validateConfig(t); code != 0 || printed != "VALID  configuration\n" {
+		t.Errorf("valid configuration = %d, %q", code, printed)
+	}
+
+	// Defaulted values are reported as strict mode would reject them, even without it
+	t.Setenv("REDIS_TLS", "yes")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("BNET_REGION", "mars")
+	code, printed := validateConfig(t)
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	want := `INVALID  BNET_REGION="mars": expected us, eu, kr or tw
+INVALID  REDIS_TLS="yes": expected true or false
+INVALID  LOG_FORMAT="xml": expected text or json
+`
+	if printed != want {
+		t.Errorf("output =\n%s\nwant\n%s", printed, want)
+	}
+}